@@ -2,16 +2,11 @@ package builtin
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
-	"bytes"
-	"io"
-
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -84,22 +79,6 @@ type ArtifactoryRepositorySize struct {
 	ItemsCount    int64  `json:"itemsCount,omitempty"`
 }
 
-// ArtifactoryPermission represents a permission target in Artifactory
-type ArtifactoryPermission struct {
-	Name string `json:"name"`
-	Repo struct {
-		Repositories []string `json:"repositories"`
-		Actions      struct {
-			Users  map[string][]string `json:"users,omitempty"`
-			Groups map[string][]string `json:"groups,omitempty"`
-		} `json:"actions"`
-	} `json:"repo"`
-	Principals struct {
-		Users  map[string][]string `json:"users,omitempty"`
-		Groups map[string][]string `json:"groups,omitempty"`
-	} `json:"principals"`
-}
-
 // ArtifactoryGroup represents a group in Artifactory
 type ArtifactoryGroup struct {
 	Name            string   `json:"name"`
@@ -108,7 +87,7 @@ type ArtifactoryGroup struct {
 	AdminPrivileges bool     `json:"adminPrivileges,omitempty"`
 	Realm           string   `json:"realm,omitempty"`
 	RealmAttributes string   `json:"realmAttributes,omitempty"`
-	UsersNames      []string `json:"usersNames,omitempty"`
+	UserNames       []string `json:"userNames,omitempty"`
 }
 
 // ArtifactoryCreateRepository represents a repository creation request
@@ -159,219 +138,126 @@ func NewArtifactoryServer(options map[string]any) (*server.MCPServer, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load Artifactory configuration: %v", err)
 	}
-	
+
 	// Create the server
 	s := server.NewMCPServer("artifactory-server", "1.0.0", server.WithToolCapabilities(true))
-	
+
 	// Store configuration in server context for use by tools
 	// We'll use a global variable for now since MCP server doesn't have context storage
 	globalArtifactoryConfig = config
 
 	// Register the healthcheck tool
 	healthcheckTool := mcp.NewTool("artifactory_healthcheck",
-		mcp.WithDescription("Check the health status of an Artifactory instance using the /router/api/v1/system/health endpoint. Defaults to localhost with admin credentials if no parameters provided."),
-		mcp.WithString("base_url",
-			mcp.Description("The base URL of the Artifactory instance (e.g., http://localhost, https://artifactory.example.com). Defaults to http://localhost if not provided."),
-		),
-		mcp.WithString("username",
-			mcp.Description("Username for authentication (optional, defaults to 'admin')"),
-		),
-		mcp.WithString("password",
-			mcp.Description("Password for authentication (optional, defaults to 'B@55w0rd')"),
-		),
-		mcp.WithString("api_key",
-			mcp.Description("API key for authentication (alternative to username/password)"),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Optional timeout in seconds (max 120)"),
-			mcp.Min(0),
-			mcp.Max(120),
-		),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Check the health status of an Artifactory instance using the /router/api/v1/system/health endpoint."),
+		}, artifactoryConnectionOptions()...)...,
 	)
 
 	// Register the repositories tool
 	repositoriesTool := mcp.NewTool("artifactory_get_repositories",
-		mcp.WithDescription("Get a list of all repositories in an Artifactory instance using the /artifactory/api/repositories endpoint. Uses configuration from local.json if available."),
-		mcp.WithString("instance",
-			mcp.Description("Artifactory instance name from configuration (e.g., 'default', 'staging', 'production'). If not provided, uses default instance."),
-		),
-		mcp.WithString("base_url",
-			mcp.Description("The base URL of the Artifactory instance (e.g., http://localhost, https://artifactory.example.com). Overrides configuration if provided."),
-		),
-		mcp.WithString("username",
-			mcp.Description("Username for authentication. Overrides configuration if provided."),
-		),
-		mcp.WithString("password",
-			mcp.Description("Password for authentication. Overrides configuration if provided."),
-		),
-		mcp.WithString("api_key",
-			mcp.Description("API key for authentication (alternative to username/password). Overrides configuration if provided."),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Optional timeout in seconds (max 120). Overrides configuration if provided."),
-			mcp.Min(0),
-			mcp.Max(120),
-		),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get a list of all repositories in an Artifactory instance using the /artifactory/api/repositories endpoint."),
+		}, artifactoryConnectionOptions()...)...,
 	)
 
 	// Register the users tool
 	usersTool := mcp.NewTool("artifactory_get_users",
-		mcp.WithDescription("Get a list of all users in an Artifactory instance using the /artifactory/api/security/users endpoint. Defaults to localhost with admin credentials if no parameters provided."),
-		mcp.WithString("base_url",
-			mcp.Description("The base URL of the Artifactory instance (e.g., http://localhost, https://artifactory.example.com). Defaults to http://localhost if not provided."),
-		),
-		mcp.WithString("username",
-			mcp.Description("Username for authentication (optional, defaults to 'admin')"),
-		),
-		mcp.WithString("password",
-			mcp.Description("Password for authentication (optional, defaults to 'B@55w0rd')"),
-		),
-		mcp.WithString("api_key",
-			mcp.Description("API key for authentication (alternative to username/password)"),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Optional timeout in seconds (max 120)"),
-			mcp.Min(0),
-			mcp.Max(120),
-		),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get a list of all users in an Artifactory instance using the /artifactory/api/security/users endpoint."),
+		}, artifactoryConnectionOptions()...)...,
 	)
 
 	// Register the create user tool
 	createUserTool := mcp.NewTool("artifactory_create_user",
-		mcp.WithDescription("Create a new user in an Artifactory instance using the /artifactory/api/security/users/{username} endpoint. Defaults to localhost with admin credentials if no parameters provided."),
-		mcp.WithString("base_url",
-			mcp.Description("The base URL of the Artifactory instance (e.g., http://localhost, https://artifactory.example.com). Defaults to http://localhost if not provided."),
-		),
-		mcp.WithString("username",
-			mcp.Description("Username for authentication (optional, defaults to 'admin')"),
-		),
-		mcp.WithString("password",
-			mcp.Description("Password for authentication (optional, defaults to 'B@55w0rd')"),
-		),
-		mcp.WithString("api_key",
-			mcp.Description("API key for authentication (alternative to username/password)"),
-		),
-		mcp.WithString("new_username",
-			mcp.Description("The username for the new user to create (required)"),
-		),
-		mcp.WithString("new_password",
-			mcp.Description("The password for the new user (required)"),
-		),
-		mcp.WithString("email",
-			mcp.Description("Email address for the new user (optional)"),
-		),
-		mcp.WithBoolean("admin",
-			mcp.Description("Whether the new user should have admin privileges (optional, defaults to false)"),
-		),
-		mcp.WithString("realm",
-			mcp.Description("The realm for the new user (optional, defaults to 'internal')"),
-		),
-		mcp.WithString("groups",
-			mcp.Description("Comma-separated list of groups for the new user (optional)"),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Optional timeout in seconds (max 120)"),
-			mcp.Min(0),
-			mcp.Max(120),
-		),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create a new user in an Artifactory instance using the /artifactory/api/security/users/{username} endpoint."),
+			mcp.WithString("new_username",
+				mcp.Description("The username for the new user to create (required)"),
+			),
+			mcp.WithString("new_password",
+				mcp.Description("The password for the new user (required)"),
+			),
+			mcp.WithString("email",
+				mcp.Description("Email address for the new user (optional)"),
+			),
+			mcp.WithBoolean("admin",
+				mcp.Description("Whether the new user should have admin privileges (optional, defaults to false)"),
+			),
+			mcp.WithString("realm",
+				mcp.Description("The realm for the new user (optional, defaults to 'internal')"),
+			),
+			mcp.WithString("groups",
+				mcp.Description("Comma-separated list of groups for the new user (optional)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
 	)
 
 	// Register the repository sizes tool
 	repositorySizesTool := mcp.NewTool("artifactory_get_repository_sizes",
-		mcp.WithDescription("Get size information for all repositories in an Artifactory instance using the /artifactory/api/storageinfo endpoint. Defaults to localhost with admin credentials if no parameters provided."),
-		mcp.WithString("base_url",
-			mcp.Description("The base URL of the Artifactory instance (e.g., http://localhost, https://artifactory.example.com). Defaults to http://localhost if not provided."),
-		),
-		mcp.WithString("username",
-			mcp.Description("Username for authentication (optional, defaults to 'admin')"),
-		),
-		mcp.WithString("password",
-			mcp.Description("Password for authentication (optional, defaults to 'B@55w0rd')"),
-		),
-		mcp.WithString("api_key",
-			mcp.Description("API key for authentication (alternative to username/password)"),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Optional timeout in seconds (max 120)"),
-			mcp.Min(0),
-			mcp.Max(120),
-		),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get size information for all repositories in an Artifactory instance using the /artifactory/api/storageinfo endpoint."),
+		}, artifactoryConnectionOptions()...)...,
 	)
 
 	// Register the repository creation tool
 	createRepositoryTool := mcp.NewTool("artifactory_create_repository",
-		mcp.WithDescription("Create a new repository in Artifactory. Supports LOCAL, REMOTE, and VIRTUAL repository types. Defaults to localhost with admin credentials if no parameters provided."),
-		mcp.WithString("base_url",
-			mcp.Description("The base URL of the Artifactory instance (e.g., http://localhost, https://artifactory.example.com). Defaults to http://localhost if not provided."),
-		),
-		mcp.WithString("username",
-			mcp.Description("Username for authentication (optional, defaults to 'admin')"),
-		),
-		mcp.WithString("password",
-			mcp.Description("Password for authentication (optional, defaults to 'B@55w0rd')"),
-		),
-		mcp.WithString("api_key",
-			mcp.Description("API key for authentication (alternative to username/password)"),
-		),
-		mcp.WithString("repo_key",
-			mcp.Description("The unique key/name for the repository (required)"),
-		),
-		mcp.WithString("repo_type",
-			mcp.Description("Repository type: LOCAL, REMOTE, or VIRTUAL (required)"),
-		),
-		mcp.WithString("package_type",
-			mcp.Description("Package type: Generic, Maven, Gradle, Ivy, Sbt, NuGet, Gems, Npm, Bower, Debian, Composer, PyPI, Docker, GitLfs, YUM, Conan, Chef, Puppet, Helm, Go, P2, R, Swift, CocoaPods, Opkg, Vagrant, Cran, Conda, P2, VCS, etc. (required)"),
-		),
-		mcp.WithString("description",
-			mcp.Description("Description of the repository (optional)"),
-		),
-		mcp.WithString("notes",
-			mcp.Description("Additional notes about the repository (optional)"),
-		),
-		// Remote repository specific parameters
-		mcp.WithString("remote_url",
-			mcp.Description("URL of the remote repository (required for REMOTE type)"),
-		),
-		mcp.WithString("remote_username",
-			mcp.Description("Username for remote repository authentication (optional)"),
-		),
-		mcp.WithString("remote_password",
-			mcp.Description("Password for remote repository authentication (optional)"),
-		),
-		mcp.WithString("proxy",
-			mcp.Description("Proxy configuration name (optional)"),
-		),
-		// Virtual repository specific parameters
-		mcp.WithString("virtual_repositories",
-			mcp.Description("Comma-separated list of repository keys to include in virtual repository (required for VIRTUAL type)"),
-		),
-		mcp.WithString("default_deployment_repo",
-			mcp.Description("Default deployment repository for virtual repository (optional)"),
-		),
-		// Common repository settings
-		mcp.WithBoolean("handle_releases",
-			mcp.Description("Whether to handle release artifacts (optional, defaults to true)"),
-		),
-		mcp.WithBoolean("handle_snapshots",
-			mcp.Description("Whether to handle snapshot artifacts (optional, defaults to true)"),
-		),
-		mcp.WithBoolean("suppress_pom_consistency_checks",
-			mcp.Description("Whether to suppress POM consistency checks (optional, defaults to false)"),
-		),
-		mcp.WithBoolean("blacked_out",
-			mcp.Description("Whether the repository is blacked out (optional, defaults to false)"),
-		),
-		mcp.WithBoolean("archive_browsing_enabled",
-			mcp.Description("Whether archive browsing is enabled (optional, defaults to false)"),
-		),
-		mcp.WithNumber("max_unique_snapshots",
-			mcp.Description("Maximum number of unique snapshots to keep (optional, defaults to 0)"),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Optional timeout in seconds (max 120)"),
-			mcp.Min(0),
-			mcp.Max(120),
-		),
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create a new repository in Artifactory. Supports LOCAL, REMOTE, and VIRTUAL repository types."),
+			mcp.WithString("repo_key",
+				mcp.Description("The unique key/name for the repository (required)"),
+			),
+			mcp.WithString("repo_type",
+				mcp.Description("Repository type: LOCAL, REMOTE, or VIRTUAL (required)"),
+			),
+			mcp.WithString("package_type",
+				mcp.Description("Package type: Generic, Maven, Gradle, Ivy, Sbt, NuGet, Gems, Npm, Bower, Debian, Composer, PyPI, Docker, GitLfs, YUM, Conan, Chef, Puppet, Helm, Go, P2, R, Swift, CocoaPods, Opkg, Vagrant, Cran, Conda, P2, VCS, etc. (required)"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Description of the repository (optional)"),
+			),
+			mcp.WithString("notes",
+				mcp.Description("Additional notes about the repository (optional)"),
+			),
+			// Remote repository specific parameters
+			mcp.WithString("remote_url",
+				mcp.Description("URL of the remote repository (required for REMOTE type)"),
+			),
+			mcp.WithString("remote_username",
+				mcp.Description("Username for remote repository authentication (optional)"),
+			),
+			mcp.WithString("remote_password",
+				mcp.Description("Password for remote repository authentication (optional)"),
+			),
+			mcp.WithString("proxy",
+				mcp.Description("Proxy configuration name (optional)"),
+			),
+			// Virtual repository specific parameters
+			mcp.WithString("virtual_repositories",
+				mcp.Description("Comma-separated list of repository keys to include in virtual repository (required for VIRTUAL type)"),
+			),
+			mcp.WithString("default_deployment_repo",
+				mcp.Description("Default deployment repository for virtual repository (optional)"),
+			),
+			// Common repository settings
+			mcp.WithBoolean("handle_releases",
+				mcp.Description("Whether to handle release artifacts (optional, defaults to true)"),
+			),
+			mcp.WithBoolean("handle_snapshots",
+				mcp.Description("Whether to handle snapshot artifacts (optional, defaults to true)"),
+			),
+			mcp.WithBoolean("suppress_pom_consistency_checks",
+				mcp.Description("Whether to suppress POM consistency checks (optional, defaults to false)"),
+			),
+			mcp.WithBoolean("blacked_out",
+				mcp.Description("Whether the repository is blacked out (optional, defaults to false)"),
+			),
+			mcp.WithBoolean("archive_browsing_enabled",
+				mcp.Description("Whether archive browsing is enabled (optional, defaults to false)"),
+			),
+			mcp.WithNumber("max_unique_snapshots",
+				mcp.Description("Maximum number of unique snapshots to keep (optional, defaults to 0)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
 	)
 
 	s.AddTool(healthcheckTool, executeArtifactoryHealthcheck)
@@ -382,91 +268,30 @@ func NewArtifactoryServer(options map[string]any) (*server.MCPServer, error) {
 
 	s.AddTool(createRepositoryTool, executeArtifactoryCreateRepository)
 
+	addArtifactoryArtifactTools(s)
+	addArtifactoryPermissionTools(s)
+	addArtifactorySecurityTools(s)
+	addArtifactoryRepositoryTools(s)
+	addArtifactoryCleanupTools(s)
+	addArtifactoryDiagnosticsTools(s)
+	addArtifactoryBuildTools(s)
+	addArtifactoryCompareTools(s)
+
 	return s, nil
 }
 
 // executeArtifactoryHealthcheck handles the healthcheck tool execution
 func executeArtifactoryHealthcheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract parameters with localhost default
-	baseURL := request.GetString("base_url", "http://localhost")
-
-	username := request.GetString("username", "admin")
-	password := request.GetString("password", "B@55w0rd")
-	apiKey := request.GetString("api_key", "")
-
-	// Parse timeout (optional)
-	timeout := artifactoryDefaultTimeout
-	if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
-		timeoutDuration := time.Duration(timeoutSec) * time.Second
-		if timeoutDuration > artifactoryMaxTimeout {
-			timeout = artifactoryMaxTimeout
-		} else {
-			timeout = timeoutDuration
-		}
-	}
-
-	// Validate and construct the health check URL
-	parsedURL, err := url.Parse(baseURL)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("invalid base URL: %v", err)), nil
-	}
-
-	// Ensure URL has a scheme
-	if parsedURL.Scheme == "" {
-		baseURL = "http://" + baseURL
-		parsedURL, err = url.Parse(baseURL)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid URL after adding http: %v", err)), nil
-		}
-	}
-
-	// Only allow HTTP and HTTPS
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return mcp.NewToolResultError("URL must use http:// or https://"), nil
-	}
-
-	// Construct the health check endpoint URL
-	healthURL := fmt.Sprintf("%s/router/api/v1/system/health", baseURL)
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", healthURL, nil)
+	client, err := newArtifactoryClientFromRequest(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create request: %v", err)), nil
-	}
-
-	// Set authentication headers
-	if apiKey != "" {
-		req.Header.Set("X-JFrog-Art-Api", apiKey)
-	} else if username != "" && password != "" {
-		req.SetBasicAuth(username, password)
+		return mcp.NewToolResultError(err.Error()), nil
 	}
+	// A health probe should report the current state rather than wait out a recovery
+	client.maxRetries = 0
 
-	// Set headers
-	req.Header.Set("User-Agent", "MCP-Artifactory-Healthcheck/1.0")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to execute request: %v", err)), nil
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		return mcp.NewToolResultError(fmt.Sprintf("Artifactory health check failed with status code: %d", resp.StatusCode)), nil
-	}
-
-	// Parse the response
 	var healthResponse ArtifactoryHealthResponse
-	if err := json.NewDecoder(resp.Body).Decode(&healthResponse); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to parse response: %v", err)), nil
+	if err := client.doJSON(ctx, "GET", "router/api/v1/system/health", nil, &healthResponse); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory health check failed: %v", err)), nil
 	}
 
 	// Check if Artifactory reports healthy status
@@ -497,277 +322,63 @@ func executeArtifactoryHealthcheck(ctx context.Context, request mcp.CallToolRequ
 		"artifactory_status": healthResponse.Router.State,
 		"router_message":     healthResponse.Router.Message,
 		"services_count":     len(healthResponse.Services),
-		"url":                healthURL,
+		"url":                client.url("router/api/v1/system/health"),
 		"timestamp":          time.Now().Format(time.RFC3339),
 	}
 
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
-}
-
-// getArtifactoryInstanceConfig gets the configuration for a specific instance
-func getArtifactoryInstanceConfig(instanceName string) (*ArtifactoryInstanceConfig, error) {
-	if globalArtifactoryConfig == nil {
-		return nil, fmt.Errorf("Artifactory configuration not loaded")
-	}
-	
-	return globalArtifactoryConfig.GetInstanceConfig(instanceName)
+	return artifactoryJSONResult(result)
 }
 
 // executeArtifactoryGetRepositories handles the repositories tool execution
 func executeArtifactoryGetRepositories(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Get instance name from request (default to empty for default instance)
-	instanceName := request.GetString("instance", "")
-	
-	// Get configuration for the instance
-	instanceConfig, err := getArtifactoryInstanceConfig(instanceName)
+	client, err := newArtifactoryClientFromRequest(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to get Artifactory configuration: %v", err)), nil
-	}
-	
-	// Use configuration values, with fallback to request parameters
-	baseURL := request.GetString("base_url", instanceConfig.URL)
-	username, password, apiKey := instanceConfig.GetCredentials()
-	
-	// Override with request parameters if provided
-	if requestURL := request.GetString("base_url", ""); requestURL != "" {
-		baseURL = requestURL
-	}
-	if requestUsername := request.GetString("username", ""); requestUsername != "" {
-		username = requestUsername
-	}
-	if requestPassword := request.GetString("password", ""); requestPassword != "" {
-		password = requestPassword
-	}
-	if requestAPIKey := request.GetString("api_key", ""); requestAPIKey != "" {
-		apiKey = requestAPIKey
-	}
-
-	// Parse timeout (optional)
-	timeout := time.Duration(instanceConfig.Timeout) * time.Second
-	if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
-		timeoutDuration := time.Duration(timeoutSec) * time.Second
-		if timeoutDuration > artifactoryMaxTimeout {
-			timeout = artifactoryMaxTimeout
-		} else {
-			timeout = timeoutDuration
-		}
-	}
-
-	// Validate and construct the repositories API URL
-	parsedURL, err := url.Parse(baseURL)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("invalid base URL: %v", err)), nil
-	}
-
-	// Ensure URL has a scheme
-	if parsedURL.Scheme == "" {
-		baseURL = "http://" + baseURL
-		parsedURL, err = url.Parse(baseURL)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid URL after adding http: %v", err)), nil
-		}
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Only allow HTTP and HTTPS
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return mcp.NewToolResultError("URL must use http:// or https://"), nil
-	}
-
-	// Construct the repositories endpoint URL
-	repositoriesURL := fmt.Sprintf("%s/artifactory/api/repositories", baseURL)
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", repositoriesURL, nil)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create request: %v", err)), nil
-	}
-
-	// Set authentication headers
-	if apiKey != "" {
-		req.Header.Set("X-JFrog-Art-Api", apiKey)
-	} else if username != "" && password != "" {
-		req.SetBasicAuth(username, password)
-	}
-
-	// Set headers
-	req.Header.Set("User-Agent", "MCP-Artifactory-Repositories/1.0")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to execute request: %v", err)), nil
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		return mcp.NewToolResultError(fmt.Sprintf("Artifactory repositories request failed with status code: %d", resp.StatusCode)), nil
-	}
-
-	// Parse the response - Artifactory returns an array directly
+	// Artifactory returns an array directly
 	var repositories []ArtifactoryRepository
-	if err := json.NewDecoder(resp.Body).Decode(&repositories); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to parse response: %v", err)), nil
+	if err := client.doJSON(ctx, "GET", "artifactory/api/repositories", nil, &repositories); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory repositories request failed: %v", err)), nil
 	}
 
 	// Return success result
 	result := map[string]interface{}{
 		"repositories": repositories,
 		"count":        len(repositories),
-		"url":          repositoriesURL,
+		"url":          client.url("artifactory/api/repositories"),
 		"timestamp":    time.Now().Format(time.RFC3339),
 	}
 
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return artifactoryJSONResult(result)
 }
 
 // executeArtifactoryGetUsers handles the users tool execution
 func executeArtifactoryGetUsers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract parameters with localhost default
-	baseURL := request.GetString("base_url", "http://localhost")
-
-	username := request.GetString("username", "admin")
-	password := request.GetString("password", "B@55w0rd")
-	apiKey := request.GetString("api_key", "")
-
-	// Parse timeout (optional)
-	timeout := artifactoryDefaultTimeout
-	if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
-		timeoutDuration := time.Duration(timeoutSec) * time.Second
-		if timeoutDuration > artifactoryMaxTimeout {
-			timeout = artifactoryMaxTimeout
-		} else {
-			timeout = timeoutDuration
-		}
-	}
-
-	// Validate and construct the users API URL
-	parsedURL, err := url.Parse(baseURL)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("invalid base URL: %v", err)), nil
-	}
-
-	// Ensure URL has a scheme
-	if parsedURL.Scheme == "" {
-		baseURL = "http://" + baseURL
-		parsedURL, err = url.Parse(baseURL)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid URL after adding http: %v", err)), nil
-		}
-	}
-
-	// Only allow HTTP and HTTPS
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return mcp.NewToolResultError("URL must use http:// or https://"), nil
-	}
-
-	// Construct the users endpoint URL
-	usersURL := fmt.Sprintf("%s/artifactory/api/security/users", baseURL)
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", usersURL, nil)
+	client, err := newArtifactoryClientFromRequest(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create request: %v", err)), nil
-	}
-
-	// Set authentication headers
-	if apiKey != "" {
-		req.Header.Set("X-JFrog-Art-Api", apiKey)
-	} else if username != "" && password != "" {
-		req.SetBasicAuth(username, password)
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Set headers
-	req.Header.Set("User-Agent", "MCP-Artifactory-Users/1.0")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to execute request: %v", err)), nil
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		return mcp.NewToolResultError(fmt.Sprintf("Artifactory users request failed with status code: %d", resp.StatusCode)), nil
-	}
-
-	// Parse the response - Artifactory returns an array directly
+	// Artifactory returns an array directly
 	var users []ArtifactoryUser
-	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to parse response: %v", err)), nil
+	if err := client.doJSON(ctx, "GET", "artifactory/api/security/users", nil, &users); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory users request failed: %v", err)), nil
 	}
 
 	// Return success result
 	result := map[string]interface{}{
 		"users":     users,
 		"count":     len(users),
-		"url":       usersURL,
+		"url":       client.url("artifactory/api/security/users"),
 		"timestamp": time.Now().Format(time.RFC3339),
 	}
 
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return artifactoryJSONResult(result)
 }
 
 // executeArtifactoryCreateUser handles the create user tool execution
 func executeArtifactoryCreateUser(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract parameters with localhost default
-	baseURL := request.GetString("base_url", "http://localhost")
-
-	username := request.GetString("username", "admin")
-	password := request.GetString("password", "B@55w0rd")
-	apiKey := request.GetString("api_key", "")
-
 	// Extract new user parameters
 	newUsername := request.GetString("new_username", "")
 	if newUsername == "" {
@@ -793,40 +404,11 @@ func executeArtifactoryCreateUser(ctx context.Context, request mcp.CallToolReque
 		}
 	}
 
-	// Parse timeout (optional)
-	timeout := artifactoryDefaultTimeout
-	if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
-		timeoutDuration := time.Duration(timeoutSec) * time.Second
-		if timeoutDuration > artifactoryMaxTimeout {
-			timeout = artifactoryMaxTimeout
-		} else {
-			timeout = timeoutDuration
-		}
-	}
-
-	// Validate and construct the create user API URL
-	parsedURL, err := url.Parse(baseURL)
+	client, err := newArtifactoryClientFromRequest(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("invalid base URL: %v", err)), nil
-	}
-
-	// Ensure URL has a scheme
-	if parsedURL.Scheme == "" {
-		baseURL = "http://" + baseURL
-		parsedURL, err = url.Parse(baseURL)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid URL after adding http: %v", err)), nil
-		}
-	}
-
-	// Only allow HTTP and HTTPS
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return mcp.NewToolResultError("URL must use http:// or https://"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Construct the create user endpoint URL
-	createUserURL := fmt.Sprintf("%s/artifactory/api/security/users/%s", baseURL, newUsername)
-
 	// Create user data
 	userData := map[string]interface{}{
 		"name":     newUsername,
@@ -843,158 +425,37 @@ func executeArtifactoryCreateUser(ctx context.Context, request mcp.CallToolReque
 		userData["groups"] = groups
 	}
 
-	// Marshal user data to JSON
-	userDataJSON, err := json.Marshal(userData)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal user data: %v", err)), nil
-	}
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "PUT", createUserURL, bytes.NewBuffer(userDataJSON))
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create request: %v", err)), nil
-	}
-
-	// Set authentication headers
-	if apiKey != "" {
-		req.Header.Set("X-JFrog-Art-Api", apiKey)
-	} else if username != "" && password != "" {
-		req.SetBasicAuth(username, password)
-	}
-
-	// Set headers
-	req.Header.Set("User-Agent", "MCP-Artifactory-CreateUser/1.0")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to execute request: %v", err)), nil
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return mcp.NewToolResultError(fmt.Sprintf("Artifactory create user request failed with status code: %d, response: %s", resp.StatusCode, string(bodyBytes))), nil
+	endpoint := "artifactory/api/security/users/" + url.PathEscape(newUsername)
+	if err := client.doJSON(ctx, "PUT", endpoint, userData, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory create user request failed: %v", err)), nil
 	}
 
 	// Return success result
 	result := map[string]interface{}{
-		"message":     "User created successfully",
-		"username":    newUsername,
-		"email":       email,
-		"admin":       admin,
-		"realm":       realm,
-		"groups":      groups,
-		"url":         createUserURL,
-		"status_code": resp.StatusCode,
-		"timestamp":   time.Now().Format(time.RFC3339),
-	}
-
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+		"message":   "User created successfully",
+		"username":  newUsername,
+		"email":     email,
+		"admin":     admin,
+		"realm":     realm,
+		"groups":    groups,
+		"url":       client.url(endpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return artifactoryJSONResult(result)
 }
 
 // executeArtifactoryGetRepositorySizes handles the repository sizes tool execution
 func executeArtifactoryGetRepositorySizes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract parameters with localhost default
-	baseURL := request.GetString("base_url", "http://localhost")
-
-	username := request.GetString("username", "admin")
-	password := request.GetString("password", "B@55w0rd")
-	apiKey := request.GetString("api_key", "")
-
-	// Parse timeout (optional)
-	timeout := artifactoryDefaultTimeout
-	if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
-		timeoutDuration := time.Duration(timeoutSec) * time.Second
-		if timeoutDuration > artifactoryMaxTimeout {
-			timeout = artifactoryMaxTimeout
-		} else {
-			timeout = timeoutDuration
-		}
-	}
-
-	// Validate and construct the storage info API URL
-	parsedURL, err := url.Parse(baseURL)
+	client, err := newArtifactoryClientFromRequest(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("invalid base URL: %v", err)), nil
-	}
-
-	// Ensure URL has a scheme
-	if parsedURL.Scheme == "" {
-		baseURL = "http://" + baseURL
-		parsedURL, err = url.Parse(baseURL)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid URL after adding http: %v", err)), nil
-		}
-	}
-
-	// Only allow HTTP and HTTPS
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return mcp.NewToolResultError("URL must use http:// or https://"), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	// First, get the list of repositories
-	repositoriesURL := fmt.Sprintf("%s/artifactory/api/repositories", baseURL)
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	// Create request for repositories list
-	req, err := http.NewRequestWithContext(ctx, "GET", repositoriesURL, nil)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create repositories request: %v", err)), nil
-	}
-
-	// Set authentication headers
-	if apiKey != "" {
-		req.Header.Set("X-JFrog-Art-Api", apiKey)
-	} else if username != "" && password != "" {
-		req.SetBasicAuth(username, password)
-	}
-
-	// Set headers
-	req.Header.Set("User-Agent", "MCP-Artifactory-Repositories/1.0")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the repositories request
-	resp, err := client.Do(req)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to execute repositories request: %v", err)), nil
-	}
-	defer resp.Body.Close()
-
-	// Check HTTP status code for repositories
-	if resp.StatusCode != http.StatusOK {
-		return mcp.NewToolResultError(fmt.Sprintf("Artifactory repositories request failed with status code: %d", resp.StatusCode)), nil
-	}
-
-	// Parse the repositories response
 	var repositories []ArtifactoryRepository
-	if err := json.NewDecoder(resp.Body).Decode(&repositories); err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to parse repositories response: %v", err)), nil
+	if err := client.doJSON(ctx, "GET", "artifactory/api/repositories", nil, &repositories); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory repositories request failed: %v", err)), nil
 	}
 
 	// Get storage info for each repository
@@ -1005,73 +466,16 @@ func executeArtifactoryGetRepositorySizes(ctx context.Context, request mcp.CallT
 	totalItems := int64(0)
 
 	for _, repo := range repositories {
-		// Get storage info for this repository
-		storageURL := fmt.Sprintf("%s/artifactory/api/storage/%s", baseURL, repo.Key)
-
-		storageReq, err := http.NewRequestWithContext(ctx, "GET", storageURL, nil)
+		// Skip repositories whose storage info cannot be retrieved
+		repoSize, err := getArtifactoryRepositorySize(ctx, client, repo)
 		if err != nil {
-			// Skip this repository if we can't create the request
 			continue
 		}
 
-		// Set authentication headers
-		if apiKey != "" {
-			storageReq.Header.Set("X-JFrog-Art-Api", apiKey)
-		} else if username != "" && password != "" {
-			storageReq.SetBasicAuth(username, password)
-		}
-
-		// Set headers
-		storageReq.Header.Set("User-Agent", "MCP-Artifactory-Storage/1.0")
-		storageReq.Header.Set("Accept", "application/json")
-		storageReq.Header.Set("Content-Type", "application/json")
-
-		// Execute the storage request
-		storageResp, err := client.Do(storageReq)
-		if err != nil {
-			// Skip this repository if the request fails
-			continue
-		}
-
-		// Parse storage info
-		var storageInfo map[string]interface{}
-		if err := json.NewDecoder(storageResp.Body).Decode(&storageInfo); err != nil {
-			storageResp.Body.Close()
-			continue
-		}
-		storageResp.Body.Close()
-
-		// Extract size information
-		repoSize := ArtifactoryRepositorySize{
-			Key:         repo.Key,
-			Type:        repo.Type,
-			PackageType: repo.PackageType,
-			Description: repo.Description,
-			URL:         repo.URL,
-		}
-
-		// Extract size data from storage info
-		if size, ok := storageInfo["size"].(float64); ok {
-			repoSize.Size = int64(size)
-			repoSize.SizeFormatted = formatBytes(int64(size))
-			totalSize += int64(size)
-		}
-
-		if files, ok := storageInfo["filesCount"].(float64); ok {
-			repoSize.FileCount = int64(files)
-			totalFiles += int64(files)
-		}
-
-		if folders, ok := storageInfo["foldersCount"].(float64); ok {
-			repoSize.FolderCount = int64(folders)
-			totalFolders += int64(folders)
-		}
-
-		if items, ok := storageInfo["itemsCount"].(float64); ok {
-			repoSize.ItemsCount = int64(items)
-			totalItems += int64(items)
-		}
-
+		totalSize += repoSize.Size
+		totalFiles += repoSize.FileCount
+		totalFolders += repoSize.FolderCount
+		totalItems += repoSize.ItemsCount
 		repositorySizes = append(repositorySizes, repoSize)
 	}
 
@@ -1084,23 +488,44 @@ func executeArtifactoryGetRepositorySizes(ctx context.Context, request mcp.CallT
 		"totalFiles":         totalFiles,
 		"totalFolders":       totalFolders,
 		"totalItems":         totalItems,
-		"url":                repositoriesURL,
+		"url":                client.url("artifactory/api/repositories"),
 		"timestamp":          time.Now().Format(time.RFC3339),
 	}
 
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	return artifactoryJSONResult(result)
+}
+
+// getArtifactoryRepositorySize fetches the storage summary of a single repository
+func getArtifactoryRepositorySize(ctx context.Context, client *artifactoryClient, repo ArtifactoryRepository) (ArtifactoryRepositorySize, error) {
+	repoSize := ArtifactoryRepositorySize{
+		Key:         repo.Key,
+		Type:        repo.Type,
+		PackageType: repo.PackageType,
+		Description: repo.Description,
+		URL:         repo.URL,
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	var storageInfo map[string]interface{}
+	if err := client.doJSON(ctx, "GET", "artifactory/api/storage/"+url.PathEscape(repo.Key), nil, &storageInfo); err != nil {
+		return repoSize, err
+	}
+
+	// Extract size data from storage info
+	if size, ok := storageInfo["size"].(float64); ok {
+		repoSize.Size = int64(size)
+		repoSize.SizeFormatted = formatBytes(int64(size))
+	}
+	if files, ok := storageInfo["filesCount"].(float64); ok {
+		repoSize.FileCount = int64(files)
+	}
+	if folders, ok := storageInfo["foldersCount"].(float64); ok {
+		repoSize.FolderCount = int64(folders)
+	}
+	if items, ok := storageInfo["itemsCount"].(float64); ok {
+		repoSize.ItemsCount = int64(items)
+	}
+
+	return repoSize, nil
 }
 
 // formatBytes converts bytes to human readable format
@@ -1119,12 +544,6 @@ func formatBytes(bytes int64) string {
 
 // executeArtifactoryCreateRepository handles the repository creation tool execution
 func executeArtifactoryCreateRepository(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Extract parameters with localhost default
-	baseURL := request.GetString("base_url", "http://localhost")
-	username := request.GetString("username", "admin")
-	password := request.GetString("password", "B@55w0rd")
-	apiKey := request.GetString("api_key", "")
-
 	// Extract repository parameters
 	repoKey := request.GetString("repo_key", "")
 	repoType := request.GetString("repo_type", "")
@@ -1202,58 +621,14 @@ func executeArtifactoryCreateRepository(ctx context.Context, request mcp.CallToo
 		repo.DefaultDeploymentRepo = request.GetString("default_deployment_repo", "")
 	}
 
-	// Create HTTP client with timeout
-	timeout := 30 * time.Second
-	if timeoutSec := request.GetFloat("timeout", 0); timeoutSec > 0 {
-		timeoutDuration := time.Duration(timeoutSec) * time.Second
-		if timeoutDuration > 120*time.Second {
-			timeout = 120 * time.Second
-		} else {
-			timeout = timeoutDuration
-		}
-	}
-
-	client := &http.Client{
-		Timeout: timeout,
-	}
-
-	// Create repository JSON
-	repoJSON, err := json.Marshal(repo)
+	client, err := newArtifactoryClientFromRequest(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal repository data: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Create repository request
-	repoURL := fmt.Sprintf("%s/artifactory/api/repositories/%s", baseURL, repoKey)
-	repoReq, err := http.NewRequestWithContext(ctx, "PUT", repoURL, bytes.NewBuffer(repoJSON))
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create repository request: %v", err)), nil
-	}
-
-	// Set authentication headers
-	if apiKey != "" {
-		repoReq.Header.Set("X-JFrog-Art-Api", apiKey)
-	} else if username != "" && password != "" {
-		repoReq.SetBasicAuth(username, password)
-	}
-
-	// Set headers
-	repoReq.Header.Set("User-Agent", "MCP-Artifactory-Repository/1.0")
-	repoReq.Header.Set("Accept", "application/json")
-	repoReq.Header.Set("Content-Type", "application/json")
-
-	// Execute repository creation request
-	repoResp, err := client.Do(repoReq)
-	if err != nil {
+	if err := client.doJSON(ctx, "PUT", "artifactory/api/repositories/"+url.PathEscape(repoKey), repo, nil); err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to create repository: %v", err)), nil
 	}
-	defer repoResp.Body.Close()
-
-	// Check repository creation status
-	if repoResp.StatusCode != http.StatusOK && repoResp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(repoResp.Body)
-		return mcp.NewToolResultError(fmt.Sprintf("failed to create repository: status %d, response: %s", repoResp.StatusCode, string(bodyBytes))), nil
-	}
 
 	// Return success result
 	result := map[string]interface{}{
@@ -1263,7 +638,7 @@ func executeArtifactoryCreateRepository(ctx context.Context, request mcp.CallToo
 			"packageType": packageType,
 			"description": description,
 			"notes":       notes,
-			"url":         fmt.Sprintf("%s/artifactory/%s", baseURL, repoKey),
+			"url":         client.url("artifactory/" + url.PathEscape(repoKey)),
 			"created":     true,
 		},
 		"settings": map[string]interface{}{
@@ -1287,17 +662,5 @@ func executeArtifactoryCreateRepository(ctx context.Context, request mcp.CallToo
 		}
 	}
 
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			mcp.TextContent{
-				Type: "text",
-				Text: string(resultJSON),
-			},
-		},
-	}, nil
+	return artifactoryJSONResult(result)
 }
@@ -0,0 +1,666 @@
+package builtin
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ArtifactorySearchResult represents a single hit returned by the GAVC and checksum search APIs
+type ArtifactorySearchResult struct {
+	URI string `json:"uri"`
+}
+
+// ArtifactorySearchResponse represents the response from the GAVC and checksum search APIs
+type ArtifactorySearchResponse struct {
+	Results []ArtifactorySearchResult `json:"results"`
+}
+
+// ArtifactoryAQLResponse represents the response from the AQL search API
+type ArtifactoryAQLResponse struct {
+	Results []map[string]any `json:"results"`
+	Range   struct {
+		StartPos int `json:"start_pos"`
+		EndPos   int `json:"end_pos"`
+		Total    int `json:"total"`
+		Limit    int `json:"limit,omitempty"`
+	} `json:"range"`
+}
+
+// ArtifactoryItemProperties represents the response from the item properties API
+type ArtifactoryItemProperties struct {
+	URI        string              `json:"uri"`
+	Properties map[string][]string `json:"properties"`
+}
+
+// ArtifactoryDeployResponse represents the response from an artifact deploy
+type ArtifactoryDeployResponse struct {
+	Repo        string `json:"repo"`
+	Path        string `json:"path"`
+	Created     string `json:"created,omitempty"`
+	CreatedBy   string `json:"createdBy,omitempty"`
+	DownloadURI string `json:"downloadUri,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        string `json:"size,omitempty"`
+	Checksums   struct {
+		SHA1   string `json:"sha1,omitempty"`
+		MD5    string `json:"md5,omitempty"`
+		SHA256 string `json:"sha256,omitempty"`
+	} `json:"checksums"`
+	URI string `json:"uri,omitempty"`
+}
+
+// addArtifactoryArtifactTools registers the artifact search, properties, download and deploy tools
+func addArtifactoryArtifactTools(s *server.MCPServer) {
+	aqlTool := mcp.NewTool("artifactory_search_aql",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Run an Artifactory Query Language (AQL) query using the /artifactory/api/search/aql endpoint, e.g. items.find({\"repo\":\"libs-release-local\",\"name\":{\"$match\":\"*.jar\"}}).include(\"name\",\"path\",\"size\").limit(50)"),
+			mcp.WithString("query",
+				mcp.Required(),
+				mcp.Description("The AQL query to execute"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	gavcTool := mcp.NewTool("artifactory_search_gavc",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Search Maven artifacts by group, artifact, version and classifier using the /artifactory/api/search/gavc endpoint"),
+			mcp.WithString("group",
+				mcp.Description("Group ID (supports * wildcards)"),
+			),
+			mcp.WithString("artifact",
+				mcp.Description("Artifact ID (supports * wildcards)"),
+			),
+			mcp.WithString("version",
+				mcp.Description("Version (supports * wildcards)"),
+			),
+			mcp.WithString("classifier",
+				mcp.Description("Classifier (supports * wildcards)"),
+			),
+			mcp.WithString("repos",
+				mcp.Description("Comma-separated list of repositories to limit the search to (optional)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	checksumTool := mcp.NewTool("artifactory_search_checksum",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Find artifacts by checksum using the /artifactory/api/search/checksum endpoint. Provide exactly one of md5, sha1 or sha256."),
+			mcp.WithString("md5",
+				mcp.Description("MD5 checksum to search for"),
+			),
+			mcp.WithString("sha1",
+				mcp.Description("SHA-1 checksum to search for"),
+			),
+			mcp.WithString("sha256",
+				mcp.Description("SHA-256 checksum to search for"),
+			),
+			mcp.WithString("repos",
+				mcp.Description("Comma-separated list of repositories to limit the search to (optional)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	getPropertiesTool := mcp.NewTool("artifactory_get_item_properties",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get the properties of an artifact or folder using the /artifactory/api/storage/{repoPath}?properties endpoint"),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Repository path of the item, starting with the repository key (e.g., libs-release-local/org/acme/app/1.0/app-1.0.jar)"),
+			),
+			mcp.WithString("properties",
+				mcp.Description("Comma-separated list of property names to return (optional, defaults to all)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	setPropertiesTool := mcp.NewTool("artifactory_set_item_properties",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Set properties on an artifact or folder using the /artifactory/api/storage/{repoPath}?properties= endpoint"),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Repository path of the item, starting with the repository key"),
+			),
+			mcp.WithString("properties",
+				mcp.Required(),
+				mcp.Description("Semicolon-separated key=value pairs; multiple values are comma-separated (e.g., 'qa.status=passed;team=core,platform')"),
+			),
+			mcp.WithBoolean("recursive",
+				mcp.Description("Apply the properties to all items under a folder (default: true)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	downloadTool := mcp.NewTool("artifactory_download_artifact",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Download an artifact into a local allowed directory, verifying the checksum reported by Artifactory"),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Repository path of the artifact, starting with the repository key"),
+			),
+			mcp.WithString("target_dir",
+				mcp.Description("Local directory to download into; must be inside an allowed directory (defaults to the first allowed directory)"),
+			),
+			mcp.WithString("file_name",
+				mcp.Description("Local file name (defaults to the artifact name)"),
+			),
+			mcp.WithBoolean("overwrite",
+				mcp.Description("Overwrite an existing local file (default: false)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	deployTool := mcp.NewTool("artifactory_deploy_artifact",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Deploy a local file to a repository path, sending MD5, SHA-1 and SHA-256 checksum headers"),
+			mcp.WithString("local_path",
+				mcp.Required(),
+				mcp.Description("Path of the local file to upload; must be inside an allowed directory"),
+			),
+			mcp.WithString("target_path",
+				mcp.Required(),
+				mcp.Description("Repository path to deploy to, starting with the repository key. A trailing slash appends the local file name."),
+			),
+			mcp.WithString("properties",
+				mcp.Description("Semicolon-separated key=value pairs to attach as matrix parameters (optional)"),
+			),
+			mcp.WithBoolean("checksum_deploy",
+				mcp.Description("Try a checksum-only deploy first, avoiding the upload if the binary already exists (default: false)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(aqlTool, executeArtifactorySearchAQL)
+	s.AddTool(gavcTool, executeArtifactorySearchGAVC)
+	s.AddTool(checksumTool, executeArtifactorySearchChecksum)
+	s.AddTool(getPropertiesTool, executeArtifactoryGetItemProperties)
+	s.AddTool(setPropertiesTool, executeArtifactorySetItemProperties)
+	s.AddTool(downloadTool, executeArtifactoryDownloadArtifact)
+	s.AddTool(deployTool, executeArtifactoryDeployArtifact)
+}
+
+// executeArtifactorySearchAQL handles the AQL search tool execution
+func executeArtifactorySearchAQL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.TrimSpace(request.GetString("query", ""))
+	if query == "" {
+		return mcp.NewToolResultError("query is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var aqlResponse ArtifactoryAQLResponse
+	if err := client.doText(withArtifactoryReadOnly(ctx), "POST", "artifactory/api/search/aql", query, &aqlResponse); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("AQL search failed: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"results":   aqlResponse.Results,
+		"count":     len(aqlResponse.Results),
+		"range":     aqlResponse.Range,
+		"query":     query,
+		"url":       client.url("artifactory/api/search/aql"),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactorySearchGAVC handles the GAVC search tool execution
+func executeArtifactorySearchGAVC(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := url.Values{}
+	for param, key := range map[string]string{"group": "g", "artifact": "a", "version": "v", "classifier": "c"} {
+		if value := request.GetString(param, ""); value != "" {
+			params.Set(key, value)
+		}
+	}
+	if len(params) == 0 {
+		return mcp.NewToolResultError("at least one of group, artifact, version or classifier is required"), nil
+	}
+	if repos := parseCommaSeparated(request.GetString("repos", "")); len(repos) > 0 {
+		params.Set("repos", strings.Join(repos, ","))
+	}
+
+	return runArtifactorySearch(ctx, request, "artifactory/api/search/gavc?"+params.Encode())
+}
+
+// executeArtifactorySearchChecksum handles the checksum search tool execution
+func executeArtifactorySearchChecksum(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := url.Values{}
+	for _, algorithm := range []string{"md5", "sha1", "sha256"} {
+		if value := strings.TrimSpace(request.GetString(algorithm, "")); value != "" {
+			params.Set(algorithm, strings.ToLower(value))
+		}
+	}
+	if len(params) != 1 {
+		return mcp.NewToolResultError("exactly one of md5, sha1 or sha256 is required"), nil
+	}
+	if repos := parseCommaSeparated(request.GetString("repos", "")); len(repos) > 0 {
+		params.Set("repos", strings.Join(repos, ","))
+	}
+
+	return runArtifactorySearch(ctx, request, "artifactory/api/search/checksum?"+params.Encode())
+}
+
+// runArtifactorySearch executes a search endpoint that returns a list of item URIs
+func runArtifactorySearch(ctx context.Context, request mcp.CallToolRequest, endpoint string) (*mcp.CallToolResult, error) {
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var searchResponse ArtifactorySearchResponse
+	if err := client.doJSON(ctx, "GET", endpoint, nil, &searchResponse); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"results":   searchResponse.Results,
+		"count":     len(searchResponse.Results),
+		"url":       client.url(endpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryGetItemProperties handles the get item properties tool execution
+func executeArtifactoryGetItemProperties(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoPath := request.GetString("path", "")
+	if repoPath == "" {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	endpoint := "artifactory/api/storage/" + artifactoryRepoPath(repoPath) + "?properties"
+	if names := parseCommaSeparated(request.GetString("properties", "")); len(names) > 0 {
+		endpoint += "=" + url.QueryEscape(strings.Join(names, ","))
+	}
+
+	var properties ArtifactoryItemProperties
+	if err := client.doJSON(ctx, "GET", endpoint, nil, &properties); err != nil {
+		// Artifactory answers 404 when the item exists but has no properties
+		if httpErr, ok := err.(*artifactoryHTTPError); !ok || httpErr.StatusCode != 404 {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get item properties: %v", err)), nil
+		}
+	}
+	if properties.Properties == nil {
+		properties.Properties = map[string][]string{}
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"path":       repoPath,
+		"properties": properties.Properties,
+		"count":      len(properties.Properties),
+		"url":        client.url(endpoint),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactorySetItemProperties handles the set item properties tool execution
+func executeArtifactorySetItemProperties(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoPath := request.GetString("path", "")
+	if repoPath == "" {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+
+	properties, err := parseArtifactoryProperties(request.GetString("properties", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(properties) == 0 {
+		return mcp.NewToolResultError("properties is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	recursive := "0"
+	if request.GetBool("recursive", true) {
+		recursive = "1"
+	}
+	params := url.Values{}
+	params.Set("properties", formatArtifactoryProperties(properties, ";"))
+	params.Set("recursive", recursive)
+	endpoint := "artifactory/api/storage/" + artifactoryRepoPath(repoPath) + "?" + params.Encode()
+
+	if err := client.doJSON(ctx, "PUT", endpoint, nil, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to set item properties: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"message":    "Properties set successfully",
+		"path":       repoPath,
+		"properties": properties,
+		"recursive":  recursive == "1",
+		"url":        client.url(endpoint),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryDownloadArtifact handles the artifact download tool execution
+func executeArtifactoryDownloadArtifact(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoPath := strings.Trim(request.GetString("path", ""), "/")
+	if repoPath == "" {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+
+	targetDir := request.GetString("target_dir", "")
+	if targetDir == "" {
+		if globalArtifactoryConfig == nil || len(globalArtifactoryConfig.AllowedDirectories) == 0 {
+			return mcp.NewToolResultError("target_dir is required when no allowed directories are configured"), nil
+		}
+		targetDir = globalArtifactoryConfig.AllowedDirectories[0]
+	}
+	fileName := request.GetString("file_name", path.Base(repoPath))
+	if fileName == "" || fileName != filepath.Base(fileName) {
+		return mcp.NewToolResultError("file_name must be a plain file name"), nil
+	}
+
+	localPath, err := resolveArtifactoryLocalPath(filepath.Join(targetDir, fileName))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if _, err := os.Stat(localPath); err == nil && !request.GetBool("overwrite", false) {
+		return mcp.NewToolResultError(fmt.Sprintf("local file already exists: %s (set overwrite to replace it)", localPath)), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// The request timeout would also cover reading the body, so large artifacts get the transfer timeout instead
+	downloadCtx, cancel := context.WithTimeout(ctx, artifactoryTransferTimeout)
+	defer cancel()
+
+	endpoint := "artifactory/" + artifactoryRepoPath(repoPath)
+	req, err := client.newRequest(downloadCtx, "GET", endpoint, nil)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := client.streamingClient().do(req)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to download artifact: %v", err)), nil
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create target directory: %v", err)), nil
+	}
+
+	// Write to a temporary file first so a failed or corrupt download never replaces an existing file
+	tmpFile, err := os.CreateTemp(filepath.Dir(localPath), "."+fileName+".download-*")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create temporary file: %v", err)), nil
+	}
+	defer os.Remove(tmpFile.Name())
+
+	sha1Hash := sha1.New()
+	sha256Hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmpFile, sha1Hash, sha256Hash), resp.Body)
+	tmpFile.Close()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to write artifact: %v", err)), nil
+	}
+
+	actualSHA1 := hex.EncodeToString(sha1Hash.Sum(nil))
+	actualSHA256 := hex.EncodeToString(sha256Hash.Sum(nil))
+	verified := false
+	if expected := resp.Header.Get("X-Checksum-Sha256"); expected != "" {
+		if !strings.EqualFold(expected, actualSHA256) {
+			return mcp.NewToolResultError(fmt.Sprintf("SHA-256 mismatch: expected %s, got %s", expected, actualSHA256)), nil
+		}
+		verified = true
+	} else if expected := resp.Header.Get("X-Checksum-Sha1"); expected != "" {
+		if !strings.EqualFold(expected, actualSHA1) {
+			return mcp.NewToolResultError(fmt.Sprintf("SHA-1 mismatch: expected %s, got %s", expected, actualSHA1)), nil
+		}
+		verified = true
+	}
+
+	if err := os.Rename(tmpFile.Name(), localPath); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to move artifact into place: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"message":           "Artifact downloaded successfully",
+		"path":              repoPath,
+		"local_path":        localPath,
+		"size":              size,
+		"sizeFormatted":     formatBytes(size),
+		"sha1":              actualSHA1,
+		"sha256":            actualSHA256,
+		"checksum_verified": verified,
+		"url":               client.url(endpoint),
+		"timestamp":         time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryDeployArtifact handles the artifact deploy tool execution
+func executeArtifactoryDeployArtifact(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	localPath := request.GetString("local_path", "")
+	if localPath == "" {
+		return mcp.NewToolResultError("local_path is required"), nil
+	}
+	targetPath := strings.TrimPrefix(request.GetString("target_path", ""), "/")
+	if targetPath == "" {
+		return mcp.NewToolResultError("target_path is required"), nil
+	}
+
+	localPath, err := resolveArtifactoryLocalPath(localPath)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to stat local file: %v", err)), nil
+	}
+	if info.IsDir() {
+		return mcp.NewToolResultError("local_path must be a file"), nil
+	}
+	if strings.HasSuffix(targetPath, "/") {
+		targetPath += filepath.Base(localPath)
+	}
+	if !strings.Contains(targetPath, "/") {
+		return mcp.NewToolResultError("target_path must include a repository key and a file path"), nil
+	}
+
+	properties, err := parseArtifactoryProperties(request.GetString("properties", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	checksums, err := computeFileChecksums(localPath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to compute checksums: %v", err)), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	endpoint := "artifactory/" + artifactoryRepoPath(targetPath)
+	if len(properties) > 0 {
+		endpoint += ";" + formatArtifactoryMatrixParams(properties)
+	}
+
+	// The request timeout would also cover sending the body, so full uploads get the transfer timeout instead
+	uploadCtx, cancel := context.WithTimeout(ctx, artifactoryTransferTimeout)
+	defer cancel()
+
+	deploy := func(checksumOnly bool) (*ArtifactoryDeployResponse, error) {
+		var body io.Reader
+		requestCtx, requestClient := ctx, client
+		if !checksumOnly {
+			requestCtx, requestClient = uploadCtx, client.streamingClient()
+			file, err := os.Open(localPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open local file: %v", err)
+			}
+			defer file.Close()
+			body = file
+		}
+
+		req, err := client.newRequest(requestCtx, "PUT", endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		if !checksumOnly {
+			req.ContentLength = info.Size()
+			// Reopen the file so the client can resend the upload on a retryable response
+			req.GetBody = func() (io.ReadCloser, error) {
+				return os.Open(localPath)
+			}
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("X-Checksum", checksums["md5"])
+		req.Header.Set("X-Checksum-Sha1", checksums["sha1"])
+		req.Header.Set("X-Checksum-Sha256", checksums["sha256"])
+		if checksumOnly {
+			req.Header.Set("X-Checksum-Deploy", "true")
+		}
+
+		resp, err := requestClient.do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		var deployResponse ArtifactoryDeployResponse
+		if err := decodeOptionalJSON(resp.Body, &deployResponse); err != nil {
+			return nil, err
+		}
+		return &deployResponse, nil
+	}
+
+	checksumDeployed := false
+	var deployResponse *ArtifactoryDeployResponse
+	if request.GetBool("checksum_deploy", false) {
+		// A 404 means Artifactory does not hold the binary yet, so fall back to a full upload
+		deployResponse, err = deploy(true)
+		if err == nil {
+			checksumDeployed = true
+		} else if httpErr, ok := err.(*artifactoryHTTPError); !ok || httpErr.StatusCode != 404 {
+			return mcp.NewToolResultError(fmt.Sprintf("checksum deploy failed: %v", err)), nil
+		}
+	}
+	if deployResponse == nil {
+		deployResponse, err = deploy(false)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to deploy artifact: %v", err)), nil
+		}
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"message":           "Artifact deployed successfully",
+		"local_path":        localPath,
+		"target_path":       targetPath,
+		"size":              info.Size(),
+		"sizeFormatted":     formatBytes(info.Size()),
+		"checksums":         checksums,
+		"checksum_deployed": checksumDeployed,
+		"properties":        properties,
+		"response":          deployResponse,
+		"url":               client.url(endpoint),
+		"timestamp":         time.Now().Format(time.RFC3339),
+	})
+}
+
+// resolveArtifactoryLocalPath returns the absolute form of a local path after checking it lies inside an allowed directory
+func resolveArtifactoryLocalPath(localPath string) (string, error) {
+	if globalArtifactoryConfig == nil || len(globalArtifactoryConfig.AllowedDirectories) == 0 {
+		return "", fmt.Errorf("no allowed directories configured for Artifactory file transfers")
+	}
+	return resolveAllowedLocalPath(localPath, globalArtifactoryConfig.AllowedDirectories)
+}
+
+// parseArtifactoryProperties parses "key=value;key2=v1,v2" into a property map
+func parseArtifactoryProperties(input string) (map[string][]string, error) {
+	properties := make(map[string][]string)
+	for _, pair := range strings.Split(input, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid property %q: expected key=value", pair)
+		}
+		properties[key] = append(properties[key], parseCommaSeparated(value)...)
+	}
+	return properties, nil
+}
+
+// formatArtifactoryProperties renders a property map in the key=v1,v2 form used by the properties API
+func formatArtifactoryProperties(properties map[string][]string, separator string) string {
+	pairs := make([]string, 0, len(properties))
+	for _, key := range sortedKeys(properties) {
+		values := make([]string, len(properties[key]))
+		for i, value := range properties[key] {
+			values[i] = escapeArtifactoryPropertyValue(value)
+		}
+		pairs = append(pairs, escapeArtifactoryPropertyValue(key)+"="+strings.Join(values, ","))
+	}
+	return strings.Join(pairs, separator)
+}
+
+// formatArtifactoryMatrixParams renders a property map as URL-encoded matrix parameters
+func formatArtifactoryMatrixParams(properties map[string][]string) string {
+	var params []string
+	for _, key := range sortedKeys(properties) {
+		for _, value := range properties[key] {
+			params = append(params, url.PathEscape(key)+"="+url.PathEscape(value))
+		}
+	}
+	return strings.Join(params, ";")
+}
+
+// escapeArtifactoryPropertyValue backslash-escapes the characters the properties API treats as separators
+func escapeArtifactoryPropertyValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ",", `\,`, "|", `\|`, "=", `\=`, ";", `\;`)
+	return replacer.Replace(value)
+}
+
+// computeFileChecksums returns the md5, sha1 and sha256 hex digests of a file
+func computeFileChecksums(filePath string) (map[string]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	md5Hash := md5.New()
+	sha1Hash := sha1.New()
+	sha256Hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), file); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"md5":    hex.EncodeToString(md5Hash.Sum(nil)),
+		"sha1":   hex.EncodeToString(sha1Hash.Sum(nil)),
+		"sha256": hex.EncodeToString(sha256Hash.Sum(nil)),
+	}, nil
+}
@@ -0,0 +1,370 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// useTestArtifactoryConfig points the global Artifactory configuration at a test server for the duration of a test
+func useTestArtifactoryConfig(t *testing.T, baseURL string, allowedDirs ...string) {
+	t.Helper()
+
+	previous := globalArtifactoryConfig
+	globalArtifactoryConfig = &ArtifactoryConfig{
+		Instances: map[string]ArtifactoryInstanceConfig{
+			"test": {
+				Name:     "test",
+				URL:      baseURL,
+				Username: "tester",
+				Password: "secret",
+				Timeout:  5,
+			},
+		},
+		DefaultInstance:    "test",
+		AllowedDirectories: allowedDirs,
+	}
+	t.Cleanup(func() { globalArtifactoryConfig = previous })
+}
+
+// callArtifactoryTool runs a tool handler and decodes its JSON text result
+func callArtifactoryTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (map[string]any, *mcp.CallToolResult) {
+	t.Helper()
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Arguments: args,
+		},
+	}
+
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+	if result == nil || len(result.Content) == 0 {
+		t.Fatal("Result should have content")
+	}
+
+	textContent, ok := mcp.AsTextContent(result.Content[0])
+	if !ok {
+		t.Fatal("Expected text content")
+	}
+	if result.IsError {
+		return nil, result
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal([]byte(textContent.Text), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return response, result
+}
+
+func TestExecuteArtifactorySearchAQL(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifactory/api/search/aql" || r.Method != "POST" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("Expected text/plain content type, got %s", r.Header.Get("Content-Type"))
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "tester" || pass != "secret" {
+			t.Errorf("Expected basic auth from instance config")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.HasPrefix(string(body), "items.find") {
+			t.Errorf("Unexpected AQL body: %s", body)
+		}
+		w.Write([]byte(`{"results":[{"repo":"libs-release-local","path":"org/acme","name":"app.jar"}],"range":{"start_pos":0,"end_pos":1,"total":1}}`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactorySearchAQL, map[string]any{
+		"query": `items.find({"repo":"libs-release-local"})`,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected error result: %v", result.Content)
+	}
+	if response["count"] != float64(1) {
+		t.Errorf("Expected count 1, got %v", response["count"])
+	}
+}
+
+func TestExecuteArtifactorySearchGAVCAndChecksum(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/search/gavc":
+			if r.URL.Query().Get("g") != "org.acme" || r.URL.Query().Get("repos") != "libs-release-local" {
+				t.Errorf("Unexpected GAVC query: %s", r.URL.RawQuery)
+			}
+		case "/artifactory/api/search/checksum":
+			if r.URL.Query().Get("sha256") != "abc123" {
+				t.Errorf("Unexpected checksum query: %s", r.URL.RawQuery)
+			}
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"results":[{"uri":"http://example/api/storage/libs-release-local/org/acme/app.jar"}]}`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactorySearchGAVC, map[string]any{
+		"group": "org.acme",
+		"repos": "libs-release-local",
+	})
+	if result.IsError || response["count"] != float64(1) {
+		t.Errorf("Unexpected GAVC result: %v", result.Content)
+	}
+
+	response, result = callArtifactoryTool(t, executeArtifactorySearchChecksum, map[string]any{
+		"sha256": "ABC123",
+	})
+	if result.IsError || response["count"] != float64(1) {
+		t.Errorf("Unexpected checksum result: %v", result.Content)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactorySearchChecksum, map[string]any{
+		"sha1": "a",
+		"md5":  "b",
+	})
+	if !result.IsError {
+		t.Error("Expected an error when more than one checksum is given")
+	}
+}
+
+func TestExecuteArtifactoryItemProperties(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifactory/api/storage/libs-release-local/org/acme/app.jar" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(`{"uri":"x","properties":{"qa.status":["passed"]}}`))
+		case "PUT":
+			if got := r.URL.Query().Get("properties"); got != "qa.status=passed;team=core,platform" {
+				t.Errorf("Unexpected properties parameter: %s", got)
+			}
+			if r.URL.Query().Get("recursive") != "0" {
+				t.Errorf("Expected recursive=0, got %s", r.URL.Query().Get("recursive"))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryGetItemProperties, map[string]any{
+		"path": "libs-release-local/org/acme/app.jar",
+	})
+	if result.IsError || response["count"] != float64(1) {
+		t.Errorf("Unexpected get properties result: %v", result.Content)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactorySetItemProperties, map[string]any{
+		"path":       "libs-release-local/org/acme/app.jar",
+		"properties": "team=core,platform;qa.status=passed",
+		"recursive":  false,
+	})
+	if result.IsError {
+		t.Errorf("Unexpected set properties error: %v", result.Content)
+	}
+}
+
+func TestExecuteArtifactoryDownloadArtifact(t *testing.T) {
+	const content = "artifact-bytes"
+	checksums := map[string]string{}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Checksum-Sha256", checksums["sha256"])
+		w.Write([]byte(content))
+	}))
+	defer mockServer.Close()
+
+	allowedDir := t.TempDir()
+	useTestArtifactoryConfig(t, mockServer.URL, allowedDir)
+
+	source := filepath.Join(allowedDir, "source.txt")
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	computed, err := computeFileChecksums(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksums["sha256"] = computed["sha256"]
+
+	response, result := callArtifactoryTool(t, executeArtifactoryDownloadArtifact, map[string]any{
+		"path": "libs-release-local/org/acme/app.jar",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected download error: %v", result.Content)
+	}
+	if response["checksum_verified"] != true {
+		t.Error("Expected checksum to be verified")
+	}
+	data, err := os.ReadFile(filepath.Join(allowedDir, "app.jar"))
+	if err != nil || string(data) != content {
+		t.Errorf("Downloaded file mismatch: %q, %v", data, err)
+	}
+
+	// A mismatching checksum must not leave a file behind
+	checksums["sha256"] = strings.Repeat("0", 64)
+	_, result = callArtifactoryTool(t, executeArtifactoryDownloadArtifact, map[string]any{
+		"path":      "libs-release-local/org/acme/app.jar",
+		"file_name": "corrupt.jar",
+	})
+	if !result.IsError {
+		t.Error("Expected checksum mismatch error")
+	}
+	if _, err := os.Stat(filepath.Join(allowedDir, "corrupt.jar")); !os.IsNotExist(err) {
+		t.Error("Corrupt download should not be kept")
+	}
+
+	// Targets outside the allowed directories are rejected
+	_, result = callArtifactoryTool(t, executeArtifactoryDownloadArtifact, map[string]any{
+		"path":       "libs-release-local/org/acme/app.jar",
+		"target_dir": t.TempDir(),
+	})
+	if !result.IsError {
+		t.Error("Expected error for target outside allowed directories")
+	}
+
+	// A symlink inside an allowed directory must not lead a download outside of it
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(allowedDir, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	_, result = callArtifactoryTool(t, executeArtifactoryDownloadArtifact, map[string]any{
+		"path":       "libs-release-local/org/acme/app.jar",
+		"target_dir": filepath.Join(allowedDir, "escape"),
+	})
+	if !result.IsError {
+		t.Error("Expected error for a symlink pointing outside allowed directories")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "app.jar")); !os.IsNotExist(err) {
+		t.Error("Download must not be written through the symlink")
+	}
+}
+
+func TestExecuteArtifactoryDownloadArtifactSlowBody(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first-half-"))
+		w.(http.Flusher).Flush()
+		time.Sleep(1500 * time.Millisecond)
+		w.Write([]byte("second-half"))
+	}))
+	defer mockServer.Close()
+
+	allowedDir := t.TempDir()
+	useTestArtifactoryConfig(t, mockServer.URL, allowedDir)
+	instance := globalArtifactoryConfig.Instances["test"]
+	instance.Timeout = 1
+	globalArtifactoryConfig.Instances["test"] = instance
+
+	// The body takes longer than the 1s request timeout, which must not cut the download off
+	_, result := callArtifactoryTool(t, executeArtifactoryDownloadArtifact, map[string]any{
+		"path": "libs-release-local/big.bin",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected download error: %v", result.Content)
+	}
+	data, err := os.ReadFile(filepath.Join(allowedDir, "big.bin"))
+	if err != nil || string(data) != "first-half-second-half" {
+		t.Errorf("Downloaded file mismatch: %q, %v", data, err)
+	}
+}
+
+func TestExecuteArtifactoryDeployArtifact(t *testing.T) {
+	allowedDir := t.TempDir()
+	localFile := filepath.Join(allowedDir, "app.jar")
+	if err := os.WriteFile(localFile, []byte("jar-content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := computeFileChecksums(localFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			t.Errorf("Expected PUT, got %s", r.Method)
+		}
+		if !strings.HasPrefix(r.URL.Path, "/artifactory/libs-release-local/org/acme/app.jar") {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if !strings.Contains(r.URL.Path, ";build.number=42") {
+			t.Errorf("Expected matrix params in path, got %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Checksum-Sha1") != expected["sha1"] || r.Header.Get("X-Checksum-Sha256") != expected["sha256"] || r.Header.Get("X-Checksum") != expected["md5"] {
+			t.Error("Missing or wrong checksum headers")
+		}
+		if r.Header.Get("X-Checksum-Deploy") == "true" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "jar-content" {
+			t.Errorf("Unexpected body %q", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"repo":"libs-release-local","path":"/org/acme/app.jar","size":"11"}`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL, allowedDir)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryDeployArtifact, map[string]any{
+		"local_path":      localFile,
+		"target_path":     "libs-release-local/org/acme/",
+		"properties":      "build.number=42",
+		"checksum_deploy": true,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected deploy error: %v", result.Content)
+	}
+	if response["target_path"] != "libs-release-local/org/acme/app.jar" {
+		t.Errorf("Unexpected target path %v", response["target_path"])
+	}
+	if response["checksum_deployed"] != false {
+		t.Error("Checksum deploy should have fallen back to a full upload")
+	}
+}
+
+func TestExecuteArtifactoryDeployArtifactSlowUpload(t *testing.T) {
+	allowedDir := t.TempDir()
+	localFile := filepath.Join(allowedDir, "big.bin")
+	if err := os.WriteFile(localFile, []byte("big-content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		time.Sleep(1500 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"repo":"libs-release-local","path":"/big.bin","size":"11"}`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL, allowedDir)
+	instance := globalArtifactoryConfig.Instances["test"]
+	instance.Timeout = 1
+	globalArtifactoryConfig.Instances["test"] = instance
+
+	// The upload takes longer than the 1s request timeout, which must not cut it off
+	_, result := callArtifactoryTool(t, executeArtifactoryDeployArtifact, map[string]any{
+		"local_path":  localFile,
+		"target_path": "libs-release-local/big.bin",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected deploy error: %v", result.Content)
+	}
+}
@@ -0,0 +1,686 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// artifactoryBuildsEndpoint is the build info API
+const artifactoryBuildsEndpoint = "artifactory/api/build"
+
+// artifactorySecretPropertyPattern matches build properties that usually hold credentials
+var artifactorySecretPropertyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|api_key|credential|private)`)
+
+// ArtifactoryBuildRef is an entry of the build list
+type ArtifactoryBuildRef struct {
+	URI         string `json:"uri"`
+	LastStarted string `json:"lastStarted,omitempty"`
+}
+
+// ArtifactoryBuildNumberRef is an entry of the build numbers list
+type ArtifactoryBuildNumberRef struct {
+	URI     string `json:"uri"`
+	Started string `json:"started,omitempty"`
+}
+
+// ArtifactoryBuildArtifact represents an artifact produced by a build module
+type ArtifactoryBuildArtifact struct {
+	Type   string `json:"type,omitempty"`
+	Name   string `json:"name"`
+	Path   string `json:"path,omitempty"`
+	SHA1   string `json:"sha1,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	MD5    string `json:"md5,omitempty"`
+}
+
+// ArtifactoryBuildDependency represents a dependency of a build module
+type ArtifactoryBuildDependency struct {
+	ID     string   `json:"id"`
+	Type   string   `json:"type,omitempty"`
+	SHA1   string   `json:"sha1,omitempty"`
+	SHA256 string   `json:"sha256,omitempty"`
+	MD5    string   `json:"md5,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// ArtifactoryBuildModule represents a module of a build
+type ArtifactoryBuildModule struct {
+	ID           string                       `json:"id"`
+	Type         string                       `json:"type,omitempty"`
+	Artifacts    []ArtifactoryBuildArtifact   `json:"artifacts,omitempty"`
+	Dependencies []ArtifactoryBuildDependency `json:"dependencies,omitempty"`
+}
+
+// ArtifactoryBuildVCS represents the version control information of a build
+type ArtifactoryBuildVCS struct {
+	URL      string `json:"url,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// ArtifactoryBuildInfo represents the build info of a single build number
+type ArtifactoryBuildInfo struct {
+	Name                 string                   `json:"name"`
+	Number               string                   `json:"number"`
+	Started              string                   `json:"started,omitempty"`
+	DurationMillis       int64                    `json:"durationMillis,omitempty"`
+	URL                  string                   `json:"url,omitempty"`
+	Principal            string                   `json:"principal,omitempty"`
+	ArtifactoryPrincipal string                   `json:"artifactoryPrincipal,omitempty"`
+	Agent                map[string]any           `json:"agent,omitempty"`
+	BuildAgent           map[string]any           `json:"buildAgent,omitempty"`
+	VCS                  []ArtifactoryBuildVCS    `json:"vcs,omitempty"`
+	Properties           map[string]string        `json:"properties,omitempty"`
+	Modules              []ArtifactoryBuildModule `json:"modules,omitempty"`
+	Statuses             []map[string]any         `json:"statuses,omitempty"`
+}
+
+// addArtifactoryBuildTools registers the build info tools
+func addArtifactoryBuildTools(s *server.MCPServer) {
+	projectOption := mcp.WithString("project",
+		mcp.Description("Project key when the build belongs to a JFrog project (optional)"),
+	)
+
+	listTool := mcp.NewTool("artifactory_list_builds",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("List builds using the /artifactory/api/build endpoint. With build_name, lists the numbers of that build, newest first."),
+			mcp.WithString("build_name",
+				mcp.Description("Build name whose build numbers should be listed (optional)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of entries to return (defaults to 50)"),
+				mcp.Min(1),
+			),
+			projectOption,
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	getTool := mcp.NewTool("artifactory_get_build",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get the build info of a build number: VCS, agent, modules with their artifacts and dependencies, and optionally the environment properties"),
+			mcp.WithString("build_name",
+				mcp.Required(),
+				mcp.Description("The build name"),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+				mcp.Description("The build number"),
+			),
+			mcp.WithBoolean("include_modules",
+				mcp.Description("Include the artifacts and dependencies of every module (defaults to true)"),
+			),
+			mcp.WithBoolean("include_env",
+				mcp.Description("Include environment and system properties; values of keys that look like secrets are masked (defaults to false)"),
+			),
+			projectOption,
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	diffTool := mcp.NewTool("artifactory_diff_builds",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Compare two build numbers of the same build: added, removed and changed artifacts, dependencies and environment properties, plus the VCS revisions"),
+			mcp.WithString("build_name",
+				mcp.Required(),
+				mcp.Description("The build name"),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+				mcp.Description("The newer build number"),
+			),
+			mcp.WithString("base_build_number",
+				mcp.Required(),
+				mcp.Description("The older build number to compare against"),
+			),
+			projectOption,
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	promoteTool := mcp.NewTool("artifactory_promote_build",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Promote a build by copying or moving its artifacts to a target repository. Runs as a dry run unless dry_run is false."),
+			mcp.WithString("build_name",
+				mcp.Required(),
+				mcp.Description("The build name"),
+			),
+			mcp.WithString("build_number",
+				mcp.Required(),
+				mcp.Description("The build number"),
+			),
+			mcp.WithString("target_repo",
+				mcp.Required(),
+				mcp.Description("Repository the artifacts are promoted to"),
+			),
+			mcp.WithString("source_repo",
+				mcp.Description("Only promote artifacts from this repository (optional)"),
+			),
+			mcp.WithString("mode",
+				mcp.Description("copy or move (defaults to copy)"),
+				mcp.Enum("copy", "move"),
+			),
+			mcp.WithString("status",
+				mcp.Description("Promotion status recorded on the build, e.g. 'staged' or 'released' (optional)"),
+			),
+			mcp.WithString("comment",
+				mcp.Description("Promotion comment (optional)"),
+			),
+			mcp.WithBoolean("include_dependencies",
+				mcp.Description("Also promote the build dependencies (defaults to false)"),
+			),
+			mcp.WithString("scopes",
+				mcp.Description("Comma-separated dependency scopes to promote when include_dependencies is set (optional)"),
+			),
+			mcp.WithString("properties",
+				mcp.Description("Properties to set on the promoted artifacts, e.g. 'release=1.2;qa.status=passed' (optional)"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Only check what would be promoted (defaults to true)"),
+			),
+			projectOption,
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	deleteTool := mcp.NewTool("artifactory_delete_builds",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Delete old build numbers of a build, selected explicitly, by keeping the newest N, or by age. Runs as a dry run unless dry_run is false."),
+			mcp.WithString("build_name",
+				mcp.Required(),
+				mcp.Description("The build name"),
+			),
+			mcp.WithString("build_numbers",
+				mcp.Description("Comma-separated build numbers to delete (optional)"),
+			),
+			mcp.WithNumber("keep_last",
+				mcp.Description("Delete every build number except the newest N (optional)"),
+				mcp.Min(1),
+			),
+			mcp.WithNumber("older_than_days",
+				mcp.Description("Delete build numbers started more than this many days ago (optional)"),
+				mcp.Min(1),
+			),
+			mcp.WithBoolean("delete_artifacts",
+				mcp.Description("Also delete the artifacts of the deleted build numbers (defaults to false)"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Only list the build numbers that would be deleted (defaults to true)"),
+			),
+			projectOption,
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(listTool, executeArtifactoryListBuilds)
+	s.AddTool(getTool, executeArtifactoryGetBuild)
+	s.AddTool(diffTool, executeArtifactoryDiffBuilds)
+	s.AddTool(promoteTool, executeArtifactoryPromoteBuild)
+	s.AddTool(deleteTool, executeArtifactoryDeleteBuilds)
+}
+
+// artifactoryBuildEndpoint joins the build API path segments and the optional project parameter
+func artifactoryBuildEndpoint(project string, segments ...string) string {
+	endpoint := artifactoryBuildsEndpoint
+	for _, segment := range segments {
+		endpoint += "/" + url.PathEscape(segment)
+	}
+	if project != "" {
+		endpoint += "?project=" + url.QueryEscape(project)
+	}
+	return endpoint
+}
+
+// getArtifactoryBuildNumbers lists the numbers of a build, newest first
+func getArtifactoryBuildNumbers(ctx context.Context, client *artifactoryClient, project, buildName string) ([]ArtifactoryBuildNumberRef, error) {
+	var response struct {
+		BuildsNumbers []ArtifactoryBuildNumberRef `json:"buildsNumbers"`
+	}
+	if err := client.doJSON(ctx, "GET", artifactoryBuildEndpoint(project, buildName), nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to list build numbers of '%s': %v", buildName, err)
+	}
+
+	numbers := response.BuildsNumbers
+	sort.SliceStable(numbers, func(i, j int) bool {
+		return parseArtifactoryTime(numbers[i].Started).After(parseArtifactoryTime(numbers[j].Started))
+	})
+	return numbers, nil
+}
+
+// getArtifactoryBuildInfo fetches the build info of a build number
+func getArtifactoryBuildInfo(ctx context.Context, client *artifactoryClient, project, buildName, buildNumber string) (*ArtifactoryBuildInfo, error) {
+	var response struct {
+		BuildInfo ArtifactoryBuildInfo `json:"buildInfo"`
+	}
+	if err := client.doJSON(ctx, "GET", artifactoryBuildEndpoint(project, buildName, buildNumber), nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get build '%s' number '%s': %v", buildName, buildNumber, err)
+	}
+	return &response.BuildInfo, nil
+}
+
+// parseArtifactoryTime parses the timestamps used by the build API, returning the zero time when it cannot
+func parseArtifactoryTime(value string) time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05.000Z0700", time.RFC3339Nano} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// maskArtifactoryBuildProperties masks values of properties whose keys look like credentials
+func maskArtifactoryBuildProperties(properties map[string]string) map[string]string {
+	masked := make(map[string]string, len(properties))
+	for key, value := range properties {
+		if artifactorySecretPropertyPattern.MatchString(key) {
+			value = "********"
+		}
+		masked[key] = value
+	}
+	return masked
+}
+
+// executeArtifactoryListBuilds handles the list builds tool execution
+func executeArtifactoryListBuilds(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	buildName := request.GetString("build_name", "")
+	project := request.GetString("project", "")
+	limit := int(request.GetFloat("limit", 50))
+	if limit < 1 {
+		limit = 50
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if buildName != "" {
+		numbers, err := getArtifactoryBuildNumbers(ctx, client, project, buildName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		total := len(numbers)
+		if len(numbers) > limit {
+			numbers = numbers[:limit]
+		}
+		entries := make([]map[string]string, 0, len(numbers))
+		for _, number := range numbers {
+			entries = append(entries, map[string]string{"number": strings.TrimPrefix(number.URI, "/"), "started": number.Started})
+		}
+		return artifactoryJSONResult(map[string]interface{}{
+			"build_name":    buildName,
+			"build_numbers": entries,
+			"count":         len(entries),
+			"total":         total,
+			"url":           client.url(artifactoryBuildEndpoint(project, buildName)),
+			"timestamp":     time.Now().Format(time.RFC3339),
+		})
+	}
+
+	var response struct {
+		Builds []ArtifactoryBuildRef `json:"builds"`
+	}
+	if err := client.doJSON(ctx, "GET", artifactoryBuildEndpoint(project), nil, &response); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory builds request failed: %v", err)), nil
+	}
+
+	builds := response.Builds
+	sort.SliceStable(builds, func(i, j int) bool {
+		return parseArtifactoryTime(builds[i].LastStarted).After(parseArtifactoryTime(builds[j].LastStarted))
+	})
+	total := len(builds)
+	if len(builds) > limit {
+		builds = builds[:limit]
+	}
+	entries := make([]map[string]string, 0, len(builds))
+	for _, build := range builds {
+		name, _ := url.PathUnescape(strings.TrimPrefix(build.URI, "/"))
+		entries = append(entries, map[string]string{"name": name, "lastStarted": build.LastStarted})
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"builds":    entries,
+		"count":     len(entries),
+		"total":     total,
+		"url":       client.url(artifactoryBuildEndpoint(project)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryGetBuild handles the get build tool execution
+func executeArtifactoryGetBuild(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	buildName := request.GetString("build_name", "")
+	buildNumber := request.GetString("build_number", "")
+	if buildName == "" || buildNumber == "" {
+		return mcp.NewToolResultError("build_name and build_number are required"), nil
+	}
+	project := request.GetString("project", "")
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	build, err := getArtifactoryBuildInfo(ctx, client, project, buildName, buildNumber)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	artifactCount, dependencyCount := 0, 0
+	modules := make([]map[string]interface{}, 0, len(build.Modules))
+	for _, module := range build.Modules {
+		artifactCount += len(module.Artifacts)
+		dependencyCount += len(module.Dependencies)
+		modules = append(modules, map[string]interface{}{
+			"id":           module.ID,
+			"artifacts":    len(module.Artifacts),
+			"dependencies": len(module.Dependencies),
+		})
+	}
+
+	if request.GetBool("include_env", false) {
+		build.Properties = maskArtifactoryBuildProperties(build.Properties)
+	} else {
+		build.Properties = nil
+	}
+
+	if !request.GetBool("include_modules", true) {
+		build.Modules = nil
+	}
+
+	result := map[string]interface{}{
+		"build": build,
+		"summary": map[string]interface{}{
+			"modules":      modules,
+			"artifacts":    artifactCount,
+			"dependencies": dependencyCount,
+		},
+		"url":       client.url(artifactoryBuildEndpoint(project, buildName, buildNumber)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	return artifactoryJSONResult(result)
+}
+
+// executeArtifactoryDiffBuilds handles the diff builds tool execution
+func executeArtifactoryDiffBuilds(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	buildName := request.GetString("build_name", "")
+	buildNumber := request.GetString("build_number", "")
+	baseNumber := request.GetString("base_build_number", "")
+	if buildName == "" || buildNumber == "" || baseNumber == "" {
+		return mcp.NewToolResultError("build_name, build_number and base_build_number are required"), nil
+	}
+	project := request.GetString("project", "")
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	base, err := getArtifactoryBuildInfo(ctx, client, project, buildName, baseNumber)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	build, err := getArtifactoryBuildInfo(ctx, client, project, buildName, buildNumber)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := diffArtifactoryBuilds(base, build)
+	result["build_name"] = buildName
+	result["build_number"] = buildNumber
+	result["base_build_number"] = baseNumber
+	result["url"] = client.url(artifactoryBuildEndpoint(project, buildName, buildNumber))
+	result["timestamp"] = time.Now().Format(time.RFC3339)
+	return artifactoryJSONResult(result)
+}
+
+// diffArtifactoryBuilds compares the artifacts, dependencies, properties and VCS revisions of two builds
+func diffArtifactoryBuilds(base, build *ArtifactoryBuildInfo) map[string]interface{} {
+	artifacts := func(info *ArtifactoryBuildInfo) map[string]string {
+		checksums := map[string]string{}
+		for _, module := range info.Modules {
+			for _, artifact := range module.Artifacts {
+				checksums[module.ID+" :: "+artifact.Name] = artifact.SHA1
+			}
+		}
+		return checksums
+	}
+	dependencies := func(info *ArtifactoryBuildInfo) map[string]string {
+		checksums := map[string]string{}
+		for _, module := range info.Modules {
+			for _, dependency := range module.Dependencies {
+				checksums[dependency.ID] = dependency.SHA1
+			}
+		}
+		return checksums
+	}
+	revisions := func(info *ArtifactoryBuildInfo) []string {
+		values := []string{}
+		for _, vcs := range info.VCS {
+			values = append(values, vcs.Revision)
+		}
+		return values
+	}
+
+	artifactDiff := diffArtifactoryStringMaps(artifacts(base), artifacts(build), false)
+	dependencyDiff := diffArtifactoryStringMaps(dependencies(base), dependencies(build), false)
+	propertyDiff := diffArtifactoryStringMaps(maskArtifactoryBuildProperties(base.Properties), maskArtifactoryBuildProperties(build.Properties), true)
+
+	return map[string]interface{}{
+		"artifacts":    artifactDiff,
+		"dependencies": dependencyDiff,
+		"properties":   propertyDiff,
+		"vcs": map[string]interface{}{
+			"base":    revisions(base),
+			"current": revisions(build),
+		},
+		"started": map[string]string{
+			"base":    base.Started,
+			"current": build.Started,
+		},
+		"summary": map[string]int{
+			"artifacts_added":      len(artifactDiff["added"].([]string)),
+			"artifacts_removed":    len(artifactDiff["removed"].([]string)),
+			"artifacts_changed":    len(artifactDiff["changed"].([]string)),
+			"dependencies_added":   len(dependencyDiff["added"].([]string)),
+			"dependencies_removed": len(dependencyDiff["removed"].([]string)),
+			"dependencies_changed": len(dependencyDiff["changed"].([]string)),
+			"properties_changed":   len(propertyDiff["added"].([]string)) + len(propertyDiff["removed"].([]string)) + len(propertyDiff["changed"].([]string)),
+		},
+	}
+}
+
+// diffArtifactoryStringMaps lists the keys added, removed and changed between two maps.
+// With showValues, changed entries also include the old and new value.
+func diffArtifactoryStringMaps(base, current map[string]string, showValues bool) map[string]interface{} {
+	added, removed, changed := []string{}, []string{}, []string{}
+	values := map[string]map[string]string{}
+	for _, key := range sortedKeys(current) {
+		old, ok := base[key]
+		switch {
+		case !ok:
+			added = append(added, key)
+		case old != current[key]:
+			changed = append(changed, key)
+			values[key] = map[string]string{"from": old, "to": current[key]}
+		}
+	}
+	for _, key := range sortedKeys(base) {
+		if _, ok := current[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	diff := map[string]interface{}{"added": added, "removed": removed, "changed": changed}
+	if showValues {
+		diff["values"] = values
+	}
+	return diff
+}
+
+// executeArtifactoryPromoteBuild handles the promote build tool execution
+func executeArtifactoryPromoteBuild(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	buildName := request.GetString("build_name", "")
+	buildNumber := request.GetString("build_number", "")
+	targetRepo := request.GetString("target_repo", "")
+	if buildName == "" || buildNumber == "" || targetRepo == "" {
+		return mcp.NewToolResultError("build_name, build_number and target_repo are required"), nil
+	}
+	mode := strings.ToLower(request.GetString("mode", "copy"))
+	if mode != "copy" && mode != "move" {
+		return mcp.NewToolResultError("mode must be copy or move"), nil
+	}
+	properties, err := parseArtifactoryProperties(request.GetString("properties", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	dryRun := request.GetBool("dry_run", true)
+	project := request.GetString("project", "")
+
+	promotion := map[string]interface{}{
+		"targetRepo":   targetRepo,
+		"copy":         mode == "copy",
+		"artifacts":    true,
+		"dependencies": request.GetBool("include_dependencies", false),
+		"dryRun":       dryRun,
+		"failFast":     true,
+	}
+	if sourceRepo := request.GetString("source_repo", ""); sourceRepo != "" {
+		promotion["sourceRepo"] = sourceRepo
+	}
+	if status := request.GetString("status", ""); status != "" {
+		promotion["status"] = status
+	}
+	if comment := request.GetString("comment", ""); comment != "" {
+		promotion["comment"] = comment
+	}
+	if scopes := parseCommaSeparated(request.GetString("scopes", "")); len(scopes) > 0 {
+		promotion["scopes"] = scopes
+	}
+	if len(properties) > 0 {
+		promotion["properties"] = properties
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	endpoint := artifactoryBuildEndpoint(project, "promote", buildName, buildNumber)
+	var response struct {
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := client.doJSON(ctx, "POST", endpoint, promotion, &response); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("build promotion failed: %v", err)), nil
+	}
+
+	message := fmt.Sprintf("Build '%s' #%s promoted to '%s' (%s)", buildName, buildNumber, targetRepo, mode)
+	if dryRun {
+		message = fmt.Sprintf("Dry run: build '%s' #%s can be promoted to '%s' (%s). Set dry_run to false to promote it.", buildName, buildNumber, targetRepo, mode)
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":   message,
+		"dry_run":   dryRun,
+		"promotion": promotion,
+		"messages":  response.Messages,
+		"url":       client.url(endpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryDeleteBuilds handles the delete builds tool execution
+func executeArtifactoryDeleteBuilds(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	buildName := request.GetString("build_name", "")
+	if buildName == "" {
+		return mcp.NewToolResultError("build_name is required"), nil
+	}
+	explicit := parseCommaSeparated(request.GetString("build_numbers", ""))
+	keepLast := int(request.GetFloat("keep_last", 0))
+	olderThanDays := int(request.GetFloat("older_than_days", 0))
+	if len(explicit) == 0 && keepLast <= 0 && olderThanDays <= 0 {
+		return mcp.NewToolResultError("select build numbers with build_numbers, keep_last or older_than_days"), nil
+	}
+	deleteArtifacts := request.GetBool("delete_artifacts", false)
+	dryRun := request.GetBool("dry_run", true)
+	project := request.GetString("project", "")
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	numbers, err := getArtifactoryBuildNumbers(ctx, client, project, buildName)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	selected := selectArtifactoryBuildNumbers(numbers, explicit, keepLast, olderThanDays, time.Now())
+	result := map[string]interface{}{
+		"build_name":       buildName,
+		"build_numbers":    selected,
+		"count":            len(selected),
+		"remaining":        len(numbers) - len(selected),
+		"delete_artifacts": deleteArtifacts,
+		"dry_run":          dryRun,
+		"url":              client.url(artifactoryBuildEndpoint(project, buildName)),
+		"timestamp":        time.Now().Format(time.RFC3339),
+	}
+
+	if len(selected) == 0 {
+		result["message"] = "No build numbers match the selection"
+		return artifactoryJSONResult(result)
+	}
+	if dryRun {
+		result["message"] = fmt.Sprintf("Dry run: %d build numbers of '%s' would be deleted. Set dry_run to false to delete them.", len(selected), buildName)
+		return artifactoryJSONResult(result)
+	}
+
+	params := url.Values{}
+	params.Set("buildNumbers", strings.Join(selected, ","))
+	params.Set("artifacts", strconv.Itoa(boolToInt(deleteArtifacts)))
+	if project != "" {
+		params.Set("project", project)
+	}
+	endpoint := artifactoryBuildsEndpoint + "/" + url.PathEscape(buildName) + "?" + params.Encode()
+	if err := client.doJSON(ctx, "DELETE", endpoint, nil, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete builds: %v", err)), nil
+	}
+
+	result["message"] = fmt.Sprintf("Deleted %d build numbers of '%s'", len(selected), buildName)
+	return artifactoryJSONResult(result)
+}
+
+// selectArtifactoryBuildNumbers picks the build numbers to delete from a newest-first list.
+// Explicit numbers are always selected; keep_last and older_than_days must both hold when both are set.
+func selectArtifactoryBuildNumbers(numbers []ArtifactoryBuildNumberRef, explicit []string, keepLast, olderThanDays int, now time.Time) []string {
+	wanted := map[string]bool{}
+	for _, number := range explicit {
+		wanted[number] = true
+	}
+	cutoff := now.AddDate(0, 0, -olderThanDays)
+
+	selected := []string{}
+	for i, ref := range numbers {
+		number := strings.TrimPrefix(ref.URI, "/")
+		if !wanted[number] {
+			if keepLast <= 0 && olderThanDays <= 0 {
+				continue
+			}
+			if keepLast > 0 && i < keepLast {
+				continue
+			}
+			if started := parseArtifactoryTime(ref.Started); olderThanDays > 0 && (started.IsZero() || !started.Before(cutoff)) {
+				continue
+			}
+		}
+		selected = append(selected, number)
+	}
+	return selected
+}
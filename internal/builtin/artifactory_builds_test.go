@@ -0,0 +1,147 @@
+package builtin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffArtifactoryBuilds(t *testing.T) {
+	base := &ArtifactoryBuildInfo{
+		Started: "2024-05-01T10:00:00.000+0000",
+		VCS:     []ArtifactoryBuildVCS{{Revision: "abc"}},
+		Properties: map[string]string{
+			"buildInfo.env.JAVA_HOME": "/opt/jdk17",
+			"buildInfo.env.API_TOKEN": "old",
+		},
+		Modules: []ArtifactoryBuildModule{{
+			ID:           "org.acme:app:1.0",
+			Artifacts:    []ArtifactoryBuildArtifact{{Name: "app.jar", SHA1: "1"}, {Name: "app-sources.jar", SHA1: "2"}},
+			Dependencies: []ArtifactoryBuildDependency{{ID: "org.slf4j:slf4j-api:2.0.9", SHA1: "a"}},
+		}},
+	}
+	build := &ArtifactoryBuildInfo{
+		Started: "2024-05-02T10:00:00.000+0000",
+		VCS:     []ArtifactoryBuildVCS{{Revision: "def"}},
+		Properties: map[string]string{
+			"buildInfo.env.JAVA_HOME": "/opt/jdk21",
+			"buildInfo.env.API_TOKEN": "new",
+		},
+		Modules: []ArtifactoryBuildModule{{
+			ID:           "org.acme:app:1.0",
+			Artifacts:    []ArtifactoryBuildArtifact{{Name: "app.jar", SHA1: "3"}, {Name: "app.pom", SHA1: "4"}},
+			Dependencies: []ArtifactoryBuildDependency{{ID: "org.slf4j:slf4j-api:2.0.13", SHA1: "b"}},
+		}},
+	}
+
+	diff := diffArtifactoryBuilds(base, build)
+	artifacts := diff["artifacts"].(map[string]interface{})
+	if !reflect.DeepEqual(artifacts["added"], []string{"org.acme:app:1.0 :: app.pom"}) ||
+		!reflect.DeepEqual(artifacts["removed"], []string{"org.acme:app:1.0 :: app-sources.jar"}) ||
+		!reflect.DeepEqual(artifacts["changed"], []string{"org.acme:app:1.0 :: app.jar"}) {
+		t.Errorf("Unexpected artifact diff: %v", artifacts)
+	}
+
+	summary := diff["summary"].(map[string]int)
+	if summary["dependencies_added"] != 1 || summary["dependencies_removed"] != 1 {
+		t.Errorf("Unexpected dependency summary: %v", summary)
+	}
+
+	// Only the JAVA_HOME change should show its values; the token stays masked on both sides
+	properties := diff["properties"].(map[string]interface{})
+	if !reflect.DeepEqual(properties["changed"], []string{"buildInfo.env.JAVA_HOME"}) {
+		t.Errorf("Unexpected property changes: %v", properties)
+	}
+}
+
+func TestSelectArtifactoryBuildNumbers(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	numbers := []ArtifactoryBuildNumberRef{
+		{URI: "/5", Started: "2024-05-31T10:00:00.000+0000"},
+		{URI: "/4", Started: "2024-05-20T10:00:00.000+0000"},
+		{URI: "/3", Started: "2024-04-01T10:00:00.000+0000"},
+		{URI: "/2", Started: "2024-03-01T10:00:00.000+0000"},
+		{URI: "/1", Started: "2024-02-01T10:00:00.000+0000"},
+	}
+
+	if selected := selectArtifactoryBuildNumbers(numbers, nil, 2, 0, now); !reflect.DeepEqual(selected, []string{"3", "2", "1"}) {
+		t.Errorf("keep_last: got %v", selected)
+	}
+	if selected := selectArtifactoryBuildNumbers(numbers, nil, 0, 45, now); !reflect.DeepEqual(selected, []string{"3", "2", "1"}) {
+		t.Errorf("older_than_days: got %v", selected)
+	}
+	if selected := selectArtifactoryBuildNumbers(numbers, nil, 4, 45, now); !reflect.DeepEqual(selected, []string{"1"}) {
+		t.Errorf("keep_last and older_than_days: got %v", selected)
+	}
+	if selected := selectArtifactoryBuildNumbers(numbers, []string{"4"}, 0, 0, now); !reflect.DeepEqual(selected, []string{"4"}) {
+		t.Errorf("explicit: got %v", selected)
+	}
+}
+
+func TestArtifactoryPromoteAndDeleteBuilds(t *testing.T) {
+	var promotion map[string]any
+	deleteQuery := ""
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/artifactory/api/build/promote/my build/7":
+			json.NewDecoder(r.Body).Decode(&promotion)
+			json.NewEncoder(w).Encode(map[string]any{"messages": []any{}})
+		case r.Method == "GET" && r.URL.Path == "/artifactory/api/build/my build":
+			json.NewEncoder(w).Encode(map[string]any{"buildsNumbers": []ArtifactoryBuildNumberRef{
+				{URI: "/6", Started: "2024-05-01T10:00:00.000+0000"},
+				{URI: "/7", Started: "2024-05-02T10:00:00.000+0000"},
+				{URI: "/5", Started: "2024-04-30T10:00:00.000+0000"},
+			}})
+		case r.Method == "DELETE" && r.URL.Path == "/artifactory/api/build/my build":
+			deleteQuery = r.URL.RawQuery
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryPromoteBuild, map[string]any{
+		"build_name":   "my build",
+		"build_number": "7",
+		"target_repo":  "libs-release-local",
+		"mode":         "move",
+		"status":       "released",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected promote error: %v", result.Content)
+	}
+	if promotion["dryRun"] != true || promotion["copy"] != false || promotion["status"] != "released" {
+		t.Errorf("Unexpected promotion request: %v", promotion)
+	}
+	if response["dry_run"] != true {
+		t.Error("Promotion should default to a dry run")
+	}
+
+	response, result = callArtifactoryTool(t, executeArtifactoryDeleteBuilds, map[string]any{
+		"build_name": "my build",
+		"keep_last":  1,
+	})
+	if result.IsError || deleteQuery != "" {
+		t.Fatalf("Dry run must not delete: %v", result.Content)
+	}
+	if !reflect.DeepEqual(response["build_numbers"], []any{"6", "5"}) {
+		t.Errorf("Expected the two oldest numbers, got %v", response["build_numbers"])
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryDeleteBuilds, map[string]any{
+		"build_name": "my build",
+		"keep_last":  1,
+		"dry_run":    false,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected delete error: %v", result.Content)
+	}
+	if deleteQuery != "artifacts=0&buildNumbers=6%2C5" {
+		t.Errorf("Unexpected delete query: %s", deleteQuery)
+	}
+}
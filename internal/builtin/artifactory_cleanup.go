@@ -0,0 +1,623 @@
+package builtin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// artifactoryCleanupPlanTTL is how long a cleanup plan can be executed after it was created
+const artifactoryCleanupPlanTTL = 24 * time.Hour
+
+var (
+	artifactoryCleanupPlansMu sync.Mutex
+	artifactoryCleanupPlans   = map[string]*ArtifactoryCleanupPlan{}
+
+	// artifactorySnapshotBuildPattern matches the timestamp-buildNumber part of unique Maven snapshot file names
+	artifactorySnapshotBuildPattern = regexp.MustCompile(`-(\d{8}\.\d{6})-(\d+)`)
+)
+
+// ArtifactoryCleanupCandidate is an artifact the cleanup plan proposes to delete
+type ArtifactoryCleanupCandidate struct {
+	Repo           string `json:"repo"`
+	Path           string `json:"path"`
+	Name           string `json:"name"`
+	Size           int64  `json:"size"`
+	Created        string `json:"created,omitempty"`
+	LastDownloaded string `json:"lastDownloaded,omitempty"`
+	Reason         string `json:"reason"`
+}
+
+// ArtifactoryFolderUsage is the space used by a folder of a repository
+type ArtifactoryFolderUsage struct {
+	Path          string `json:"path"`
+	Size          int64  `json:"size"`
+	SizeFormatted string `json:"sizeFormatted"`
+	FileCount     int64  `json:"fileCount"`
+}
+
+// ArtifactoryCleanupRepoSummary summarizes the plan for one repository
+type ArtifactoryCleanupRepoSummary struct {
+	Repo                      string                   `json:"repo"`
+	Size                      int64                    `json:"size,omitempty"`
+	SizeFormatted             string                   `json:"sizeFormatted,omitempty"`
+	Candidates                int                      `json:"candidates"`
+	ProjectedSavings          int64                    `json:"projectedSavings"`
+	ProjectedSavingsFormatted string                   `json:"projectedSavingsFormatted"`
+	SavingsPercent            float64                  `json:"savingsPercent,omitempty"`
+	StaleArtifacts            int                      `json:"staleArtifacts"`
+	ExpiredSnapshots          int                      `json:"expiredSnapshots"`
+	LargestFolders            []ArtifactoryFolderUsage `json:"largestFolders,omitempty"`
+	Truncated                 bool                     `json:"truncated,omitempty"`
+	Warnings                  []string                 `json:"warnings,omitempty"`
+}
+
+// ArtifactoryCleanupPlan is a dry-run cleanup plan that can later be executed by its ID
+type ArtifactoryCleanupPlan struct {
+	ID                    string                          `json:"id"`
+	BaseURL               string                          `json:"baseUrl"`
+	CreatedAt             time.Time                       `json:"createdAt"`
+	ExpiresAt             time.Time                       `json:"expiresAt"`
+	NotDownloadedDays     int                             `json:"notDownloadedDays"`
+	SnapshotRetention     int                             `json:"snapshotRetention"`
+	Repositories          []ArtifactoryCleanupRepoSummary `json:"repositories"`
+	TotalCandidates       int                             `json:"totalCandidates"`
+	TotalSavings          int64                           `json:"totalSavings"`
+	TotalSavingsFormatted string                          `json:"totalSavingsFormatted"`
+	Candidates            []ArtifactoryCleanupCandidate   `json:"-"`
+	Executed              bool                            `json:"executed"`
+	Processed             int                             `json:"processed,omitempty"`
+	Failed                int                             `json:"failed,omitempty"`
+	executionInProgress   bool
+}
+
+// addArtifactoryCleanupTools registers the cleanup planning and execution tools
+func addArtifactoryCleanupTools(s *server.MCPServer) {
+	planTool := mcp.NewTool("artifactory_cleanup_plan",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Build a dry-run cleanup plan using AQL: artifacts not downloaded in N days, snapshot builds beyond a retention count and the largest folders per repository. Nothing is deleted; the returned plan_id can be passed to artifactory_cleanup_execute."),
+			mcp.WithString("repositories",
+				mcp.Description("Comma-separated list of repositories to analyze (optional, defaults to all LOCAL repositories)"),
+			),
+			mcp.WithNumber("not_downloaded_days",
+				mcp.Description("Propose artifacts older than this many days that were not downloaded within it; 0 disables the check (defaults to 180)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("snapshot_retention",
+				mcp.Description("Number of snapshot builds to keep per snapshot version; older builds are proposed for deletion, 0 disables the check (defaults to 5)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("largest_folders",
+				mcp.Description("Number of largest folders to report per repository (defaults to 10)"),
+				mcp.Min(0),
+				mcp.Max(100),
+			),
+			mcp.WithNumber("folder_depth",
+				mcp.Description("Folder depth used to group the largest folders (defaults to 2)"),
+				mcp.Min(1),
+				mcp.Max(10),
+			),
+			mcp.WithNumber("max_items",
+				mcp.Description("Maximum number of items each AQL query returns per repository (defaults to 10000)"),
+				mcp.Min(1),
+				mcp.Max(100000),
+			),
+			mcp.WithNumber("preview",
+				mcp.Description("Number of candidates to include in the response (defaults to 50)"),
+				mcp.Min(0),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	executeTool := mcp.NewTool("artifactory_cleanup_execute",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Execute a cleanup plan created by artifactory_cleanup_plan. Deletes the planned artifacts in batches and reports progress. Plans expire after 24 hours. A completed plan cannot be executed again, but a cancelled or partly failed run can be retried with the same plan_id."),
+			mcp.WithString("plan_id",
+				mcp.Required(),
+				mcp.Description("The plan ID returned by artifactory_cleanup_plan"),
+			),
+			mcp.WithNumber("batch_size",
+				mcp.Description("Number of artifacts deleted per batch (defaults to 100)"),
+				mcp.Min(1),
+				mcp.Max(1000),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(planTool, executeArtifactoryCleanupPlan)
+	s.AddTool(executeTool, executeArtifactoryCleanupExecute)
+}
+
+// executeArtifactoryCleanupPlan handles the cleanup plan tool execution
+func executeArtifactoryCleanupPlan(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	notDownloadedDays := int(request.GetFloat("not_downloaded_days", 180))
+	snapshotRetention := int(request.GetFloat("snapshot_retention", 5))
+	largestFolders := int(request.GetFloat("largest_folders", 10))
+	folderDepth := int(request.GetFloat("folder_depth", 2))
+	maxItems := int(request.GetFloat("max_items", 10000))
+	preview := int(request.GetFloat("preview", 50))
+	if notDownloadedDays <= 0 && snapshotRetention <= 0 && largestFolders <= 0 {
+		return mcp.NewToolResultError("nothing to analyze: enable not_downloaded_days, snapshot_retention or largest_folders"), nil
+	}
+	if folderDepth < 1 {
+		folderDepth = 1
+	}
+	if maxItems < 1 {
+		maxItems = 10000
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var repositories []ArtifactoryRepository
+	if err := client.doJSON(ctx, "GET", "artifactory/api/repositories", nil, &repositories); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory repositories request failed: %v", err)), nil
+	}
+
+	selected := []ArtifactoryRepository{}
+	if requested := parseCommaSeparated(request.GetString("repositories", "")); len(requested) > 0 {
+		known := map[string]ArtifactoryRepository{}
+		for _, repo := range repositories {
+			known[repo.Key] = repo
+		}
+		for _, key := range requested {
+			repo, ok := known[key]
+			if !ok {
+				// Remote repository caches are not listed but can still be searched
+				repo = ArtifactoryRepository{Key: key}
+			}
+			selected = append(selected, repo)
+		}
+	} else {
+		for _, repo := range repositories {
+			if strings.EqualFold(repo.Type, "LOCAL") {
+				selected = append(selected, repo)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return mcp.NewToolResultError("no repositories to analyze"), nil
+	}
+
+	plan := &ArtifactoryCleanupPlan{
+		ID:                newArtifactoryCleanupPlanID(),
+		BaseURL:           client.baseURL,
+		CreatedAt:         time.Now(),
+		NotDownloadedDays: notDownloadedDays,
+		SnapshotRetention: snapshotRetention,
+	}
+	plan.ExpiresAt = plan.CreatedAt.Add(artifactoryCleanupPlanTTL)
+
+	for _, repo := range selected {
+		summary, candidates, err := planArtifactoryRepositoryCleanup(ctx, client, repo, notDownloadedDays, snapshotRetention, largestFolders, folderDepth, maxItems)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		plan.Repositories = append(plan.Repositories, summary)
+		plan.Candidates = append(plan.Candidates, candidates...)
+		plan.TotalSavings += summary.ProjectedSavings
+	}
+	plan.TotalCandidates = len(plan.Candidates)
+	plan.TotalSavingsFormatted = formatBytes(plan.TotalSavings)
+
+	// Biggest wins first
+	sort.SliceStable(plan.Candidates, func(i, j int) bool {
+		return plan.Candidates[i].Size > plan.Candidates[j].Size
+	})
+	storeArtifactoryCleanupPlan(plan)
+
+	if preview < 0 {
+		preview = 0
+	}
+	if preview > len(plan.Candidates) {
+		preview = len(plan.Candidates)
+	}
+
+	message := fmt.Sprintf("Dry run: %d artifacts (%s) can be deleted. Run artifactory_cleanup_execute with plan_id '%s' to apply the plan.", plan.TotalCandidates, plan.TotalSavingsFormatted, plan.ID)
+	if plan.TotalCandidates == 0 {
+		message = "Dry run: no artifacts match the cleanup criteria"
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":    message,
+		"plan_id":    plan.ID,
+		"plan":       plan,
+		"candidates": plan.Candidates[:preview],
+		"url":        client.url("artifactory/api/search/aql"),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// planArtifactoryRepositoryCleanup runs the cleanup queries for a single repository
+func planArtifactoryRepositoryCleanup(ctx context.Context, client *artifactoryClient, repo ArtifactoryRepository, notDownloadedDays, snapshotRetention, largestFolders, folderDepth, maxItems int) (ArtifactoryCleanupRepoSummary, []ArtifactoryCleanupCandidate, error) {
+	summary := ArtifactoryCleanupRepoSummary{Repo: repo.Key}
+	if repoSize, err := getArtifactoryRepositorySize(ctx, client, repo); err == nil {
+		summary.Size = repoSize.Size
+		summary.SizeFormatted = repoSize.SizeFormatted
+	} else {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("storage info unavailable: %v", err))
+	}
+
+	seen := map[string]bool{}
+	candidates := []ArtifactoryCleanupCandidate{}
+	addCandidate := func(candidate ArtifactoryCleanupCandidate) bool {
+		key := path.Join(candidate.Repo, candidate.Path, candidate.Name)
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		candidates = append(candidates, candidate)
+		summary.ProjectedSavings += candidate.Size
+		return true
+	}
+
+	if notDownloadedDays > 0 {
+		period := fmt.Sprintf("%dd", notDownloadedDays)
+		criteria := map[string]any{
+			"repo":    repo.Key,
+			"type":    "file",
+			"created": map[string]any{"$before": period},
+			"$or": []any{
+				map[string]any{"stat.downloaded": map[string]any{"$before": period}},
+				map[string]any{"stat.downloads": map[string]any{"$eq": nil}},
+			},
+		}
+		items, truncated, err := runArtifactoryCleanupQuery(ctx, client, criteria, []string{"repo", "path", "name", "size", "created", "stat.downloaded"}, maxItems)
+		if err != nil {
+			return summary, nil, fmt.Errorf("stale artifact search in '%s' failed: %v", repo.Key, err)
+		}
+		summary.Truncated = summary.Truncated || truncated
+		for _, item := range items {
+			candidate := newArtifactoryCleanupCandidate(item, fmt.Sprintf("not downloaded in %d days", notDownloadedDays))
+			if candidate.LastDownloaded == "" {
+				candidate.Reason = fmt.Sprintf("never downloaded, created more than %d days ago", notDownloadedDays)
+			}
+			if addCandidate(candidate) {
+				summary.StaleArtifacts++
+			}
+		}
+	}
+
+	if snapshotRetention > 0 {
+		criteria := map[string]any{
+			"repo": repo.Key,
+			"type": "file",
+			"path": map[string]any{"$match": "*-SNAPSHOT"},
+		}
+		items, truncated, err := runArtifactoryCleanupQuery(ctx, client, criteria, []string{"repo", "path", "name", "size", "created"}, maxItems)
+		if err != nil {
+			return summary, nil, fmt.Errorf("snapshot search in '%s' failed: %v", repo.Key, err)
+		}
+		summary.Truncated = summary.Truncated || truncated
+		for _, candidate := range expiredArtifactorySnapshots(items, snapshotRetention) {
+			if addCandidate(candidate) {
+				summary.ExpiredSnapshots++
+			}
+		}
+	}
+
+	if largestFolders > 0 {
+		criteria := map[string]any{"repo": repo.Key, "type": "file"}
+		items, truncated, err := runArtifactoryCleanupQuery(ctx, client, criteria, []string{"path", "size"}, maxItems)
+		if err != nil {
+			return summary, nil, fmt.Errorf("folder size search in '%s' failed: %v", repo.Key, err)
+		}
+		summary.Truncated = summary.Truncated || truncated
+		summary.LargestFolders = largestArtifactoryFolders(items, folderDepth, largestFolders)
+	}
+
+	if summary.Truncated {
+		summary.Warnings = append(summary.Warnings, fmt.Sprintf("AQL results were capped at %d items; run the plan again after cleanup or raise max_items", maxItems))
+	}
+	summary.Candidates = len(candidates)
+	summary.ProjectedSavingsFormatted = formatBytes(summary.ProjectedSavings)
+	if summary.Size > 0 {
+		summary.SavingsPercent = float64(int(float64(summary.ProjectedSavings)/float64(summary.Size)*1000)) / 10
+	}
+	return summary, candidates, nil
+}
+
+// runArtifactoryCleanupQuery runs an items.find AQL query and reports whether the limit was reached
+func runArtifactoryCleanupQuery(ctx context.Context, client *artifactoryClient, criteria map[string]any, include []string, limit int) ([]map[string]any, bool, error) {
+	criteriaJSON, err := json.Marshal(criteria)
+	if err != nil {
+		return nil, false, err
+	}
+	includeJSON, _ := json.Marshal(include)
+	includeList := strings.TrimSuffix(strings.TrimPrefix(string(includeJSON), "["), "]")
+	query := fmt.Sprintf("items.find(%s).include(%s).limit(%d)", criteriaJSON, includeList, limit)
+
+	var aqlResponse ArtifactoryAQLResponse
+	if err := client.doText(withArtifactoryReadOnly(ctx), "POST", "artifactory/api/search/aql", query, &aqlResponse); err != nil {
+		return nil, false, err
+	}
+	return aqlResponse.Results, len(aqlResponse.Results) >= limit, nil
+}
+
+// newArtifactoryCleanupCandidate converts an AQL item into a cleanup candidate
+func newArtifactoryCleanupCandidate(item map[string]any, reason string) ArtifactoryCleanupCandidate {
+	candidate := ArtifactoryCleanupCandidate{Reason: reason}
+	candidate.Repo, _ = item["repo"].(string)
+	candidate.Path, _ = item["path"].(string)
+	candidate.Name, _ = item["name"].(string)
+	candidate.Created, _ = item["created"].(string)
+	if size, ok := item["size"].(float64); ok {
+		candidate.Size = int64(size)
+	}
+	// Included stat fields come back as a "stats" array
+	if stats, ok := item["stats"].([]any); ok && len(stats) > 0 {
+		if stat, ok := stats[0].(map[string]any); ok {
+			candidate.LastDownloaded, _ = stat["downloaded"].(string)
+		}
+	}
+	return candidate
+}
+
+// expiredArtifactorySnapshots returns the snapshot files that belong to builds beyond the retention count.
+// Builds are identified by the timestamp-buildNumber part of unique snapshot file names; files without
+// it, like maven-metadata.xml, are always kept.
+func expiredArtifactorySnapshots(items []map[string]any, retention int) []ArtifactoryCleanupCandidate {
+	type snapshotBuild struct {
+		timestamp string
+		number    int
+		files     []ArtifactoryCleanupCandidate
+	}
+
+	folders := map[string]map[string]*snapshotBuild{}
+	for _, item := range items {
+		candidate := newArtifactoryCleanupCandidate(item, "")
+		match := artifactorySnapshotBuildPattern.FindStringSubmatch(candidate.Name)
+		if match == nil {
+			continue
+		}
+		folder := candidate.Repo + "/" + candidate.Path
+		if folders[folder] == nil {
+			folders[folder] = map[string]*snapshotBuild{}
+		}
+		key := match[1] + "-" + match[2]
+		build := folders[folder][key]
+		if build == nil {
+			build = &snapshotBuild{timestamp: match[1]}
+			fmt.Sscanf(match[2], "%d", &build.number)
+			folders[folder][key] = build
+		}
+		build.files = append(build.files, candidate)
+	}
+
+	expired := []ArtifactoryCleanupCandidate{}
+	for _, folder := range sortedKeys(folders) {
+		builds := make([]*snapshotBuild, 0, len(folders[folder]))
+		for _, build := range folders[folder] {
+			builds = append(builds, build)
+		}
+		// Newest first
+		sort.Slice(builds, func(i, j int) bool {
+			if builds[i].timestamp != builds[j].timestamp {
+				return builds[i].timestamp > builds[j].timestamp
+			}
+			return builds[i].number > builds[j].number
+		})
+		for i := retention; i < len(builds); i++ {
+			for _, file := range builds[i].files {
+				file.Reason = fmt.Sprintf("snapshot build %s-%d beyond the newest %d builds", builds[i].timestamp, builds[i].number, retention)
+				expired = append(expired, file)
+			}
+		}
+	}
+	return expired
+}
+
+// largestArtifactoryFolders sums file sizes per folder prefix and returns the largest folders
+func largestArtifactoryFolders(items []map[string]any, depth, limit int) []ArtifactoryFolderUsage {
+	usage := map[string]*ArtifactoryFolderUsage{}
+	for _, item := range items {
+		itemPath, _ := item["path"].(string)
+		folder := "."
+		if itemPath != "" && itemPath != "." {
+			segments := strings.Split(itemPath, "/")
+			if len(segments) > depth {
+				segments = segments[:depth]
+			}
+			folder = strings.Join(segments, "/")
+		}
+		if usage[folder] == nil {
+			usage[folder] = &ArtifactoryFolderUsage{Path: folder}
+		}
+		if size, ok := item["size"].(float64); ok {
+			usage[folder].Size += int64(size)
+		}
+		usage[folder].FileCount++
+	}
+
+	folders := make([]ArtifactoryFolderUsage, 0, len(usage))
+	for _, folder := range usage {
+		folder.SizeFormatted = formatBytes(folder.Size)
+		folders = append(folders, *folder)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		if folders[i].Size != folders[j].Size {
+			return folders[i].Size > folders[j].Size
+		}
+		return folders[i].Path < folders[j].Path
+	})
+	if len(folders) > limit {
+		folders = folders[:limit]
+	}
+	return folders
+}
+
+// newArtifactoryCleanupPlanID generates a unique cleanup plan ID
+func newArtifactoryCleanupPlanID() string {
+	bytes := make([]byte, 6)
+	rand.Read(bytes)
+	return "cleanup-" + time.Now().Format("20060102") + "-" + hex.EncodeToString(bytes)
+}
+
+// storeArtifactoryCleanupPlan keeps a plan for later execution and drops expired plans
+func storeArtifactoryCleanupPlan(plan *ArtifactoryCleanupPlan) {
+	artifactoryCleanupPlansMu.Lock()
+	defer artifactoryCleanupPlansMu.Unlock()
+
+	now := time.Now()
+	for id, stored := range artifactoryCleanupPlans {
+		if now.After(stored.ExpiresAt) {
+			delete(artifactoryCleanupPlans, id)
+		}
+	}
+	artifactoryCleanupPlans[plan.ID] = plan
+}
+
+// claimArtifactoryCleanupPlan returns a plan and marks it as being executed so it cannot run twice
+func claimArtifactoryCleanupPlan(id, baseURL string) (*ArtifactoryCleanupPlan, error) {
+	artifactoryCleanupPlansMu.Lock()
+	defer artifactoryCleanupPlansMu.Unlock()
+
+	plan, ok := artifactoryCleanupPlans[id]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("cleanup plan '%s' not found; plans are kept in memory for %s, create a new one with artifactory_cleanup_plan", id, artifactoryCleanupPlanTTL)
+	case time.Now().After(plan.ExpiresAt):
+		delete(artifactoryCleanupPlans, id)
+		return nil, fmt.Errorf("cleanup plan '%s' expired at %s; create a new one", id, plan.ExpiresAt.Format(time.RFC3339))
+	case plan.BaseURL != baseURL:
+		return nil, fmt.Errorf("cleanup plan '%s' was created for %s, not %s", id, plan.BaseURL, baseURL)
+	case plan.Executed || plan.executionInProgress:
+		return nil, fmt.Errorf("cleanup plan '%s' has already been executed", id)
+	}
+	plan.executionInProgress = true
+	return plan, nil
+}
+
+// executeArtifactoryCleanupExecute handles the cleanup execution tool execution
+func executeArtifactoryCleanupExecute(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	planID := request.GetString("plan_id", "")
+	if planID == "" {
+		return mcp.NewToolResultError("plan_id is required"), nil
+	}
+	batchSize := int(request.GetFloat("batch_size", 100))
+	if batchSize < 1 {
+		batchSize = 100
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	plan, err := claimArtifactoryCleanupPlan(planID, client.baseURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	total := len(plan.Candidates)
+	deleted := 0
+	missing := 0
+	freed := int64(0)
+	failures := []map[string]string{}
+	batches := []map[string]interface{}{}
+	cancelled := false
+
+	// Only a run that handled every candidate without failures completes the plan.
+	// Otherwise the plan can be executed again; items deleted by this run are then counted as already gone.
+	defer func() {
+		artifactoryCleanupPlansMu.Lock()
+		plan.executionInProgress = false
+		plan.Processed = deleted + missing + len(failures)
+		plan.Failed = len(failures)
+		plan.Executed = plan.Processed == total && len(failures) == 0
+		artifactoryCleanupPlansMu.Unlock()
+	}()
+
+	for start := 0; start < total; start += batchSize {
+		if ctx.Err() != nil {
+			cancelled = true
+			break
+		}
+		end := start + batchSize
+		if end > total {
+			end = total
+		}
+
+		batchDeleted, batchFailed := 0, 0
+		for _, candidate := range plan.Candidates[start:end] {
+			itemPath := path.Join(candidate.Repo, candidate.Path, candidate.Name)
+			err := client.doJSON(ctx, "DELETE", "artifactory/"+artifactoryRepoPath(itemPath), nil, nil)
+			if httpErr, ok := err.(*artifactoryHTTPError); ok && httpErr.StatusCode == 404 {
+				// Already gone since the plan was created
+				missing++
+				continue
+			}
+			if err != nil {
+				batchFailed++
+				failures = append(failures, map[string]string{"path": itemPath, "error": err.Error()})
+				continue
+			}
+			batchDeleted++
+			freed += candidate.Size
+		}
+		deleted += batchDeleted
+
+		batches = append(batches, map[string]interface{}{
+			"batch":   len(batches) + 1,
+			"items":   end - start,
+			"deleted": batchDeleted,
+			"failed":  batchFailed,
+		})
+		notifyToolProgress(ctx, request, end, total, fmt.Sprintf("Deleted %d of %d artifacts (%s freed)", deleted, total, formatBytes(freed)))
+	}
+
+	message := fmt.Sprintf("Cleanup plan '%s' executed: %d deleted, %d already gone, %d failed, %s freed. Deleted items may stay in the trash can until it is emptied.", plan.ID, deleted, missing, len(failures), formatBytes(freed))
+	if cancelled {
+		message = fmt.Sprintf("Cleanup plan '%s' was cancelled after %d of %d artifacts.", plan.ID, deleted+missing+len(failures), total)
+	}
+	if cancelled || len(failures) > 0 {
+		message += fmt.Sprintf(" Execute plan '%s' again to retry the remaining artifacts.", plan.ID)
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":        message,
+		"plan_id":        plan.ID,
+		"total":          total,
+		"deleted":        deleted,
+		"alreadyDeleted": missing,
+		"failed":         len(failures),
+		"failures":       failures,
+		"freed":          freed,
+		"freedFormatted": formatBytes(freed),
+		"batches":        batches,
+		"cancelled":      cancelled,
+		"url":            client.url("artifactory"),
+		"timestamp":      time.Now().Format(time.RFC3339),
+	})
+}
+
+// notifyToolProgress sends a progress notification when the caller asked for progress updates
+func notifyToolProgress(ctx context.Context, request mcp.CallToolRequest, progress, total int, message string) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return
+	}
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return
+	}
+	// Progress is best effort, a client that cannot receive it still gets the final result
+	_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+		"progressToken": request.Params.Meta.ProgressToken,
+		"progress":      progress,
+		"total":         total,
+		"message":       message,
+	})
+}
@@ -0,0 +1,195 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestExpiredArtifactorySnapshots(t *testing.T) {
+	items := []map[string]any{}
+	for _, name := range []string{
+		"app-1.0-20240101.100000-1.jar", "app-1.0-20240101.100000-1.pom",
+		"app-1.0-20240102.100000-2.jar",
+		"app-1.0-20240103.100000-3.jar",
+		"maven-metadata.xml",
+	} {
+		items = append(items, map[string]any{"repo": "libs-snapshot", "path": "org/acme/app/1.0-SNAPSHOT", "name": name, "size": float64(100)})
+	}
+
+	expired := expiredArtifactorySnapshots(items, 2)
+	names := []string{}
+	for _, candidate := range expired {
+		names = append(names, candidate.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "app-1.0-20240101.100000-1.jar,app-1.0-20240101.100000-1.pom" {
+		t.Errorf("Expected only the oldest build to expire, got %v", names)
+	}
+}
+
+func TestLargestArtifactoryFolders(t *testing.T) {
+	items := []map[string]any{
+		{"path": "org/acme/app/1.0", "size": float64(300)},
+		{"path": "org/acme/app/2.0", "size": float64(200)},
+		{"path": "org/other/lib/1.0", "size": float64(400)},
+		{"path": ".", "size": float64(50)},
+	}
+
+	folders := largestArtifactoryFolders(items, 3, 2)
+	if len(folders) != 2 || folders[0].Path != "org/acme/app" || folders[0].Size != 500 || folders[1].Path != "org/other/lib" {
+		t.Errorf("Unexpected folders: %+v", folders)
+	}
+}
+
+func TestArtifactoryCleanupPlanAndExecute(t *testing.T) {
+	deleted := []string{}
+	queries := []string{}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/artifactory/api/repositories":
+			json.NewEncoder(w).Encode([]ArtifactoryRepository{
+				{Key: "libs-snapshot", Type: "LOCAL"},
+				{Key: "maven-remote", Type: "REMOTE"},
+			})
+		case r.URL.Path == "/artifactory/api/storage/libs-snapshot":
+			json.NewEncoder(w).Encode(map[string]any{"size": float64(1000)})
+		case r.URL.Path == "/artifactory/api/search/aql":
+			body, _ := io.ReadAll(r.Body)
+			query := string(body)
+			queries = append(queries, query)
+			var results []map[string]any
+			switch {
+			case strings.Contains(query, "stat.downloaded"):
+				results = []map[string]any{
+					{"repo": "libs-snapshot", "path": "org/acme/old/0.1", "name": "old-0.1.jar", "size": float64(300), "stats": []any{map[string]any{"downloaded": "2023-01-01T00:00:00.000Z"}}},
+					{"repo": "libs-snapshot", "path": "org/acme/app/1.0-SNAPSHOT", "name": "app-1.0-20240101.100000-1.jar", "size": float64(100)},
+				}
+			case strings.Contains(query, "$match"):
+				results = []map[string]any{
+					{"repo": "libs-snapshot", "path": "org/acme/app/1.0-SNAPSHOT", "name": "app-1.0-20240101.100000-1.jar", "size": float64(100)},
+					{"repo": "libs-snapshot", "path": "org/acme/app/1.0-SNAPSHOT", "name": "app-1.0-20240102.100000-2.jar", "size": float64(100)},
+				}
+			default:
+				results = []map[string]any{{"path": "org/acme/old/0.1", "size": float64(300)}}
+			}
+			json.NewEncoder(w).Encode(ArtifactoryAQLResponse{Results: results})
+		case r.Method == "DELETE":
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/artifactory/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryCleanupPlan, map[string]any{
+		"not_downloaded_days": 90,
+		"snapshot_retention":  1,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected plan error: %v", result.Content)
+	}
+	if len(deleted) != 0 {
+		t.Fatal("Planning must not delete anything")
+	}
+	for _, query := range queries {
+		if strings.Contains(query, "maven-remote") {
+			t.Error("Only LOCAL repositories should be analyzed by default")
+		}
+	}
+
+	plan := response["plan"].(map[string]any)
+	// The snapshot found by both checks must only be counted once
+	if plan["totalCandidates"] != float64(2) || plan["totalSavings"] != float64(400) {
+		t.Fatalf("Unexpected plan totals: %v", plan)
+	}
+	repo := plan["repositories"].([]any)[0].(map[string]any)
+	if repo["savingsPercent"] != float64(40) || len(repo["largestFolders"].([]any)) != 1 {
+		t.Errorf("Unexpected repository summary: %v", repo)
+	}
+
+	planID := response["plan_id"].(string)
+	response, result = callArtifactoryTool(t, executeArtifactoryCleanupExecute, map[string]any{
+		"plan_id":    planID,
+		"batch_size": 1,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected execute error: %v", result.Content)
+	}
+	if response["deleted"] != float64(2) || len(response["batches"].([]any)) != 2 || len(deleted) != 2 {
+		t.Errorf("Unexpected execution result: %v (deleted %v)", response, deleted)
+	}
+	if deleted[0] != "libs-snapshot/org/acme/old/0.1/old-0.1.jar" {
+		t.Errorf("Expected the largest artifact to be deleted first, got %v", deleted)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryCleanupExecute, map[string]any{"plan_id": planID})
+	if !result.IsError {
+		t.Error("A plan must not be executed twice")
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryCleanupExecute, map[string]any{"plan_id": "cleanup-unknown"})
+	if !result.IsError {
+		t.Error("Expected an error for an unknown plan")
+	}
+}
+
+func TestArtifactoryCleanupExecuteIncomplete(t *testing.T) {
+	existing := map[string]bool{"/artifactory/libs/a/a.jar": true, "/artifactory/libs/b/b.jar": true}
+	locked := true
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/artifactory/libs/b/b.jar" && locked:
+			w.WriteHeader(http.StatusForbidden)
+		case existing[r.URL.Path]:
+			delete(existing, r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	plan := &ArtifactoryCleanupPlan{
+		ID:        newArtifactoryCleanupPlanID(),
+		BaseURL:   mockServer.URL,
+		ExpiresAt: time.Now().Add(time.Hour),
+		Candidates: []ArtifactoryCleanupCandidate{
+			{Repo: "libs", Path: "a", Name: "a.jar", Size: 10},
+			{Repo: "libs", Path: "b", Name: "b.jar", Size: 20},
+		},
+	}
+	storeArtifactoryCleanupPlan(plan)
+
+	// A cancelled run processes nothing and leaves the plan executable
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, _ := executeArtifactoryCleanupExecute(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"plan_id": plan.ID}}})
+	if result.IsError || plan.Executed || plan.Processed != 0 {
+		t.Fatalf("Cancelled run must not complete the plan: %v %+v", result.Content, plan)
+	}
+
+	response, result := callArtifactoryTool(t, executeArtifactoryCleanupExecute, map[string]any{"plan_id": plan.ID})
+	if result.IsError || response["deleted"] != float64(1) || response["failed"] != float64(1) {
+		t.Fatalf("Unexpected execution result: %v", response)
+	}
+	if plan.Executed || plan.Processed != 2 || plan.Failed != 1 || !strings.Contains(response["message"].(string), "again") {
+		t.Errorf("A run with failures must leave the plan executable: %+v, %v", plan, response["message"])
+	}
+
+	locked = false
+	response, result = callArtifactoryTool(t, executeArtifactoryCleanupExecute, map[string]any{"plan_id": plan.ID})
+	if result.IsError || response["deleted"] != float64(1) || response["alreadyDeleted"] != float64(1) || !plan.Executed {
+		t.Errorf("Expected the retry to finish the plan: %v %+v", response, plan)
+	}
+}
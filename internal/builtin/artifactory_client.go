@@ -0,0 +1,500 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// artifactoryClient performs authenticated REST calls against a single Artifactory instance
+type artifactoryClient struct {
+	instance   *ArtifactoryInstanceConfig
+	baseURL    string
+	username   string
+	password   string
+	apiKey     string
+	settings   ArtifactoryCommonSettings
+	maxRetries int
+	httpClient *http.Client
+}
+
+var (
+	artifactoryTransportsMu sync.Mutex
+	artifactoryTransports   = make(map[bool]*http.Transport)
+
+	// artifactoryRetryDelayUnit scales commonSettings.retryDelay; tests shorten it
+	artifactoryRetryDelayUnit = time.Second
+
+	artifactoryLogger = log.New(os.Stderr, "[artifactory] ", log.LstdFlags)
+)
+
+const (
+	artifactoryMaxBackoff    = 60 * time.Second
+	artifactoryMaxRetryAfter = 120 * time.Second
+
+	// artifactoryTransferTimeout bounds artifact downloads and uploads, which are not limited by the request timeout
+	artifactoryTransferTimeout = 30 * time.Minute
+)
+
+// artifactoryLogLevels orders the commonSettings.logLevel values from most to least verbose
+var artifactoryLogLevels = map[string]int{
+	"debug":   0,
+	"info":    1,
+	"warn":    2,
+	"warning": 2,
+	"error":   3,
+	"off":     4,
+	"none":    4,
+}
+
+// artifactoryHTTPError is returned when Artifactory answers with a non-2xx status code
+type artifactoryHTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *artifactoryHTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s %s failed with status code: %d", e.Method, e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s failed with status code: %d, response: %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// artifactoryConnectionOptions returns the tool parameters shared by all instance-aware Artifactory tools
+func artifactoryConnectionOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("instance",
+			mcp.Description("Artifactory instance name from configuration (e.g., 'default', 'staging', 'production'). If not provided, uses default instance."),
+		),
+		mcp.WithString("base_url",
+			mcp.Description("The base URL of the Artifactory instance (e.g., https://artifactory.example.com). Overrides the instance URL, or connects directly when no instance is configured. Configured credentials are only sent when it matches the instance URL; other hosts need username/password or api_key."),
+		),
+		mcp.WithString("username",
+			mcp.Description("Username for authentication. Overrides configuration if provided."),
+		),
+		mcp.WithString("password",
+			mcp.Description("Password for authentication. Overrides configuration if provided."),
+		),
+		mcp.WithString("api_key",
+			mcp.Description("API key for authentication (alternative to username/password). Overrides configuration if provided."),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Optional timeout in seconds (max 120). Overrides configuration if provided."),
+			mcp.Min(0),
+			mcp.Max(120),
+		),
+	}
+}
+
+// newArtifactoryClientFromRequest resolves the instance named in the request and applies any per-call overrides
+func newArtifactoryClientFromRequest(request mcp.CallToolRequest) (*artifactoryClient, error) {
+	instanceName := request.GetString("instance", "")
+	requestURL := request.GetString("base_url", "")
+
+	config := globalArtifactoryConfig
+	if config == nil {
+		config = &ArtifactoryConfig{}
+	}
+	instance, err := config.ResolveInstance(instanceName, requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Artifactory instance: %v", err)
+	}
+
+	// Override with request parameters if provided
+	instance.ApplyOverrides(requestURL, request.GetString("username", ""), request.GetString("password", ""), request.GetString("api_key", ""))
+
+	timeout := time.Duration(request.GetFloat("timeout", 0)) * time.Second
+	return newArtifactoryClient(&instance, timeout)
+}
+
+// ApplyOverrides replaces the URL and credentials of an instance with the non-empty values given.
+// Stored credentials only go to the configured host, so a base URL pointing elsewhere must bring its own.
+func (i *ArtifactoryInstanceConfig) ApplyOverrides(baseURL, username, password, apiKey string) {
+	if baseURL != "" {
+		if !sameArtifactoryBaseURL(i.URL, baseURL) {
+			i.Username, i.Password, i.APIKey = "", "", ""
+		}
+		i.URL = baseURL
+	}
+	if username != "" {
+		i.Username = username
+	}
+	if password != "" {
+		i.Password = password
+	}
+	if apiKey != "" {
+		i.APIKey = apiKey
+	}
+}
+
+// sameArtifactoryBaseURL reports whether two base URLs point at the same Artifactory
+func sameArtifactoryBaseURL(configured, requested string) bool {
+	configuredURL, err := normalizeArtifactoryBaseURL(configured)
+	if err != nil {
+		return false
+	}
+	requestedURL, err := normalizeArtifactoryBaseURL(requested)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(configuredURL, requestedURL)
+}
+
+// newArtifactoryClient creates a client for an instance using the loaded common settings.
+// A zero timeout falls back to the instance timeout.
+func newArtifactoryClient(instance *ArtifactoryInstanceConfig, timeout time.Duration) (*artifactoryClient, error) {
+	settings := defaultArtifactoryCommonSettings()
+	if globalArtifactoryConfig != nil {
+		settings = globalArtifactoryConfig.CommonSettings
+	}
+	return newArtifactoryClientWithSettings(instance, settings, timeout)
+}
+
+// newArtifactoryClientWithSettings creates a client for an instance with explicit common settings
+func newArtifactoryClientWithSettings(instance *ArtifactoryInstanceConfig, settings ArtifactoryCommonSettings, timeout time.Duration) (*artifactoryClient, error) {
+	baseURL, err := normalizeArtifactoryBaseURL(instance.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = artifactoryDefaultTimeout
+		if instance.Timeout > 0 {
+			timeout = time.Duration(instance.Timeout) * time.Second
+		}
+	}
+	if timeout > artifactoryMaxTimeout {
+		timeout = artifactoryMaxTimeout
+	}
+
+	username, password, apiKey := instance.GetCredentials()
+	return &artifactoryClient{
+		instance:   instance,
+		baseURL:    baseURL,
+		username:   username,
+		password:   password,
+		apiKey:     apiKey,
+		settings:   settings,
+		maxRetries: settings.MaxRetries,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: artifactoryTransport(instance.VerifySSL),
+		},
+	}, nil
+}
+
+// streamingClient returns a copy of the client without the overall request timeout, which also covers reading
+// the body and so cuts off large transfers; callers bound the transfer with a context deadline instead
+func (c *artifactoryClient) streamingClient() *artifactoryClient {
+	streaming := *c
+	streaming.httpClient = &http.Client{Transport: c.httpClient.Transport}
+	return &streaming
+}
+
+// artifactoryTransport returns a shared transport so connections are pooled across tool calls
+func artifactoryTransport(verifySSL bool) *http.Transport {
+	artifactoryTransportsMu.Lock()
+	defer artifactoryTransportsMu.Unlock()
+
+	if transport, ok := artifactoryTransports[verifySSL]; ok {
+		return transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !verifySSL {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	artifactoryTransports[verifySSL] = transport
+	return transport
+}
+
+// normalizeArtifactoryBaseURL adds a missing scheme, strips trailing slashes and rejects non-HTTP URLs
+func normalizeArtifactoryBaseURL(baseURL string) (string, error) {
+	if baseURL == "" {
+		return "", fmt.Errorf("Artifactory base URL is not configured")
+	}
+
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %v", err)
+	}
+
+	// Ensure URL has a scheme
+	if parsedURL.Scheme == "" {
+		baseURL = "http://" + baseURL
+		parsedURL, err = url.Parse(baseURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid URL after adding http: %v", err)
+		}
+	}
+
+	// Only allow HTTP and HTTPS
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return "", fmt.Errorf("URL must use http:// or https://")
+	}
+
+	return strings.TrimSuffix(baseURL, "/"), nil
+}
+
+// url returns the absolute URL for an endpoint relative to the instance base URL
+func (c *artifactoryClient) url(endpoint string) string {
+	return c.baseURL + "/" + strings.TrimPrefix(endpoint, "/")
+}
+
+// newRequest creates an authenticated request for an endpoint relative to the instance base URL
+func (c *artifactoryClient) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(endpoint), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	// Set authentication headers
+	if c.apiKey != "" {
+		req.Header.Set("X-JFrog-Art-Api", c.apiKey)
+	} else if c.username != "" && c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	userAgent := c.settings.UserAgent
+	if userAgent == "" {
+		userAgent = defaultArtifactoryUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	return req, nil
+}
+
+// do executes a request, retrying 429 and transient 5xx responses with backoff, and converts
+// non-2xx responses into an artifactoryHTTPError
+func (c *artifactoryClient) do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %v", err)
+			}
+			req.Body = body
+		}
+
+		c.logf("debug", "%s %s (attempt %d)", req.Method, req.URL, attempt+1)
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			c.logf("error", "%s %s failed: %v", req.Method, req.URL, err)
+			return nil, fmt.Errorf("failed to execute request: %v", err)
+		}
+		c.logf("debug", "%s %s -> %d in %s", req.Method, req.URL, resp.StatusCode, time.Since(start))
+
+		if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			return resp, nil
+		}
+
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		httpErr := &artifactoryHTTPError{
+			Method:     req.Method,
+			URL:        req.URL.String(),
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(bodyBytes)),
+		}
+
+		retryable := isRetryableArtifactoryStatus(req, resp.StatusCode) && canReplayArtifactoryRequest(req)
+		if !retryable || attempt >= c.maxRetries {
+			if retryable && attempt > 0 {
+				c.logf("error", "%s %s giving up after %d attempts: status %d", req.Method, req.URL, attempt+1, resp.StatusCode)
+			}
+			return nil, httpErr
+		}
+
+		delay := c.retryDelay(attempt, resp.Header.Get("Retry-After"))
+		c.logf("warn", "%s %s returned %d, retrying in %s (%d/%d)", req.Method, req.URL, resp.StatusCode, delay, attempt+1, c.maxRetries)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to execute request: %v", req.Context().Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// artifactoryReadOnlyKey marks the context of a POST that only reads, such as an AQL search
+type artifactoryReadOnlyKey struct{}
+
+// withArtifactoryReadOnly marks the POST requests made with ctx as safe to send again after a gateway error
+func withArtifactoryReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, artifactoryReadOnlyKey{}, true)
+}
+
+// isRetryableArtifactoryStatus reports whether a response status indicates a transient failure.
+// A POST may have been applied before a proxy answered 502, 503 or 504, and sending it again
+// would create a second token, bundle or user, so only 429 is retried unless it only reads.
+// A plain 500 on POST is never retried.
+func isRetryableArtifactoryStatus(req *http.Request, statusCode int) bool {
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if req.Method == http.MethodPost {
+		readOnly, _ := req.Context().Value(artifactoryReadOnlyKey{}).(bool)
+		if !readOnly {
+			return false
+		}
+	}
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return statusCode >= 500 && req.Method != http.MethodPost
+}
+
+// canReplayArtifactoryRequest reports whether the request body can be sent again
+func canReplayArtifactoryRequest(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryDelay returns how long to wait before the next attempt, preferring the server's Retry-After hint
+func (c *artifactoryClient) retryDelay(attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && seconds >= 0 {
+			return clampArtifactoryDelay(time.Duration(seconds)*time.Second, artifactoryMaxRetryAfter)
+		}
+		if when, err := http.ParseTime(retryAfter); err == nil {
+			return clampArtifactoryDelay(time.Until(when), artifactoryMaxRetryAfter)
+		}
+	}
+
+	delay := time.Duration(c.settings.RetryDelay) * artifactoryRetryDelayUnit
+	if delay <= 0 {
+		delay = artifactoryRetryDelayUnit
+	}
+	delay <<= attempt
+	return clampArtifactoryDelay(delay, artifactoryMaxBackoff)
+}
+
+// clampArtifactoryDelay bounds a delay to the range [0, limit]
+func clampArtifactoryDelay(delay, limit time.Duration) time.Duration {
+	if delay < 0 {
+		return 0
+	}
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
+
+// logf writes a log line when level is at or above the configured commonSettings.logLevel
+func (c *artifactoryClient) logf(level, format string, args ...any) {
+	configured, ok := artifactoryLogLevels[strings.ToLower(c.settings.LogLevel)]
+	if !ok {
+		configured = artifactoryLogLevels["info"]
+	}
+	if artifactoryLogLevels[level] < configured {
+		return
+	}
+	artifactoryLogger.Printf("%s [%s] %s", strings.ToUpper(level), c.instance.Name, fmt.Sprintf(format, args...))
+}
+
+// doJSON sends an optional JSON body and decodes the JSON response into out when out is non-nil
+func (c *artifactoryClient) doJSON(ctx context.Context, method, endpoint string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		bodyJSON, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		reader = bytes.NewReader(bodyJSON)
+	}
+
+	req, err := c.newRequest(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	return decodeOptionalJSON(resp.Body, out)
+}
+
+// doText sends a plain-text body (such as an AQL query) and decodes the JSON response into out
+func (c *artifactoryClient) doText(ctx context.Context, method, endpoint, body string, out any) error {
+	req, err := c.newRequest(ctx, method, endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeOptionalJSON(resp.Body, out)
+}
+
+// decodeOptionalJSON decodes a JSON body into out, treating an empty body as success
+func decodeOptionalJSON(body io.Reader, out any) error {
+	if err := json.NewDecoder(body).Decode(out); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+// artifactoryRepoPath escapes each segment of a "repo/path/to/item" string for use in a URL path
+func artifactoryRepoPath(repoPath string) string {
+	segments := strings.Split(strings.Trim(repoPath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sortedKeys returns the keys of a map in lexical order for deterministic output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// artifactoryJSONResult marshals a tool result into a text content result
+func artifactoryJSONResult(result any) (*mcp.CallToolResult, error) {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
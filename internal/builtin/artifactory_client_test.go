@@ -0,0 +1,270 @@
+package builtin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// useTestArtifactoryRetries configures common settings for a test and makes retry delays instant
+func useTestArtifactoryRetries(t *testing.T, settings ArtifactoryCommonSettings) {
+	t.Helper()
+
+	previousUnit := artifactoryRetryDelayUnit
+	artifactoryRetryDelayUnit = time.Millisecond
+	globalArtifactoryConfig.CommonSettings = settings
+	t.Cleanup(func() { artifactoryRetryDelayUnit = previousUnit })
+}
+
+func TestArtifactoryClientRetriesTransientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "acme-bot/2.0" {
+			t.Errorf("Expected configured user agent, got %s", r.Header.Get("User-Agent"))
+		}
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+	useTestArtifactoryRetries(t, ArtifactoryCommonSettings{MaxRetries: 3, RetryDelay: 1, UserAgent: "acme-bot/2.0", LogLevel: "off"})
+
+	response, result := callArtifactoryTool(t, executeArtifactoryGetRepositories, map[string]any{})
+	if result.IsError {
+		t.Fatalf("Expected success after retries: %v", result.Content)
+	}
+	if response["count"] != float64(0) {
+		t.Errorf("Expected count 0, got %v", response["count"])
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestArtifactoryClientGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+	useTestArtifactoryRetries(t, ArtifactoryCommonSettings{MaxRetries: 2, RetryDelay: 1, LogLevel: "off"})
+
+	_, result := callArtifactoryTool(t, executeArtifactoryGetRepositories, map[string]any{})
+	if !result.IsError {
+		t.Error("Expected an error once retries are exhausted")
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestArtifactoryClientDoesNotRetryPostServerError(t *testing.T) {
+	var attempts atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+	useTestArtifactoryRetries(t, ArtifactoryCommonSettings{MaxRetries: 3, RetryDelay: 1, LogLevel: "off"})
+
+	_, result := callArtifactoryTool(t, executeArtifactorySearchAQL, map[string]any{
+		"query": `items.find({"repo":"libs-release-local"})`,
+	})
+	if !result.IsError {
+		t.Error("Expected an error result")
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("Expected a single attempt for POST, got %d", attempts.Load())
+	}
+}
+
+func TestArtifactoryClientPostGatewayErrors(t *testing.T) {
+	var attempts atomic.Int32
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		w.Write([]byte(`{"results":[],"range":{"total":0}}`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+	useTestArtifactoryRetries(t, ArtifactoryCommonSettings{MaxRetries: 3, RetryDelay: 1, LogLevel: "off"})
+
+	// The token may have been created before the gateway gave up, so it must not be requested again
+	_, result := callArtifactoryTool(t, executeArtifactoryCreateToken, map[string]any{"token_username": "ci-bot"})
+	if !result.IsError || attempts.Load() != 1 {
+		t.Errorf("Expected a single attempt for a token request, got %d: %v", attempts.Load(), result.Content)
+	}
+
+	// AQL searches only read, so they are sent again
+	attempts.Store(0)
+	_, result = callArtifactoryTool(t, executeArtifactorySearchAQL, map[string]any{
+		"query": `items.find({"repo":"libs-release-local"})`,
+	})
+	if result.IsError || attempts.Load() != 2 {
+		t.Errorf("Expected the search to succeed on the second attempt, got %d: %v", attempts.Load(), result.Content)
+	}
+}
+
+func TestArtifactoryClientVerifySSL(t *testing.T) {
+	mockServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	instance := globalArtifactoryConfig.Instances["test"]
+	instance.VerifySSL = true
+	globalArtifactoryConfig.Instances["test"] = instance
+
+	_, result := callArtifactoryTool(t, executeArtifactoryGetRepositories, map[string]any{})
+	if !result.IsError {
+		t.Error("Expected certificate verification to fail for a self-signed server")
+	}
+
+	instance.VerifySSL = false
+	globalArtifactoryConfig.Instances["test"] = instance
+
+	_, result = callArtifactoryTool(t, executeArtifactoryGetRepositories, map[string]any{})
+	if result.IsError {
+		t.Errorf("Expected success with verifySSL disabled: %v", result.Content)
+	}
+}
+
+func TestArtifactoryRetryDelay(t *testing.T) {
+	client := &artifactoryClient{settings: ArtifactoryCommonSettings{RetryDelay: 5}}
+
+	if got := client.retryDelay(0, ""); got != 5*time.Second {
+		t.Errorf("Expected 5s for the first retry, got %s", got)
+	}
+	if got := client.retryDelay(2, ""); got != 20*time.Second {
+		t.Errorf("Expected 20s for the third retry, got %s", got)
+	}
+	if got := client.retryDelay(10, ""); got != artifactoryMaxBackoff {
+		t.Errorf("Expected backoff to be capped at %s, got %s", artifactoryMaxBackoff, got)
+	}
+	if got := client.retryDelay(0, "7"); got != 7*time.Second {
+		t.Errorf("Expected Retry-After seconds to be honored, got %s", got)
+	}
+	when := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := client.retryDelay(0, when); got != artifactoryMaxRetryAfter {
+		t.Errorf("Expected Retry-After date to be capped, got %s", got)
+	}
+}
+
+func TestArtifactoryClientRetryRespectsContext(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+	useTestArtifactoryRetries(t, ArtifactoryCommonSettings{MaxRetries: 3, LogLevel: "off"})
+
+	client, err := newArtifactoryClient(&ArtifactoryInstanceConfig{Name: "test", URL: mockServer.URL}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = client.doJSON(ctx, "GET", "artifactory/api/repositories", nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("Expected context deadline error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Retry wait should stop when the context is done")
+	}
+}
+
+func TestArtifactoryClientBaseURLOverrideCredentials(t *testing.T) {
+	var auth string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte("{}"))
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, "https://artifactory.example.com")
+
+	request := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: map[string]any{"base_url": mockServer.URL}}}
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.doJSON(context.Background(), "GET", "artifactory/api/repositories", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "" {
+		t.Errorf("Configured credentials must not be sent to another host, got %q", auth)
+	}
+
+	request.Params.Arguments = map[string]any{"base_url": mockServer.URL, "username": "other", "password": "pw"}
+	client, _ = newArtifactoryClientFromRequest(request)
+	client.doJSON(context.Background(), "GET", "artifactory/api/repositories", nil, nil)
+	if !strings.HasPrefix(auth, "Basic ") {
+		t.Errorf("Expected the request credentials, got %q", auth)
+	}
+
+	useTestArtifactoryConfig(t, mockServer.URL)
+	request.Params.Arguments = map[string]any{"base_url": mockServer.URL + "/"}
+	client, _ = newArtifactoryClientFromRequest(request)
+	client.doJSON(context.Background(), "GET", "artifactory/api/repositories", nil, nil)
+	if auth == "" {
+		t.Error("Expected the configured credentials for the instance URL")
+	}
+
+	// The permissions CLI applies its flags the same way
+	instance := ArtifactoryInstanceConfig{URL: "https://artifactory.example.com", Username: "tester", Password: "secret", APIKey: "key"}
+	instance.ApplyOverrides("https://elsewhere.example.com", "", "", "")
+	if instance.Username != "" || instance.Password != "" || instance.APIKey != "" || instance.URL != "https://elsewhere.example.com" {
+		t.Errorf("Expected the stored credentials to be dropped, got %+v", instance)
+	}
+	instance.ApplyOverrides("", "", "", "other-key")
+	if instance.APIKey != "other-key" {
+		t.Errorf("Expected the given API key, got %+v", instance)
+	}
+}
+
+func TestLoadArtifactoryConfigLayouts(t *testing.T) {
+	instances := map[string]any{
+		"prod": map[string]any{"url": "https://prod.example.com", "apiKey": "key"},
+	}
+
+	for name, options := range map[string]map[string]any{
+		"nested": {"config": map[string]any{"instances": instances, "defaultInstance": "prod"}},
+		"direct": {"instances": instances, "defaultInstance": "prod"},
+	} {
+		config, err := LoadArtifactoryConfig(options)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		instance, err := config.GetInstanceConfig("")
+		if err != nil || instance.URL != "https://prod.example.com" {
+			t.Errorf("%s: expected prod instance, got %v, %v", name, instance, err)
+		}
+	}
+
+	config, err := LoadArtifactoryConfig(map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Instances) != 0 {
+		t.Errorf("Expected no instances without configuration, got %v", config.InstanceNames())
+	}
+}
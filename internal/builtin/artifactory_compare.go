@@ -0,0 +1,624 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// artifactoryCompareSections are the parts of an instance artifactory_compare_instances can compare
+var artifactoryCompareSections = []string{"repositories", "configs", "security", "permissions", "storage"}
+
+// ArtifactoryRepoStorage is an entry of the repositoriesSummaryList in the storage info API
+type ArtifactoryRepoStorage struct {
+	RepoKey          string  `json:"repoKey"`
+	RepoType         string  `json:"repoType,omitempty"`
+	FilesCount       int64   `json:"filesCount"`
+	UsedSpace        string  `json:"usedSpace,omitempty"`
+	UsedSpaceInBytes float64 `json:"usedSpaceInBytes,omitempty"`
+}
+
+// ArtifactoryStorageInfo represents the response from the storage info API
+type ArtifactoryStorageInfo struct {
+	BinariesSummary         map[string]any           `json:"binariesSummary"`
+	FileStoreSummary        map[string]any           `json:"fileStoreSummary"`
+	RepositoriesSummaryList []ArtifactoryRepoStorage `json:"repositoriesSummaryList"`
+}
+
+// ArtifactoryDrift describes an item that exists on some of the compared instances only
+type ArtifactoryDrift struct {
+	Name        string   `json:"name"`
+	PresentIn   []string `json:"present_in"`
+	MissingFrom []string `json:"missing_from"`
+}
+
+// ArtifactoryConfigDrift describes how an item's configuration on one instance differs from the baseline
+type ArtifactoryConfigDrift struct {
+	Name     string         `json:"name"`
+	Instance string         `json:"instance"`
+	Changes  map[string]any `json:"changes"`
+}
+
+// artifactoryInstanceSnapshot holds everything collected from one instance for a comparison
+type artifactoryInstanceSnapshot struct {
+	name         string
+	url          string
+	repositories map[string]ArtifactoryRepository
+	configs      map[string]map[string]any
+	users        []string
+	groups       []string
+	permissions  map[string]map[string]any
+	storage      *ArtifactoryStorageInfo
+	errors       []string
+}
+
+// addArtifactoryCompareTools registers the multi-instance comparison and replication status tools
+func addArtifactoryCompareTools(s *server.MCPServer) {
+	compareTool := mcp.NewTool("artifactory_compare_instances",
+		mcp.WithDescription("Compare two or more configured Artifactory instances (e.g. staging and prod) and report drift in repositories, repository configurations, users and groups, permission targets and storage totals. The first instance is the baseline."),
+		mcp.WithString("instances",
+			mcp.Required(),
+			mcp.Description("Comma-separated list of at least two configured instance names, baseline first"),
+		),
+		mcp.WithString("sections",
+			mcp.Description("Comma-separated sections to compare: repositories, configs, security, permissions, storage (defaults to all)"),
+		),
+		mcp.WithString("repositories",
+			mcp.Description("Comma-separated repository keys whose configurations are compared (optional, defaults to every repository present on all instances)"),
+		),
+		mcp.WithNumber("max_repo_configs",
+			mcp.Description("Maximum number of repository configurations fetched per instance (defaults to 200)"),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Request timeout in seconds (optional, defaults to each instance's timeout)"),
+			mcp.Min(1),
+			mcp.Max(120),
+		),
+	)
+
+	replicationStatusTool := mcp.NewTool("artifactory_replication_status",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Report replication and federation health: the last run and status of every push/pull replication and federated repository mirror, with failing and stale replications called out"),
+			mcp.WithString("repo_key",
+				mcp.Description("Only report this repository (optional, defaults to every repository with replication or federation)"),
+			),
+			mcp.WithNumber("stale_hours",
+				mcp.Description("Flag replications whose last successful run is older than this many hours (defaults to 24)"),
+				mcp.Min(1),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(compareTool, executeArtifactoryCompareInstances)
+	s.AddTool(replicationStatusTool, executeArtifactoryReplicationStatus)
+}
+
+// executeArtifactoryCompareInstances handles the instance comparison tool execution
+func executeArtifactoryCompareInstances(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	names := parseCommaSeparated(request.GetString("instances", ""))
+	if len(names) < 2 {
+		return mcp.NewToolResultError("at least two instance names are required"), nil
+	}
+	if globalArtifactoryConfig == nil {
+		return mcp.NewToolResultError("no Artifactory instances configured"), nil
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			return mcp.NewToolResultError(fmt.Sprintf("instance '%s' is listed more than once", name)), nil
+		}
+		seen[name] = true
+	}
+
+	sections := map[string]bool{}
+	for _, section := range parseCommaSeparated(request.GetString("sections", strings.Join(artifactoryCompareSections, ","))) {
+		section = strings.ToLower(section)
+		valid := false
+		for _, known := range artifactoryCompareSections {
+			valid = valid || section == known
+		}
+		if !valid {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown section '%s'; valid sections: %s", section, strings.Join(artifactoryCompareSections, ", "))), nil
+		}
+		sections[section] = true
+	}
+	repoFilter := parseCommaSeparated(request.GetString("repositories", ""))
+	maxConfigs := int(request.GetFloat("max_repo_configs", 200))
+	timeout := time.Duration(request.GetFloat("timeout", 0)) * time.Second
+
+	clients := make([]*artifactoryClient, len(names))
+	for i, name := range names {
+		instance, err := globalArtifactoryConfig.GetInstanceConfig(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		client, err := newArtifactoryClient(instance, timeout)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("instance '%s': %v", name, err)), nil
+		}
+		clients[i] = client
+	}
+
+	// The first pass collects the lists; repository configs need the common repositories first
+	snapshots := make([]*artifactoryInstanceSnapshot, len(names))
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			snapshots[i] = collectArtifactoryInstanceSnapshot(ctx, names[i], clients[i], sections)
+		}(i)
+	}
+	wg.Wait()
+
+	result := map[string]interface{}{
+		"baseline":  names[0],
+		"instances": names,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	instanceURLs := map[string]string{}
+	for _, snapshot := range snapshots {
+		instanceURLs[snapshot.name] = snapshot.url
+	}
+	result["urls"] = instanceURLs
+	warnings := []string{}
+
+	driftCount := 0
+	if sections["repositories"] || sections["configs"] {
+		repoNames := map[string][]string{}
+		for _, snapshot := range snapshots {
+			repoNames[snapshot.name] = sortedKeys(snapshot.repositories)
+		}
+		drift := artifactoryPresenceDrift(names, repoNames)
+		if sections["repositories"] {
+			result["repositories"] = drift
+			driftCount += len(drift)
+		}
+
+		if sections["configs"] {
+			common := artifactoryCommonNames(names, repoNames)
+			if len(repoFilter) > 0 {
+				common = artifactoryIntersect(common, repoFilter)
+			}
+			if len(common) > maxConfigs {
+				warnings = append(warnings, fmt.Sprintf("only the first %d of %d common repositories were compared; raise max_repo_configs or pass repositories", maxConfigs, len(common)))
+				common = common[:maxConfigs]
+			}
+			for i := range snapshots {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					collectArtifactoryRepoConfigs(ctx, clients[i], snapshots[i], common)
+				}(i)
+			}
+			wg.Wait()
+
+			configDrift := artifactoryConfigDrift(snapshots, func(snapshot *artifactoryInstanceSnapshot) map[string]map[string]any { return snapshot.configs })
+			result["repository_configs"] = configDrift
+			driftCount += len(configDrift)
+		}
+	}
+
+	if sections["security"] {
+		users, groups := map[string][]string{}, map[string][]string{}
+		for _, snapshot := range snapshots {
+			users[snapshot.name] = snapshot.users
+			groups[snapshot.name] = snapshot.groups
+		}
+		userDrift := artifactoryPresenceDrift(names, users)
+		groupDrift := artifactoryPresenceDrift(names, groups)
+		result["users"] = userDrift
+		result["groups"] = groupDrift
+		driftCount += len(userDrift) + len(groupDrift)
+	}
+
+	if sections["permissions"] {
+		permissionNames := map[string][]string{}
+		for _, snapshot := range snapshots {
+			permissionNames[snapshot.name] = sortedKeys(snapshot.permissions)
+		}
+		presence := artifactoryPresenceDrift(names, permissionNames)
+		configDrift := artifactoryConfigDrift(snapshots, func(snapshot *artifactoryInstanceSnapshot) map[string]map[string]any { return snapshot.permissions })
+		result["permissions"] = map[string]interface{}{
+			"presence": presence,
+			"configs":  configDrift,
+		}
+		driftCount += len(presence) + len(configDrift)
+	}
+
+	if sections["storage"] {
+		result["storage"] = compareArtifactoryStorage(snapshots)
+	}
+
+	// Errors are collected last so failed repository config lookups are included
+	instanceErrors := map[string][]string{}
+	for _, snapshot := range snapshots {
+		if len(snapshot.errors) > 0 {
+			instanceErrors[snapshot.name] = snapshot.errors
+		}
+	}
+	result["drift_count"] = driftCount
+	result["errors"] = instanceErrors
+	result["warnings"] = warnings
+	result["message"] = fmt.Sprintf("Found %d differences between %s (baseline) and %s", driftCount, names[0], strings.Join(names[1:], ", "))
+	if driftCount == 0 {
+		result["message"] = fmt.Sprintf("No drift found between %s", strings.Join(names, ", "))
+	}
+
+	return artifactoryJSONResult(result)
+}
+
+// collectArtifactoryInstanceSnapshot fetches the lists needed for a comparison, recording failures instead of aborting
+func collectArtifactoryInstanceSnapshot(ctx context.Context, name string, client *artifactoryClient, sections map[string]bool) *artifactoryInstanceSnapshot {
+	snapshot := &artifactoryInstanceSnapshot{
+		name:         name,
+		url:          client.baseURL,
+		repositories: map[string]ArtifactoryRepository{},
+		configs:      map[string]map[string]any{},
+		permissions:  map[string]map[string]any{},
+	}
+	fail := func(what string, err error) {
+		snapshot.errors = append(snapshot.errors, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	if sections["repositories"] || sections["configs"] {
+		var repositories []ArtifactoryRepository
+		if err := client.doJSON(ctx, "GET", "artifactory/api/repositories", nil, &repositories); err != nil {
+			fail("repositories", err)
+		}
+		for _, repo := range repositories {
+			snapshot.repositories[repo.Key] = repo
+		}
+	}
+
+	if sections["security"] {
+		var users []ArtifactoryUser
+		if err := client.doJSON(ctx, "GET", "artifactory/api/security/users", nil, &users); err != nil {
+			fail("users", err)
+		}
+		for _, user := range users {
+			snapshot.users = append(snapshot.users, user.Name)
+		}
+		var groups []ArtifactoryGroupRef
+		if err := client.doJSON(ctx, "GET", artifactoryGroupsEndpoint, nil, &groups); err != nil {
+			fail("groups", err)
+		}
+		for _, group := range groups {
+			snapshot.groups = append(snapshot.groups, group.Name)
+		}
+	}
+
+	if sections["permissions"] {
+		manager := &ArtifactoryPermissionManager{client: client}
+		permissions, err := manager.ListDetailed(ctx)
+		if err != nil {
+			fail("permissions", err)
+		}
+		for _, permission := range permissions {
+			snapshot.permissions[permission.Name] = artifactoryToJSONMap(permission)
+		}
+	}
+
+	if sections["storage"] {
+		var storage ArtifactoryStorageInfo
+		if err := client.doJSON(ctx, "GET", "artifactory/api/storageinfo", nil, &storage); err != nil {
+			fail("storage", err)
+		} else {
+			snapshot.storage = &storage
+		}
+	}
+
+	return snapshot
+}
+
+// collectArtifactoryRepoConfigs fetches the configuration of the given repositories into the snapshot
+func collectArtifactoryRepoConfigs(ctx context.Context, client *artifactoryClient, snapshot *artifactoryInstanceSnapshot, repoKeys []string) {
+	for _, key := range repoKeys {
+		config, err := getArtifactoryRepositoryConfig(ctx, client, key)
+		if err != nil {
+			snapshot.errors = append(snapshot.errors, err.Error())
+			continue
+		}
+		snapshot.configs[key] = config
+	}
+}
+
+// artifactoryPresenceDrift lists the names that are missing from at least one instance
+func artifactoryPresenceDrift(instances []string, names map[string][]string) []ArtifactoryDrift {
+	presence := map[string]map[string]bool{}
+	for instance, list := range names {
+		for _, name := range list {
+			if presence[name] == nil {
+				presence[name] = map[string]bool{}
+			}
+			presence[name][instance] = true
+		}
+	}
+
+	drift := []ArtifactoryDrift{}
+	for _, name := range sortedKeys(presence) {
+		entry := ArtifactoryDrift{Name: name, PresentIn: []string{}, MissingFrom: []string{}}
+		for _, instance := range instances {
+			if presence[name][instance] {
+				entry.PresentIn = append(entry.PresentIn, instance)
+			} else {
+				entry.MissingFrom = append(entry.MissingFrom, instance)
+			}
+		}
+		if len(entry.MissingFrom) > 0 {
+			drift = append(drift, entry)
+		}
+	}
+	return drift
+}
+
+// artifactoryCommonNames returns the names present on every instance
+func artifactoryCommonNames(instances []string, names map[string][]string) []string {
+	counts := map[string]int{}
+	for _, instance := range instances {
+		for _, name := range names[instance] {
+			counts[name]++
+		}
+	}
+	common := []string{}
+	for _, name := range sortedKeys(counts) {
+		if counts[name] == len(instances) {
+			common = append(common, name)
+		}
+	}
+	return common
+}
+
+// artifactoryIntersect keeps the names of list that also appear in filter
+func artifactoryIntersect(list, filter []string) []string {
+	wanted := map[string]bool{}
+	for _, name := range filter {
+		wanted[name] = true
+	}
+	kept := []string{}
+	for _, name := range list {
+		if wanted[name] {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// artifactoryConfigDrift compares the configurations of every instance against the baseline (the first snapshot).
+// Fields that look like credentials are skipped because Artifactory returns them encrypted per instance.
+func artifactoryConfigDrift(snapshots []*artifactoryInstanceSnapshot, configs func(*artifactoryInstanceSnapshot) map[string]map[string]any) []ArtifactoryConfigDrift {
+	drift := []ArtifactoryConfigDrift{}
+	baseline := configs(snapshots[0])
+	for _, snapshot := range snapshots[1:] {
+		other := configs(snapshot)
+		for _, name := range sortedKeys(baseline) {
+			config, ok := other[name]
+			if !ok {
+				continue
+			}
+			changes := diffArtifactoryConfig(withoutArtifactorySecrets(baseline[name]), withoutArtifactorySecrets(config))
+			if len(changes) > 0 {
+				drift = append(drift, ArtifactoryConfigDrift{Name: name, Instance: snapshot.name, Changes: changes})
+			}
+		}
+	}
+	return drift
+}
+
+// withoutArtifactorySecrets returns a copy of a configuration without credential fields
+func withoutArtifactorySecrets(config map[string]any) map[string]any {
+	cleaned := make(map[string]any, len(config))
+	for key, value := range config {
+		if !artifactorySecretPropertyPattern.MatchString(key) {
+			cleaned[key] = value
+		}
+	}
+	return cleaned
+}
+
+// artifactoryToJSONMap converts a value into its generic JSON form so it can be diffed field by field
+func artifactoryToJSONMap(value any) map[string]any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var converted map[string]any
+	json.Unmarshal(data, &converted)
+	return converted
+}
+
+// compareArtifactoryStorage reports the storage totals per instance and the repositories whose file counts differ most
+func compareArtifactoryStorage(snapshots []*artifactoryInstanceSnapshot) map[string]interface{} {
+	totals := map[string]interface{}{}
+	fileCounts := map[string]map[string]int64{}
+	for _, snapshot := range snapshots {
+		if snapshot.storage == nil {
+			continue
+		}
+		instanceTotals := map[string]interface{}{
+			"binaries":  snapshot.storage.BinariesSummary,
+			"filestore": snapshot.storage.FileStoreSummary,
+		}
+		for _, repo := range snapshot.storage.RepositoriesSummaryList {
+			if repo.RepoKey == "TOTAL" {
+				instanceTotals["files"] = repo.FilesCount
+				instanceTotals["usedSpace"] = repo.UsedSpace
+				continue
+			}
+			if fileCounts[repo.RepoKey] == nil {
+				fileCounts[repo.RepoKey] = map[string]int64{}
+			}
+			fileCounts[repo.RepoKey][snapshot.name] = repo.FilesCount
+		}
+		totals[snapshot.name] = instanceTotals
+	}
+
+	type repoDifference struct {
+		Repo       string           `json:"repo"`
+		FileCounts map[string]int64 `json:"file_counts"`
+		Difference int64            `json:"difference"`
+	}
+	differences := []repoDifference{}
+	for _, repo := range sortedKeys(fileCounts) {
+		counts := fileCounts[repo]
+		if len(counts) < 2 {
+			continue
+		}
+		lowest, highest := int64(-1), int64(0)
+		for _, count := range counts {
+			if lowest < 0 || count < lowest {
+				lowest = count
+			}
+			if count > highest {
+				highest = count
+			}
+		}
+		if highest != lowest {
+			differences = append(differences, repoDifference{Repo: repo, FileCounts: counts, Difference: highest - lowest})
+		}
+	}
+	sort.SliceStable(differences, func(i, j int) bool {
+		return differences[i].Difference > differences[j].Difference
+	})
+	if len(differences) > 20 {
+		differences = differences[:20]
+	}
+
+	return map[string]interface{}{
+		"totals":                 totals,
+		"file_count_differences": differences,
+	}
+}
+
+// executeArtifactoryReplicationStatus handles the replication status tool execution
+func executeArtifactoryReplicationStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoKey := request.GetString("repo_key", "")
+	staleAfter := time.Duration(request.GetFloat("stale_hours", 24) * float64(time.Hour))
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var repositories []ArtifactoryRepository
+	if err := client.doJSON(ctx, "GET", "artifactory/api/repositories", nil, &repositories); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory repositories request failed: %v", err)), nil
+	}
+	if repoKey != "" {
+		filtered := []ArtifactoryRepository{}
+		for _, repo := range repositories {
+			if repo.Key == repoKey {
+				filtered = append(filtered, repo)
+			}
+		}
+		if len(filtered) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("repository '%s' not found", repoKey)), nil
+		}
+		repositories = filtered
+	}
+
+	now := time.Now()
+	entries := []map[string]interface{}{}
+	statusCounts := map[string]int{}
+	failing := []string{}
+	stale := []string{}
+	warnings := []string{}
+
+	for _, repo := range repositories {
+		switch strings.ToUpper(repo.Type) {
+		case "FEDERATED":
+			var status map[string]any
+			endpoint := "artifactory/api/federation/status/repo/" + url.PathEscape(repo.Key)
+			if err := client.doJSON(ctx, "GET", endpoint, nil, &status); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: federation status unavailable: %v", repo.Key, err))
+				continue
+			}
+			entry := map[string]interface{}{"repo": repo.Key, "type": "federation", "details": status}
+			healthy := true
+			if mirrors, ok := status["mirrorEventsStatusInfo"].([]any); ok {
+				for _, mirror := range mirrors {
+					mirrorInfo, ok := mirror.(map[string]any)
+					if !ok {
+						continue
+					}
+					mirrorStatus, _ := mirrorInfo["status"].(string)
+					statusCounts[strings.ToLower(mirrorStatus)]++
+					if mirrorStatus != "" && !strings.EqualFold(mirrorStatus, "healthy") && !strings.EqualFold(mirrorStatus, "ok") {
+						healthy = false
+					}
+				}
+			}
+			if !healthy {
+				failing = append(failing, repo.Key)
+			}
+			entry["healthy"] = healthy
+			entries = append(entries, entry)
+
+		case "LOCAL", "REMOTE":
+			replications, err := getArtifactoryReplications(ctx, client, repo.Key)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: replication config unavailable: %v", repo.Key, err))
+				continue
+			}
+			if len(replications) == 0 {
+				continue
+			}
+
+			var status struct {
+				Status        string           `json:"status"`
+				LastCompleted string           `json:"lastCompleted"`
+				Targets       []map[string]any `json:"targets,omitempty"`
+			}
+			if err := client.doJSON(ctx, "GET", "artifactory/api/replication/"+url.PathEscape(repo.Key), nil, &status); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: replication status unavailable: %v", repo.Key, err))
+				continue
+			}
+
+			normalized := strings.ToLower(status.Status)
+			statusCounts[normalized]++
+			entry := map[string]interface{}{
+				"repo":          repo.Key,
+				"type":          "replication",
+				"status":        status.Status,
+				"lastCompleted": status.LastCompleted,
+				"targets":       status.Targets,
+				"replications":  replications,
+			}
+			if normalized == "failure" || normalized == "inconsistent" || normalized == "error" {
+				failing = append(failing, repo.Key)
+			}
+			enabled := false
+			for _, replication := range replications {
+				enabled = enabled || replication.Enabled
+			}
+			completed := parseArtifactoryTime(status.LastCompleted)
+			if enabled && (normalized == "never_run" || (!completed.IsZero() && now.Sub(completed) > staleAfter)) {
+				stale = append(stale, repo.Key)
+				entry["stale"] = true
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	message := fmt.Sprintf("%d replicated or federated repositories: %d failing, %d stale", len(entries), len(failing), len(stale))
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":      message,
+		"repositories": entries,
+		"count":        len(entries),
+		"status":       statusCounts,
+		"failing":      failing,
+		"stale":        stale,
+		"warnings":     warnings,
+		"url":          client.url("artifactory/api/replication"),
+		"timestamp":    now.Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,194 @@
+package builtin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// compareTestInstance is the state served by newCompareTestServer
+type compareTestInstance struct {
+	repos   map[string]map[string]any
+	users   []string
+	groups  []string
+	storage ArtifactoryStorageInfo
+}
+
+// newCompareTestServer serves the read-only APIs used by artifactory_compare_instances
+func newCompareTestServer(t *testing.T, instance compareTestInstance) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/artifactory/api/repositories":
+			repos := []ArtifactoryRepository{}
+			for _, key := range sortedKeys(instance.repos) {
+				repos = append(repos, ArtifactoryRepository{Key: key, Type: "LOCAL"})
+			}
+			json.NewEncoder(w).Encode(repos)
+		case strings.HasPrefix(r.URL.Path, "/artifactory/api/repositories/"):
+			json.NewEncoder(w).Encode(instance.repos[strings.TrimPrefix(r.URL.Path, "/artifactory/api/repositories/")])
+		case r.URL.Path == "/artifactory/api/security/users":
+			users := []ArtifactoryUser{}
+			for _, name := range instance.users {
+				users = append(users, ArtifactoryUser{Name: name})
+			}
+			json.NewEncoder(w).Encode(users)
+		case r.URL.Path == "/artifactory/api/security/groups":
+			groups := []ArtifactoryGroupRef{}
+			for _, name := range instance.groups {
+				groups = append(groups, ArtifactoryGroupRef{Name: name})
+			}
+			json.NewEncoder(w).Encode(groups)
+		case r.URL.Path == "/artifactory/api/v2/security/permissions":
+			json.NewEncoder(w).Encode([]ArtifactoryPermissionRef{})
+		case r.URL.Path == "/artifactory/api/storageinfo":
+			json.NewEncoder(w).Encode(instance.storage)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestArtifactoryCompareInstances(t *testing.T) {
+	staging := newCompareTestServer(t, compareTestInstance{
+		repos: map[string]map[string]any{
+			"libs-release": {"key": "libs-release", "rclass": "local", "xrayIndex": true, "password": "enc-1"},
+			"libs-staging": {"key": "libs-staging", "rclass": "local"},
+		},
+		users:  []string{"admin", "ci"},
+		groups: []string{"readers"},
+		storage: ArtifactoryStorageInfo{RepositoriesSummaryList: []ArtifactoryRepoStorage{
+			{RepoKey: "libs-release", FilesCount: 100},
+			{RepoKey: "TOTAL", FilesCount: 150},
+		}},
+	})
+	defer staging.Close()
+	prod := newCompareTestServer(t, compareTestInstance{
+		repos: map[string]map[string]any{
+			"libs-release": {"key": "libs-release", "rclass": "local", "xrayIndex": false, "password": "enc-2"},
+		},
+		users:  []string{"admin", "ci"},
+		groups: []string{"readers", "auditors"},
+		storage: ArtifactoryStorageInfo{RepositoriesSummaryList: []ArtifactoryRepoStorage{
+			{RepoKey: "libs-release", FilesCount: 90},
+			{RepoKey: "TOTAL", FilesCount: 90},
+		}},
+	})
+	defer prod.Close()
+
+	useTestArtifactoryConfig(t, staging.URL)
+	globalArtifactoryConfig.Instances["prod"] = ArtifactoryInstanceConfig{Name: "prod", URL: prod.URL, Timeout: 5}
+
+	response, result := callArtifactoryTool(t, executeArtifactoryCompareInstances, map[string]any{"instances": "test,prod"})
+	if result.IsError {
+		t.Fatalf("Unexpected compare error: %v", result.Content)
+	}
+
+	repos := response["repositories"].([]any)
+	if len(repos) != 1 || repos[0].(map[string]any)["name"] != "libs-staging" ||
+		!reflect.DeepEqual(repos[0].(map[string]any)["missing_from"], []any{"prod"}) {
+		t.Errorf("Unexpected repository drift: %v", repos)
+	}
+
+	configs := response["repository_configs"].([]any)
+	if len(configs) != 1 {
+		t.Fatalf("Expected one config drift, got %v", configs)
+	}
+	changes := configs[0].(map[string]any)["changes"].(map[string]any)
+	if _, ok := changes["xrayIndex"]; !ok || len(changes) != 1 {
+		t.Errorf("Expected only xrayIndex to differ (passwords are skipped), got %v", changes)
+	}
+
+	groups := response["groups"].([]any)
+	if len(groups) != 1 || groups[0].(map[string]any)["name"] != "auditors" {
+		t.Errorf("Unexpected group drift: %v", groups)
+	}
+	if len(response["users"].([]any)) != 0 {
+		t.Errorf("Expected no user drift, got %v", response["users"])
+	}
+
+	storage := response["storage"].(map[string]any)
+	differences := storage["file_count_differences"].([]any)
+	if len(differences) != 1 || differences[0].(map[string]any)["difference"] != float64(10) {
+		t.Errorf("Unexpected storage differences: %v", differences)
+	}
+	if response["drift_count"] != float64(3) {
+		t.Errorf("Expected 3 differences, got %v", response["drift_count"])
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryCompareInstances, map[string]any{"instances": "test"})
+	if !result.IsError {
+		t.Error("Expected an error with a single instance")
+	}
+}
+
+func TestArtifactoryReplicationStatus(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).Format("2006-01-02T15:04:05.000-0700")
+	old := time.Now().Add(-72 * time.Hour).Format("2006-01-02T15:04:05.000-0700")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/repositories":
+			json.NewEncoder(w).Encode([]ArtifactoryRepository{
+				{Key: "healthy-local", Type: "LOCAL"},
+				{Key: "stale-remote", Type: "REMOTE"},
+				{Key: "failing-local", Type: "LOCAL"},
+				{Key: "no-replication", Type: "LOCAL"},
+			})
+		case "/artifactory/api/replications/healthy-local", "/artifactory/api/replications/failing-local":
+			json.NewEncoder(w).Encode([]ArtifactoryReplication{{URL: "https://dr/artifactory/x", Enabled: true, CronExp: "0 0 * * * ?"}})
+		case "/artifactory/api/replications/stale-remote":
+			json.NewEncoder(w).Encode(ArtifactoryReplication{Enabled: true, CronExp: "0 0 * * * ?"})
+		case "/artifactory/api/replication/healthy-local":
+			json.NewEncoder(w).Encode(map[string]any{"status": "ok", "lastCompleted": recent})
+		case "/artifactory/api/replication/stale-remote":
+			json.NewEncoder(w).Encode(map[string]any{"status": "ok", "lastCompleted": old})
+		case "/artifactory/api/replication/failing-local":
+			json.NewEncoder(w).Encode(map[string]any{"status": "failure", "lastCompleted": recent})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryReplicationStatus, map[string]any{})
+	if result.IsError {
+		t.Fatalf("Unexpected status error: %v", result.Content)
+	}
+	if response["count"] != float64(3) {
+		t.Errorf("Expected three replicated repositories, got %v", response["count"])
+	}
+	if !reflect.DeepEqual(response["failing"], []any{"failing-local"}) || !reflect.DeepEqual(response["stale"], []any{"stale-remote"}) {
+		t.Errorf("Unexpected failing/stale lists: %v / %v", response["failing"], response["stale"])
+	}
+}
+
+func TestArtifactoryReplicationStatusFederated(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/repositories":
+			json.NewEncoder(w).Encode([]ArtifactoryRepository{{Key: "libs-federated", Type: "FEDERATED"}})
+		case "/artifactory/api/federation/status/repo/libs-federated":
+			w.Write([]byte(`{"mirrorEventsStatusInfo": [null, "unexpected", {"status": "BLOCKED"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	// Entries that are not objects are skipped rather than crashing the handler
+	response, result := callArtifactoryTool(t, executeArtifactoryReplicationStatus, map[string]any{})
+	if result.IsError {
+		t.Fatalf("Unexpected status error: %v", result.Content)
+	}
+	if !reflect.DeepEqual(response["failing"], []any{"libs-federated"}) {
+		t.Errorf("Expected the blocked mirror to fail, got %v", response["failing"])
+	}
+}
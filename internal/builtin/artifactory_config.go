@@ -1,8 +1,12 @@
 package builtin
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
+
+	"github.com/mark3labs/mcphost/internal/config"
 )
 
 // ArtifactoryInstanceConfig represents configuration for a single Artifactory instance
@@ -25,11 +29,26 @@ type ArtifactoryCommonSettings struct {
 	LogLevel    string `json:"logLevel,omitempty"`
 }
 
+// defaultArtifactoryUserAgent is sent when commonSettings.userAgent is not configured
+const defaultArtifactoryUserAgent = "MCPHost-Artifactory-Client/1.0"
+
+// defaultArtifactoryCommonSettings returns the settings used when commonSettings is omitted
+func defaultArtifactoryCommonSettings() ArtifactoryCommonSettings {
+	return ArtifactoryCommonSettings{
+		MaxRetries: 3,
+		RetryDelay: 5,
+		UserAgent:  defaultArtifactoryUserAgent,
+		LogLevel:   "info",
+	}
+}
+
 // ArtifactoryConfig represents the complete Artifactory configuration
 type ArtifactoryConfig struct {
 	Instances       map[string]ArtifactoryInstanceConfig `json:"instances"`
 	DefaultInstance string                              `json:"defaultInstance"`
 	CommonSettings  ArtifactoryCommonSettings           `json:"commonSettings"`
+	// AllowedDirectories restricts where artifacts may be downloaded to and deployed from
+	AllowedDirectories []string `json:"allowedDirectories,omitempty"`
 }
 
 // GetInstanceConfig retrieves configuration for a specific instance
@@ -37,74 +56,162 @@ func (c *ArtifactoryConfig) GetInstanceConfig(instanceName string) (*Artifactory
 	if instanceName == "" {
 		instanceName = c.DefaultInstance
 	}
-	
+
 	if instanceName == "" {
-		// Fall back to first available instance
-		for _, config := range c.Instances {
-			return &config, nil
+		switch len(c.Instances) {
+		case 0:
+			return nil, fmt.Errorf("no Artifactory instances configured; add one under \"instances\" in the artifactory server config")
+		case 1:
+			// A single instance is unambiguous even without a defaultInstance
+			for _, config := range c.Instances {
+				return &config, nil
+			}
 		}
-		return nil, fmt.Errorf("no Artifactory instances configured")
+		return nil, fmt.Errorf("no Artifactory instance specified and no defaultInstance configured; configured instances: %s", c.describeInstances())
 	}
-	
+
 	config, exists := c.Instances[instanceName]
 	if !exists {
-		return nil, fmt.Errorf("Artifactory instance '%s' not found in configuration", instanceName)
+		return nil, fmt.Errorf("Artifactory instance '%s' not found in configuration; configured instances: %s", instanceName, c.describeInstances())
 	}
-	
+
 	return &config, nil
 }
 
+// ResolveInstance returns a copy of the named instance that callers may override freely.
+// When no instance is named and none resolves, an explicit baseURL yields an ad-hoc
+// instance without credentials.
+func (c *ArtifactoryConfig) ResolveInstance(instanceName, baseURL string) (ArtifactoryInstanceConfig, error) {
+	instance, err := c.GetInstanceConfig(instanceName)
+	if err == nil {
+		return *instance, nil
+	}
+	if instanceName == "" && baseURL != "" {
+		return ArtifactoryInstanceConfig{Name: "ad-hoc", URL: baseURL, VerifySSL: true}, nil
+	}
+	return ArtifactoryInstanceConfig{}, err
+}
+
+// InstanceNames returns the configured instance names in sorted order
+func (c *ArtifactoryConfig) InstanceNames() []string {
+	return sortedKeys(c.Instances)
+}
+
+// describeInstances lists the configured instances with their URLs for error messages
+func (c *ArtifactoryConfig) describeInstances() string {
+	if len(c.Instances) == 0 {
+		return "none"
+	}
+
+	descriptions := make([]string, 0, len(c.Instances))
+	for _, name := range c.InstanceNames() {
+		description := fmt.Sprintf("%s (%s)", name, c.Instances[name].URL)
+		if name == c.DefaultInstance {
+			description += " [default]"
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
 // LoadArtifactoryConfig loads Artifactory configuration from options
 func LoadArtifactoryConfig(options map[string]any) (*ArtifactoryConfig, error) {
 	if options == nil {
 		return nil, fmt.Errorf("no configuration options provided")
 	}
 	
-	// Check if config is directly in options
-	if configData, ok := options["config"]; ok {
-		if configMap, ok := configData.(map[string]any); ok {
-			return parseArtifactoryConfig(configMap)
+	allowedDirs, err := getAllowedDirectoriesOption(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if config is nested under "config" or given directly in options
+	configMap, ok := options["config"].(map[string]any)
+	if !ok {
+		if _, hasInstances := options["instances"]; hasInstances {
+			configMap = options
+		}
+	}
+	if configMap != nil {
+		config, err := parseArtifactoryConfig(configMap)
+		if err != nil {
+			return nil, err
 		}
+		config.AllowedDirectories = allowedDirs
+		return config, nil
 	}
 	
 	// Fallback: create minimal config from individual options
 	config := &ArtifactoryConfig{
-		Instances: make(map[string]ArtifactoryInstanceConfig),
-		CommonSettings: ArtifactoryCommonSettings{
-			MaxRetries: 3,
-			RetryDelay: 5,
-			UserAgent:  "MCPHost-Artifactory-Client/1.0",
-			LogLevel:   "info",
-		},
+		Instances:          make(map[string]ArtifactoryInstanceConfig),
+		CommonSettings:     defaultArtifactoryCommonSettings(),
+		AllowedDirectories: allowedDirs,
 	}
-	
-	// Extract instance configuration from options
-	instance := ArtifactoryInstanceConfig{
-		Name:      "default",
-		URL:       getStringOption(options, "url", "http://localhost"),
-		Username:  getStringOption(options, "username", "admin"),
-		Password:  getStringOption(options, "password", "B@55w0rd"),
-		APIKey:    getStringOption(options, "apiKey", ""),
-		Timeout:   getIntOption(options, "timeout", 30),
-		VerifySSL: getBoolOption(options, "verifySSL", true),
+
+	// Without an explicit url there is no instance; tools report that instead of guessing one
+	url := getStringOption(options, "url", "")
+	if url == "" {
+		url = getStringOption(options, "base_url", "")
 	}
-	
-	config.Instances["default"] = instance
-	config.DefaultInstance = "default"
-	
+	if url != "" {
+		config.Instances["default"] = ArtifactoryInstanceConfig{
+			Name:      "default",
+			URL:       url,
+			Username:  getStringOption(options, "username", ""),
+			Password:  getStringOption(options, "password", ""),
+			APIKey:    getStringOption(options, "apiKey", getStringOption(options, "api_key", "")),
+			Timeout:   getIntOption(options, "timeout", 30),
+			VerifySSL: getBoolOption(options, "verifySSL", true),
+		}
+		config.DefaultInstance = "default"
+	}
+
 	return config, nil
 }
 
+// LoadArtifactoryConfigFile loads the artifactory server options from an mcphost JSON config file
+func LoadArtifactoryConfigFile(configFile string) (*ArtifactoryConfig, error) {
+	if configFile == "" {
+		return nil, fmt.Errorf("config file path is required")
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %v", configFile, err)
+	}
+
+	// Resolve ${env://VAR} references the same way the main config loader does
+	substituter := &config.EnvSubstituter{}
+	content, err := substituter.SubstituteEnvVars(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to substitute environment variables in %s: %v", configFile, err)
+	}
+
+	var fileConfig struct {
+		MCPServers map[string]map[string]any `json:"mcpServers"`
+	}
+	if err := json.Unmarshal([]byte(content), &fileConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %v", configFile, err)
+	}
+
+	serverConfig, ok := fileConfig.MCPServers["artifactory"]
+	if !ok {
+		return nil, fmt.Errorf("no Artifactory configuration found in %s", configFile)
+	}
+
+	// Builtin servers take their settings from "options"; older files put them beside it
+	options, ok := serverConfig["options"].(map[string]any)
+	if !ok {
+		options = serverConfig
+	}
+	return LoadArtifactoryConfig(options)
+}
+
 // parseArtifactoryConfig parses the configuration from a map
 func parseArtifactoryConfig(configMap map[string]any) (*ArtifactoryConfig, error) {
 	config := &ArtifactoryConfig{
 		Instances: make(map[string]ArtifactoryInstanceConfig),
-		CommonSettings: ArtifactoryCommonSettings{
-			MaxRetries: 3,
-			RetryDelay: 5,
-			UserAgent:  "MCPHost-Artifactory-Client/1.0",
-			LogLevel:   "info",
-		},
+		CommonSettings:  defaultArtifactoryCommonSettings(),
 	}
 	
 	// Parse instances
@@ -129,15 +236,16 @@ func parseArtifactoryConfig(configMap map[string]any) (*ArtifactoryConfig, error
 	}
 	
 	// Parse default instance
-	config.DefaultInstance = getStringOption(configMap, "defaultInstance", "default")
+	config.DefaultInstance = getStringOption(configMap, "defaultInstance", "")
 	
 	// Parse common settings
 	if commonData, ok := configMap["commonSettings"]; ok {
 		if commonMap, ok := commonData.(map[string]any); ok {
-			config.CommonSettings.MaxRetries = getIntOption(commonMap, "maxRetries", 3)
-			config.CommonSettings.RetryDelay = getIntOption(commonMap, "retryDelay", 5)
-			config.CommonSettings.UserAgent = getStringOption(commonMap, "userAgent", "MCPHost-Artifactory-Client/1.0")
-			config.CommonSettings.LogLevel = getStringOption(commonMap, "logLevel", "info")
+			defaults := defaultArtifactoryCommonSettings()
+			config.CommonSettings.MaxRetries = getIntOption(commonMap, "maxRetries", defaults.MaxRetries)
+			config.CommonSettings.RetryDelay = getIntOption(commonMap, "retryDelay", defaults.RetryDelay)
+			config.CommonSettings.UserAgent = getStringOption(commonMap, "userAgent", defaults.UserAgent)
+			config.CommonSettings.LogLevel = getStringOption(commonMap, "logLevel", defaults.LogLevel)
 		}
 	}
 	
@@ -0,0 +1,423 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// artifactorySupportBundleEndpoint is the support bundle API
+const artifactorySupportBundleEndpoint = "artifactory/api/system/support/bundle"
+
+var (
+	// artifactorySupportBundlePollInterval is how often the bundle status is checked; tests shorten it
+	artifactorySupportBundlePollInterval = 5 * time.Second
+
+	// Patterns removed from log lines so that repeated messages group together
+	supportBundleTimestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}([.,]\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	supportBundleIDPattern        = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b|\b[0-9a-fA-F]{12,}\b`)
+	supportBundleNumberPattern    = regexp.MustCompile(`\d+`)
+)
+
+// ArtifactorySupportBundle represents a support bundle as returned by the support bundle API
+type ArtifactorySupportBundle struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Created     string `json:"created,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+// SupportBundleIssue is a group of log lines that only differ in timestamps, IDs and numbers
+type SupportBundleIssue struct {
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+	Category  string `json:"category"`
+	FirstFile string `json:"first_file"`
+	Sample    string `json:"sample"`
+}
+
+// SupportBundleFileCount is the number of matches found in a file
+type SupportBundleFileCount struct {
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
+// addArtifactoryDiagnosticsTools registers the support bundle diagnostics tool
+func addArtifactoryDiagnosticsTools(s *server.MCPServer) {
+	diagnoseTool := mcp.NewTool("artifactory_diagnose",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create a support bundle through the /api/system/support/bundle API, wait until it is ready, download and extract it, and analyze it. Returns a single diagnostic report with the system version, recurring errors, exceptions and the noisiest files."),
+			mcp.WithString("work_dir",
+				mcp.Description("Directory the bundle is downloaded and extracted into; must be inside the allowed directories (defaults to support-bundles in the first allowed directory)"),
+			),
+			mcp.WithNumber("log_days",
+				mcp.Description("Number of days of logs to include in the bundle (defaults to 1)"),
+				mcp.Min(1),
+				mcp.Max(30),
+			),
+			mcp.WithBoolean("include_thread_dump",
+				mcp.Description("Include a thread dump in the bundle (defaults to true)"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Description stored with the bundle, e.g. the incident ID (optional)"),
+			),
+			mcp.WithString("search_patterns",
+				mcp.Description("Comma-separated list of patterns to search for (defaults to 'ERROR,WARNING,Exception')"),
+			),
+			mcp.WithNumber("max_results",
+				mcp.Description("Maximum number of matches kept per category (defaults to 500)"),
+				mcp.Min(1),
+			),
+			mcp.WithNumber("top_issues",
+				mcp.Description("Number of recurring issues to report (defaults to 15)"),
+				mcp.Min(1),
+				mcp.Max(100),
+			),
+			mcp.WithNumber("poll_timeout",
+				mcp.Description("Maximum time in seconds to wait for the bundle to be created and downloaded (defaults to 600)"),
+				mcp.Min(10),
+				mcp.Max(3600),
+			),
+			mcp.WithBoolean("keep_bundle",
+				mcp.Description("Keep the downloaded and extracted bundle after the analysis (defaults to true)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(diagnoseTool, executeArtifactoryDiagnose)
+}
+
+// executeArtifactoryDiagnose handles the diagnostics tool execution
+func executeArtifactoryDiagnose(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	workDir := request.GetString("work_dir", "")
+	if workDir == "" {
+		if globalArtifactoryConfig == nil || len(globalArtifactoryConfig.AllowedDirectories) == 0 {
+			return mcp.NewToolResultError("work_dir is required when no allowed directories are configured"), nil
+		}
+		workDir = filepath.Join(globalArtifactoryConfig.AllowedDirectories[0], "support-bundles")
+	}
+	workDir, err := resolveArtifactoryLocalPath(workDir)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	logDays := int(request.GetFloat("log_days", 1))
+	if logDays < 1 {
+		logDays = 1
+	}
+	searchPatterns := parseCommaSeparated(request.GetString("search_patterns", "ERROR,WARNING,Exception"))
+	if len(searchPatterns) == 0 {
+		return mcp.NewToolResultError("at least one search pattern is required"), nil
+	}
+	maxResults := int(request.GetFloat("max_results", 500))
+	topIssues := int(request.GetFloat("top_issues", 15))
+	pollTimeout := time.Duration(request.GetFloat("poll_timeout", 600)) * time.Second
+	keepBundle := request.GetBool("keep_bundle", true)
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	warnings := []string{}
+	system := map[string]interface{}{}
+	var version map[string]interface{}
+	if err := client.doJSON(ctx, "GET", "artifactory/api/system/version", nil, &version); err != nil {
+		warnings = append(warnings, fmt.Sprintf("version unavailable: %v", err))
+	} else {
+		system["version"] = version["version"]
+		system["revision"] = version["revision"]
+		system["license"] = version["license"]
+	}
+
+	// Create the bundle
+	endDate := time.Now()
+	bundleRequest := map[string]interface{}{
+		"name":        "mcphost-diagnostics-" + endDate.Format("20060102-150405"),
+		"description": request.GetString("description", "Created by artifactory_diagnose"),
+		"parameters": map[string]interface{}{
+			"configuration": true,
+			"system":        true,
+			"logs": map[string]interface{}{
+				"include":    true,
+				"start_date": endDate.AddDate(0, 0, -logDays).Format("2006-01-02"),
+				"end_date":   endDate.Format("2006-01-02"),
+			},
+			"thread_dump": map[string]interface{}{
+				"count":    boolToInt(request.GetBool("include_thread_dump", true)),
+				"interval": 0,
+			},
+		},
+	}
+
+	var created ArtifactorySupportBundle
+	if err := client.doJSON(ctx, "POST", artifactorySupportBundleEndpoint, bundleRequest, &created); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create support bundle: %v", err)), nil
+	}
+	if created.ID == "" {
+		return mcp.NewToolResultError("support bundle was created but no bundle ID was returned"), nil
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+
+	bundle, err := waitForArtifactorySupportBundle(pollCtx, request, client, created.ID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Download and extract
+	bundleDir := filepath.Join(workDir, sanitizeSupportBundleID(created.ID))
+	// The directory is removed afterwards, so it must never be the work directory or lie outside it
+	if rel, err := filepath.Rel(workDir, bundleDir); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return mcp.NewToolResultError(fmt.Sprintf("support bundle ID %q does not name a directory inside %s", created.ID, workDir)), nil
+	}
+	if err := os.MkdirAll(bundleDir, 0755); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create work directory: %v", err)), nil
+	}
+	archivePath := bundleDir + ".zip"
+	// Clean up before anything can fail so a broken download or archive is not left behind
+	if !keepBundle {
+		defer os.RemoveAll(bundleDir)
+		defer os.Remove(archivePath)
+	}
+	size, err := downloadArtifactorySupportBundle(pollCtx, client, created.ID, archivePath)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	notifyToolProgress(ctx, request, 2, 3, fmt.Sprintf("Downloaded support bundle %s (%s), analyzing", created.ID, formatBytes(size)))
+
+	if err := extractArchive(archivePath, bundleDir); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to extract support bundle: %v", err)), nil
+	}
+
+	analysis := &SupportBundleAnalysis{
+		BundlePath:     bundleDir,
+		SearchPatterns: searchPatterns,
+		AnalysisTime:   time.Now(),
+	}
+	options := supportBundleOptions{
+		searchPatterns:     searchPatterns,
+		fileTypes:          []string{".log", ".txt", ".out"},
+		includeArchives:    true,
+		extractArchives:    true,
+		includeThreadDumps: true,
+		maxResults:         maxResults,
+		contextLines:       2,
+	}
+	if err := analyzeSupportBundle(ctx, bundleDir, options, analysis); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to analyze support bundle: %v", err)), nil
+	}
+	analysis.Duration = time.Since(analysis.AnalysisTime)
+
+	issues, files := summarizeSupportBundleAnalysis(analysis, topIssues)
+	for category, results := range map[string][]SupportBundleSearchResult{"error": analysis.ErrorLogs, "warning": analysis.WarningLogs, "exception": analysis.ExceptionLogs} {
+		if len(results) >= maxResults {
+			warnings = append(warnings, fmt.Sprintf("%s matches were capped at %d; raise max_results for complete counts", category, maxResults))
+		}
+	}
+	sort.Strings(warnings)
+
+	report := map[string]interface{}{
+		"message": fmt.Sprintf("Analyzed support bundle %s: %d errors, %d warnings, %d exceptions in %d files",
+			created.ID, len(analysis.ErrorLogs), len(analysis.WarningLogs), len(analysis.ExceptionLogs), analysis.TotalFiles),
+		"system": system,
+		"bundle": map[string]interface{}{
+			"id":             created.ID,
+			"name":           bundle.Name,
+			"created":        bundle.Created,
+			"archive_path":   archivePath,
+			"extracted_path": bundleDir,
+			"size":           size,
+			"sizeFormatted":  formatBytes(size),
+			"kept":           keepBundle,
+		},
+		"summary": map[string]interface{}{
+			"files_analyzed": analysis.TotalFiles,
+			"errors":         len(analysis.ErrorLogs),
+			"warnings":       len(analysis.WarningLogs),
+			"exceptions":     len(analysis.ExceptionLogs),
+		},
+		"top_issues":   issues,
+		"noisy_files":  files,
+		"thread_dumps": analysis.ThreadDumps,
+		"warnings":     warnings,
+		"duration":     time.Since(startTime).Round(time.Second).String(),
+		"url":          client.url(artifactorySupportBundleEndpoint + "/" + url.PathEscape(created.ID)),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+	notifyToolProgress(ctx, request, 3, 3, "Support bundle analysis complete")
+
+	return artifactoryJSONResult(report)
+}
+
+// waitForArtifactorySupportBundle polls the bundle status until it is ready, failed or the context ends
+func waitForArtifactorySupportBundle(ctx context.Context, request mcp.CallToolRequest, client *artifactoryClient, id string) (*ArtifactorySupportBundle, error) {
+	endpoint := artifactorySupportBundleEndpoint + "/" + url.PathEscape(id)
+	for {
+		var bundle ArtifactorySupportBundle
+		err := client.doJSON(ctx, "GET", endpoint, nil, &bundle)
+		if httpErr, ok := err.(*artifactoryHTTPError); ok && httpErr.StatusCode == 404 {
+			// The bundle may not be listed until creation starts
+			err = nil
+			bundle.Status = "pending"
+		}
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("failed to get support bundle status: %v", err)
+		}
+
+		status := strings.ToLower(bundle.Status)
+		switch {
+		case err == nil && (status == "" || strings.Contains(status, "success") || strings.Contains(status, "complete") || strings.Contains(status, "ready")):
+			// Older versions create the bundle synchronously and report no status
+			return &bundle, nil
+		case strings.Contains(status, "fail") || strings.Contains(status, "error"):
+			return nil, fmt.Errorf("support bundle %s failed with status '%s'", id, bundle.Status)
+		}
+		notifyToolProgress(ctx, request, 1, 3, fmt.Sprintf("Waiting for support bundle %s (%s)", id, bundle.Status))
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for support bundle %s (last status '%s')", id, bundle.Status)
+		case <-time.After(artifactorySupportBundlePollInterval):
+		}
+	}
+}
+
+// downloadArtifactorySupportBundle downloads the bundle archive to a local file
+func downloadArtifactorySupportBundle(ctx context.Context, client *artifactoryClient, id, archivePath string) (int64, error) {
+	endpoint := artifactorySupportBundleEndpoint + "/" + url.PathEscape(id) + "/archive"
+	req, err := client.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "*/*")
+
+	// Bundles can be large, so the download is bounded by the poll deadline of ctx instead of the request timeout
+	resp, err := client.streamingClient().do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download support bundle: %v", err)
+	}
+	defer resp.Body.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(archivePath), "."+filepath.Base(archivePath)+".download-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	size, err := io.Copy(tmpFile, resp.Body)
+	tmpFile.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to write support bundle: %v", err)
+	}
+	if err := os.Rename(tmpFile.Name(), archivePath); err != nil {
+		return 0, fmt.Errorf("failed to move support bundle into place: %v", err)
+	}
+	return size, nil
+}
+
+// summarizeSupportBundleAnalysis groups recurring messages and counts matches per file
+func summarizeSupportBundleAnalysis(analysis *SupportBundleAnalysis, limit int) ([]SupportBundleIssue, []SupportBundleFileCount) {
+	issues := map[string]*SupportBundleIssue{}
+	fileCounts := map[string]int{}
+
+	add := func(category string, results []SupportBundleSearchResult) {
+		for _, result := range results {
+			file := result.FilePath
+			if result.ArchivePath != "" {
+				file = result.ArchivePath
+			}
+			fileCounts[file]++
+
+			message := normalizeSupportBundleMessage(result.FullLine)
+			key := category + "|" + message
+			issue := issues[key]
+			if issue == nil {
+				issue = &SupportBundleIssue{Message: message, Category: category, FirstFile: file, Sample: result.FullLine}
+				issues[key] = issue
+			}
+			issue.Count++
+		}
+	}
+	add("error", analysis.ErrorLogs)
+	add("exception", analysis.ExceptionLogs)
+	add("warning", analysis.WarningLogs)
+
+	grouped := make([]SupportBundleIssue, 0, len(issues))
+	for _, issue := range issues {
+		grouped = append(grouped, *issue)
+	}
+	sort.Slice(grouped, func(i, j int) bool {
+		if grouped[i].Count != grouped[j].Count {
+			return grouped[i].Count > grouped[j].Count
+		}
+		return grouped[i].Message < grouped[j].Message
+	})
+	if len(grouped) > limit {
+		grouped = grouped[:limit]
+	}
+
+	files := make([]SupportBundleFileCount, 0, len(fileCounts))
+	for file, count := range fileCounts {
+		files = append(files, SupportBundleFileCount{File: file, Count: count})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Count != files[j].Count {
+			return files[i].Count > files[j].Count
+		}
+		return files[i].File < files[j].File
+	})
+	if len(files) > 10 {
+		files = files[:10]
+	}
+
+	return grouped, files
+}
+
+// normalizeSupportBundleMessage strips timestamps, IDs and numbers from a log line
+func normalizeSupportBundleMessage(line string) string {
+	message := supportBundleTimestampPattern.ReplaceAllString(line, "")
+	message = supportBundleIDPattern.ReplaceAllString(message, "<id>")
+	message = supportBundleNumberPattern.ReplaceAllString(message, "N")
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) > 240 {
+		message = message[:240] + "..."
+	}
+	return message
+}
+
+// sanitizeSupportBundleID turns a bundle ID into a safe directory name
+func sanitizeSupportBundleID(id string) string {
+	// "." and ".." would name the work directory or its parent
+	if strings.Trim(id, ".") == "" {
+		return strings.Repeat("_", len(id))
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
+// boolToInt converts a bool into 1 or 0
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
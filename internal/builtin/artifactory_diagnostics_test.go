@@ -0,0 +1,179 @@
+package builtin
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSupportBundleMessage(t *testing.T) {
+	first := normalizeSupportBundleMessage("2024-05-01T10:00:00.123Z [jfrt ] [ERROR] [4f3a2b1c9d8e7f60] Failed to connect to db after 3 retries")
+	second := normalizeSupportBundleMessage("2024-05-02T11:30:00.456Z [jfrt ] [ERROR] [0a1b2c3d4e5f6789] Failed to connect to db after 5 retries")
+	if first != second {
+		t.Errorf("Expected both lines to group together, got %q and %q", first, second)
+	}
+}
+
+func TestExecuteArtifactoryDiagnose(t *testing.T) {
+	previousInterval := artifactorySupportBundlePollInterval
+	artifactorySupportBundlePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { artifactorySupportBundlePollInterval = previousInterval })
+
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	logFile, _ := zipWriter.Create("artifactory/log/artifactory-service.log")
+	logFile.Write([]byte("2024-05-01T10:00:00.123Z [jfrt ] [ERROR] [4f3a2b1c9d8e7f60] Failed to connect to db after 3 retries\n" +
+		"2024-05-01T10:00:01.123Z [jfrt ] [INFO ] Started\n" +
+		"2024-05-01T10:00:02.123Z [jfrt ] [ERROR] [0a1b2c3d4e5f6789] Failed to connect to db after 4 retries\n" +
+		"2024-05-01T10:00:03.123Z [jfrt ] [WARNING] Disk usage above 80%\n"))
+	zipWriter.Close()
+
+	polls := 0
+	var bundleRequest map[string]any
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/system/version":
+			json.NewEncoder(w).Encode(map[string]any{"version": "7.77.3", "revision": "77703900"})
+		case "/artifactory/api/system/support/bundle":
+			json.NewDecoder(r.Body).Decode(&bundleRequest)
+			json.NewEncoder(w).Encode(map[string]any{"id": "SUPP-1"})
+		case "/artifactory/api/system/support/bundle/SUPP-1":
+			polls++
+			status := "in progress"
+			if polls > 1 {
+				status = "success"
+			}
+			json.NewEncoder(w).Encode(ArtifactorySupportBundle{ID: "SUPP-1", Status: status})
+		case "/artifactory/api/system/support/bundle/SUPP-1/archive":
+			w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+
+	workDir := t.TempDir()
+	useTestArtifactoryConfig(t, mockServer.URL, workDir)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryDiagnose, map[string]any{"log_days": 2})
+	if result.IsError {
+		t.Fatalf("Unexpected diagnose error: %v", result.Content)
+	}
+	if polls < 2 {
+		t.Errorf("Expected the bundle status to be polled until ready, got %d polls", polls)
+	}
+	if bundleRequest["parameters"].(map[string]any)["logs"].(map[string]any)["include"] != true {
+		t.Errorf("Unexpected bundle request: %v", bundleRequest)
+	}
+
+	if response["system"].(map[string]any)["version"] != "7.77.3" {
+		t.Errorf("Expected the version in the report, got %v", response["system"])
+	}
+	summary := response["summary"].(map[string]any)
+	if summary["errors"] != float64(2) || summary["warnings"] != float64(1) {
+		t.Errorf("Unexpected summary: %v", summary)
+	}
+	issues := response["top_issues"].([]any)
+	if top := issues[0].(map[string]any); top["count"] != float64(2) || top["category"] != "error" {
+		t.Errorf("Expected the repeated database error first, got %v", top)
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, "support-bundles", "SUPP-1.zip")); err != nil {
+		t.Errorf("Expected the bundle to be kept: %v", err)
+	}
+}
+
+func TestExecuteArtifactoryDiagnose_BundleFailure(t *testing.T) {
+	previousInterval := artifactorySupportBundlePollInterval
+	artifactorySupportBundlePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { artifactorySupportBundlePollInterval = previousInterval })
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/system/support/bundle":
+			json.NewEncoder(w).Encode(map[string]any{"id": "SUPP-2"})
+		case "/artifactory/api/system/support/bundle/SUPP-2":
+			json.NewEncoder(w).Encode(ArtifactorySupportBundle{ID: "SUPP-2", Status: "failure"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL, t.TempDir())
+
+	_, result := callArtifactoryTool(t, executeArtifactoryDiagnose, map[string]any{})
+	if !result.IsError {
+		t.Error("Expected an error when the bundle fails")
+	}
+}
+
+func TestExecuteArtifactoryDiagnose_RemovesBrokenBundle(t *testing.T) {
+	previousInterval := artifactorySupportBundlePollInterval
+	artifactorySupportBundlePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { artifactorySupportBundlePollInterval = previousInterval })
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/api/system/support/bundle":
+			json.NewEncoder(w).Encode(map[string]any{"id": "SUPP-3"})
+		case "/artifactory/api/system/support/bundle/SUPP-3":
+			json.NewEncoder(w).Encode(ArtifactorySupportBundle{ID: "SUPP-3", Status: "success"})
+		case "/artifactory/api/system/support/bundle/SUPP-3/archive":
+			w.Write([]byte("not a zip archive"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	workDir := t.TempDir()
+	useTestArtifactoryConfig(t, mockServer.URL, workDir)
+
+	_, result := callArtifactoryTool(t, executeArtifactoryDiagnose, map[string]any{"keep_bundle": false})
+	if !result.IsError {
+		t.Fatal("Expected an error for a broken archive")
+	}
+	entries, _ := os.ReadDir(filepath.Join(workDir, "support-bundles"))
+	if len(entries) != 0 {
+		t.Errorf("Expected the broken bundle to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestExecuteArtifactoryDiagnose_DotBundleID(t *testing.T) {
+	previousInterval := artifactorySupportBundlePollInterval
+	artifactorySupportBundlePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { artifactorySupportBundlePollInterval = previousInterval })
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/artifactory/api/system/support/bundle":
+			json.NewEncoder(w).Encode(map[string]any{"id": ".."})
+		case strings.HasSuffix(r.URL.Path, "/archive"):
+			w.Write([]byte("not a zip archive"))
+		default:
+			json.NewEncoder(w).Encode(ArtifactorySupportBundle{ID: "..", Status: "success"})
+		}
+	}))
+	defer mockServer.Close()
+	workDir := t.TempDir()
+	sentinel := filepath.Join(workDir, "keep.txt")
+	os.WriteFile(sentinel, []byte("keep"), 0644)
+	useTestArtifactoryConfig(t, mockServer.URL, workDir)
+
+	callArtifactoryTool(t, executeArtifactoryDiagnose, map[string]any{"keep_bundle": false})
+	if _, err := os.Stat(sentinel); err != nil {
+		t.Errorf("A bundle ID of \"..\" must not remove the work directory: %v", err)
+	}
+	if name := sanitizeSupportBundleID("."); name != "_" {
+		t.Errorf("Expected \".\" to become \"_\", got %q", name)
+	}
+	if name := sanitizeSupportBundleID("../SUPP-1"); name != ".._SUPP-1" {
+		t.Errorf("Unexpected directory name %q", name)
+	}
+}
@@ -0,0 +1,666 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// artifactoryPermissionsEndpoint is the v2 permission targets API
+const artifactoryPermissionsEndpoint = "artifactory/api/v2/security/permissions"
+
+// artifactoryPermissionActionAliases maps accepted privilege names to v2 permission actions
+var artifactoryPermissionActionAliases = map[string]string{
+	"read":            "read",
+	"write":           "write",
+	"deploy":          "write",
+	"annotate":        "annotate",
+	"delete":          "delete",
+	"manage":          "manage",
+	"admin":           "manage",
+	"managedxraymeta": "managedXrayMeta",
+	"distribute":      "distribute",
+}
+
+// artifactoryAnyRepositoryTypes maps the typed ANY repository keywords to the repository types they cover
+var artifactoryAnyRepositoryTypes = map[string][]string{
+	"ANY LOCAL":        {"local", "federated"},
+	"ANY REMOTE":       {"remote"},
+	"ANY DISTRIBUTION": {"distribution"},
+}
+
+// ArtifactoryPermissionActions maps users and groups to the actions granted to them
+type ArtifactoryPermissionActions struct {
+	Users  map[string][]string `json:"users,omitempty"`
+	Groups map[string][]string `json:"groups,omitempty"`
+}
+
+// ArtifactoryPermissionSection is the repo, build or release bundle part of a v2 permission target
+type ArtifactoryPermissionSection struct {
+	IncludePatterns []string                     `json:"include-patterns,omitempty"`
+	ExcludePatterns []string                     `json:"exclude-patterns,omitempty"`
+	Repositories    []string                     `json:"repositories"`
+	Actions         ArtifactoryPermissionActions `json:"actions"`
+}
+
+// ArtifactoryPermission represents a v2 permission target in Artifactory
+type ArtifactoryPermission struct {
+	Name          string                        `json:"name"`
+	Repo          *ArtifactoryPermissionSection `json:"repo,omitempty"`
+	Build         *ArtifactoryPermissionSection `json:"build,omitempty"`
+	ReleaseBundle *ArtifactoryPermissionSection `json:"releaseBundle,omitempty"`
+}
+
+// ArtifactoryPermissionRef is an entry of the permission target list
+type ArtifactoryPermissionRef struct {
+	Name string `json:"name"`
+	URI  string `json:"uri"`
+}
+
+// ArtifactoryPermissionSpec describes a new permission target in terms of principals and repositories
+type ArtifactoryPermissionSpec struct {
+	Name            string
+	Users           []string
+	Groups          []string
+	Repositories    []string
+	Actions         []string
+	IncludePatterns []string
+	ExcludePatterns []string
+}
+
+// ArtifactoryPermissionChanges describes an in-place update of an existing permission target.
+// Empty fields leave the current value untouched.
+type ArtifactoryPermissionChanges struct {
+	Repositories    []string
+	IncludePatterns []string
+	ExcludePatterns []string
+	Users           []string
+	Groups          []string
+	Actions         []string
+	RemoveUsers     []string
+	RemoveGroups    []string
+}
+
+// ArtifactoryPermissionManager manages v2 permission targets on a single Artifactory instance
+type ArtifactoryPermissionManager struct {
+	client *artifactoryClient
+}
+
+// NewArtifactoryPermissionManager creates a permission manager for an instance using the given common settings
+func NewArtifactoryPermissionManager(instance *ArtifactoryInstanceConfig, settings ArtifactoryCommonSettings, timeout time.Duration) (*ArtifactoryPermissionManager, error) {
+	client, err := newArtifactoryClientWithSettings(instance, settings, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &ArtifactoryPermissionManager{client: client}, nil
+}
+
+// URL returns the full URL of a permission target, or of the list endpoint when name is empty
+func (m *ArtifactoryPermissionManager) URL(name string) string {
+	return m.client.url(artifactoryPermissionEndpoint(name))
+}
+
+// List returns the names of all permission targets
+func (m *ArtifactoryPermissionManager) List(ctx context.Context) ([]ArtifactoryPermissionRef, error) {
+	var refs []ArtifactoryPermissionRef
+	if err := m.client.doJSON(ctx, "GET", artifactoryPermissionsEndpoint, nil, &refs); err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %v", err)
+	}
+	return refs, nil
+}
+
+// ListDetailed returns every permission target with its repositories and principals
+func (m *ArtifactoryPermissionManager) ListDetailed(ctx context.Context) ([]ArtifactoryPermission, error) {
+	refs, err := m.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]ArtifactoryPermission, 0, len(refs))
+	for _, ref := range refs {
+		permission, err := m.Get(ctx, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, *permission)
+	}
+	return permissions, nil
+}
+
+// Get returns a single permission target
+func (m *ArtifactoryPermissionManager) Get(ctx context.Context, name string) (*ArtifactoryPermission, error) {
+	var permission ArtifactoryPermission
+	if err := m.client.doJSON(ctx, "GET", artifactoryPermissionEndpoint(name), nil, &permission); err != nil {
+		return nil, fmt.Errorf("failed to get permission '%s': %v", name, err)
+	}
+	return &permission, nil
+}
+
+// Create creates a new permission target
+func (m *ArtifactoryPermissionManager) Create(ctx context.Context, permission *ArtifactoryPermission) error {
+	if err := m.client.doJSON(ctx, "POST", artifactoryPermissionEndpoint(permission.Name), permission, nil); err != nil {
+		return fmt.Errorf("failed to create permission '%s': %v", permission.Name, err)
+	}
+	return nil
+}
+
+// Update applies changes to an existing permission target and returns the result
+func (m *ArtifactoryPermissionManager) Update(ctx context.Context, name string, changes ArtifactoryPermissionChanges) (*ArtifactoryPermission, error) {
+	permission, err := m.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := permission.Apply(changes); err != nil {
+		return nil, err
+	}
+	if err := m.client.doJSON(ctx, "PUT", artifactoryPermissionEndpoint(name), permission, nil); err != nil {
+		return nil, fmt.Errorf("failed to update permission '%s': %v", name, err)
+	}
+	return permission, nil
+}
+
+// Delete removes a permission target
+func (m *ArtifactoryPermissionManager) Delete(ctx context.Context, name string) error {
+	if err := m.client.doJSON(ctx, "DELETE", artifactoryPermissionEndpoint(name), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete permission '%s': %v", name, err)
+	}
+	return nil
+}
+
+// artifactoryPermissionEndpoint returns the API endpoint of a permission target
+func artifactoryPermissionEndpoint(name string) string {
+	if name == "" {
+		return artifactoryPermissionsEndpoint
+	}
+	return artifactoryPermissionsEndpoint + "/" + url.PathEscape(name)
+}
+
+// NewArtifactoryPermission builds a repository permission target from a spec
+func NewArtifactoryPermission(spec ArtifactoryPermissionSpec) (*ArtifactoryPermission, error) {
+	if spec.Name == "" {
+		return nil, fmt.Errorf("permission name is required")
+	}
+	if len(spec.Users) == 0 && len(spec.Groups) == 0 {
+		return nil, fmt.Errorf("at least one user or group must be specified")
+	}
+
+	actions, err := NormalizeArtifactoryPermissionActions(spec.Actions)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		actions = []string{"read"}
+	}
+
+	repositories := spec.Repositories
+	if len(repositories) == 0 {
+		repositories = []string{"ANY"}
+	}
+	includePatterns := spec.IncludePatterns
+	if len(includePatterns) == 0 {
+		includePatterns = []string{"**"}
+	}
+
+	section := &ArtifactoryPermissionSection{
+		IncludePatterns: includePatterns,
+		ExcludePatterns: spec.ExcludePatterns,
+		Repositories:    repositories,
+	}
+	section.grant(spec.Users, spec.Groups, actions)
+
+	return &ArtifactoryPermission{Name: spec.Name, Repo: section}, nil
+}
+
+// Apply updates the repository section of a permission target in place
+func (p *ArtifactoryPermission) Apply(changes ArtifactoryPermissionChanges) error {
+	actions, err := NormalizeArtifactoryPermissionActions(changes.Actions)
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 && (len(changes.Users) > 0 || len(changes.Groups) > 0) {
+		return fmt.Errorf("actions are required when granting users or groups")
+	}
+
+	if p.Repo == nil {
+		p.Repo = &ArtifactoryPermissionSection{IncludePatterns: []string{"**"}}
+	}
+	if len(changes.Repositories) > 0 {
+		p.Repo.Repositories = changes.Repositories
+	}
+	if len(changes.IncludePatterns) > 0 {
+		p.Repo.IncludePatterns = changes.IncludePatterns
+	}
+	if len(changes.ExcludePatterns) > 0 {
+		p.Repo.ExcludePatterns = changes.ExcludePatterns
+	}
+
+	p.Repo.grant(changes.Users, changes.Groups, actions)
+	for _, user := range changes.RemoveUsers {
+		delete(p.Repo.Actions.Users, user)
+	}
+	for _, group := range changes.RemoveGroups {
+		delete(p.Repo.Actions.Groups, group)
+	}
+
+	if len(p.Repo.Repositories) == 0 {
+		return fmt.Errorf("permission '%s' must cover at least one repository", p.Name)
+	}
+	return nil
+}
+
+// grant sets the actions of the given users and groups, replacing any they already had
+func (s *ArtifactoryPermissionSection) grant(users, groups, actions []string) {
+	for _, user := range users {
+		if s.Actions.Users == nil {
+			s.Actions.Users = make(map[string][]string)
+		}
+		s.Actions.Users[user] = actions
+	}
+	for _, group := range groups {
+		if s.Actions.Groups == nil {
+			s.Actions.Groups = make(map[string][]string)
+		}
+		s.Actions.Groups[group] = actions
+	}
+}
+
+// CoversRepository reports whether the repository section applies to a repository of the given type
+// (the rclass: local, remote, federated, virtual or distribution). ANY covers every repository, while
+// ANY LOCAL, ANY REMOTE and ANY DISTRIBUTION only cover repositories of their type.
+func (p *ArtifactoryPermission) CoversRepository(repository, repositoryType string) bool {
+	if p.Repo == nil {
+		return false
+	}
+	for _, repo := range p.Repo.Repositories {
+		if repo == repository || repo == "ANY" {
+			return true
+		}
+		if types, ok := artifactoryAnyRepositoryTypes[repo]; ok && slices.Contains(types, strings.ToLower(repositoryType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesTypedAnyRepository reports whether any permission target is on ANY LOCAL, ANY REMOTE or ANY DISTRIBUTION
+func usesTypedAnyRepository(permissions []ArtifactoryPermission) bool {
+	for _, permission := range permissions {
+		if permission.Repo == nil {
+			continue
+		}
+		for _, repo := range permission.Repo.Repositories {
+			if _, ok := artifactoryAnyRepositoryTypes[repo]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NormalizeArtifactoryPermissionActions converts privilege names such as READ or DEPLOY into v2 actions
+func NormalizeArtifactoryPermissionActions(privileges []string) ([]string, error) {
+	actions := make([]string, 0, len(privileges))
+	for _, privilege := range privileges {
+		action, ok := artifactoryPermissionActionAliases[strings.ToLower(strings.TrimSpace(privilege))]
+		if !ok {
+			return nil, fmt.Errorf("unknown permission action '%s' (valid: read, write, annotate, delete, manage, managedXrayMeta, distribute)", privilege)
+		}
+		if !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions, nil
+}
+
+// filterArtifactoryPermissions keeps targets matching the repository, principal and action filters.
+// Principals without the requested action are dropped from the returned copies.
+func filterArtifactoryPermissions(permissions []ArtifactoryPermission, repository, repositoryType, user, group, action string) []ArtifactoryPermission {
+	filtered := []ArtifactoryPermission{}
+	for _, permission := range permissions {
+		if repository != "" && !permission.CoversRepository(repository, repositoryType) {
+			continue
+		}
+		if permission.Repo == nil {
+			if user == "" && group == "" && action == "" {
+				filtered = append(filtered, permission)
+			}
+			continue
+		}
+
+		section := *permission.Repo
+		section.Actions = ArtifactoryPermissionActions{
+			Users:  filterArtifactoryPrincipals(section.Actions.Users, user, action, group == ""),
+			Groups: filterArtifactoryPrincipals(section.Actions.Groups, group, action, user == ""),
+		}
+		if (user != "" || group != "" || action != "") && len(section.Actions.Users) == 0 && len(section.Actions.Groups) == 0 {
+			continue
+		}
+		permission.Repo = &section
+		filtered = append(filtered, permission)
+	}
+	return filtered
+}
+
+// filterArtifactoryPrincipals returns the principals matching name and holding action.
+// With no name filter, all principals are kept only when include is true.
+func filterArtifactoryPrincipals(principals map[string][]string, name, action string, include bool) map[string][]string {
+	if name == "" && !include {
+		return nil
+	}
+	result := make(map[string][]string)
+	for principal, actions := range principals {
+		if name != "" && principal != name {
+			continue
+		}
+		if action != "" && !slices.Contains(actions, action) {
+			continue
+		}
+		result[principal] = actions
+	}
+	return result
+}
+
+// addArtifactoryPermissionTools registers the permission target management tools
+func addArtifactoryPermissionTools(s *server.MCPServer) {
+	listTool := mcp.NewTool("artifactory_permission_list",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("List permission targets using the /artifactory/api/v2/security/permissions endpoint. Filters answer questions such as \"who can deploy to libs-release?\" (repository=libs-release, action=write)."),
+			mcp.WithString("repository",
+				mcp.Description("Only include targets covering this repository (targets on ANY, or on ANY LOCAL/REMOTE/DISTRIBUTION matching its type, are included)"),
+			),
+			mcp.WithString("user",
+				mcp.Description("Only include targets granting this user"),
+			),
+			mcp.WithString("group",
+				mcp.Description("Only include targets granting this group"),
+			),
+			mcp.WithString("action",
+				mcp.Description("Only include principals holding this action: read, write (deploy), annotate, delete, manage, managedXrayMeta, distribute"),
+			),
+			mcp.WithBoolean("details",
+				mcp.Description("Include repositories and principals of every target even without filters (one request per target, defaults to false)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	getTool := mcp.NewTool("artifactory_permission_get",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get a permission target with its repositories, patterns, users and groups"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Permission target name"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	createTool := mcp.NewTool("artifactory_permission_create",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create a repository permission target using the v2 permissions API"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Permission target name"),
+			),
+			mcp.WithString("users",
+				mcp.Description("Comma-separated list of users to grant"),
+			),
+			mcp.WithString("groups",
+				mcp.Description("Comma-separated list of groups to grant"),
+			),
+			mcp.WithString("repositories",
+				mcp.Description("Comma-separated list of repositories, or ANY, ANY LOCAL, ANY REMOTE (defaults to ANY)"),
+			),
+			mcp.WithString("actions",
+				mcp.Description("Comma-separated actions granted to every user and group: read, write, annotate, delete, manage (READ/DEPLOY/ADMIN are accepted, defaults to read)"),
+			),
+			mcp.WithString("include_patterns",
+				mcp.Description("Comma-separated include patterns (defaults to **)"),
+			),
+			mcp.WithString("exclude_patterns",
+				mcp.Description("Comma-separated exclude patterns"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	updateTool := mcp.NewTool("artifactory_permission_update",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Update an existing permission target in place: replace its repositories or patterns, grant users and groups, or remove them"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Permission target name"),
+			),
+			mcp.WithString("repositories",
+				mcp.Description("Comma-separated list of repositories replacing the current list"),
+			),
+			mcp.WithString("users",
+				mcp.Description("Comma-separated list of users to grant the given actions (replaces their current actions)"),
+			),
+			mcp.WithString("groups",
+				mcp.Description("Comma-separated list of groups to grant the given actions (replaces their current actions)"),
+			),
+			mcp.WithString("actions",
+				mcp.Description("Comma-separated actions for the users and groups being granted"),
+			),
+			mcp.WithString("remove_users",
+				mcp.Description("Comma-separated list of users to remove from the target"),
+			),
+			mcp.WithString("remove_groups",
+				mcp.Description("Comma-separated list of groups to remove from the target"),
+			),
+			mcp.WithString("include_patterns",
+				mcp.Description("Comma-separated include patterns replacing the current ones"),
+			),
+			mcp.WithString("exclude_patterns",
+				mcp.Description("Comma-separated exclude patterns replacing the current ones"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	deleteTool := mcp.NewTool("artifactory_permission_delete",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Delete a permission target"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("Permission target name"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(listTool, executeArtifactoryPermissionList)
+	s.AddTool(getTool, executeArtifactoryPermissionGet)
+	s.AddTool(createTool, executeArtifactoryPermissionCreate)
+	s.AddTool(updateTool, executeArtifactoryPermissionUpdate)
+	s.AddTool(deleteTool, executeArtifactoryPermissionDelete)
+}
+
+// newArtifactoryPermissionManagerFromRequest creates a permission manager for the instance named in the request
+func newArtifactoryPermissionManagerFromRequest(request mcp.CallToolRequest) (*ArtifactoryPermissionManager, error) {
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	return &ArtifactoryPermissionManager{client: client}, nil
+}
+
+// executeArtifactoryPermissionList handles the permission list tool execution
+func executeArtifactoryPermissionList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repository := request.GetString("repository", "")
+	user := request.GetString("user", "")
+	group := request.GetString("group", "")
+	action := request.GetString("action", "")
+	if action != "" {
+		actions, err := NormalizeArtifactoryPermissionActions([]string{action})
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		action = actions[0]
+	}
+
+	manager, err := newArtifactoryPermissionManagerFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := map[string]any{
+		"url":       manager.URL(""),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	filtered := repository != "" || user != "" || group != "" || action != ""
+	if !filtered && !request.GetBool("details", false) {
+		refs, err := manager.List(ctx)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		result["permissions"] = refs
+		result["count"] = len(refs)
+		return artifactoryJSONResult(result)
+	}
+
+	permissions, err := manager.ListDetailed(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if filtered {
+		// Typed ANY targets only cover the repository when its type matches
+		repositoryType := ""
+		if repository != "" && usesTypedAnyRepository(permissions) {
+			config, err := getArtifactoryRepositoryConfig(ctx, manager.client, repository)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			repositoryType, _ = config["rclass"].(string)
+		}
+		permissions = filterArtifactoryPermissions(permissions, repository, repositoryType, user, group, action)
+		result["filters"] = map[string]string{
+			"repository": repository,
+			"user":       user,
+			"group":      group,
+			"action":     action,
+		}
+	}
+	result["permissions"] = permissions
+	result["count"] = len(permissions)
+	return artifactoryJSONResult(result)
+}
+
+// executeArtifactoryPermissionGet handles the permission get tool execution
+func executeArtifactoryPermissionGet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	manager, err := newArtifactoryPermissionManagerFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	permission, err := manager.Get(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"permission": permission,
+		"url":        manager.URL(name),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryPermissionCreate handles the permission create tool execution
+func executeArtifactoryPermissionCreate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	permission, err := NewArtifactoryPermission(ArtifactoryPermissionSpec{
+		Name:            request.GetString("name", ""),
+		Users:           parseCommaSeparated(request.GetString("users", "")),
+		Groups:          parseCommaSeparated(request.GetString("groups", "")),
+		Repositories:    parseCommaSeparated(request.GetString("repositories", "")),
+		Actions:         parseCommaSeparated(request.GetString("actions", "")),
+		IncludePatterns: parseCommaSeparated(request.GetString("include_patterns", "")),
+		ExcludePatterns: parseCommaSeparated(request.GetString("exclude_patterns", "")),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	manager, err := newArtifactoryPermissionManagerFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := manager.Create(ctx, permission); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"message":    "Permission target created successfully",
+		"permission": permission,
+		"url":        manager.URL(permission.Name),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryPermissionUpdate handles the permission update tool execution
+func executeArtifactoryPermissionUpdate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	changes := ArtifactoryPermissionChanges{
+		Repositories:    parseCommaSeparated(request.GetString("repositories", "")),
+		IncludePatterns: parseCommaSeparated(request.GetString("include_patterns", "")),
+		ExcludePatterns: parseCommaSeparated(request.GetString("exclude_patterns", "")),
+		Users:           parseCommaSeparated(request.GetString("users", "")),
+		Groups:          parseCommaSeparated(request.GetString("groups", "")),
+		Actions:         parseCommaSeparated(request.GetString("actions", "")),
+		RemoveUsers:     parseCommaSeparated(request.GetString("remove_users", "")),
+		RemoveGroups:    parseCommaSeparated(request.GetString("remove_groups", "")),
+	}
+
+	manager, err := newArtifactoryPermissionManagerFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	permission, err := manager.Update(ctx, name, changes)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"message":    "Permission target updated successfully",
+		"permission": permission,
+		"url":        manager.URL(name),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryPermissionDelete handles the permission delete tool execution
+func executeArtifactoryPermissionDelete(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	manager, err := newArtifactoryPermissionManagerFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := manager.Delete(ctx, name); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return artifactoryJSONResult(map[string]any{
+		"message":   fmt.Sprintf("Permission target '%s' deleted successfully", name),
+		"name":      name,
+		"url":       manager.URL(name),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
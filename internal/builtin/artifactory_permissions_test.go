@@ -0,0 +1,269 @@
+package builtin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newPermissionTestServer serves a small in-memory v2 permissions API
+func newPermissionTestServer(t *testing.T, permissions map[string]*ArtifactoryPermission) *httptest.Server {
+	t.Helper()
+
+	const prefix = "/artifactory/api/v2/security/permissions"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix && r.Method == "GET" {
+			refs := []ArtifactoryPermissionRef{}
+			for _, name := range sortedKeys(permissions) {
+				refs = append(refs, ArtifactoryPermissionRef{Name: name, URI: "http://example" + prefix + "/" + name})
+			}
+			json.NewEncoder(w).Encode(refs)
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		switch r.Method {
+		case "GET":
+			permission, ok := permissions[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(permission)
+		case "POST", "PUT":
+			if _, exists := permissions[name]; exists != (r.Method == "PUT") {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			var permission ArtifactoryPermission
+			if err := json.NewDecoder(r.Body).Decode(&permission); err != nil {
+				t.Errorf("Invalid permission body: %v", err)
+			}
+			permissions[name] = &permission
+			w.WriteHeader(http.StatusOK)
+		case "DELETE":
+			delete(permissions, name)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+}
+
+func TestArtifactoryPermissionCreateAndDelete(t *testing.T) {
+	permissions := map[string]*ArtifactoryPermission{}
+	mockServer := newPermissionTestServer(t, permissions)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	_, result := callArtifactoryTool(t, executeArtifactoryPermissionCreate, map[string]any{
+		"name":         "dev-permissions",
+		"users":        "alice,bob",
+		"groups":       "developers",
+		"repositories": "libs-release-local",
+		"actions":      "READ,DEPLOY",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected create error: %v", result.Content)
+	}
+
+	created, ok := permissions["dev-permissions"]
+	if !ok || created.Repo == nil {
+		t.Fatal("Permission target was not created")
+	}
+	if !reflect.DeepEqual(created.Repo.Actions.Users["alice"], []string{"read", "write"}) {
+		t.Errorf("Expected normalized v2 actions, got %v", created.Repo.Actions.Users["alice"])
+	}
+	if !reflect.DeepEqual(created.Repo.IncludePatterns, []string{"**"}) {
+		t.Errorf("Expected default include pattern, got %v", created.Repo.IncludePatterns)
+	}
+
+	// Creating the same target again must not silently replace it
+	_, result = callArtifactoryTool(t, executeArtifactoryPermissionCreate, map[string]any{
+		"name":  "dev-permissions",
+		"users": "carol",
+	})
+	if !result.IsError {
+		t.Error("Expected an error when the target already exists")
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryPermissionDelete, map[string]any{
+		"name": "dev-permissions",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected delete error: %v", result.Content)
+	}
+	if _, ok := permissions["dev-permissions"]; ok {
+		t.Error("Permission target was not deleted")
+	}
+}
+
+func TestArtifactoryPermissionListFilters(t *testing.T) {
+	permissions := map[string]*ArtifactoryPermission{
+		"release-deployers": {Name: "release-deployers", Repo: &ArtifactoryPermissionSection{
+			Repositories: []string{"libs-release"},
+			Actions: ArtifactoryPermissionActions{
+				Users:  map[string][]string{"ci": {"read", "write"}, "viewer": {"read"}},
+				Groups: map[string][]string{"release-team": {"read", "write", "delete"}},
+			},
+		}},
+		"global-admins": {Name: "global-admins", Repo: &ArtifactoryPermissionSection{
+			Repositories: []string{"ANY"},
+			Actions: ArtifactoryPermissionActions{
+				Groups: map[string][]string{"admins": {"read", "write", "manage"}},
+			},
+		}},
+		"snapshots": {Name: "snapshots", Repo: &ArtifactoryPermissionSection{
+			Repositories: []string{"libs-snapshot"},
+			Actions: ArtifactoryPermissionActions{
+				Users: map[string][]string{"dev": {"read", "write"}},
+			},
+		}},
+	}
+	mockServer := newPermissionTestServer(t, permissions)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryPermissionList, map[string]any{})
+	if result.IsError || response["count"] != float64(3) {
+		t.Fatalf("Unexpected plain list result: %v", result.Content)
+	}
+
+	// Who can deploy to libs-release?
+	response, result = callArtifactoryTool(t, executeArtifactoryPermissionList, map[string]any{
+		"repository": "libs-release",
+		"action":     "DEPLOY",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected filtered list error: %v", result.Content)
+	}
+
+	var filtered []ArtifactoryPermission
+	data, _ := json.Marshal(response["permissions"])
+	json.Unmarshal(data, &filtered)
+	if len(filtered) != 2 {
+		t.Fatalf("Expected 2 matching targets, got %d", len(filtered))
+	}
+	for _, permission := range filtered {
+		if _, ok := permission.Repo.Actions.Users["viewer"]; ok {
+			t.Error("Read-only user should be filtered out")
+		}
+	}
+	if filtered[1].Name != "release-deployers" || len(filtered[1].Repo.Actions.Users) != 1 || len(filtered[1].Repo.Actions.Groups) != 1 {
+		t.Errorf("Unexpected principals for release-deployers: %+v", filtered[1].Repo.Actions)
+	}
+}
+
+func TestArtifactoryPermissionCoversRepository(t *testing.T) {
+	target := func(repositories ...string) ArtifactoryPermission {
+		return ArtifactoryPermission{Repo: &ArtifactoryPermissionSection{Repositories: repositories}}
+	}
+	for _, tc := range []struct {
+		permission     ArtifactoryPermission
+		repositoryType string
+		expected       bool
+	}{
+		{target("libs-release"), "", true},
+		{target("ANY"), "", true},
+		{target("ANY LOCAL"), "local", true},
+		{target("ANY LOCAL"), "federated", true},
+		{target("ANY LOCAL"), "remote", false},
+		{target("ANY REMOTE"), "remote", true},
+		{target("ANY REMOTE"), "", false},
+		{target("ANY DISTRIBUTION"), "local", false},
+		{target("ANYTHING-goes"), "local", false},
+		{target("other"), "local", false},
+		{ArtifactoryPermission{}, "local", false},
+	} {
+		if covers := tc.permission.CoversRepository("libs-release", tc.repositoryType); covers != tc.expected {
+			t.Errorf("%v covering a %q repository = %v, expected %v", tc.permission.Repo, tc.repositoryType, covers, tc.expected)
+		}
+	}
+}
+
+func TestArtifactoryPermissionListTypedAny(t *testing.T) {
+	permissions := map[string]*ArtifactoryPermission{
+		"local-readers":  {Name: "local-readers", Repo: &ArtifactoryPermissionSection{Repositories: []string{"ANY LOCAL"}, Actions: ArtifactoryPermissionActions{Users: map[string][]string{"ci": {"read"}}}}},
+		"remote-readers": {Name: "remote-readers", Repo: &ArtifactoryPermissionSection{Repositories: []string{"ANY REMOTE"}, Actions: ArtifactoryPermissionActions{Users: map[string][]string{"ci": {"read"}}}}},
+	}
+	permissionServer := newPermissionTestServer(t, permissions)
+	defer permissionServer.Close()
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/artifactory/api/repositories/libs-release" {
+			w.Write([]byte(`{"key": "libs-release", "rclass": "local"}`))
+			return
+		}
+		permissionServer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryPermissionList, map[string]any{"repository": "libs-release"})
+	if result.IsError {
+		t.Fatalf("Unexpected filtered list error: %v", result.Content)
+	}
+	if response["count"] != float64(1) {
+		t.Errorf("Expected only the ANY LOCAL target, got %v", response["permissions"])
+	}
+}
+
+func TestArtifactoryPermissionUpdate(t *testing.T) {
+	permissions := map[string]*ArtifactoryPermission{
+		"dev-permissions": {Name: "dev-permissions", Repo: &ArtifactoryPermissionSection{
+			IncludePatterns: []string{"**"},
+			Repositories:    []string{"libs-snapshot"},
+			Actions: ArtifactoryPermissionActions{
+				Users: map[string][]string{"alice": {"read"}, "bob": {"read", "write"}},
+			},
+		}},
+	}
+	mockServer := newPermissionTestServer(t, permissions)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	_, result := callArtifactoryTool(t, executeArtifactoryPermissionUpdate, map[string]any{
+		"name":         "dev-permissions",
+		"users":        "alice",
+		"actions":      "read,write",
+		"remove_users": "bob",
+		"groups":       "qa",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected update error: %v", result.Content)
+	}
+
+	updated := permissions["dev-permissions"].Repo
+	if !reflect.DeepEqual(updated.Actions.Users, map[string][]string{"alice": {"read", "write"}}) {
+		t.Errorf("Unexpected users after update: %v", updated.Actions.Users)
+	}
+	if !reflect.DeepEqual(updated.Actions.Groups, map[string][]string{"qa": {"read", "write"}}) {
+		t.Errorf("Unexpected groups after update: %v", updated.Actions.Groups)
+	}
+	if !reflect.DeepEqual(updated.Repositories, []string{"libs-snapshot"}) {
+		t.Errorf("Repositories should be untouched, got %v", updated.Repositories)
+	}
+
+	// Granting principals without actions is ambiguous
+	_, result = callArtifactoryTool(t, executeArtifactoryPermissionUpdate, map[string]any{
+		"name":  "dev-permissions",
+		"users": "carol",
+	})
+	if !result.IsError {
+		t.Error("Expected an error when granting users without actions")
+	}
+}
+
+func TestNormalizeArtifactoryPermissionActions(t *testing.T) {
+	actions, err := NormalizeArtifactoryPermissionActions([]string{"READ", "Deploy", "write", "ADMIN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actions, []string{"read", "write", "manage"}) {
+		t.Errorf("Unexpected actions %v", actions)
+	}
+
+	if _, err := NormalizeArtifactoryPermissionActions([]string{"execute"}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
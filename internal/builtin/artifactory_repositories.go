@@ -0,0 +1,450 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ArtifactoryReplication represents a push or pull replication configuration
+type ArtifactoryReplication struct {
+	URL                             string `json:"url,omitempty"`
+	Username                        string `json:"username,omitempty"`
+	Password                        string `json:"password,omitempty"`
+	CronExp                         string `json:"cronExp"`
+	RepoKey                         string `json:"repoKey,omitempty"`
+	Enabled                         bool   `json:"enabled"`
+	SyncDeletes                     bool   `json:"syncDeletes"`
+	SyncProperties                  bool   `json:"syncProperties"`
+	SyncStatistics                  bool   `json:"syncStatistics"`
+	EnableEventReplication          bool   `json:"enableEventReplication"`
+	PathPrefix                      string `json:"pathPrefix,omitempty"`
+	SocketTimeoutMillis             int    `json:"socketTimeoutMillis,omitempty"`
+	CheckBinaryExistenceInFilestore bool   `json:"checkBinaryExistenceInFilestore,omitempty"`
+}
+
+// addArtifactoryRepositoryTools registers the repository configuration and replication tools
+func addArtifactoryRepositoryTools(s *server.MCPServer) {
+	getConfigTool := mcp.NewTool("artifactory_get_repository_config",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get the full configuration of a repository using the /artifactory/api/repositories/{key} endpoint"),
+			mcp.WithString("repo_key",
+				mcp.Required(),
+				mcp.Description("The repository key"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	updateTool := mcp.NewTool("artifactory_update_repository",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Update a repository with a partial JSON configuration. Only the given fields are sent; Artifactory merges nested objects and replaces other values. Artifactory keeps fields that are missing from an update, so fields cannot be removed with null; set them to an explicit value such as \"\", false or [] instead."),
+			mcp.WithString("repo_key",
+				mcp.Required(),
+				mcp.Description("The repository key"),
+			),
+			mcp.WithString("config",
+				mcp.Required(),
+				mcp.Description("JSON object with the fields to change, e.g. {\"description\": \"Old builds\", \"blackedOut\": true}"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Only show the changes and the merged configuration without applying them (defaults to false)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	deleteTool := mcp.NewTool("artifactory_delete_repository",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Delete a repository and ALL of its artifacts. This cannot be undone."),
+			mcp.WithString("repo_key",
+				mcp.Required(),
+				mcp.Description("The repository key"),
+			),
+			mcp.WithString("confirm",
+				mcp.Required(),
+				mcp.Description("Must repeat the repository key exactly to confirm the deletion"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	getReplicationTool := mcp.NewTool("artifactory_get_replication",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get the push (local repositories) or pull (remote repositories) replication configuration of a repository"),
+			mcp.WithString("repo_key",
+				mcp.Required(),
+				mcp.Description("The repository key"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	setReplicationTool := mcp.NewTool("artifactory_set_replication",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Set the replication configuration of a repository. Local repositories get a push replication to the target URL, remote repositories a pull replication from their remote URL."),
+			mcp.WithString("repo_key",
+				mcp.Required(),
+				mcp.Description("The repository key"),
+			),
+			mcp.WithString("cron_exp",
+				mcp.Required(),
+				mcp.Description("Quartz cron expression for the replication schedule, e.g. '0 0 2 * * ?'"),
+			),
+			mcp.WithString("target_url",
+				mcp.Description("Target repository URL for push replication, e.g. https://dr.example.com/artifactory/libs-release-local (required for local repositories)"),
+			),
+			mcp.WithString("target_username",
+				mcp.Description("User on the target instance for push replication"),
+			),
+			mcp.WithString("target_password",
+				mcp.Description("Password or token of the target user for push replication"),
+			),
+			mcp.WithBoolean("enabled",
+				mcp.Description("Whether the replication is enabled (defaults to true)"),
+			),
+			mcp.WithBoolean("sync_deletes",
+				mcp.Description("Delete artifacts on the target that no longer exist on the source (defaults to false)"),
+			),
+			mcp.WithBoolean("sync_properties",
+				mcp.Description("Replicate properties (defaults to true)"),
+			),
+			mcp.WithBoolean("sync_statistics",
+				mcp.Description("Replicate download statistics (defaults to false)"),
+			),
+			mcp.WithBoolean("event_replication",
+				mcp.Description("Replicate every change as it happens in addition to the schedule (defaults to false)"),
+			),
+			mcp.WithString("path_prefix",
+				mcp.Description("Only replicate artifacts under this path (optional)"),
+			),
+			mcp.WithNumber("socket_timeout_ms",
+				mcp.Description("Socket timeout in milliseconds (defaults to 15000)"),
+				mcp.Min(0),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(getConfigTool, executeArtifactoryGetRepositoryConfig)
+	s.AddTool(updateTool, executeArtifactoryUpdateRepository)
+	s.AddTool(deleteTool, executeArtifactoryDeleteRepository)
+	s.AddTool(getReplicationTool, executeArtifactoryGetReplication)
+	s.AddTool(setReplicationTool, executeArtifactorySetReplication)
+}
+
+// artifactoryRepositoryEndpoint returns the API endpoint of a repository configuration
+func artifactoryRepositoryEndpoint(repoKey string) string {
+	return "artifactory/api/repositories/" + url.PathEscape(repoKey)
+}
+
+// artifactoryReplicationEndpoint returns the API endpoint of a repository's replication configuration
+func artifactoryReplicationEndpoint(repoKey string) string {
+	return "artifactory/api/replications/" + url.PathEscape(repoKey)
+}
+
+// getArtifactoryRepositoryConfig fetches the full configuration of a repository
+func getArtifactoryRepositoryConfig(ctx context.Context, client *artifactoryClient, repoKey string) (map[string]any, error) {
+	var config map[string]any
+	if err := client.doJSON(ctx, "GET", artifactoryRepositoryEndpoint(repoKey), nil, &config); err != nil {
+		return nil, fmt.Errorf("failed to get configuration of repository '%s': %v", repoKey, err)
+	}
+	return config, nil
+}
+
+// mergeArtifactoryConfig returns the configuration Artifactory ends up with after a partial update:
+// objects are merged recursively and anything else replaces the current value. Artifactory keeps
+// the fields missing from an update, so there is no way to remove one and the update tool rejects nulls.
+func mergeArtifactoryConfig(config, patch map[string]any) map[string]any {
+	merged := make(map[string]any, len(config))
+	for key, value := range config {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		patchObject, patchIsObject := value.(map[string]any)
+		currentObject, currentIsObject := merged[key].(map[string]any)
+		if patchIsObject && currentIsObject {
+			merged[key] = mergeArtifactoryConfig(currentObject, patchObject)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// findArtifactoryConfigNull returns the dotted path of the first null value in a patch, or ""
+func findArtifactoryConfigNull(patch map[string]any) string {
+	for _, key := range sortedKeys(patch) {
+		switch value := patch[key].(type) {
+		case nil:
+			return key
+		case map[string]any:
+			if path := findArtifactoryConfigNull(value); path != "" {
+				return key + "." + path
+			}
+		}
+	}
+	return ""
+}
+
+// diffArtifactoryConfig lists the top-level fields that differ between two configurations
+func diffArtifactoryConfig(before, after map[string]any) map[string]any {
+	changes := map[string]any{}
+	for _, key := range sortedKeys(after) {
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, after[key]) {
+			changes[key] = map[string]any{"from": before[key], "to": after[key]}
+		}
+	}
+	for key, old := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = map[string]any{"from": old, "to": nil}
+		}
+	}
+	return changes
+}
+
+// executeArtifactoryGetRepositoryConfig handles the repository configuration tool execution
+func executeArtifactoryGetRepositoryConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoKey := request.GetString("repo_key", "")
+	if repoKey == "" {
+		return mcp.NewToolResultError("repo_key is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	config, err := getArtifactoryRepositoryConfig(ctx, client, repoKey)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"repo_key":  repoKey,
+		"config":    config,
+		"url":       client.url(artifactoryRepositoryEndpoint(repoKey)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryUpdateRepository handles the repository update tool execution
+func executeArtifactoryUpdateRepository(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoKey := request.GetString("repo_key", "")
+	if repoKey == "" {
+		return mcp.NewToolResultError("repo_key is required"), nil
+	}
+
+	var patch map[string]any
+	if err := json.Unmarshal([]byte(request.GetString("config", "")), &patch); err != nil || patch == nil {
+		return mcp.NewToolResultError("config must be a JSON object with the fields to change"), nil
+	}
+	if path := findArtifactoryConfigNull(patch); path != "" {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is null: Artifactory keeps fields that are missing from an update, set an explicit value such as \"\", false or [] instead", path)), nil
+	}
+	dryRun := request.GetBool("dry_run", false)
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	current, err := getArtifactoryRepositoryConfig(ctx, client, repoKey)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	merged := mergeArtifactoryConfig(current, patch)
+	// Artifactory cannot rename a repository or change its class or package type in place
+	for _, field := range []string{"key", "rclass", "packageType"} {
+		if !reflect.DeepEqual(current[field], merged[field]) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s cannot be changed on an existing repository", field)), nil
+		}
+	}
+
+	changes := diffArtifactoryConfig(current, merged)
+	result := map[string]interface{}{
+		"repo_key":  repoKey,
+		"changes":   changes,
+		"dry_run":   dryRun,
+		"url":       client.url(artifactoryRepositoryEndpoint(repoKey)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	if len(changes) == 0 {
+		result["message"] = "Configuration already matches, nothing to update"
+		return artifactoryJSONResult(result)
+	}
+	if dryRun {
+		result["message"] = "Dry run: no changes were applied"
+		result["config"] = merged
+		return artifactoryJSONResult(result)
+	}
+
+	// Only the patch is sent: the fetched configuration holds masked passwords and server-derived
+	// fields that would overwrite the real values if they were sent back
+	if err := client.doJSON(ctx, "POST", artifactoryRepositoryEndpoint(repoKey), patch, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to update repository: %v", err)), nil
+	}
+
+	result["message"] = fmt.Sprintf("Repository '%s' updated successfully", repoKey)
+	return artifactoryJSONResult(result)
+}
+
+// executeArtifactoryDeleteRepository handles the repository deletion tool execution
+func executeArtifactoryDeleteRepository(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoKey := request.GetString("repo_key", "")
+	if repoKey == "" {
+		return mcp.NewToolResultError("repo_key is required"), nil
+	}
+	if confirm := request.GetString("confirm", ""); confirm != repoKey {
+		return mcp.NewToolResultError(fmt.Sprintf("deletion not confirmed: set confirm to '%s' to delete the repository and all of its artifacts", repoKey)), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := client.doJSON(ctx, "DELETE", artifactoryRepositoryEndpoint(repoKey), nil, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete repository: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":   fmt.Sprintf("Repository '%s' deleted successfully", repoKey),
+		"repo_key":  repoKey,
+		"url":       client.url(artifactoryRepositoryEndpoint(repoKey)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// getArtifactoryReplications fetches the replication configuration of a repository.
+// Artifactory returns a single object for pull replication and an array for push replication.
+func getArtifactoryReplications(ctx context.Context, client *artifactoryClient, repoKey string) ([]ArtifactoryReplication, error) {
+	var raw json.RawMessage
+	if err := client.doJSON(ctx, "GET", artifactoryReplicationEndpoint(repoKey), nil, &raw); err != nil {
+		if httpErr, ok := err.(*artifactoryHTTPError); ok && httpErr.StatusCode == 404 {
+			return []ArtifactoryReplication{}, nil
+		}
+		return nil, err
+	}
+
+	replications := []ArtifactoryReplication{}
+	trimmed := strings.TrimSpace(string(raw))
+	switch {
+	case trimmed == "" || trimmed == "null":
+	case strings.HasPrefix(trimmed, "["):
+		if err := json.Unmarshal(raw, &replications); err != nil {
+			return nil, fmt.Errorf("failed to parse replication response: %v", err)
+		}
+	default:
+		var replication ArtifactoryReplication
+		if err := json.Unmarshal(raw, &replication); err != nil {
+			return nil, fmt.Errorf("failed to parse replication response: %v", err)
+		}
+		replications = append(replications, replication)
+	}
+
+	for i := range replications {
+		if replications[i].Password != "" {
+			replications[i].Password = "********"
+		}
+	}
+	return replications, nil
+}
+
+// executeArtifactoryGetReplication handles the get replication tool execution
+func executeArtifactoryGetReplication(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoKey := request.GetString("repo_key", "")
+	if repoKey == "" {
+		return mcp.NewToolResultError("repo_key is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	replications, err := getArtifactoryReplications(ctx, client, repoKey)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory replication request failed: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"repo_key":     repoKey,
+		"replications": replications,
+		"count":        len(replications),
+		"url":          client.url(artifactoryReplicationEndpoint(repoKey)),
+		"timestamp":    time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactorySetReplication handles the set replication tool execution
+func executeArtifactorySetReplication(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	repoKey := request.GetString("repo_key", "")
+	if repoKey == "" {
+		return mcp.NewToolResultError("repo_key is required"), nil
+	}
+	cronExp := request.GetString("cron_exp", "")
+	if cronExp == "" {
+		return mcp.NewToolResultError("cron_exp is required"), nil
+	}
+
+	replication := ArtifactoryReplication{
+		CronExp:                cronExp,
+		RepoKey:                repoKey,
+		Enabled:                request.GetBool("enabled", true),
+		SyncDeletes:            request.GetBool("sync_deletes", false),
+		SyncProperties:         request.GetBool("sync_properties", true),
+		SyncStatistics:         request.GetBool("sync_statistics", false),
+		EnableEventReplication: request.GetBool("event_replication", false),
+		PathPrefix:             request.GetString("path_prefix", ""),
+		SocketTimeoutMillis:    int(request.GetFloat("socket_timeout_ms", 15000)),
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	config, err := getArtifactoryRepositoryConfig(ctx, client, repoKey)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mode := ""
+	switch rclass, _ := config["rclass"].(string); strings.ToLower(rclass) {
+	case "local":
+		mode = "push"
+		replication.URL = request.GetString("target_url", "")
+		replication.Username = request.GetString("target_username", "")
+		replication.Password = request.GetString("target_password", "")
+		if replication.URL == "" {
+			return mcp.NewToolResultError("target_url is required for push replication of a local repository"), nil
+		}
+	case "remote":
+		mode = "pull"
+		if request.GetString("target_url", "") != "" {
+			return mcp.NewToolResultError("target_url is not used for pull replication; remote repositories replicate from their own remote URL"), nil
+		}
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("replication is only supported for local and remote repositories, '%s' is %v", repoKey, config["rclass"])), nil
+	}
+
+	if err := client.doJSON(ctx, "PUT", artifactoryReplicationEndpoint(repoKey), replication, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to set replication: %v", err)), nil
+	}
+
+	if replication.Password != "" {
+		replication.Password = "********"
+	}
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":     fmt.Sprintf("%s replication for '%s' configured successfully", strings.ToUpper(mode[:1])+mode[1:], repoKey),
+		"mode":        mode,
+		"replication": replication,
+		"url":         client.url(artifactoryReplicationEndpoint(repoKey)),
+		"timestamp":   time.Now().Format(time.RFC3339),
+	})
+}
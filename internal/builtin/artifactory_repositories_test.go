@@ -0,0 +1,240 @@
+package builtin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// repositoryUpdates records the repository POST bodies received by newRepositoryTestServer
+var repositoryUpdates []map[string]any
+
+// newRepositoryTestServer serves in-memory repository and replication configuration APIs. Like
+// Artifactory, a POST to an existing repository merges the body into its configuration.
+func newRepositoryTestServer(t *testing.T, repos map[string]map[string]any, replications map[string]any) *httptest.Server {
+	t.Helper()
+	repositoryUpdates = nil
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := strings.CutPrefix(r.URL.Path, "/artifactory/api/repositories/"); ok {
+			switch r.Method {
+			case "GET":
+				config, ok := repos[key]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewEncoder(w).Encode(config)
+			case "POST":
+				var config map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+					t.Errorf("Invalid repository body: %v", err)
+				}
+				repositoryUpdates = append(repositoryUpdates, config)
+				if current, ok := repos[key]; ok {
+					config = mergeArtifactoryConfig(current, config)
+				}
+				repos[key] = config
+			case "DELETE":
+				delete(repos, key)
+			}
+			return
+		}
+
+		if key, ok := strings.CutPrefix(r.URL.Path, "/artifactory/api/replications/"); ok {
+			switch r.Method {
+			case "GET":
+				replication, ok := replications[key]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewEncoder(w).Encode(replication)
+			case "PUT":
+				var replication map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&replication); err != nil {
+					t.Errorf("Invalid replication body: %v", err)
+				}
+				replications[key] = replication
+			}
+			return
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+func TestMergeArtifactoryConfig(t *testing.T) {
+	config := map[string]any{
+		"key":         "libs",
+		"description": "old",
+		"notes":       "kept",
+		"contentSynchronisation": map[string]any{
+			"enabled":    false,
+			"statistics": map[string]any{"enabled": false},
+		},
+	}
+	patch := map[string]any{
+		"description": "new",
+		"contentSynchronisation": map[string]any{
+			"enabled": true,
+		},
+	}
+
+	merged := mergeArtifactoryConfig(config, patch)
+	expected := map[string]any{
+		"key":         "libs",
+		"description": "new",
+		"notes":       "kept",
+		"contentSynchronisation": map[string]any{
+			"enabled":    true,
+			"statistics": map[string]any{"enabled": false},
+		},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Unexpected merge result: %v", merged)
+	}
+	if config["description"] != "old" {
+		t.Error("The original configuration must not be modified")
+	}
+}
+
+func TestArtifactoryUpdateRepository(t *testing.T) {
+	repos := map[string]map[string]any{
+		"libs-release-local": {"key": "libs-release-local", "rclass": "local", "packageType": "maven", "description": "Releases", "blackedOut": false},
+	}
+	mockServer := newRepositoryTestServer(t, repos, nil)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryUpdateRepository, map[string]any{
+		"repo_key": "libs-release-local",
+		"config":   `{"blackedOut": true}`,
+		"dry_run":  true,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected dry run error: %v", result.Content)
+	}
+	if repos["libs-release-local"]["blackedOut"] != false {
+		t.Error("Dry run must not change the repository")
+	}
+	if _, ok := response["changes"].(map[string]any)["blackedOut"]; !ok {
+		t.Errorf("Expected blackedOut in the changes, got %v", response["changes"])
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryUpdateRepository, map[string]any{
+		"repo_key": "libs-release-local",
+		"config":   `{"blackedOut": true}`,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected update error: %v", result.Content)
+	}
+	// Only the patch is sent, so masked and server-derived fields are never written back
+	if len(repositoryUpdates) != 1 || !reflect.DeepEqual(repositoryUpdates[0], map[string]any{"blackedOut": true}) {
+		t.Errorf("Unexpected update request bodies %v", repositoryUpdates)
+	}
+	expected := map[string]any{"key": "libs-release-local", "rclass": "local", "packageType": "maven", "description": "Releases", "blackedOut": true}
+	if updated := repos["libs-release-local"]; !reflect.DeepEqual(updated, expected) {
+		t.Errorf("Unexpected repository configuration %v", updated)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryUpdateRepository, map[string]any{
+		"repo_key": "libs-release-local",
+		"config":   `{"cdnRedirect": {"enabled": null}}`,
+	})
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "cdnRedirect.enabled is null") {
+		t.Errorf("Expected nulls to be rejected, got %v", result.Content)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryUpdateRepository, map[string]any{
+		"repo_key": "libs-release-local",
+		"config":   `{"rclass": "remote"}`,
+	})
+	if !result.IsError {
+		t.Error("Expected an error when changing the repository class")
+	}
+}
+
+func TestArtifactoryDeleteRepositoryRequiresConfirmation(t *testing.T) {
+	repos := map[string]map[string]any{
+		"old-snapshots": {"key": "old-snapshots", "rclass": "local"},
+	}
+	mockServer := newRepositoryTestServer(t, repos, nil)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	_, result := callArtifactoryTool(t, executeArtifactoryDeleteRepository, map[string]any{
+		"repo_key": "old-snapshots",
+		"confirm":  "yes",
+	})
+	if !result.IsError {
+		t.Fatal("Expected an error without a matching confirmation")
+	}
+	if _, ok := repos["old-snapshots"]; !ok {
+		t.Fatal("Repository must not be deleted without confirmation")
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryDeleteRepository, map[string]any{
+		"repo_key": "old-snapshots",
+		"confirm":  "old-snapshots",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected delete error: %v", result.Content)
+	}
+	if _, ok := repos["old-snapshots"]; ok {
+		t.Error("Repository was not deleted")
+	}
+}
+
+func TestArtifactoryReplication(t *testing.T) {
+	repos := map[string]map[string]any{
+		"libs-release-local": {"key": "libs-release-local", "rclass": "local"},
+		"maven-remote":       {"key": "maven-remote", "rclass": "remote"},
+	}
+	replications := map[string]any{
+		"maven-remote": map[string]any{"cronExp": "0 0 * * * ?", "enabled": true, "password": "secret"},
+	}
+	mockServer := newRepositoryTestServer(t, repos, replications)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryGetReplication, map[string]any{"repo_key": "maven-remote"})
+	if result.IsError || response["count"] != float64(1) {
+		t.Fatalf("Unexpected get result: %v", result.Content)
+	}
+	first := response["replications"].([]any)[0].(map[string]any)
+	if first["password"] != "********" {
+		t.Errorf("Expected a masked password, got %v", first["password"])
+	}
+
+	response, result = callArtifactoryTool(t, executeArtifactoryGetReplication, map[string]any{"repo_key": "libs-release-local"})
+	if result.IsError || response["count"] != float64(0) {
+		t.Fatalf("Expected no replications, got %v", result.Content)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactorySetReplication, map[string]any{
+		"repo_key": "libs-release-local",
+		"cron_exp": "0 0 2 * * ?",
+	})
+	if !result.IsError {
+		t.Error("Expected an error for push replication without a target URL")
+	}
+
+	response, result = callArtifactoryTool(t, executeArtifactorySetReplication, map[string]any{
+		"repo_key":     "libs-release-local",
+		"cron_exp":     "0 0 2 * * ?",
+		"target_url":   "https://dr.example.com/artifactory/libs-release-local",
+		"sync_deletes": true,
+	})
+	if result.IsError || response["mode"] != "push" {
+		t.Fatalf("Unexpected set result: %v", result.Content)
+	}
+	stored := replications["libs-release-local"].(map[string]any)
+	if stored["url"] != "https://dr.example.com/artifactory/libs-release-local" || stored["syncDeletes"] != true || stored["enabled"] != true {
+		t.Errorf("Unexpected stored replication: %v", stored)
+	}
+}
@@ -0,0 +1,544 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// artifactoryGroupsEndpoint is the security groups API
+const artifactoryGroupsEndpoint = "artifactory/api/security/groups"
+
+// artifactoryTokensEndpoint is the Access tokens API
+const artifactoryTokensEndpoint = "access/api/v1/tokens"
+
+// ArtifactoryGroupRef is an entry of the group list
+type ArtifactoryGroupRef struct {
+	Name string `json:"name"`
+	URI  string `json:"uri"`
+}
+
+// ArtifactoryAccessToken represents a token returned by the Access tokens list API
+type ArtifactoryAccessToken struct {
+	TokenID     string `json:"token_id"`
+	Issuer      string `json:"issuer,omitempty"`
+	Subject     string `json:"subject,omitempty"`
+	Expiry      int64  `json:"expiry,omitempty"`
+	Refreshable bool   `json:"refreshable"`
+	IssuedAt    int64  `json:"issued_at,omitempty"`
+	Description string `json:"description,omitempty"`
+	// ExpiresAt and IssuedAtTime are rendered from the Unix timestamps for readability
+	ExpiresAt    string `json:"expires_at,omitempty"`
+	IssuedAtTime string `json:"issued_at_time,omitempty"`
+}
+
+// ArtifactoryAccessTokensResponse represents the response from the Access tokens list API
+type ArtifactoryAccessTokensResponse struct {
+	Tokens []ArtifactoryAccessToken `json:"tokens"`
+}
+
+// ArtifactoryCreateTokenResponse represents the response from the Access token creation API
+type ArtifactoryCreateTokenResponse struct {
+	TokenID      string `json:"token_id"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+}
+
+// addArtifactorySecurityTools registers the group and access token tools
+func addArtifactorySecurityTools(s *server.MCPServer) {
+	getGroupsTool := mcp.NewTool("artifactory_get_groups",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get a list of all groups in an Artifactory instance using the /artifactory/api/security/groups endpoint"),
+			mcp.WithBoolean("include_members",
+				mcp.Description("Fetch every group with its settings and members (one request per group, defaults to false)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	createGroupTool := mcp.NewTool("artifactory_create_group",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create a new group in an Artifactory instance using the /artifactory/api/security/groups/{name} endpoint"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The name of the group to create"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Description of the group (optional)"),
+			),
+			mcp.WithBoolean("auto_join",
+				mcp.Description("Whether new users are added to the group automatically (optional, defaults to false)"),
+			),
+			mcp.WithBoolean("admin_privileges",
+				mcp.Description("Whether group members get admin privileges (optional, defaults to false)"),
+			),
+			mcp.WithString("realm",
+				mcp.Description("The realm of the group (optional, defaults to 'internal')"),
+			),
+			mcp.WithString("members",
+				mcp.Description("Comma-separated list of users to add to the group (optional)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	updateGroupTool := mcp.NewTool("artifactory_update_group",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Update the settings of an existing group. Only the parameters given are changed."),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The name of the group to update"),
+			),
+			mcp.WithString("description",
+				mcp.Description("New description of the group"),
+			),
+			mcp.WithBoolean("auto_join",
+				mcp.Description("Whether new users are added to the group automatically"),
+			),
+			mcp.WithBoolean("admin_privileges",
+				mcp.Description("Whether group members get admin privileges"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	deleteGroupTool := mcp.NewTool("artifactory_delete_group",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Delete a group from an Artifactory instance"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The name of the group to delete"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	addMembersTool := mcp.NewTool("artifactory_add_group_members",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Add users to an existing group"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The name of the group"),
+			),
+			mcp.WithString("users",
+				mcp.Required(),
+				mcp.Description("Comma-separated list of users to add"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	removeMembersTool := mcp.NewTool("artifactory_remove_group_members",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Remove users from an existing group"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The name of the group"),
+			),
+			mcp.WithString("users",
+				mcp.Required(),
+				mcp.Description("Comma-separated list of users to remove"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	createTokenTool := mcp.NewTool("artifactory_create_token",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Create an access token using the /access/api/v1/tokens endpoint. The token value is only returned once."),
+			mcp.WithString("token_username",
+				mcp.Description("The user the token is issued for (optional, defaults to the authenticated user)"),
+			),
+			mcp.WithString("scope",
+				mcp.Description("Token scope, e.g. 'applied-permissions/user', 'applied-permissions/admin' or 'applied-permissions/groups:readers,deployers' (optional, defaults to 'applied-permissions/user')"),
+			),
+			mcp.WithNumber("expires_in",
+				mcp.Description("Token lifetime in seconds; 0 requests a non-expiring token if the server allows it (optional, defaults to the server setting)"),
+				mcp.Min(0),
+			),
+			mcp.WithBoolean("refreshable",
+				mcp.Description("Whether the token can be refreshed (optional, defaults to false)"),
+			),
+			mcp.WithString("description",
+				mcp.Description("Free text description, e.g. the CI pipeline that uses the token (optional)"),
+			),
+			mcp.WithString("audience",
+				mcp.Description("Space-separated list of services the token is valid for (optional)"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	listTokensTool := mcp.NewTool("artifactory_list_tokens",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("List access tokens using the /access/api/v1/tokens endpoint. Token values are never returned."),
+			mcp.WithString("subject",
+				mcp.Description("Only include tokens whose subject contains this text, e.g. a user name (optional)"),
+			),
+			mcp.WithNumber("expiring_within_days",
+				mcp.Description("Only include tokens expiring within this many days (optional)"),
+				mcp.Min(0),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	revokeTokenTool := mcp.NewTool("artifactory_revoke_token",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Revoke an access token by its token ID"),
+			mcp.WithString("token_id",
+				mcp.Required(),
+				mcp.Description("The ID of the token to revoke, as returned by artifactory_list_tokens"),
+			),
+		}, artifactoryConnectionOptions()...)...,
+	)
+
+	s.AddTool(getGroupsTool, executeArtifactoryGetGroups)
+	s.AddTool(createGroupTool, executeArtifactoryCreateGroup)
+	s.AddTool(updateGroupTool, executeArtifactoryUpdateGroup)
+	s.AddTool(deleteGroupTool, executeArtifactoryDeleteGroup)
+	s.AddTool(addMembersTool, executeArtifactoryAddGroupMembers)
+	s.AddTool(removeMembersTool, executeArtifactoryRemoveGroupMembers)
+	s.AddTool(createTokenTool, executeArtifactoryCreateToken)
+	s.AddTool(listTokensTool, executeArtifactoryListTokens)
+	s.AddTool(revokeTokenTool, executeArtifactoryRevokeToken)
+}
+
+// artifactoryGroupEndpoint returns the API endpoint of a group
+func artifactoryGroupEndpoint(name string) string {
+	return artifactoryGroupsEndpoint + "/" + url.PathEscape(name)
+}
+
+// getArtifactoryGroup fetches a group including its members
+func getArtifactoryGroup(ctx context.Context, client *artifactoryClient, name string) (*ArtifactoryGroup, error) {
+	var group ArtifactoryGroup
+	if err := client.doJSON(ctx, "GET", artifactoryGroupEndpoint(name)+"?includeUsers=true", nil, &group); err != nil {
+		return nil, fmt.Errorf("failed to get group '%s': %v", name, err)
+	}
+	return &group, nil
+}
+
+// executeArtifactoryGetGroups handles the groups tool execution
+func executeArtifactoryGetGroups(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Artifactory returns an array directly
+	var refs []ArtifactoryGroupRef
+	if err := client.doJSON(ctx, "GET", artifactoryGroupsEndpoint, nil, &refs); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory groups request failed: %v", err)), nil
+	}
+
+	result := map[string]interface{}{
+		"count":     len(refs),
+		"url":       client.url(artifactoryGroupsEndpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	if !request.GetBool("include_members", false) {
+		result["groups"] = refs
+		return artifactoryJSONResult(result)
+	}
+
+	groups := make([]ArtifactoryGroup, 0, len(refs))
+	for _, ref := range refs {
+		group, err := getArtifactoryGroup(ctx, client, ref.Name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		groups = append(groups, *group)
+	}
+	result["groups"] = groups
+	return artifactoryJSONResult(result)
+}
+
+// executeArtifactoryCreateGroup handles the create group tool execution
+func executeArtifactoryCreateGroup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	group := ArtifactoryGroup{
+		Name:            name,
+		Description:     request.GetString("description", ""),
+		AutoJoin:        request.GetBool("auto_join", false),
+		AdminPrivileges: request.GetBool("admin_privileges", false),
+		Realm:           request.GetString("realm", "internal"),
+		UserNames:       parseCommaSeparated(request.GetString("members", "")),
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := client.doJSON(ctx, "PUT", artifactoryGroupEndpoint(name), group, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory create group request failed: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":   "Group created successfully",
+		"group":     group,
+		"url":       client.url(artifactoryGroupEndpoint(name)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryUpdateGroup handles the update group tool execution
+func executeArtifactoryUpdateGroup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	// Only send the fields that were given so the rest of the group is left untouched
+	arguments := request.GetArguments()
+	changes := map[string]interface{}{}
+	if _, ok := arguments["description"]; ok {
+		changes["description"] = request.GetString("description", "")
+	}
+	if _, ok := arguments["auto_join"]; ok {
+		changes["autoJoin"] = request.GetBool("auto_join", false)
+	}
+	if _, ok := arguments["admin_privileges"]; ok {
+		changes["adminPrivileges"] = request.GetBool("admin_privileges", false)
+	}
+	if len(changes) == 0 {
+		return mcp.NewToolResultError("nothing to update: provide description, auto_join or admin_privileges"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := client.doJSON(ctx, "POST", artifactoryGroupEndpoint(name), changes, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory update group request failed: %v", err)), nil
+	}
+
+	group, err := getArtifactoryGroup(ctx, client, name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":   "Group updated successfully",
+		"group":     group,
+		"changes":   changes,
+		"url":       client.url(artifactoryGroupEndpoint(name)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryDeleteGroup handles the delete group tool execution
+func executeArtifactoryDeleteGroup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := client.doJSON(ctx, "DELETE", artifactoryGroupEndpoint(name), nil, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory delete group request failed: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":   fmt.Sprintf("Group '%s' deleted successfully", name),
+		"name":      name,
+		"url":       client.url(artifactoryGroupEndpoint(name)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryAddGroupMembers handles the add group members tool execution
+func executeArtifactoryAddGroupMembers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return updateArtifactoryGroupMembers(ctx, request, true)
+}
+
+// executeArtifactoryRemoveGroupMembers handles the remove group members tool execution
+func executeArtifactoryRemoveGroupMembers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return updateArtifactoryGroupMembers(ctx, request, false)
+}
+
+// updateArtifactoryGroupMembers adds or removes users by rewriting the group's member list
+func updateArtifactoryGroupMembers(ctx context.Context, request mcp.CallToolRequest, add bool) (*mcp.CallToolResult, error) {
+	name := request.GetString("name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+	users := parseCommaSeparated(request.GetString("users", ""))
+	if len(users) == 0 {
+		return mcp.NewToolResultError("users is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	group, err := getArtifactoryGroup(ctx, client, name)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	members := slices.Clone(group.UserNames)
+	changed := []string{}
+	unchanged := []string{}
+	for _, user := range users {
+		isMember := slices.Contains(members, user)
+		switch {
+		case add && !isMember:
+			members = append(members, user)
+			changed = append(changed, user)
+		case !add && isMember:
+			members = slices.DeleteFunc(members, func(member string) bool { return member == user })
+			changed = append(changed, user)
+		default:
+			unchanged = append(unchanged, user)
+		}
+	}
+
+	if len(changed) > 0 {
+		// An empty list must still be sent when the last member is removed
+		update := map[string]interface{}{"userNames": members}
+		if members == nil {
+			update["userNames"] = []string{}
+		}
+		if err := client.doJSON(ctx, "POST", artifactoryGroupEndpoint(name), update, nil); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Artifactory update group members request failed: %v", err)), nil
+		}
+	}
+
+	result := map[string]interface{}{
+		"group":     name,
+		"members":   members,
+		"count":     len(members),
+		"url":       client.url(artifactoryGroupEndpoint(name)),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if add {
+		result["added"] = changed
+		result["already_members"] = unchanged
+	} else {
+		result["removed"] = changed
+		result["not_members"] = unchanged
+	}
+	return artifactoryJSONResult(result)
+}
+
+// executeArtifactoryCreateToken handles the create token tool execution
+func executeArtifactoryCreateToken(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	scope := request.GetString("scope", "applied-permissions/user")
+
+	tokenRequest := map[string]interface{}{
+		"scope":       scope,
+		"refreshable": request.GetBool("refreshable", false),
+	}
+	if username := request.GetString("token_username", ""); username != "" {
+		tokenRequest["username"] = username
+	}
+	if _, ok := request.GetArguments()["expires_in"]; ok {
+		tokenRequest["expires_in"] = int64(request.GetFloat("expires_in", 0))
+	}
+	if description := request.GetString("description", ""); description != "" {
+		tokenRequest["description"] = description
+	}
+	if audience := request.GetString("audience", ""); audience != "" {
+		tokenRequest["audience"] = audience
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var token ArtifactoryCreateTokenResponse
+	if err := client.doJSON(ctx, "POST", artifactoryTokensEndpoint, tokenRequest, &token); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory create token request failed: %v", err)), nil
+	}
+
+	result := map[string]interface{}{
+		"message":   "Token created successfully; store the access_token now, it cannot be retrieved again",
+		"token":     token,
+		"url":       client.url(artifactoryTokensEndpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if token.ExpiresIn > 0 {
+		result["expires_at"] = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second).Format(time.RFC3339)
+	}
+	return artifactoryJSONResult(result)
+}
+
+// executeArtifactoryListTokens handles the list tokens tool execution
+func executeArtifactoryListTokens(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	subject := request.GetString("subject", "")
+	expiringWithinDays := request.GetFloat("expiring_within_days", 0)
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var response ArtifactoryAccessTokensResponse
+	if err := client.doJSON(ctx, "GET", artifactoryTokensEndpoint, nil, &response); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory tokens request failed: %v", err)), nil
+	}
+
+	deadline := time.Now().Add(time.Duration(expiringWithinDays * 24 * float64(time.Hour)))
+	tokens := []ArtifactoryAccessToken{}
+	for _, token := range response.Tokens {
+		if subject != "" && !strings.Contains(token.Subject, subject) {
+			continue
+		}
+		if expiringWithinDays > 0 && (token.Expiry == 0 || time.Unix(token.Expiry, 0).After(deadline)) {
+			continue
+		}
+		if token.Expiry > 0 {
+			token.ExpiresAt = time.Unix(token.Expiry, 0).Format(time.RFC3339)
+		}
+		if token.IssuedAt > 0 {
+			token.IssuedAtTime = time.Unix(token.IssuedAt, 0).Format(time.RFC3339)
+		}
+		tokens = append(tokens, token)
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"tokens":    tokens,
+		"count":     len(tokens),
+		"url":       client.url(artifactoryTokensEndpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// executeArtifactoryRevokeToken handles the revoke token tool execution
+func executeArtifactoryRevokeToken(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tokenID := request.GetString("token_id", "")
+	if tokenID == "" {
+		return mcp.NewToolResultError("token_id is required"), nil
+	}
+
+	client, err := newArtifactoryClientFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	endpoint := artifactoryTokensEndpoint + "/" + url.PathEscape(tokenID)
+	if err := client.doJSON(ctx, "DELETE", endpoint, nil, nil); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Artifactory revoke token request failed: %v", err)), nil
+	}
+
+	return artifactoryJSONResult(map[string]interface{}{
+		"message":   fmt.Sprintf("Token '%s' revoked successfully", tokenID),
+		"token_id":  tokenID,
+		"url":       client.url(endpoint),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,211 @@
+package builtin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newGroupTestServer serves a small in-memory security groups API
+func newGroupTestServer(t *testing.T, groups map[string]*ArtifactoryGroup) *httptest.Server {
+	t.Helper()
+
+	const prefix = "/artifactory/api/security/groups"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix && r.Method == "GET" {
+			refs := []ArtifactoryGroupRef{}
+			for _, name := range sortedKeys(groups) {
+				refs = append(refs, ArtifactoryGroupRef{Name: name, URI: "http://example" + prefix + "/" + name})
+			}
+			json.NewEncoder(w).Encode(refs)
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		switch r.Method {
+		case "GET":
+			group, ok := groups[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(group)
+		case "PUT":
+			var group ArtifactoryGroup
+			if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+				t.Errorf("Invalid group body: %v", err)
+			}
+			groups[name] = &group
+			w.WriteHeader(http.StatusCreated)
+		case "POST":
+			group, ok := groups[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			// Partial update: only the fields present in the body change
+			if err := json.NewDecoder(r.Body).Decode(group); err != nil {
+				t.Errorf("Invalid group body: %v", err)
+			}
+			w.WriteHeader(http.StatusOK)
+		case "DELETE":
+			delete(groups, name)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+}
+
+func TestArtifactoryGroupLifecycle(t *testing.T) {
+	groups := map[string]*ArtifactoryGroup{}
+	mockServer := newGroupTestServer(t, groups)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	_, result := callArtifactoryTool(t, executeArtifactoryCreateGroup, map[string]any{
+		"name":        "developers",
+		"description": "Dev team",
+		"members":     "alice,bob",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected create error: %v", result.Content)
+	}
+	created, ok := groups["developers"]
+	if !ok || created.Realm != "internal" || !reflect.DeepEqual(created.UserNames, []string{"alice", "bob"}) {
+		t.Fatalf("Unexpected created group: %+v", created)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryUpdateGroup, map[string]any{
+		"name":      "developers",
+		"auto_join": true,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected update error: %v", result.Content)
+	}
+	if !created.AutoJoin || created.Description != "Dev team" {
+		t.Errorf("Update should only change auto_join, got %+v", created)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryUpdateGroup, map[string]any{"name": "developers"})
+	if !result.IsError {
+		t.Error("Expected an error when nothing is updated")
+	}
+
+	response, result := callArtifactoryTool(t, executeArtifactoryGetGroups, map[string]any{"include_members": true})
+	if result.IsError || response["count"] != float64(1) {
+		t.Fatalf("Unexpected list result: %v", result.Content)
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryDeleteGroup, map[string]any{"name": "developers"})
+	if result.IsError {
+		t.Fatalf("Unexpected delete error: %v", result.Content)
+	}
+	if _, ok := groups["developers"]; ok {
+		t.Error("Group was not deleted")
+	}
+}
+
+func TestArtifactoryGroupMembers(t *testing.T) {
+	groups := map[string]*ArtifactoryGroup{
+		"qa": {Name: "qa", Realm: "internal", UserNames: []string{"alice"}},
+	}
+	mockServer := newGroupTestServer(t, groups)
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryAddGroupMembers, map[string]any{
+		"name":  "qa",
+		"users": "bob, alice",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected add error: %v", result.Content)
+	}
+	if !reflect.DeepEqual(groups["qa"].UserNames, []string{"alice", "bob"}) {
+		t.Errorf("Unexpected members after add: %v", groups["qa"].UserNames)
+	}
+	if !reflect.DeepEqual(response["already_members"], []any{"alice"}) {
+		t.Errorf("Expected alice reported as existing member, got %v", response["already_members"])
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryRemoveGroupMembers, map[string]any{
+		"name":  "qa",
+		"users": "alice,bob",
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected remove error: %v", result.Content)
+	}
+	if len(groups["qa"].UserNames) != 0 {
+		t.Errorf("Expected empty member list, got %v", groups["qa"].UserNames)
+	}
+}
+
+func TestArtifactoryTokens(t *testing.T) {
+	now := time.Now()
+	var created map[string]any
+	revoked := ""
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/access/api/v1/tokens":
+			json.NewDecoder(r.Body).Decode(&created)
+			json.NewEncoder(w).Encode(ArtifactoryCreateTokenResponse{
+				TokenID:     "tok-1",
+				AccessToken: "secret-token",
+				ExpiresIn:   3600,
+				Scope:       created["scope"].(string),
+				TokenType:   "Bearer",
+			})
+		case r.Method == "GET" && r.URL.Path == "/access/api/v1/tokens":
+			json.NewEncoder(w).Encode(ArtifactoryAccessTokensResponse{Tokens: []ArtifactoryAccessToken{
+				{TokenID: "tok-1", Subject: "jfac@01/users/ci", Expiry: now.Add(24 * time.Hour).Unix()},
+				{TokenID: "tok-2", Subject: "jfac@01/users/ci", Expiry: now.Add(90 * 24 * time.Hour).Unix()},
+				{TokenID: "tok-3", Subject: "jfac@01/users/alice"},
+			}})
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/access/api/v1/tokens/"):
+			revoked = strings.TrimPrefix(r.URL.Path, "/access/api/v1/tokens/")
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mockServer.Close()
+	useTestArtifactoryConfig(t, mockServer.URL)
+
+	response, result := callArtifactoryTool(t, executeArtifactoryCreateToken, map[string]any{
+		"token_username": "ci",
+		"scope":          "applied-permissions/groups:deployers",
+		"expires_in":     3600,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected create error: %v", result.Content)
+	}
+	if created["username"] != "ci" || created["expires_in"] != float64(3600) || created["scope"] != "applied-permissions/groups:deployers" {
+		t.Errorf("Unexpected token request: %v", created)
+	}
+	if _, ok := response["expires_at"]; !ok {
+		t.Error("Expected expires_at in the result")
+	}
+
+	response, result = callArtifactoryTool(t, executeArtifactoryListTokens, map[string]any{
+		"subject":              "users/ci",
+		"expiring_within_days": 30,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected list error: %v", result.Content)
+	}
+	tokens := response["tokens"].([]any)
+	if response["count"] != float64(1) || tokens[0].(map[string]any)["token_id"] != "tok-1" {
+		t.Errorf("Expected only tok-1, got %v", tokens)
+	}
+	if tokens[0].(map[string]any)["expires_at"] == nil {
+		t.Error("Expected a formatted expiry")
+	}
+
+	_, result = callArtifactoryTool(t, executeArtifactoryRevokeToken, map[string]any{"token_id": "tok-2"})
+	if result.IsError || revoked != "tok-2" {
+		t.Errorf("Expected tok-2 to be revoked, got %q (%v)", revoked, result.Content)
+	}
+}
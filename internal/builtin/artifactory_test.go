@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,7 +13,7 @@ import (
 )
 
 func TestNewArtifactoryServer(t *testing.T) {
-	server, err := NewArtifactoryServer()
+	server, err := NewArtifactoryServer(map[string]any{})
 	if err != nil {
 		t.Fatalf("Failed to create Artifactory server: %v", err)
 	}
@@ -72,9 +73,8 @@ func TestExecuteArtifactoryHealthcheck_Success(t *testing.T) {
 		}
 
 		// Return a healthy response
-		response := ArtifactoryHealthResponse{
-			Status: "UP",
-		}
+		response := ArtifactoryHealthResponse{}
+		response.Router.State = "HEALTHY"
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -115,8 +115,8 @@ func TestExecuteArtifactoryHealthcheck_Success(t *testing.T) {
 			t.Errorf("Expected status 'healthy', got %v", response["status"])
 		}
 
-		if response["artifactory_status"] != "UP" {
-			t.Errorf("Expected artifactory_status 'UP', got %v", response["artifactory_status"])
+		if response["artifactory_status"] != "HEALTHY" {
+			t.Errorf("Expected artifactory_status 'HEALTHY', got %v", response["artifactory_status"])
 		}
 	} else {
 		t.Fatal("Expected text content")
@@ -126,10 +126,9 @@ func TestExecuteArtifactoryHealthcheck_Success(t *testing.T) {
 func TestExecuteArtifactoryHealthcheck_Unhealthy(t *testing.T) {
 	// Create a mock server that returns an unhealthy response
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := ArtifactoryHealthResponse{
-			Status: "DOWN",
-			Error:  "Database connection failed",
-		}
+		response := ArtifactoryHealthResponse{}
+		response.Router.State = "UNHEALTHY"
+		response.Router.Message = "Database connection failed"
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -172,9 +171,8 @@ func TestExecuteArtifactoryHealthcheck_WithAuth(t *testing.T) {
 			return
 		}
 
-		response := ArtifactoryHealthResponse{
-			Status: "UP",
-		}
+		response := ArtifactoryHealthResponse{}
+		response.Router.State = "HEALTHY"
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}))
@@ -233,26 +231,12 @@ func TestExecuteArtifactoryHealthcheck_InvalidURL(t *testing.T) {
 	}
 }
 
-func TestExecuteArtifactoryHealthcheck_DefaultLocalhost(t *testing.T) {
-	// Create a mock server that returns a healthy response
-	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check if the request is for the health endpoint
-		if r.URL.Path != "/router/api/v1/system/health" {
-			t.Errorf("Expected path /router/api/v1/system/health, got %s", r.URL.Path)
-			http.NotFound(w, r)
-			return
-		}
-
-		// Return a healthy response
-		response := ArtifactoryHealthResponse{
-			Status: "UP",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}))
-	defer mockServer.Close()
+func TestExecuteArtifactoryHealthcheck_NoInstance(t *testing.T) {
+	previous := globalArtifactoryConfig
+	globalArtifactoryConfig = &ArtifactoryConfig{Instances: map[string]ArtifactoryInstanceConfig{}}
+	defer func() { globalArtifactoryConfig = previous }()
 
-	// Create the request without base_url (should default to localhost:8081)
+	// Without an instance or base_url there is nothing to connect to
 	request := mcp.CallToolRequest{
 		Params: mcp.CallToolParams{
 			Name:      "artifactory_healthcheck",
@@ -260,19 +244,19 @@ func TestExecuteArtifactoryHealthcheck_DefaultLocalhost(t *testing.T) {
 		},
 	}
 
-	// Execute the healthcheck
 	result, err := executeArtifactoryHealthcheck(context.Background(), request)
 	if err != nil {
 		t.Fatalf("Healthcheck failed: %v", err)
 	}
 
-	// Should not be an error since we're using default localhost
-	if result == nil {
-		t.Fatal("Result should not be nil")
+	if result == nil || !result.IsError {
+		t.Fatal("Expected an error result when no instance is configured")
 	}
 
-	// Note: This test will likely fail in real execution since localhost:8081 might not be running
-	// but it tests that the function doesn't crash with default parameters
+	textContent, _ := mcp.AsTextContent(result.Content[0])
+	if !strings.Contains(textContent.Text, "no Artifactory instances configured") {
+		t.Errorf("Unexpected error message: %s", textContent.Text)
+	}
 }
 
 func TestExecuteArtifactoryGetRepositories_Success(t *testing.T) {
@@ -285,21 +269,19 @@ func TestExecuteArtifactoryGetRepositories_Success(t *testing.T) {
 			return
 		}
 
-		// Return repositories response
-		response := ArtifactoryRepositoriesResponse{
-			Repositories: []ArtifactoryRepository{
-				{
-					Key:         "libs-release-local",
-					Type:        "LOCAL",
-					Description: "Local repository for release artifacts",
-					PackageType: "maven",
-				},
-				{
-					Key:         "libs-snapshot-local",
-					Type:        "LOCAL",
-					Description: "Local repository for snapshot artifacts",
-					PackageType: "maven",
-				},
+		// Return repositories response - Artifactory returns an array directly
+		response := []ArtifactoryRepository{
+			{
+				Key:         "libs-release-local",
+				Type:        "LOCAL",
+				Description: "Local repository for release artifacts",
+				PackageType: "maven",
+			},
+			{
+				Key:         "libs-snapshot-local",
+				Type:        "LOCAL",
+				Description: "Local repository for snapshot artifacts",
+				PackageType: "maven",
 			},
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -355,46 +337,42 @@ func TestExecuteArtifactoryGetRepositories_Success(t *testing.T) {
 	}
 }
 
-func TestExecuteArtifactoryGetRepositories_DefaultLocalhost(t *testing.T) {
-	// Create a mock server that returns repositories
-	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check if the request is for the repositories endpoint
-		if r.URL.Path != "/artifactory/api/repositories" {
-			t.Errorf("Expected path /artifactory/api/repositories, got %s", r.URL.Path)
-			http.NotFound(w, r)
-			return
-		}
+func TestExecuteArtifactoryGetRepositories_UnknownInstance(t *testing.T) {
+	previous := globalArtifactoryConfig
+	globalArtifactoryConfig = &ArtifactoryConfig{
+		Instances: map[string]ArtifactoryInstanceConfig{
+			"staging":    {Name: "staging", URL: "https://staging.example.com"},
+			"production": {Name: "production", URL: "https://prod.example.com"},
+		},
+	}
+	defer func() { globalArtifactoryConfig = previous }()
 
-		// Return repositories response
-		response := ArtifactoryRepositoriesResponse{
-			Repositories: []ArtifactoryRepository{},
+	for _, args := range []map[string]interface{}{
+		{"instance": "qa"},
+		{},
+	} {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "artifactory_get_repositories",
+				Arguments: args,
+			},
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}))
-	defer mockServer.Close()
 
-	// Create the request without base_url (should default to localhost:8081)
-	request := mcp.CallToolRequest{
-		Params: mcp.CallToolParams{
-			Name:      "artifactory_get_repositories",
-			Arguments: map[string]interface{}{},
-		},
-	}
+		result, err := executeArtifactoryGetRepositories(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Repositories request failed: %v", err)
+		}
 
-	// Execute the repositories request
-	result, err := executeArtifactoryGetRepositories(context.Background(), request)
-	if err != nil {
-		t.Fatalf("Repositories request failed: %v", err)
-	}
+		if result == nil || !result.IsError {
+			t.Fatalf("Expected an error result for %v", args)
+		}
 
-	// Should not be an error since we're using default localhost
-	if result == nil {
-		t.Fatal("Result should not be nil")
+		// The error should list the configured instances so the caller can pick one
+		textContent, _ := mcp.AsTextContent(result.Content[0])
+		if !strings.Contains(textContent.Text, "production (https://prod.example.com), staging (https://staging.example.com)") {
+			t.Errorf("Expected configured instances in error, got: %s", textContent.Text)
+		}
 	}
-
-	// Note: This test will likely fail in real execution since localhost:8081 might not be running
-	// but it tests that the function doesn't crash with default parameters
 }
 
 func TestExecuteArtifactoryHealthcheck_Timeout(t *testing.T) {
@@ -402,9 +380,8 @@ func TestExecuteArtifactoryHealthcheck_Timeout(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Delay for 2 seconds
 		time.Sleep(2 * time.Second)
-		response := ArtifactoryHealthResponse{
-			Status: "UP",
-		}
+		response := ArtifactoryHealthResponse{}
+		response.Router.State = "HEALTHY"
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}))
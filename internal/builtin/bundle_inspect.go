@@ -0,0 +1,759 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Thresholds for the risky settings bundle_inspect flags
+const (
+	bundleMinArtifactoryHeap = 4 << 30
+	bundleMinNodeMemory      = 8 << 30
+	bundleMinNodeCPUs        = 4
+	bundleHeapUsageWarning   = 90.0
+	bundleDiskUsageWarning   = 80.0
+	bundleDiskUsageCritical  = 90.0
+)
+
+var (
+	// bundleSecretKeyPattern matches configuration keys that hold credentials or encryption keys
+	bundleSecretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|api_key|credential|private|masterkey|joinkey|licensekey|accesskey|^key$)`)
+	// bundleURLCredentialsPattern matches the user and password part of a URL
+	bundleURLCredentialsPattern = regexp.MustCompile(`://[^/@\s]+@`)
+	// bundleOracleCredentialsPattern matches the user/password part of an Oracle thin URL, e.g. jdbc:oracle:thin:art/pass@db:1521:ORCL
+	bundleOracleCredentialsPattern = regexp.MustCompile(`(?i)(jdbc:oracle:\w+:)[^@\s/]+/[^@\s]+@`)
+	// bundlePasswordParamPattern matches a password query or JDBC property, e.g. ?password=x or ;pwd=x
+	bundlePasswordParamPattern = regexp.MustCompile(`(?i)([?&;](?:password|passwd|pwd)=)[^&;\s]*`)
+	// bundleJVMPropertyPattern matches a system property in JVM options, e.g. -Djavax.net.ssl.keyStorePassword=x
+	bundleJVMPropertyPattern = regexp.MustCompile(`(-D([\w.-]+)=)("[^"]*"|'[^']*'|\S*)`)
+	// bundleJVMMemoryPattern matches a JVM memory size such as 2g or 512m
+	bundleJVMMemoryPattern = regexp.MustCompile(`(?i)^(\d+)([kmgt]?)$`)
+	// bundleUsedPercentPattern matches the percentage in storage summary values such as "27.06 GB (10.79%)"
+	bundleUsedPercentPattern = regexp.MustCompile(`\(([\d.]+)%\)`)
+	// bundleXMLCommentPattern matches XML comments, which binarystore.xml templates are often left in
+	bundleXMLCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// bundleChainTemplatePattern matches the template of a binarystore.xml chain
+	bundleChainTemplatePattern = regexp.MustCompile(`<chain[^>]*\btemplate="([^"]+)"`)
+	// bundleProviderTypePattern matches the provider types of a binarystore.xml chain
+	bundleProviderTypePattern = regexp.MustCompile(`<provider[^>]*\btype="([^"]+)"`)
+)
+
+// bundleSeverityOrder sorts risks with the most severe first
+var bundleSeverityOrder = map[string]int{"critical": 0, "warning": 1, "info": 2}
+
+// BundleServiceNode is one microservice on one node
+type BundleServiceNode struct {
+	Node          string     `json:"node"`
+	Status        string     `json:"status"`
+	Version       string     `json:"version,omitempty"`
+	ProcessMemory string     `json:"process_memory,omitempty"`
+	JVM           *BundleJVM `json:"jvm,omitempty"`
+}
+
+// BundleService is a microservice with its version and collection status per node
+type BundleService struct {
+	Name    string              `json:"name"`
+	Version string              `json:"version,omitempty"`
+	Nodes   []BundleServiceNode `json:"nodes"`
+}
+
+// BundleJVM describes the JVM of a Java microservice
+type BundleJVM struct {
+	Version     string   `json:"version"`
+	Vendor      string   `json:"vendor,omitempty"`
+	HeapMax     int64    `json:"heap_max,omitempty"`
+	HeapUsed    int64    `json:"heap_used,omitempty"`
+	Heap        string   `json:"heap,omitempty"`
+	HeapPercent float64  `json:"heap_percent,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// BundleNode holds the host facts of a node
+type BundleNode struct {
+	Node           string   `json:"node"`
+	Hostname       string   `json:"hostname,omitempty"`
+	OS             string   `json:"os,omitempty"`
+	Kernel         string   `json:"kernel,omitempty"`
+	Arch           string   `json:"arch,omitempty"`
+	CPUs           int      `json:"cpus,omitempty"`
+	Memory         int64    `json:"memory,omitempty"`
+	MemoryReadable string   `json:"memory_readable,omitempty"`
+	Virtualization string   `json:"virtualization,omitempty"`
+	Uptime         string   `json:"uptime,omitempty"`
+	Services       []string `json:"services"`
+}
+
+// BundleStorage is the Artifactory filestore from storage-summary.json and binarystore.xml
+type BundleStorage struct {
+	Node        string  `json:"node"`
+	Type        string  `json:"type,omitempty"`
+	Chain       string  `json:"chain,omitempty"`
+	Directory   string  `json:"directory,omitempty"`
+	Total       string  `json:"total,omitempty"`
+	Used        string  `json:"used,omitempty"`
+	Free        string  `json:"free,omitempty"`
+	UsedPercent float64 `json:"used_percent,omitempty"`
+}
+
+// BundleDatabase is the database the platform is configured with
+type BundleDatabase struct {
+	Type     string `json:"type"`
+	URL      string `json:"url,omitempty"`
+	Driver   string `json:"driver,omitempty"`
+	Username string `json:"username,omitempty"`
+	Source   string `json:"source"`
+}
+
+// BundleHA describes whether the platform runs as a cluster
+type BundleHA struct {
+	Enabled bool     `json:"enabled"`
+	Mode    string   `json:"mode"`
+	Nodes   []string `json:"nodes"`
+}
+
+// BundleRisk is a risky setting or fact found in the bundle
+type BundleRisk struct {
+	Severity string `json:"severity"`
+	Service  string `json:"service,omitempty"`
+	Setting  string `json:"setting,omitempty"`
+	Message  string `json:"message"`
+}
+
+// BundleInspection is the result of bundle_inspect
+type BundleInspection struct {
+	BundlePath  string                       `json:"bundle_path"`
+	Name        string                       `json:"name,omitempty"`
+	ID          string                       `json:"id,omitempty"`
+	Created     string                       `json:"created,omitempty"`
+	Status      string                       `json:"status,omitempty"`
+	ServiceType string                       `json:"service_type,omitempty"`
+	ServiceID   string                       `json:"service_id,omitempty"`
+	Collection  map[string]any               `json:"collection,omitempty"`
+	Services    []BundleService              `json:"services"`
+	Nodes       []BundleNode                 `json:"nodes"`
+	Database    *BundleDatabase              `json:"database,omitempty"`
+	HA          BundleHA                     `json:"ha"`
+	Storage     []BundleStorage              `json:"storage,omitempty"`
+	Risks       []BundleRisk                 `json:"risks"`
+	Config      map[string]map[string]string `json:"config,omitempty"`
+	Duration    string                       `json:"duration"`
+}
+
+// bundleServiceManifest is service_manifest.json at the root of a bundle
+type bundleServiceManifest struct {
+	ServiceType   string                       `json:"service_type"`
+	ServiceID     string                       `json:"service_id"`
+	Status        string                       `json:"status"`
+	Microservices map[string]map[string]string `json:"microservices"`
+	BundleInfo    struct {
+		Name       string         `json:"name"`
+		ID         string         `json:"id"`
+		Created    string         `json:"created"`
+		Status     string         `json:"status"`
+		Parameters map[string]any `json:"parameters"`
+	} `json:"bundle_info"`
+}
+
+// bundleNodeManifest is the node_manifest.json of a microservice on a node
+type bundleNodeManifest struct {
+	MicroserviceName    string `json:"microservice_name"`
+	MicroserviceVersion string `json:"microservice_version"`
+	NodeID              string `json:"node_id"`
+	BundleInfo          struct {
+		Status string `json:"status"`
+	} `json:"bundle_info"`
+}
+
+// bundleSystemInfo is system/system-info.json. Java services write the "Host Info" and "Java System Info"
+// sections; Go services write the gopsutil host facts
+type bundleSystemInfo struct {
+	HostInfo      map[string]string `json:"Host Info"`
+	JavaInfo      map[string]any    `json:"Java System Info"`
+	CPU           []json.RawMessage `json:"cpu"`
+	TotalMemory   int64             `json:"total_memory"`
+	ProcessMemory int64             `json:"process_memory"`
+	Host          struct {
+		Hostname             string `json:"hostname"`
+		Uptime               int64  `json:"uptime"`
+		Platform             string `json:"platform"`
+		PlatformVersion      string `json:"platformVersion"`
+		KernelVersion        string `json:"kernelVersion"`
+		KernelArch           string `json:"kernelArch"`
+		VirtualizationSystem string `json:"virtualizationSystem"`
+	} `json:"host"`
+	OSName string `json:"os_name"`
+}
+
+// bundleServiceDir is the directory of one microservice on one node, the one holding node_manifest.json
+type bundleServiceDir struct {
+	dir      string
+	manifest bundleNodeManifest
+}
+
+// addBundleInspectTools registers the support bundle inspection tool
+func addBundleInspectTools(s *server.MCPServer) {
+	inspectTool := mcp.NewTool("bundle_inspect",
+		mcp.WithDescription("Inspect the configuration and topology of an unzipped JFrog support bundle: microservice versions, collection status per node, JVM, OS, memory and disk facts, database type, HA mode and the effective configuration with secrets masked. Flags risky settings such as a small heap, the default Derby database, a nearly full filestore or disabled services"),
+		mcp.WithString("bundle_path",
+			mcp.Description("Path to the support bundle folder (default: ./support-bundle)"),
+		),
+		mcp.WithBoolean("include_config",
+			mcp.Description("Include the effective configuration of each microservice as flattened key/value pairs with secrets masked (default: false)"),
+		),
+		mcp.WithString("config_services",
+			mcp.Description("Comma-separated list of microservices whose configuration to include (default: all)"),
+		),
+	)
+
+	s.AddTool(inspectTool, executeBundleInspect)
+}
+
+// executeBundleInspect handles the bundle_inspect tool execution
+func executeBundleInspect(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	bundlePath := request.GetString("bundle_path", "./support-bundle")
+	includeConfig := request.GetBool("include_config", false)
+	configServices := parseCommaSeparated(request.GetString("config_services", ""))
+
+	info, err := os.Stat(bundlePath)
+	if err != nil || !info.IsDir() {
+		return mcp.NewToolResultError(fmt.Sprintf("support bundle directory does not exist: %s", bundlePath)), nil
+	}
+
+	inspection, err := inspectSupportBundle(ctx, bundlePath, includeConfig, configServices)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to inspect support bundle: %v", err)), nil
+	}
+	inspection.Duration = time.Since(startTime).String()
+
+	resultJSON, err := json.Marshal(inspection)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// inspectSupportBundle builds the overview of a bundle from its manifests, system info and configuration files
+func inspectSupportBundle(ctx context.Context, bundlePath string, includeConfig bool, configServices []string) (*BundleInspection, error) {
+	var manifestPath string
+	var serviceDirs []bundleServiceDir
+	err := filepath.WalkDir(bundlePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable directories
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		switch entry.Name() {
+		case "service_manifest.json":
+			// The bundle may be nested in the directory it was unzipped to; the outermost manifest wins
+			if manifestPath == "" || len(path) < len(manifestPath) {
+				manifestPath = path
+			}
+		case "node_manifest.json":
+			var manifest bundleNodeManifest
+			if readBundleJSON(path, &manifest) == nil && manifest.MicroserviceName != "" {
+				serviceDirs = append(serviceDirs, bundleServiceDir{dir: filepath.Dir(path), manifest: manifest})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifestPath == "" && len(serviceDirs) == 0 {
+		return nil, fmt.Errorf("no service_manifest.json or node_manifest.json found under %s", bundlePath)
+	}
+
+	inspection := &BundleInspection{BundlePath: bundlePath, Services: []BundleService{}, Nodes: []BundleNode{}, Risks: []BundleRisk{}}
+	var manifest bundleServiceManifest
+	if manifestPath != "" {
+		if err := readBundleJSON(manifestPath, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid service manifest: %v", err)
+		}
+		inspection.Name = manifest.BundleInfo.Name
+		inspection.ID = manifest.BundleInfo.ID
+		inspection.Created = manifest.BundleInfo.Created
+		inspection.Status = manifest.Status
+		inspection.ServiceType = manifest.ServiceType
+		inspection.ServiceID = manifest.ServiceID
+		inspection.Collection = manifest.BundleInfo.Parameters
+	}
+
+	services := make(map[string]*BundleService)
+	service := func(name string) *BundleService {
+		if services[name] == nil {
+			services[name] = &BundleService{Name: name}
+		}
+		return services[name]
+	}
+	nodes := make(map[string]*BundleNode)
+	node := func(name string) *BundleNode {
+		if nodes[name] == nil {
+			nodes[name] = &BundleNode{Node: name}
+		}
+		return nodes[name]
+	}
+	for name, statuses := range manifest.Microservices {
+		for nodeID, status := range statuses {
+			service(name).Nodes = append(service(name).Nodes, BundleServiceNode{Node: nodeID, Status: status})
+			node(nodeID).Services = appendUniqueStrings(node(nodeID).Services, name)
+		}
+	}
+
+	configs := make(map[string]map[string]any)
+	for _, serviceDir := range serviceDirs {
+		name, nodeID := serviceDir.manifest.MicroserviceName, serviceDir.manifest.NodeID
+		entry := bundleServiceNode(service(name), nodeID, serviceDir.manifest.BundleInfo.Status)
+		entry.Version = serviceDir.manifest.MicroserviceVersion
+		node(nodeID).Services = appendUniqueStrings(node(nodeID).Services, name)
+
+		var systemInfo bundleSystemInfo
+		if readBundleJSON(filepath.Join(serviceDir.dir, "system", "system-info.json"), &systemInfo) == nil {
+			applyBundleSystemInfo(node(nodeID), entry, &systemInfo)
+		}
+		var storage ArtifactoryStorageInfo
+		if readBundleJSON(filepath.Join(serviceDir.dir, "system", "storage-summary.json"), &storage) == nil && storage.FileStoreSummary != nil {
+			inspection.Storage = append(inspection.Storage, bundleFilestore(nodeID, storage.FileStoreSummary, filepath.Join(serviceDir.dir, "conf", "binarystore.xml")))
+		}
+
+		// system.yaml is the platform configuration; Go services also write the configuration they run with
+		for _, file := range []string{"system.yaml", "effective-system.yaml"} {
+			var config map[string]any
+			data, err := os.ReadFile(filepath.Join(serviceDir.dir, "conf", file))
+			if err != nil || yaml.Unmarshal(data, &config) != nil || config == nil {
+				continue
+			}
+			if configs[name] == nil {
+				configs[name] = make(map[string]any)
+			}
+			for key, value := range config {
+				configs[name][key] = value
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(services) {
+		current := services[name]
+		sort.Slice(current.Nodes, func(i, j int) bool { return current.Nodes[i].Node < current.Nodes[j].Node })
+		var versions []string
+		for _, entry := range current.Nodes {
+			if entry.Version != "" {
+				versions = appendUniqueStrings(versions, entry.Version)
+			}
+		}
+		current.Version = strings.Join(versions, ", ")
+		inspection.Services = append(inspection.Services, *current)
+	}
+	for _, name := range sortedKeys(nodes) {
+		sort.Strings(nodes[name].Services)
+		inspection.Nodes = append(inspection.Nodes, *nodes[name])
+	}
+
+	inspection.Database = bundleDatabase(configs)
+	inspection.HA = bundleHA(inspection.Nodes, configs)
+	inspection.Risks = bundleRisks(inspection, configs)
+	if includeConfig {
+		inspection.Config = make(map[string]map[string]string)
+		for name, config := range configs {
+			if len(configServices) == 0 || containsFold(configServices, name) {
+				flat := make(map[string]string)
+				flattenBundleConfig("", config, flat)
+				inspection.Config[name] = flat
+			}
+		}
+	}
+	return inspection, nil
+}
+
+// readBundleJSON decodes a JSON file of the bundle
+func readBundleJSON(path string, value any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// bundleServiceNode returns the entry of a node in a service, adding it when the service manifest does not list it
+func bundleServiceNode(service *BundleService, nodeID, status string) *BundleServiceNode {
+	for i := range service.Nodes {
+		if service.Nodes[i].Node == nodeID {
+			return &service.Nodes[i]
+		}
+	}
+	service.Nodes = append(service.Nodes, BundleServiceNode{Node: nodeID, Status: status})
+	return &service.Nodes[len(service.Nodes)-1]
+}
+
+// applyBundleSystemInfo copies the host facts of a system-info.json to its node and the JVM facts to the service
+func applyBundleSystemInfo(node *BundleNode, entry *BundleServiceNode, info *bundleSystemInfo) {
+	if len(info.CPU) > 0 {
+		// The gopsutil facts of Go services are the most complete view of the host
+		node.CPUs = len(info.CPU)
+		node.Memory = info.TotalMemory
+		node.MemoryReadable = formatBytes(info.TotalMemory)
+		node.Hostname = info.Host.Hostname
+		node.OS = strings.TrimSpace(info.OSName + " " + info.Host.Platform + " " + info.Host.PlatformVersion)
+		node.Kernel = info.Host.KernelVersion
+		node.Arch = info.Host.KernelArch
+		node.Virtualization = info.Host.VirtualizationSystem
+		if info.Host.Uptime > 0 {
+			node.Uptime = (time.Duration(info.Host.Uptime) * time.Second).String()
+		}
+	}
+	if info.ProcessMemory > 0 {
+		entry.ProcessMemory = formatBytes(info.ProcessMemory)
+	}
+	if info.HostInfo == nil {
+		return
+	}
+
+	if node.CPUs == 0 {
+		node.CPUs, _ = strconv.Atoi(info.HostInfo["Available Processors"])
+	}
+	if node.OS == "" {
+		node.OS = info.HostInfo["os.name"]
+		node.Kernel = info.HostInfo["os.version"]
+		node.Arch = info.HostInfo["os.arch"]
+	}
+
+	jvm := &BundleJVM{}
+	jvm.Version, _ = info.JavaInfo["java.runtime.version"].(string)
+	jvm.Vendor, _ = info.JavaInfo["java.vendor"].(string)
+	jvm.HeapMax, _ = strconv.ParseInt(info.HostInfo["Heap Memory Usage-Max"], 10, 64)
+	jvm.HeapUsed, _ = strconv.ParseInt(info.HostInfo["Heap Memory Usage-Used"], 10, 64)
+	if arguments, ok := info.JavaInfo["JVM Input arguments"].([]any); ok {
+		for _, argument := range arguments {
+			option, _ := argument.(string)
+			if strings.HasPrefix(option, "-Xm") || strings.HasPrefix(option, "-Xss") || (strings.HasPrefix(option, "-XX:") && !strings.HasPrefix(option, "-XX:OnOutOfMemoryError")) {
+				jvm.Options = appendUniqueStrings(jvm.Options, option)
+				if strings.HasPrefix(option, "-Xmx") && jvm.HeapMax <= 0 {
+					jvm.HeapMax = parseJVMMemory(strings.TrimPrefix(option, "-Xmx"))
+				}
+			}
+		}
+	}
+	if jvm.HeapMax > 0 {
+		jvm.Heap = formatBytes(jvm.HeapUsed) + " of " + formatBytes(jvm.HeapMax)
+		jvm.HeapPercent = pprofPercent(jvm.HeapUsed, jvm.HeapMax)
+	}
+	entry.JVM = jvm
+}
+
+// parseJVMMemory parses a JVM memory size such as 2g, 512m or 1048576 into bytes, returning 0 when it cannot
+func parseJVMMemory(value string) int64 {
+	match := bundleJVMMemoryPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0
+	}
+	size, _ := strconv.ParseInt(match[1], 10, 64)
+	switch strings.ToLower(match[2]) {
+	case "k":
+		size <<= 10
+	case "m":
+		size <<= 20
+	case "g":
+		size <<= 30
+	case "t":
+		size <<= 40
+	}
+	return size
+}
+
+// bundleFilestore reads the filestore facts of the storage summary and the chain of binarystore.xml
+func bundleFilestore(nodeID string, summary map[string]any, binarystorePath string) BundleStorage {
+	value := func(key string) string {
+		text, _ := summary[key].(string)
+		return text
+	}
+	storage := BundleStorage{
+		Node:      nodeID,
+		Type:      value("storageType"),
+		Directory: value("storageDirectory"),
+		Total:     value("totalSpace"),
+		Used:      value("usedSpace"),
+		Free:      value("freeSpace"),
+	}
+	if match := bundleUsedPercentPattern.FindStringSubmatch(storage.Used); match != nil {
+		storage.UsedPercent, _ = strconv.ParseFloat(match[1], 64)
+	}
+	if data, err := os.ReadFile(binarystorePath); err == nil {
+		config := bundleXMLCommentPattern.ReplaceAllString(string(data), "")
+		if match := bundleChainTemplatePattern.FindStringSubmatch(config); match != nil {
+			storage.Chain = match[1]
+		} else {
+			var providers []string
+			for _, match := range bundleProviderTypePattern.FindAllStringSubmatch(config, -1) {
+				providers = append(providers, match[1])
+			}
+			storage.Chain = strings.Join(providers, " > ")
+		}
+	}
+	return storage
+}
+
+// bundleConfigValue returns a value from a parsed configuration by its dotted path, matching keys case-insensitively
+// since effective-system.yaml lowercases them
+func bundleConfigValue(config map[string]any, path string) (any, bool) {
+	var current any = config
+	for _, key := range strings.Split(path, ".") {
+		values, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for name, value := range values {
+			if strings.EqualFold(name, key) {
+				current, found = value, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// bundleConfigString returns a configuration value as a string
+func bundleConfigString(config map[string]any, path string) string {
+	value, ok := bundleConfigValue(config, path)
+	if !ok || value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+// bundleDatabase finds the database from system.yaml, or else from the effective configuration of the
+// Go services. Artifactory uses its embedded Derby database when system.yaml configures none
+func bundleDatabase(configs map[string]map[string]any) *BundleDatabase {
+	for _, name := range sortedKeys(configs) {
+		config := configs[name]
+		if _, ok := bundleConfigValue(config, "shared"); !ok {
+			continue
+		}
+		database := &BundleDatabase{
+			Type:     bundleConfigString(config, "shared.database.type"),
+			URL:      maskBundleValue("url", bundleConfigString(config, "shared.database.url")),
+			Driver:   bundleConfigString(config, "shared.database.driver"),
+			Username: bundleConfigString(config, "shared.database.username"),
+			Source:   name + " system.yaml",
+		}
+		if database.Type == "" && database.URL == "" {
+			database.Type = "derby"
+			database.Source = "default"
+		}
+		if database.Type == "" {
+			database.Type = bundleDatabaseType(database.URL)
+		}
+		return database
+	}
+	for _, name := range sortedKeys(configs) {
+		if url := bundleConfigString(configs[name], "database.url"); url != "" {
+			return &BundleDatabase{
+				Type:     bundleDatabaseType(url),
+				URL:      maskBundleValue("url", url),
+				Username: bundleConfigString(configs[name], "database.username"),
+				Source:   name + " effective-system.yaml",
+			}
+		}
+	}
+	return nil
+}
+
+// bundleDatabaseType returns the database type of a JDBC or Go database URL
+func bundleDatabaseType(url string) string {
+	scheme := strings.TrimPrefix(strings.ToLower(url), "jdbc:")
+	if i := strings.IndexAny(scheme, ":/"); i > 0 {
+		scheme = scheme[:i]
+	}
+	switch scheme {
+	case "postgres", "postgresql":
+		return "postgresql"
+	case "sqlserver":
+		return "mssql"
+	}
+	return scheme
+}
+
+// bundleHA reports whether the platform is a cluster: more than one node in the bundle, or HA enabled in system.yaml
+func bundleHA(nodes []BundleNode, configs map[string]map[string]any) BundleHA {
+	ha := BundleHA{Nodes: []string{}}
+	for _, node := range nodes {
+		ha.Nodes = append(ha.Nodes, node.Node)
+	}
+	for _, config := range configs {
+		if strings.EqualFold(bundleConfigString(config, "shared.node.haEnabled"), "true") {
+			ha.Enabled = true
+		}
+	}
+	ha.Enabled = ha.Enabled || len(nodes) > 1
+	ha.Mode = "single node"
+	if ha.Enabled {
+		ha.Mode = fmt.Sprintf("high availability (%d node(s) in bundle)", len(nodes))
+	}
+	return ha
+}
+
+// bundleRisks flags risky settings and facts, most severe first
+func bundleRisks(inspection *BundleInspection, configs map[string]map[string]any) []BundleRisk {
+	risks := []BundleRisk{}
+	add := func(severity, service, setting, format string, args ...any) {
+		risks = append(risks, BundleRisk{Severity: severity, Service: service, Setting: setting, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if database := inspection.Database; database != nil {
+		if database.Type == "derby" {
+			add("critical", "artifactory", "shared.database", "Artifactory uses the embedded Derby database, which is meant for evaluation only; production and HA installations need an external database such as PostgreSQL")
+		} else if inspection.HA.Enabled && (strings.Contains(database.URL, "localhost") || strings.Contains(database.URL, "127.0.0.1")) {
+			add("critical", "", "shared.database.url", "HA nodes connect to the database through %s, which each node resolves to itself", database.URL)
+		}
+	}
+	for _, storage := range inspection.Storage {
+		if storage.UsedPercent >= bundleDiskUsageCritical {
+			add("critical", "artifactory", "filestore", "Filestore on %s is %.1f%% full (%s free)", storage.Node, storage.UsedPercent, storage.Free)
+		} else if storage.UsedPercent >= bundleDiskUsageWarning {
+			add("warning", "artifactory", "filestore", "Filestore on %s is %.1f%% full (%s free)", storage.Node, storage.UsedPercent, storage.Free)
+		}
+		if inspection.HA.Enabled && storage.Chain == "file-system" {
+			add("warning", "artifactory", "binarystore.xml", "HA is enabled but node %s stores binaries on a local file-system chain; the nodes need shared or cluster storage", storage.Node)
+		}
+	}
+
+	heaps := make(map[string]int64)
+	for _, service := range inspection.Services {
+		if len(service.Nodes) > 1 && strings.Contains(service.Version, ",") {
+			add("warning", service.Name, "version", "Nodes run different versions of %s: %s", service.Name, service.Version)
+		}
+		for _, entry := range service.Nodes {
+			if entry.Status != "" && entry.Status != "success" && entry.Status != "in progress" {
+				add("warning", service.Name, "", "Collection of %s on %s ended with status %q; its data may be missing from the bundle", service.Name, entry.Node, entry.Status)
+			}
+			if entry.JVM == nil || entry.JVM.HeapMax <= 0 {
+				continue
+			}
+			heaps[entry.Node] += entry.JVM.HeapMax
+			if service.Name == "artifactory" && entry.JVM.HeapMax < bundleMinArtifactoryHeap {
+				add("warning", service.Name, "-Xmx", "Small heap: Artifactory on %s has a maximum heap of %s; production instances usually need at least %s", entry.Node, formatBytes(entry.JVM.HeapMax), formatBytes(bundleMinArtifactoryHeap))
+			}
+			if entry.JVM.HeapPercent >= bundleHeapUsageWarning {
+				add("warning", service.Name, "-Xmx", "%s on %s was using %.0f%% of its heap (%s) when the bundle was collected", service.Name, entry.Node, entry.JVM.HeapPercent, entry.JVM.Heap)
+			}
+		}
+	}
+	for _, node := range inspection.Nodes {
+		if node.Memory > 0 && heaps[node.Node] > node.Memory {
+			add("warning", "", "-Xmx", "The maximum heaps of the Java services on %s add up to %s, more than the node's %s of memory; under load they can grow until the node runs out of memory", node.Node, formatBytes(heaps[node.Node]), node.MemoryReadable)
+		}
+		if node.Memory > 0 && node.Memory < bundleMinNodeMemory {
+			add("warning", "", "", "Node %s has %s of memory, below the %s minimum for the JFrog Platform", node.Node, node.MemoryReadable, formatBytes(bundleMinNodeMemory))
+		}
+		if node.CPUs > 0 && node.CPUs < bundleMinNodeCPUs {
+			add("warning", "", "", "Node %s has %d CPU(s), below the minimum of %d", node.Node, node.CPUs, bundleMinNodeCPUs)
+		}
+	}
+
+	// Services switched off in system.yaml, and settings that weaken security
+	for _, name := range sortedKeys(configs) {
+		config := configs[name]
+		if _, ok := bundleConfigValue(config, "shared"); !ok {
+			continue
+		}
+		for _, key := range sortedKeys(config) {
+			if strings.EqualFold(bundleConfigString(config, key+".enabled"), "false") {
+				add("info", key, key+".enabled", "%s is disabled in system.yaml", key)
+			}
+		}
+		if strings.EqualFold(bundleConfigString(config, "router.serviceRegistry.insecure"), "true") {
+			add("warning", "router", "router.serviceRegistry.insecure", "The router accepts the service registry over an insecure connection")
+		}
+	}
+
+	// Collection parameters that leave data out of the bundle
+	for _, section := range []string{"configuration", "system"} {
+		if enabled, ok := inspection.Collection[section].(bool); ok && !enabled {
+			add("info", "", section, "The bundle was collected without %s information", section)
+		}
+	}
+	if logs, ok := inspection.Collection["logs"].(map[string]any); ok {
+		if include, ok := logs["include"].(bool); ok && !include {
+			add("info", "", "logs", "The bundle was collected without logs")
+		}
+	}
+
+	sort.SliceStable(risks, func(i, j int) bool {
+		return bundleSeverityOrder[risks[i].Severity] < bundleSeverityOrder[risks[j].Severity]
+	})
+	return risks
+}
+
+// flattenBundleConfig flattens a parsed configuration into dotted keys with secrets masked
+func flattenBundleConfig(prefix string, value any, flat map[string]string) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, child := range typed {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenBundleConfig(name, child, flat)
+		}
+	case []any:
+		for i, child := range typed {
+			flattenBundleConfig(fmt.Sprintf("%s.%d", prefix, i), child, flat)
+		}
+	default:
+		text := ""
+		if value != nil {
+			text = strings.TrimSpace(fmt.Sprint(value))
+		}
+		flat[prefix] = maskBundleValue(prefix, text)
+	}
+}
+
+// maskBundleValue masks the value of a dotted key when any part of it names a credential, e.g.
+// shared.database.passwords.0, the credentials in connection URLs and credential system properties
+// in JVM options
+func maskBundleValue(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if isBundleSecretKey(key) {
+		return "********"
+	}
+	value = bundleURLCredentialsPattern.ReplaceAllString(value, "://********@")
+	value = bundleOracleCredentialsPattern.ReplaceAllString(value, "${1}********@")
+	value = bundlePasswordParamPattern.ReplaceAllString(value, "${1}********")
+	return bundleJVMPropertyPattern.ReplaceAllStringFunc(value, func(property string) string {
+		match := bundleJVMPropertyPattern.FindStringSubmatch(property)
+		if !isBundleSecretKey(match[2]) {
+			return property
+		}
+		return match[1] + "********"
+	})
+}
+
+// isBundleSecretKey reports whether any part of a dotted key names a credential
+func isBundleSecretKey(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		if bundleSecretKeyPattern.MatchString(part) {
+			return true
+		}
+	}
+	return false
+}
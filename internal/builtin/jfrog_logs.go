@@ -0,0 +1,511 @@
+package builtin
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// maxJFrogLogLineBytes bounds a single log line; effective configuration dumps can be long
+	maxJFrogLogLineBytes = 1024 * 1024
+	// maxJFrogStackTraceLines bounds the continuation lines kept for one entry
+	maxJFrogStackTraceLines = 50
+)
+
+// jfrogLogLinePattern matches the JFrog service log format:
+// timestamp [service] [LEVEL] [traceId] [class:line] [thread] [optional fields...] - message
+var jfrogLogLinePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?) \[([^\]]*)\] \[(\[?[^\]]*\]?)\] \[([^\]]*)\] \[([^\]]*)\] \[([^\]]*)\]((?: \[[^\]]*\])*) - ?(.*)$`)
+
+// ansiEscapePattern matches terminal color codes, which some Node.js services write around the level
+var ansiEscapePattern = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// jfrogServiceNames maps the service codes in log lines to their bundle directory names
+var jfrogServiceNames = map[string]string{
+	"jfrt":   "artifactory",
+	"jfrou":  "router",
+	"jfac":   "access",
+	"jfmd":   "metadata",
+	"jfcon":  "jfconnect",
+	"jffe":   "frontend",
+	"jfevt":  "event",
+	"jfevd":  "evidence",
+	"jfob":   "observability",
+	"jfint":  "integration",
+	"jfcfg":  "jfconfig",
+	"jftpl":  "topology",
+	"jfomr":  "onemodelregistry",
+	"jfxr":   "xray",
+	"jfxana": "xray-analysis",
+	"jfxidx": "xray-indexer",
+	"jfxpst": "xray-persist",
+	"jfds":   "distribution",
+	"jfmc":   "mission-control",
+	"jfpip":  "pipelines",
+}
+
+// JFrogLogEntry is one parsed line of a JFrog service, request or audit log
+type JFrogLogEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Service     string    `json:"service"`
+	ServiceCode string    `json:"service_code,omitempty"`
+	Node        string    `json:"node,omitempty"`
+	Level       string    `json:"level,omitempty"`
+	TraceID     string    `json:"trace_id,omitempty"`
+	Class       string    `json:"class,omitempty"`
+	ClassLine   int       `json:"class_line,omitempty"`
+	Thread      string    `json:"thread,omitempty"`
+	Fields      []string  `json:"fields,omitempty"`
+	Message     string    `json:"message"`
+	StackTrace  []string  `json:"stack_trace,omitempty"`
+	Kind        string    `json:"kind"`
+	FilePath    string    `json:"file_path"`
+	LineNumber  int       `json:"line_number"`
+}
+
+// TraceEvent is one entry of a trace timeline with its offset from the first event
+type TraceEvent struct {
+	Offset string `json:"offset"`
+	JFrogLogEntry
+}
+
+// TraceServiceStats summarizes the entries one service logged for a trace
+type TraceServiceStats struct {
+	Entries  int       `json:"entries"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	First    time.Time `json:"first"`
+	Last     time.Time `json:"last"`
+}
+
+// TraceTimeline is the cross-service timeline of one trace ID
+type TraceTimeline struct {
+	TraceID      string                        `json:"trace_id"`
+	SourcePath   string                        `json:"source_path"`
+	Found        bool                          `json:"found"`
+	Services     []string                      `json:"services"`
+	Start        time.Time                     `json:"start"`
+	End          time.Time                     `json:"end"`
+	Span         string                        `json:"span,omitempty"`
+	TotalEntries int                           `json:"total_entries"`
+	Truncated    bool                          `json:"truncated,omitempty"`
+	FirstError   *TraceEvent                   `json:"first_error,omitempty"`
+	ServiceStats map[string]*TraceServiceStats `json:"service_stats"`
+	Timeline     []TraceEvent                  `json:"timeline"`
+	FilesScanned int                           `json:"files_scanned"`
+	Warnings     []string                      `json:"warnings,omitempty"`
+	Duration     string                        `json:"duration"`
+}
+
+// addJFrogLogTools registers the JFrog log tools
+func addJFrogLogTools(s *server.MCPServer) {
+	traceTool := mcp.NewTool("trace_request",
+		mcp.WithDescription("Follow one request through a JFrog support bundle: find every service, request and audit log entry with the given trace ID across artifactory, router, access, metadata, jfconnect and the other microservices, and return them as one time-ordered timeline with per-service counts and the first error"),
+		mcp.WithString("trace_id",
+			mcp.Required(),
+			mcp.Description("Trace ID to follow, as printed in the fourth field of service logs or the second field of request logs"),
+		),
+		mcp.WithString("source_path",
+			mcp.Description("Path to the extracted support bundle or log directory (default: ./support-bundle)"),
+		),
+		mcp.WithString("services",
+			mcp.Description("Comma-separated services to include, e.g. 'router,artifactory,access' (default: all)"),
+		),
+		mcp.WithNumber("max_entries",
+			mcp.Description("Maximum timeline entries to return; the earliest are kept (default: 500)"),
+		),
+		mcp.WithBoolean("include_stack_traces",
+			mcp.Description("Include stack trace lines that follow matching entries (default: true)"),
+		),
+	)
+
+	s.AddTool(traceTool, executeTraceRequest)
+}
+
+// executeTraceRequest handles the trace_request tool execution
+func executeTraceRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	traceID := strings.TrimSpace(request.GetString("trace_id", ""))
+	sourcePath := request.GetString("source_path", "./support-bundle")
+	services := parseCommaSeparated(request.GetString("services", ""))
+	maxEntries := int(request.GetFloat("max_entries", 500))
+	includeStackTraces := request.GetBool("include_stack_traces", true)
+
+	if traceID == "" {
+		return mcp.NewToolResultError("trace_id is required"), nil
+	}
+	if strings.ContainsAny(traceID, " \t|[]") {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid trace_id '%s'", traceID)), nil
+	}
+	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
+		return mcp.NewToolResultError(fmt.Sprintf("source path does not exist: %s", sourcePath)), nil
+	}
+
+	timeline, err := traceJFrogRequest(ctx, sourcePath, traceID, services, maxEntries, includeStackTraces)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to trace request: %v", err)), nil
+	}
+	timeline.Duration = time.Since(startTime).String()
+
+	resultJSON, err := json.Marshal(timeline)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// traceJFrogRequest collects the entries of one trace from every log file under sourcePath
+func traceJFrogRequest(ctx context.Context, sourcePath, traceID string, services []string, maxEntries int, includeStackTraces bool) (*TraceTimeline, error) {
+	timeline := &TraceTimeline{
+		TraceID:      traceID,
+		SourcePath:   sourcePath,
+		Services:     []string{},
+		ServiceStats: map[string]*TraceServiceStats{},
+		Timeline:     []TraceEvent{},
+	}
+
+	var entries []JFrogLogEntry
+	err := walkJFrogLogFiles(sourcePath, func(filePath string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		timeline.FilesScanned++
+		warning, err := scanJFrogLogFile(filePath, func(line string) bool {
+			return strings.Contains(line, traceID)
+		}, func(entry JFrogLogEntry) {
+			if entry.TraceID != traceID && !strings.Contains(entry.Message, traceID) {
+				return
+			}
+			if len(services) > 0 && !containsFold(services, entry.Service) {
+				return
+			}
+			if !includeStackTraces {
+				entry.StackTrace = nil
+			}
+			entries = append(entries, entry)
+		})
+		if warning != "" {
+			timeline.Warnings = append(timeline.Warnings, warning)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries logged in the same millisecond keep their file order
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	timeline.TotalEntries = len(entries)
+	timeline.Found = len(entries) > 0
+	if !timeline.Found {
+		return timeline, nil
+	}
+	timeline.Start = entries[0].Timestamp
+	timeline.End = entries[len(entries)-1].Timestamp
+	timeline.Span = timeline.End.Sub(timeline.Start).String()
+
+	for i, entry := range entries {
+		stats, ok := timeline.ServiceStats[entry.Service]
+		if !ok {
+			stats = &TraceServiceStats{First: entry.Timestamp}
+			timeline.ServiceStats[entry.Service] = stats
+			timeline.Services = append(timeline.Services, entry.Service)
+		}
+		stats.Entries++
+		stats.Last = entry.Timestamp
+
+		event := TraceEvent{Offset: "+" + entry.Timestamp.Sub(timeline.Start).String(), JFrogLogEntry: entry}
+		switch entry.Level {
+		case "ERROR", "FATAL", "CRITICAL":
+			stats.Errors++
+			if timeline.FirstError == nil {
+				timeline.FirstError = &event
+			}
+		case "WARN", "WARNING":
+			stats.Warnings++
+		}
+
+		if maxEntries > 0 && i >= maxEntries {
+			timeline.Truncated = true
+			continue
+		}
+		timeline.Timeline = append(timeline.Timeline, event)
+	}
+	return timeline, nil
+}
+
+// walkJFrogLogFiles calls visit for every log file under root, including rotated .log.gz files
+func walkJFrogLogFiles(root string, visit func(filePath string) error) error {
+	return filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable directories
+		}
+		if entry.IsDir() || !isJFrogLogFile(entry.Name()) {
+			return nil
+		}
+		return visit(filePath)
+	})
+}
+
+// isJFrogLogFile reports whether a file name looks like a plain or gzipped log file
+func isJFrogLogFile(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".gz")
+	return strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".out") || strings.Contains(name, ".log.")
+}
+
+// openJFrogLogFile opens a log file, decompressing .gz files
+func openJFrogLogFile(filePath string) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(filePath), ".gz") {
+		return file, nil
+	}
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %v", filePath, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+// scanJFrogLogFile parses the entries of a log file. Only lines accepted by match are parsed; the
+// lines that follow a parsed entry without starting a new one, such as stack trace frames, are
+// attached to it. Unreadable files are skipped, and a file with a line too long to read returns a
+// warning naming where reading stopped
+func scanJFrogLogFile(filePath string, match func(line string) bool, visit func(entry JFrogLogEntry)) (string, error) {
+	reader, err := openJFrogLogFile(filePath)
+	if err != nil {
+		return "", nil
+	}
+	defer reader.Close()
+
+	service, node, kind := jfrogLogFileInfo(filePath)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxJFrogLogLineBytes)
+
+	var pending *JFrogLogEntry
+	flush := func() {
+		if pending != nil {
+			visit(*pending)
+			pending = nil
+		}
+	}
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if !isJFrogLogEntryStart(line) {
+			if pending != nil && len(pending.StackTrace) < maxJFrogStackTraceLines {
+				pending.StackTrace = append(pending.StackTrace, line)
+			}
+			continue
+		}
+
+		flush()
+		if match != nil && !match(line) {
+			continue
+		}
+		entry := parseJFrogLogLine(line, kind)
+		if entry.Service == "" {
+			entry.Service = service
+		}
+		entry.Node = node
+		entry.FilePath = filePath
+		entry.LineNumber = lineNumber
+		pending = &entry
+	}
+	flush()
+
+	// Files with over-long lines are reported up to the line that could not be read
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return fmt.Sprintf("stopped reading %s after line %d: the next line is longer than %s; the entries after it are missing", filePath, lineNumber, formatBytes(maxJFrogLogLineBytes)), nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", filePath, err)
+	}
+	return "", nil
+}
+
+// isJFrogLogEntryStart reports whether a line starts with an ISO 8601 date, which every entry does
+func isJFrogLogEntryStart(line string) bool {
+	if len(line) < 20 || line[4] != '-' || line[7] != '-' || line[10] != 'T' {
+		return false
+	}
+	for _, i := range []int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if line[i] < '0' || line[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseJFrogLogLine parses a service log line, falling back to the pipe-delimited request log
+// format and finally to the bare timestamp and message
+func parseJFrogLogLine(line, kind string) JFrogLogEntry {
+	if strings.Contains(line, "\x1b") {
+		line = ansiEscapePattern.ReplaceAllString(line, "")
+	}
+	if match := jfrogLogLinePattern.FindStringSubmatch(line); match != nil {
+		entry := JFrogLogEntry{
+			ServiceCode: strings.TrimSpace(match[2]),
+			Level:       normalizeJFrogLogLevel(match[3]),
+			TraceID:     strings.TrimSpace(match[4]),
+			Thread:      strings.TrimSpace(match[6]),
+			Message:     match[8],
+			Kind:        "service",
+		}
+		entry.Timestamp, _ = parseJFrogLogTime(match[1])
+		entry.Service = jfrogServiceNames[entry.ServiceCode]
+		entry.Class = strings.TrimSpace(match[5])
+		if colon := strings.LastIndex(entry.Class, ":"); colon >= 0 {
+			if classLine, err := strconv.Atoi(entry.Class[colon+1:]); err == nil {
+				entry.Class, entry.ClassLine = entry.Class[:colon], classLine
+			}
+		}
+		for _, field := range strings.Split(strings.TrimSpace(match[7]), "] [") {
+			if field = strings.TrimSpace(strings.Trim(field, "[]")); field != "" {
+				entry.Fields = append(entry.Fields, field)
+			}
+		}
+		if kind != "" && kind != "service" {
+			entry.Kind = kind
+		}
+		return entry
+	}
+
+	// Request, request-out and security audit logs: timestamp|traceId|fields...
+	if parts := strings.Split(line, "|"); len(parts) >= 3 {
+		if timestamp, err := parseJFrogLogTime(parts[0]); err == nil {
+			entry := JFrogLogEntry{
+				Timestamp: timestamp,
+				TraceID:   parts[1],
+				Message:   strings.Join(parts[2:], "|"),
+				Kind:      kind,
+			}
+			entry.Level = jfrogRequestLevel(parts, kind)
+			return entry
+		}
+	}
+
+	timestamp, message, _ := strings.Cut(line, " ")
+	entry := JFrogLogEntry{Message: message, Kind: kind}
+	entry.Timestamp, _ = parseJFrogLogTime(timestamp)
+	return entry
+}
+
+// jfrogLogLevel returns the level field of a JFrog service log line, or "" for other lines
+func jfrogLogLevel(line string) string {
+	if !isJFrogLogEntryStart(line) {
+		return ""
+	}
+	entry := parseJFrogLogLine(line, "service")
+	if entry.Kind != "service" {
+		return ""
+	}
+	return entry.Level
+}
+
+// jfrogRequestLevel derives a level from the HTTP status of a request log line
+func jfrogRequestLevel(parts []string, kind string) string {
+	// request: ts|trace|ip|user|method|path|status|...; request_out: ts|trace|url|method|status|...
+	index := 6
+	if kind == "request_out" {
+		index = 4
+	}
+	if kind != "request" && kind != "request_out" || index >= len(parts) {
+		return ""
+	}
+	status, err := strconv.Atoi(parts[index])
+	switch {
+	case err != nil:
+		return ""
+	case status >= 500:
+		return "ERROR"
+	case status >= 400:
+		return "WARN"
+	}
+	return "INFO"
+}
+
+// jfrogLogFileInfo derives the service, node and log kind from a bundle path such as
+// artifactory/.../artifactory-0/router/logs/router-request.log
+func jfrogLogFileInfo(filePath string) (service, node, kind string) {
+	base := strings.ToLower(filepath.Base(filePath))
+	base = strings.TrimSuffix(base, ".gz")
+	switch {
+	case strings.Contains(base, "-request-trace"):
+		kind = "request_trace"
+	case strings.Contains(base, "-request-out"):
+		kind = "request_out"
+	case strings.Contains(base, "-request"):
+		kind = "request"
+	case strings.Contains(base, "audit"):
+		kind = "audit"
+	default:
+		kind = "service"
+	}
+	if dash := strings.Index(base, "-"); dash > 0 {
+		service = base[:dash]
+	} else {
+		service = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	// Bundles lay logs out as <node>/<service>/logs/<file>
+	dir := filepath.Dir(filePath)
+	if name := filepath.Base(dir); name == "logs" || name == "log" {
+		node = filepath.Base(filepath.Dir(filepath.Dir(dir)))
+		if node == "." || node == string(filepath.Separator) {
+			node = ""
+		}
+	}
+	return service, node, kind
+}
+
+// normalizeJFrogLogLevel strips padding and color codes from a level field
+func normalizeJFrogLogLevel(level string) string {
+	level = ansiEscapePattern.ReplaceAllString(level, "")
+	return strings.ToUpper(strings.TrimSpace(strings.Trim(strings.TrimSpace(level), "[]")))
+}
+
+// parseJFrogLogTime parses the ISO 8601 timestamps JFrog services write
+func parseJFrogLogTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02T15:04:05.999999999"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp '%s'", value)
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJFrogLogLine(t *testing.T) {
+	entry := parseJFrogLogLine("2025-08-24T05:57:00.812Z [jfrt ] [WARN ] [377c134d1bb9a231] [o.a.c.h.HaNodeProperties:65   ] [Catalina-utility-1  ] - Artifactory is running in non-clustered mode.", "service")
+	if entry.Service != "artifactory" || entry.ServiceCode != "jfrt" || entry.Level != "WARN" || entry.TraceID != "377c134d1bb9a231" {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if entry.Class != "o.a.c.h.HaNodeProperties" || entry.ClassLine != 65 || entry.Thread != "Catalina-utility-1" || entry.Message != "Artifactory is running in non-clustered mode." {
+		t.Errorf("Unexpected class, thread or message %+v", entry)
+	}
+	if !entry.Timestamp.Equal(time.Date(2025, 8, 24, 5, 57, 0, 812000000, time.UTC)) {
+		t.Errorf("Unexpected timestamp %v", entry.Timestamp)
+	}
+
+	// Go services add a span field after the thread, and the frontend colors its level
+	entry = parseJFrogLogLine("2025-08-24T05:55:34.445Z [jfrou] [INFO ] [2889b9a4edd641a1] [bootstrap.go:91               ] [main                ] [] - Router started", "service")
+	if entry.Service != "router" || entry.Message != "Router started" || entry.Fields != nil {
+		t.Errorf("Unexpected router entry %+v", entry)
+	}
+	entry = parseJFrogLogLine("2025-08-24T05:56:26.216Z [jffe ] [\x1b[34M[ERROR]\x1b[39M] [] [frontend-service.log] [main                ] - ping failed", "service")
+	if entry.Service != "frontend" || entry.Level != "ERROR" || entry.TraceID != "" || entry.Message != "ping failed" {
+		t.Errorf("Unexpected frontend entry %+v", entry)
+	}
+
+	entry = parseJFrogLogLine("2025-08-24T05:57:09.936Z|005e86f997838d18|::1|anonymous|GET|/topology/api/v1/topology|503|103|-1|1129|JFrog-Router/7.177.4-1", "request")
+	if entry.TraceID != "005e86f997838d18" || entry.Level != "ERROR" || !strings.HasPrefix(entry.Message, "::1|anonymous|GET") {
+		t.Errorf("Unexpected request entry %+v", entry)
+	}
+
+	// The level field wins over words in the message
+	if severity := logLineSeverity("2025-08-24T05:57:00.812Z [jfrt ] [INFO ] [abc] [Foo:1] [main] - No ERROR here", compileSeverityPatterns([]string{"ERROR", "INFO"})); severity != "INFO" {
+		t.Errorf("Expected INFO, got %s", severity)
+	}
+}
+
+func TestTraceJFrogRequest(t *testing.T) {
+	bundle := t.TempDir()
+	writeLog := func(service, name, content string) string {
+		dir := filepath.Join(bundle, service, "jfrt", "artifactory-0", service, "logs")
+		os.MkdirAll(dir, 0755)
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	writeLog("router", "router-service.log", strings.Join([]string{
+		"2025-08-24T05:57:09.100Z [jfrou] [INFO ] [aaaa1111bbbb2222] [proxy.go:10] [main] [] - Forwarding GET /artifactory/api/repositories",
+		"2025-08-24T05:57:09.900Z [jfrou] [WARN ] [aaaa1111bbbb2222] [proxy.go:42] [main] [] - Upstream artifactory answered 500",
+		"2025-08-24T05:57:09.950Z [jfrou] [INFO ] [ffff0000ffff0000] [proxy.go:10] [main] [] - Unrelated request",
+	}, "\n"))
+	writeLog("artifactory", "artifactory-service.log", strings.Join([]string{
+		"2025-08-24T05:57:09.200Z [jfrt ] [ERROR] [aaaa1111bbbb2222] [o.a.s.AccessClient:88] [http-nio-8081-exec-1] - Could not reach access",
+		"java.net.ConnectException: Connection refused",
+		"\tat org.artifactory.security.AccessClient.call(AccessClient.java:88)",
+		"2025-08-24T05:57:09.300Z [jfrt ] [INFO ] [ffff0000ffff0000] [o.a.Foo:1] [main] - Unrelated",
+		"\tat org.artifactory.Unrelated.frame(Foo.java:1)",
+	}, "\n"))
+	writeLog("artifactory", "artifactory-request.log", "2025-08-24T05:57:09.890Z|aaaa1111bbbb2222|10.0.0.1|admin|GET|/api/repositories|500|0|-1|790|curl/8.0\n")
+
+	// Rotated access logs are gzipped
+	archive, _ := os.Create(writeLog("access", "access-service.2025-08-24.log.gz", ""))
+	writer := gzip.NewWriter(archive)
+	writer.Write([]byte("2025-08-24T05:57:09.150Z [jfac ] [INFO ] [aaaa1111bbbb2222] [o.j.a.Token:12] [exec-2] - Token validated\n"))
+	writer.Close()
+	archive.Close()
+
+	timeline, err := traceJFrogRequest(context.Background(), bundle, "aaaa1111bbbb2222", nil, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeline.TotalEntries != 5 || strings.Join(timeline.Services, ",") != "router,access,artifactory" {
+		t.Fatalf("Unexpected timeline %+v", timeline)
+	}
+	var order []string
+	for _, event := range timeline.Timeline {
+		order = append(order, event.Service+":"+event.Kind)
+	}
+	if strings.Join(order, " ") != "router:service access:service artifactory:service artifactory:request router:service" {
+		t.Errorf("Entries must be ordered by time across services, got %v", order)
+	}
+
+	first := timeline.FirstError
+	if first == nil || first.Service != "artifactory" || first.Offset != "+100ms" || len(first.StackTrace) != 2 || first.Node != "artifactory-0" {
+		t.Errorf("Unexpected first error %+v", first)
+	}
+	if stats := timeline.ServiceStats["artifactory"]; stats.Entries != 2 || stats.Errors != 2 {
+		t.Errorf("Unexpected artifactory stats %+v", stats)
+	}
+	if timeline.Span != "800ms" || timeline.FilesScanned != 4 {
+		t.Errorf("Unexpected span %s or files %d", timeline.Span, timeline.FilesScanned)
+	}
+
+	timeline, _ = traceJFrogRequest(context.Background(), bundle, "aaaa1111bbbb2222", []string{"router"}, 1, false)
+	if timeline.TotalEntries != 2 || len(timeline.Timeline) != 1 || !timeline.Truncated {
+		t.Errorf("Expected the router entries truncated to one, got %+v", timeline)
+	}
+
+	// A line over the limit stops the scan of its file, which must be reported
+	writeLog("router", "router-service.log", strings.Join([]string{
+		"2025-08-24T05:57:09.100Z [jfrou] [INFO ] [aaaa1111bbbb2222] [proxy.go:10] [main] [] - Forwarding GET /artifactory/api/repositories",
+		"2025-08-24T05:57:09.120Z [jfrou] [INFO ] [aaaa1111bbbb2222] [proxy.go:11] [main] [] - Headers " + strings.Repeat("x", maxJFrogLogLineBytes),
+		"2025-08-24T05:57:09.900Z [jfrou] [WARN ] [aaaa1111bbbb2222] [proxy.go:42] [main] [] - Upstream artifactory answered 500",
+	}, "\n"))
+	timeline, err = traceJFrogRequest(context.Background(), bundle, "aaaa1111bbbb2222", []string{"router"}, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeline.TotalEntries != 1 || len(timeline.Warnings) != 1 || !strings.Contains(timeline.Warnings[0], "router-service.log after line 1") {
+		t.Errorf("Expected a warning for the over-long line, got %d entries and warnings %v", timeline.TotalEntries, timeline.Warnings)
+	}
+}
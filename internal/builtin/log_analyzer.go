@@ -77,6 +77,8 @@ func NewLogAnalyzerServer() (*server.MCPServer, error) {
 	)
 
 	s.AddTool(logAnalyzerTool, executeLogAnalyzer)
+	addJFrogLogTools(s)
+	addRequestLogTools(s)
 	return s, nil
 }
 
@@ -139,36 +141,9 @@ func executeLogAnalyzer(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 
 // analyzeLogFiles performs the actual log analysis
 func analyzeLogFiles(sourcePath string, searchPatterns []string, fileTypes []string, caseSensitive bool, maxResults, contextLines int, includeTimestamps bool, severityLevels []string) (*LogAnalysisSummary, error) {
-	summary := &LogAnalysisSummary{
-		ErrorLogs:     []LogAnalysisResult{},
-		WarningLogs:   []LogAnalysisResult{},
-		InfoLogs:      []LogAnalysisResult{},
-		FileStats:     make(map[string]int),
-		SeverityStats: make(map[string]int),
-	}
-
-	// Compile regex patterns
-	var patterns []*regexp.Regexp
-	for _, pattern := range searchPatterns {
-		if !caseSensitive {
-			pattern = "(?i)" + pattern
-		}
-		regex, err := regexp.Compile(pattern)
-		if err != nil {
-			continue // Skip invalid patterns
-		}
-		patterns = append(patterns, regex)
-	}
-
-	// Compile severity patterns
-	severityPatterns := make(map[string]*regexp.Regexp)
-	for _, level := range severityLevels {
-		pattern := fmt.Sprintf(`(?i)\b%s\b`, regexp.QuoteMeta(level))
-		regex, err := regexp.Compile(pattern)
-		if err == nil {
-			severityPatterns[level] = regex
-		}
-	}
+	summary := newLogAnalysisSummary()
+	patterns := compileLogPatterns(searchPatterns, caseSensitive)
+	severityPatterns := compileSeverityPatterns(severityLevels)
 
 	// Walk through directory
 	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
@@ -197,22 +172,8 @@ func analyzeLogFiles(sourcePath string, searchPatterns []string, fileTypes []str
 		summary.TotalFiles++
 		fileResults := analyzeLogFile(path, patterns, severityPatterns, maxResults, contextLines, includeTimestamps)
 
-		// Categorize results by severity
 		for _, result := range fileResults {
-			severity := strings.ToUpper(result.Severity)
-			summary.SeverityStats[severity]++
-
-			switch severity {
-			case "ERROR", "CRITICAL", "FATAL":
-				summary.ErrorLogs = append(summary.ErrorLogs, result)
-				summary.TotalErrors++
-			case "WARNING", "WARN":
-				summary.WarningLogs = append(summary.WarningLogs, result)
-				summary.TotalWarnings++
-			case "INFO", "DEBUG":
-				summary.InfoLogs = append(summary.InfoLogs, result)
-				summary.TotalInfo++
-			}
+			addLogAnalysisResult(summary, result)
 		}
 
 		summary.FileStats[path] = len(fileResults)
@@ -226,6 +187,78 @@ func analyzeLogFiles(sourcePath string, searchPatterns []string, fileTypes []str
 	return summary, nil
 }
 
+// newLogAnalysisSummary creates an empty summary
+func newLogAnalysisSummary() *LogAnalysisSummary {
+	return &LogAnalysisSummary{
+		ErrorLogs:     []LogAnalysisResult{},
+		WarningLogs:   []LogAnalysisResult{},
+		InfoLogs:      []LogAnalysisResult{},
+		FileStats:     make(map[string]int),
+		SeverityStats: make(map[string]int),
+	}
+}
+
+// compileLogPatterns compiles the search patterns, skipping invalid ones
+func compileLogPatterns(searchPatterns []string, caseSensitive bool) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, pattern := range searchPatterns {
+		if !caseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			continue // Skip invalid patterns
+		}
+		patterns = append(patterns, regex)
+	}
+	return patterns
+}
+
+// compileSeverityPatterns compiles a whole-word pattern for each severity level
+func compileSeverityPatterns(severityLevels []string) map[string]*regexp.Regexp {
+	severityPatterns := make(map[string]*regexp.Regexp)
+	for _, level := range severityLevels {
+		pattern := fmt.Sprintf(`(?i)\b%s\b`, regexp.QuoteMeta(level))
+		regex, err := regexp.Compile(pattern)
+		if err == nil {
+			severityPatterns[level] = regex
+		}
+	}
+	return severityPatterns
+}
+
+// logLineSeverity returns the level field of JFrog service log lines, otherwise the severity level
+// mentioned in the line, or UNKNOWN
+func logLineSeverity(line string, severityPatterns map[string]*regexp.Regexp) string {
+	if level := jfrogLogLevel(line); level != "" {
+		return level
+	}
+	for level, severityPattern := range severityPatterns {
+		if severityPattern.MatchString(line) {
+			return level
+		}
+	}
+	return "UNKNOWN"
+}
+
+// addLogAnalysisResult categorizes a result by severity and adds it to the summary
+func addLogAnalysisResult(summary *LogAnalysisSummary, result LogAnalysisResult) {
+	severity := strings.ToUpper(result.Severity)
+	summary.SeverityStats[severity]++
+
+	switch severity {
+	case "ERROR", "CRITICAL", "FATAL":
+		summary.ErrorLogs = append(summary.ErrorLogs, result)
+		summary.TotalErrors++
+	case "WARNING", "WARN":
+		summary.WarningLogs = append(summary.WarningLogs, result)
+		summary.TotalWarnings++
+	case "INFO", "DEBUG":
+		summary.InfoLogs = append(summary.InfoLogs, result)
+		summary.TotalInfo++
+	}
+}
+
 // analyzeLogFile analyzes a single log file
 func analyzeLogFile(filePath string, patterns []*regexp.Regexp, severityPatterns map[string]*regexp.Regexp, maxResults, contextLines int, includeTimestamps bool) []LogAnalysisResult {
 	var results []LogAnalysisResult
@@ -252,13 +285,7 @@ func analyzeLogFile(filePath string, patterns []*regexp.Regexp, severityPatterns
 		for _, pattern := range patterns {
 			if pattern.MatchString(line) {
 				// Determine severity
-				severity := "UNKNOWN"
-				for level, severityPattern := range severityPatterns {
-					if severityPattern.MatchString(line) {
-						severity = level
-						break
-					}
-				}
+				severity := logLineSeverity(line, severityPatterns)
 
 				// Extract timestamp if requested
 				timestamp := ""
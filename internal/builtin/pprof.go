@@ -0,0 +1,841 @@
+package builtin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxPprofProfileBytes bounds a decompressed profile; heap profiles of busy services stay well below it
+const maxPprofProfileBytes = 128 * 1024 * 1024
+
+// pprofRuntimePrefixes are skipped when looking for the code a goroutine or contention belongs to
+var pprofRuntimePrefixes = []string{"runtime.", "runtime/", "sync.", "internal/", "syscall.", "time.Sleep"}
+
+// pprofValueType is a sample type or period type of a profile, e.g. cpu/nanoseconds
+type pprofValueType struct {
+	typ  int64
+	unit int64
+}
+
+// pprofSample is one stack with its values, leaf location first
+type pprofSample struct {
+	locations []uint64
+	values    []int64
+}
+
+// pprofLine is one function of a location; inlined calls give a location several lines
+type pprofLine struct {
+	function uint64
+	line     int64
+}
+
+// pprofLocation is a program counter and the functions it belongs to, innermost first
+type pprofLocation struct {
+	address uint64
+	lines   []pprofLine
+}
+
+// pprofFunction is a function name and source file, as indexes in the string table
+type pprofFunction struct {
+	name     int64
+	filename int64
+}
+
+// pprofProfile is a decoded profile.proto message, the format of Go pprof profiles
+type pprofProfile struct {
+	sampleTypes       []pprofValueType
+	samples           []pprofSample
+	locations         map[uint64]pprofLocation
+	functions         map[uint64]pprofFunction
+	strings           []string
+	timeNanos         int64
+	durationNanos     int64
+	defaultSampleType int64
+}
+
+// pprofFrame is one resolved frame of a sample stack
+type pprofFrame struct {
+	function string
+	file     string
+	line     int64
+}
+
+// PprofFunctionStat is the flat and cumulative value of a function
+type PprofFunctionStat struct {
+	Function    string  `json:"function"`
+	Flat        int64   `json:"flat"`
+	FlatPercent float64 `json:"flat_percent"`
+	Cum         int64   `json:"cum"`
+	CumPercent  float64 `json:"cum_percent"`
+	Formatted   string  `json:"formatted"`
+}
+
+// PprofStack is a group of samples with the same stack, e.g. goroutines parked at the same place
+type PprofStack struct {
+	Value     int64            `json:"value"`
+	Percent   float64          `json:"percent"`
+	Formatted string           `json:"formatted"`
+	Values    map[string]int64 `json:"values,omitempty"`
+	Frames    []string         `json:"frames"`
+	site      string
+}
+
+// PprofProfileAnalysis is the analysis of one profile file
+type PprofProfileAnalysis struct {
+	FilePath    string              `json:"file_path"`
+	Service     string              `json:"service,omitempty"`
+	Kind        string              `json:"kind"`
+	SampleTypes []string            `json:"sample_types"`
+	SampleType  string              `json:"sample_type"`
+	Samples     int                 `json:"samples"`
+	Total       int64               `json:"total"`
+	Formatted   string              `json:"total_formatted"`
+	Time        string              `json:"time,omitempty"`
+	Duration    string              `json:"duration,omitempty"`
+	Summary     string              `json:"summary"`
+	TopFlat     []PprofFunctionStat `json:"top_flat"`
+	TopCum      []PprofFunctionStat `json:"top_cum"`
+	StackCount  int                 `json:"stack_count"`
+	Stacks      []PprofStack        `json:"stacks"`
+}
+
+// PprofFunctionDiff is the change of a function's value between a base and a current profile
+type PprofFunctionDiff struct {
+	Function  string `json:"function"`
+	BaseFlat  int64  `json:"base_flat"`
+	Flat      int64  `json:"flat"`
+	DeltaFlat int64  `json:"delta_flat"`
+	BaseCum   int64  `json:"base_cum"`
+	Cum       int64  `json:"cum"`
+	DeltaCum  int64  `json:"delta_cum"`
+	Formatted string `json:"formatted_delta"`
+}
+
+// PprofDiff compares two profiles of the same kind
+type PprofDiff struct {
+	BasePath   string              `json:"base_path"`
+	SampleType string              `json:"sample_type"`
+	BaseTotal  int64               `json:"base_total"`
+	Total      int64               `json:"total"`
+	Delta      int64               `json:"delta"`
+	Formatted  string              `json:"formatted_delta"`
+	TopFlat    []PprofFunctionDiff `json:"top_flat"`
+	TopCum     []PprofFunctionDiff `json:"top_cum"`
+}
+
+// PprofReport is the result of pprof_analyze
+type PprofReport struct {
+	SourcePath string                  `json:"source_path"`
+	Profiles   []*PprofProfileAnalysis `json:"profiles"`
+	Diff       *PprofDiff              `json:"diff,omitempty"`
+	Skipped    []string                `json:"skipped,omitempty"`
+	Duration   string                  `json:"duration"`
+}
+
+// pprofOptions holds the options of one pprof_analyze call
+type pprofOptions struct {
+	sampleType string
+	top        int
+	depth      int
+}
+
+// defaultPprofOptions are used when profiles are analyzed as part of support_bundle_analyze
+var defaultPprofOptions = pprofOptions{top: 5, depth: 10}
+
+// addPprofTools registers the Go profile analysis tool
+func addPprofTools(s *server.MCPServer) {
+	pprofTool := mcp.NewTool("pprof_analyze",
+		mcp.WithDescription("Analyze Go pprof profiles (thread_dumps/*.pprof of the Go-based JFrog services in a support bundle: cpu_profile, heap_dump, heap_dump_allocs, stack_all, stack_blocking, stack_mutex, stack_threadcreate): top functions by flat and cumulative value, goroutines grouped by stack, blocking and mutex contention hotspots, and the difference between two profiles"),
+		mcp.WithString("source_path",
+			mcp.Description("Path to a .pprof file, or a directory searched for .pprof files, e.g. a service's thread_dumps directory (default: ./support-bundle)"),
+		),
+		mcp.WithString("base_path",
+			mcp.Description("Optional base .pprof file to diff against; source_path must then be a single file of the same kind"),
+		),
+		mcp.WithString("sample_type",
+			mcp.Description("Sample type to rank by, e.g. cpu, samples, inuse_space, inuse_objects, alloc_space, alloc_objects, delay, contentions (default: the profile's default)"),
+		),
+		mcp.WithNumber("top",
+			mcp.Description("Number of functions and stacks to list per profile (default: 10)"),
+		),
+		mcp.WithNumber("stack_depth",
+			mcp.Description("Number of frames shown per stack (default: 10)"),
+		),
+	)
+
+	s.AddTool(pprofTool, executePprofAnalyze)
+}
+
+// executePprofAnalyze handles the pprof_analyze tool execution
+func executePprofAnalyze(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	sourcePath := request.GetString("source_path", "./support-bundle")
+	basePath := request.GetString("base_path", "")
+	options := pprofOptions{
+		sampleType: request.GetString("sample_type", ""),
+		top:        int(request.GetFloat("top", 10)),
+		depth:      int(request.GetFloat("stack_depth", 10)),
+	}
+	if options.top <= 0 {
+		options.top = 10
+	}
+	if options.depth <= 0 {
+		options.depth = 10
+	}
+
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("source path does not exist: %s", sourcePath)), nil
+	}
+	report := &PprofReport{SourcePath: sourcePath, Profiles: []*PprofProfileAnalysis{}}
+
+	if basePath != "" {
+		if info.IsDir() {
+			return mcp.NewToolResultError("source_path must be a single profile when base_path is set"), nil
+		}
+		base, err := loadPprofProfile(basePath)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read base profile: %v", err)), nil
+		}
+		current, err := loadPprofProfile(sourcePath)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to read profile: %v", err)), nil
+		}
+		analysis, err := analyzePprofProfile(current, sourcePath, options)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if report.Diff, err = diffPprofProfiles(base, current, analysis.SampleType, options.top); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		report.Diff.BasePath = basePath
+		report.Profiles = append(report.Profiles, analysis)
+	} else {
+		files := []string{sourcePath}
+		if info.IsDir() {
+			files = nil
+			err = filepath.WalkDir(sourcePath, func(filePath string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return nil // Skip unreadable directories
+				}
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				if !entry.IsDir() && isPprofFile(entry.Name()) {
+					files = append(files, filePath)
+				}
+				return nil
+			})
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to search for profiles: %v", err)), nil
+			}
+		}
+
+		// A bad file in a directory is reported rather than failing the whole call
+		for _, filePath := range files {
+			profile, err := loadPprofProfile(filePath)
+			if err == nil {
+				var analysis *PprofProfileAnalysis
+				if analysis, err = analyzePprofProfile(profile, filePath, options); err == nil {
+					report.Profiles = append(report.Profiles, analysis)
+					continue
+				}
+			}
+			if !info.IsDir() {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to analyze %s: %v", filePath, err)), nil
+			}
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s: %v", filePath, err))
+		}
+		if len(report.Profiles) == 0 && len(report.Skipped) == 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("no .pprof files found under %s", sourcePath)), nil
+		}
+	}
+	report.Duration = time.Since(startTime).String()
+
+	resultJSON, err := json.Marshal(report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// isPprofFile reports whether a file name looks like a Go profile
+func isPprofFile(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".pprof") || strings.HasSuffix(name, ".pb.gz")
+}
+
+// loadPprofProfile reads and decodes a plain or gzipped profile file
+func loadPprofProfile(filePath string) (*pprofProfile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return decodePprofProfile(data)
+}
+
+// decodePprofProfile decodes a profile.proto message, gunzipping it first if needed
+func decodePprofProfile(data []byte) (*pprofProfile, error) {
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %v", err)
+		}
+		if data, err = io.ReadAll(io.LimitReader(reader, maxPprofProfileBytes+1)); err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %v", err)
+		}
+		if len(data) > maxPprofProfileBytes {
+			return nil, fmt.Errorf("profile is larger than %s once decompressed", formatBytes(maxPprofProfileBytes))
+		}
+	}
+
+	profile := &pprofProfile{locations: make(map[uint64]pprofLocation), functions: make(map[uint64]pprofFunction)}
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error {
+		var err error
+		switch num {
+		case 1: // sample_type
+			var valueType pprofValueType
+			valueType, err = decodePprofValueType(payload)
+			profile.sampleTypes = append(profile.sampleTypes, valueType)
+		case 2: // sample
+			var sample pprofSample
+			sample, err = decodePprofSample(payload)
+			profile.samples = append(profile.samples, sample)
+		case 4: // location
+			var id uint64
+			var location pprofLocation
+			id, location, err = decodePprofLocation(payload)
+			profile.locations[id] = location
+		case 5: // function
+			var id uint64
+			var function pprofFunction
+			id, function, err = decodePprofFunction(payload)
+			profile.functions[id] = function
+		case 6: // string_table
+			profile.strings = append(profile.strings, string(payload))
+		case 9:
+			profile.timeNanos = int64(value)
+		case 10:
+			profile.durationNanos = int64(value)
+		case 14:
+			profile.defaultSampleType = int64(value)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid profile: %v", err)
+	}
+	if len(profile.sampleTypes) == 0 {
+		return nil, fmt.Errorf("invalid profile: no sample types")
+	}
+	return profile, nil
+}
+
+// walkProtoFields calls visit for each field of a protobuf message, with the value of varint
+// fields and the payload of length-delimited fields
+func walkProtoFields(data []byte, visit func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		var value uint64
+		var payload []byte
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			payload, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if err := visit(num, typ, value, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendProtoVarints appends a repeated varint field, which is either packed or one value per field
+func appendProtoVarints(values []uint64, typ protowire.Type, value uint64, payload []byte) ([]uint64, error) {
+	if typ == protowire.VarintType {
+		return append(values, value), nil
+	}
+	for len(payload) > 0 {
+		v, n := protowire.ConsumeVarint(payload)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		values = append(values, v)
+		payload = payload[n:]
+	}
+	return values, nil
+}
+
+// decodePprofValueType decodes a ValueType message
+func decodePprofValueType(data []byte) (pprofValueType, error) {
+	var valueType pprofValueType
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error {
+		switch num {
+		case 1:
+			valueType.typ = int64(value)
+		case 2:
+			valueType.unit = int64(value)
+		}
+		return nil
+	})
+	return valueType, err
+}
+
+// decodePprofSample decodes a Sample message; labels are not used
+func decodePprofSample(data []byte) (pprofSample, error) {
+	var sample pprofSample
+	var values []uint64
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error {
+		var err error
+		switch num {
+		case 1:
+			sample.locations, err = appendProtoVarints(sample.locations, typ, value, payload)
+		case 2:
+			values, err = appendProtoVarints(values, typ, value, payload)
+		}
+		return err
+	})
+	for _, value := range values {
+		sample.values = append(sample.values, int64(value))
+	}
+	return sample, err
+}
+
+// decodePprofLocation decodes a Location message and its lines
+func decodePprofLocation(data []byte) (uint64, pprofLocation, error) {
+	var id uint64
+	var location pprofLocation
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error {
+		switch num {
+		case 1:
+			id = value
+		case 3:
+			location.address = value
+		case 4:
+			var line pprofLine
+			err := walkProtoFields(payload, func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error {
+				switch num {
+				case 1:
+					line.function = value
+				case 2:
+					line.line = int64(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			location.lines = append(location.lines, line)
+		}
+		return nil
+	})
+	return id, location, err
+}
+
+// decodePprofFunction decodes a Function message
+func decodePprofFunction(data []byte) (uint64, pprofFunction, error) {
+	var id uint64
+	var function pprofFunction
+	err := walkProtoFields(data, func(num protowire.Number, typ protowire.Type, value uint64, payload []byte) error {
+		switch num {
+		case 1:
+			id = value
+		case 2:
+			function.name = int64(value)
+		case 4:
+			function.filename = int64(value)
+		}
+		return nil
+	})
+	return id, function, err
+}
+
+// str returns an entry of the string table, or "" for an invalid index
+func (p *pprofProfile) str(index int64) string {
+	if index < 0 || index >= int64(len(p.strings)) {
+		return ""
+	}
+	return p.strings[index]
+}
+
+// sampleType returns a sample type as type/unit
+func (p *pprofProfile) sampleType(i int) string {
+	return p.str(p.sampleTypes[i].typ) + "/" + p.str(p.sampleTypes[i].unit)
+}
+
+// sampleIndex returns the index of a sample type by name, or the default sample type when name is empty
+func (p *pprofProfile) sampleIndex(name string) (int, error) {
+	if name == "" {
+		for i, valueType := range p.sampleTypes {
+			if p.defaultSampleType != 0 && valueType.typ == p.defaultSampleType {
+				return i, nil
+			}
+		}
+		// Like the pprof tool, fall back to the last sample type, e.g. cpu, inuse_space or delay
+		return len(p.sampleTypes) - 1, nil
+	}
+	var available []string
+	for i, valueType := range p.sampleTypes {
+		if strings.EqualFold(p.str(valueType.typ), name) || strings.EqualFold(p.sampleType(i), name) {
+			return i, nil
+		}
+		available = append(available, p.str(valueType.typ))
+	}
+	return 0, fmt.Errorf("sample type %q is not in the profile (available: %s)", name, strings.Join(available, ", "))
+}
+
+// frames resolves the stack of a sample, leaf first. Unsymbolized locations show their address,
+// and samples without a stack, as in threadcreate profiles of recent Go versions, get a placeholder
+func (p *pprofProfile) frames(sample pprofSample) []pprofFrame {
+	if len(sample.locations) == 0 {
+		return []pprofFrame{{function: "(no stack)"}}
+	}
+	var frames []pprofFrame
+	for _, id := range sample.locations {
+		location, ok := p.locations[id]
+		if !ok || len(location.lines) == 0 {
+			frames = append(frames, pprofFrame{function: fmt.Sprintf("0x%x", location.address)})
+			continue
+		}
+		for _, line := range location.lines {
+			function := p.functions[line.function]
+			frames = append(frames, pprofFrame{function: p.str(function.name), file: p.str(function.filename), line: line.line})
+		}
+	}
+	return frames
+}
+
+// pprofProfileKind names the kind of a profile from its file name, or else its sample types
+func pprofProfileKind(filePath string, profile *pprofProfile) string {
+	name := strings.ToLower(filepath.Base(filePath))
+	for _, kind := range []struct{ marker, kind string }{
+		{"cpu", "cpu"}, {"allocs", "allocs"}, {"heap", "heap"}, {"goroutine", "goroutine"}, {"stack_all", "goroutine"},
+		{"mutex", "mutex"}, {"block", "block"}, {"threadcreate", "threadcreate"},
+	} {
+		if strings.Contains(name, kind.marker) {
+			return kind.kind
+		}
+	}
+	types := make(map[string]bool)
+	for _, valueType := range profile.sampleTypes {
+		types[profile.str(valueType.typ)] = true
+	}
+	switch {
+	case types["cpu"]:
+		return "cpu"
+	case types["inuse_space"]:
+		return "heap"
+	case types["goroutine"]:
+		return "goroutine"
+	case types["delay"]:
+		return "contention"
+	case types["threadcreate"]:
+		return "threadcreate"
+	}
+	return "unknown"
+}
+
+// formatPprofValue formats a value in its unit, e.g. 1.5s for nanoseconds or 12.0 MB for bytes
+func formatPprofValue(value int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(value).String()
+	case "bytes":
+		if value < 0 {
+			return "-" + formatBytes(-value)
+		}
+		return formatBytes(value)
+	case "count", "":
+		return strconv.FormatInt(value, 10)
+	}
+	return fmt.Sprintf("%d %s", value, unit)
+}
+
+// pprofFunctionValues sums the flat and cumulative value of each function for one sample type.
+// A function appearing several times in a stack, as in recursion, counts once
+func pprofFunctionValues(profile *pprofProfile, index int) (flat, cum map[string]int64, total int64) {
+	flat = make(map[string]int64)
+	cum = make(map[string]int64)
+	for _, sample := range profile.samples {
+		if index >= len(sample.values) || sample.values[index] == 0 {
+			continue
+		}
+		value := sample.values[index]
+		total += value
+		frames := profile.frames(sample)
+		flat[frames[0].function] += value
+		seen := make(map[string]bool)
+		for _, frame := range frames {
+			if !seen[frame.function] {
+				seen[frame.function] = true
+				cum[frame.function] += value
+			}
+		}
+	}
+	return flat, cum, total
+}
+
+// analyzePprofProfile computes the top functions and stacks of a profile
+func analyzePprofProfile(profile *pprofProfile, filePath string, options pprofOptions) (*PprofProfileAnalysis, error) {
+	index, err := profile.sampleIndex(options.sampleType)
+	if err != nil {
+		return nil, err
+	}
+	unit := profile.str(profile.sampleTypes[index].unit)
+
+	analysis := &PprofProfileAnalysis{
+		FilePath:   filePath,
+		Kind:       pprofProfileKind(filePath, profile),
+		SampleType: profile.str(profile.sampleTypes[index].typ),
+		Samples:    len(profile.samples),
+		TopFlat:    []PprofFunctionStat{},
+		TopCum:     []PprofFunctionStat{},
+		Stacks:     []PprofStack{},
+	}
+	for i := range profile.sampleTypes {
+		analysis.SampleTypes = append(analysis.SampleTypes, profile.sampleType(i))
+	}
+	// Bundles lay profiles out as <node>/<service>/thread_dumps/<file>
+	if dir := filepath.Dir(filePath); filepath.Base(dir) == "thread_dumps" {
+		analysis.Service = filepath.Base(filepath.Dir(dir))
+	}
+	if profile.timeNanos > 0 {
+		analysis.Time = time.Unix(0, profile.timeNanos).UTC().Format(time.RFC3339)
+	}
+	if profile.durationNanos > 0 {
+		analysis.Duration = time.Duration(profile.durationNanos).String()
+	}
+
+	flat, cum, total := pprofFunctionValues(profile, index)
+	analysis.Total = total
+	analysis.Formatted = formatPprofValue(total, unit)
+	stat := func(function string) PprofFunctionStat {
+		return PprofFunctionStat{
+			Function:    function,
+			Flat:        flat[function],
+			FlatPercent: pprofPercent(flat[function], total),
+			Cum:         cum[function],
+			CumPercent:  pprofPercent(cum[function], total),
+			Formatted:   formatPprofValue(flat[function], unit) + " flat, " + formatPprofValue(cum[function], unit) + " cum",
+		}
+	}
+	for _, function := range topPprofValues(flat, options.top) {
+		analysis.TopFlat = append(analysis.TopFlat, stat(function))
+	}
+	for _, function := range topPprofValues(cum, options.top) {
+		analysis.TopCum = append(analysis.TopCum, stat(function))
+	}
+	analysis.Stacks, analysis.StackCount = pprofStacks(profile, index, total, options)
+	analysis.Summary = pprofSummary(analysis)
+	return analysis, nil
+}
+
+// pprofStacks groups the samples of a profile by stack and returns the largest groups with the
+// number of distinct stacks. Goroutine profiles give the number of goroutines per stack; block and
+// mutex profiles the contention hotspots
+func pprofStacks(profile *pprofProfile, index int, total int64, options pprofOptions) ([]PprofStack, int) {
+	unit := profile.str(profile.sampleTypes[index].unit)
+	groups := make(map[string]*PprofStack)
+	var order []string
+	for _, sample := range profile.samples {
+		if index >= len(sample.values) || sample.values[index] == 0 {
+			continue
+		}
+		var frames []string
+		for _, frame := range profile.frames(sample) {
+			if frame.file != "" {
+				frames = append(frames, fmt.Sprintf("%s (%s:%d)", frame.function, filepath.Base(frame.file), frame.line))
+			} else {
+				frames = append(frames, frame.function)
+			}
+		}
+		key := strings.Join(frames, "\n")
+		group, ok := groups[key]
+		if !ok {
+			group = &PprofStack{Values: make(map[string]int64), Frames: frames[:min(options.depth, len(frames))], site: pprofAppFrame(frames)}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.Value += sample.values[index]
+		if len(profile.sampleTypes) > 1 {
+			for i, value := range sample.values {
+				if i < len(profile.sampleTypes) {
+					group.Values[profile.str(profile.sampleTypes[i].typ)] += value
+				}
+			}
+		}
+	}
+
+	stacks := make([]PprofStack, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		group.Percent = pprofPercent(group.Value, total)
+		group.Formatted = formatPprofValue(group.Value, unit)
+		if len(group.Values) == 0 {
+			group.Values = nil
+		}
+		stacks = append(stacks, *group)
+	}
+	sort.SliceStable(stacks, func(i, j int) bool { return abs64(stacks[i].Value) > abs64(stacks[j].Value) })
+	if len(stacks) > options.top {
+		stacks = stacks[:options.top]
+	}
+	return stacks, len(order)
+}
+
+// pprofSummary describes a profile in one sentence
+func pprofSummary(analysis *PprofProfileAnalysis) string {
+	if analysis.Total == 0 {
+		return fmt.Sprintf("%s profile with no %s samples", analysis.Kind, analysis.SampleType)
+	}
+	where := ""
+	if len(analysis.Stacks) > 0 {
+		where = analysis.Stacks[0].site
+	}
+	switch analysis.Kind {
+	case "goroutine":
+		return fmt.Sprintf("%d goroutines in %d distinct stacks; the largest group has %d at %s", analysis.Total, analysis.StackCount, analysis.Stacks[0].Value, where)
+	case "block", "mutex", "contention":
+		return fmt.Sprintf("%s %s %s, %.1f%% of it at %s", analysis.Formatted, analysis.Kind, analysis.SampleType, analysis.Stacks[0].Percent, where)
+	case "threadcreate":
+		return fmt.Sprintf("%d OS threads created", analysis.Total)
+	}
+	top := analysis.TopFlat[0]
+	return fmt.Sprintf("%s %s in %s profile; top function %s with %.1f%% flat", analysis.Formatted, analysis.SampleType, analysis.Kind, top.Function, top.FlatPercent)
+}
+
+// pprofAppFrame returns the first frame outside the runtime and standard synchronization
+// packages, which tells where a goroutine waits
+func pprofAppFrame(frames []string) string {
+	for _, frame := range frames {
+		if !hasAnyPrefix(frame, pprofRuntimePrefixes) {
+			return frame
+		}
+	}
+	if len(frames) > 0 {
+		return frames[0]
+	}
+	return ""
+}
+
+// diffPprofProfiles compares the function values of two profiles for one sample type
+func diffPprofProfiles(base, current *pprofProfile, sampleType string, top int) (*PprofDiff, error) {
+	baseIndex, err := base.sampleIndex(sampleType)
+	if err != nil {
+		return nil, fmt.Errorf("base profile: %v", err)
+	}
+	currentIndex, err := current.sampleIndex(sampleType)
+	if err != nil {
+		return nil, err
+	}
+	unit := current.str(current.sampleTypes[currentIndex].unit)
+
+	baseFlat, baseCum, baseTotal := pprofFunctionValues(base, baseIndex)
+	flat, cum, total := pprofFunctionValues(current, currentIndex)
+	diff := &PprofDiff{
+		SampleType: sampleType,
+		BaseTotal:  baseTotal,
+		Total:      total,
+		Delta:      total - baseTotal,
+		Formatted:  formatPprofValue(total-baseTotal, unit),
+		TopFlat:    []PprofFunctionDiff{},
+		TopCum:     []PprofFunctionDiff{},
+	}
+
+	deltaFlat := make(map[string]int64)
+	deltaCum := make(map[string]int64)
+	for _, values := range []map[string]int64{baseCum, cum} {
+		for function := range values {
+			if delta := flat[function] - baseFlat[function]; delta != 0 {
+				deltaFlat[function] = delta
+			}
+			if delta := cum[function] - baseCum[function]; delta != 0 {
+				deltaCum[function] = delta
+			}
+		}
+	}
+	entry := func(function string, delta int64) PprofFunctionDiff {
+		return PprofFunctionDiff{
+			Function:  function,
+			BaseFlat:  baseFlat[function],
+			Flat:      flat[function],
+			DeltaFlat: flat[function] - baseFlat[function],
+			BaseCum:   baseCum[function],
+			Cum:       cum[function],
+			DeltaCum:  cum[function] - baseCum[function],
+			Formatted: formatPprofValue(delta, unit),
+		}
+	}
+	for _, function := range topPprofValues(deltaFlat, top) {
+		diff.TopFlat = append(diff.TopFlat, entry(function, deltaFlat[function]))
+	}
+	for _, function := range topPprofValues(deltaCum, top) {
+		diff.TopCum = append(diff.TopCum, entry(function, deltaCum[function]))
+	}
+	return diff, nil
+}
+
+// topPprofValues returns the keys with the largest absolute values, ties by name
+func topPprofValues(values map[string]int64, top int) []string {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		if value != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if a, b := abs64(values[keys[i]]), abs64(values[keys[j]]); a != b {
+			return a > b
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > top {
+		keys = keys[:top]
+	}
+	return keys
+}
+
+// pprofPercent returns value as a percentage of total, rounded to two decimals
+func pprofPercent(value, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(value)/float64(total)*10000) / 100
+}
+
+// abs64 returns the absolute value of an int64
+func abs64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,187 @@
+package builtin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// testPprofSample is a stack of function names, leaf first, with its values
+type testPprofSample struct {
+	stack  []string
+	values []int64
+}
+
+// buildTestPprof encodes a gzipped profile the way runtime/pprof writes it, with packed sample fields
+func buildTestPprof(t *testing.T, sampleTypes [][2]string, samples []testPprofSample) []byte {
+	t.Helper()
+	strs := []string{""}
+	index := func(value string) uint64 {
+		for i, s := range strs {
+			if s == value {
+				return uint64(i)
+			}
+		}
+		strs = append(strs, value)
+		return uint64(len(strs) - 1)
+	}
+	message := func(fields ...func([]byte) []byte) []byte {
+		var b []byte
+		for _, field := range fields {
+			b = field(b)
+		}
+		return b
+	}
+	varint := func(num protowire.Number, value uint64) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, num, protowire.VarintType)
+			return protowire.AppendVarint(b, value)
+		}
+	}
+	embedded := func(num protowire.Number, payload []byte) func([]byte) []byte {
+		return func(b []byte) []byte {
+			b = protowire.AppendTag(b, num, protowire.BytesType)
+			return protowire.AppendBytes(b, payload)
+		}
+	}
+
+	var profile []byte
+	for _, sampleType := range sampleTypes {
+		profile = embedded(1, message(varint(1, index(sampleType[0])), varint(2, index(sampleType[1]))))(profile)
+	}
+	ids := make(map[string]uint64)
+	for _, sample := range samples {
+		var locations, values []byte
+		for _, function := range sample.stack {
+			if ids[function] == 0 {
+				ids[function] = uint64(len(ids) + 1)
+				id := ids[function]
+				profile = embedded(5, message(varint(1, id), varint(2, index(function)), varint(4, index("/src/"+function+".go"))))(profile)
+				profile = embedded(4, message(varint(1, id), embedded(4, message(varint(1, id), varint(2, 10*id)))))(profile)
+			}
+			locations = protowire.AppendVarint(locations, ids[function])
+		}
+		for _, value := range sample.values {
+			values = protowire.AppendVarint(values, uint64(value))
+		}
+		profile = embedded(2, message(embedded(1, locations), embedded(2, values)))(profile)
+	}
+	for _, s := range strs {
+		profile = embedded(6, []byte(s))(profile)
+	}
+	profile = varint(10, 3e9)(profile)
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write(profile)
+	writer.Close()
+	return buf.Bytes()
+}
+
+func TestAnalyzePprofProfile(t *testing.T) {
+	data := buildTestPprof(t, [][2]string{{"samples", "count"}, {"cpu", "nanoseconds"}}, []testPprofSample{
+		{[]string{"runtime.memmove", "app.encode", "main.main"}, []int64{3, 30e6}},
+		{[]string{"app.encode", "main.main"}, []int64{1, 10e6}},
+		{[]string{"app.hash", "main.main"}, []int64{6, 60e6}},
+	})
+	profile, err := decodePprofProfile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis, err := analyzePprofProfile(profile, filepath.Join("node", "metadata", "thread_dumps", "cpu_profile.pprof"), pprofOptions{top: 2, depth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Kind != "cpu" || analysis.Service != "metadata" || analysis.SampleType != "cpu" || analysis.Total != 100e6 || analysis.Duration != "3s" {
+		t.Errorf("Unexpected analysis %+v", analysis)
+	}
+	if len(analysis.TopFlat) != 2 || analysis.TopFlat[0].Function != "app.hash" || analysis.TopFlat[0].FlatPercent != 60 {
+		t.Errorf("Unexpected top flat %+v", analysis.TopFlat)
+	}
+	if analysis.TopCum[0].Function != "main.main" || analysis.TopCum[1].Function != "app.hash" {
+		t.Errorf("Unexpected top cum %+v", analysis.TopCum)
+	}
+	if analysis.StackCount != 3 || len(analysis.Stacks) != 2 || len(analysis.Stacks[1].Frames) != 2 || analysis.Stacks[1].Frames[0] != "runtime.memmove (runtime.memmove.go:10)" || analysis.Stacks[1].Values["samples"] != 3 {
+		t.Errorf("Unexpected stacks %+v", analysis.Stacks)
+	}
+	if !strings.Contains(analysis.Summary, "100ms cpu") || !strings.Contains(analysis.Summary, "app.hash") {
+		t.Errorf("Unexpected summary %s", analysis.Summary)
+	}
+
+	if analysis, _ = analyzePprofProfile(profile, "cpu.pprof", pprofOptions{sampleType: "samples", top: 5, depth: 5}); analysis.Total != 10 {
+		t.Errorf("Expected 10 samples, got %d", analysis.Total)
+	}
+	if _, err = analyzePprofProfile(profile, "cpu.pprof", pprofOptions{sampleType: "inuse_space", top: 5, depth: 5}); err == nil || !strings.Contains(err.Error(), "samples, cpu") {
+		t.Errorf("Expected unknown sample type error, got %v", err)
+	}
+	if _, err = decodePprofProfile([]byte("not a profile")); err == nil {
+		t.Error("Expected garbage to be rejected")
+	}
+
+	// A small archive must not inflate without bound
+	var bomb bytes.Buffer
+	writer, _ := gzip.NewWriterLevel(&bomb, gzip.BestSpeed)
+	writer.Write(make([]byte, maxPprofProfileBytes+1))
+	writer.Close()
+	if _, err = decodePprofProfile(bomb.Bytes()); err == nil || !strings.Contains(err.Error(), "larger than 128.0 MB") {
+		t.Errorf("Expected the size limit error, got %v", err)
+	}
+}
+
+func TestPprofGoroutinesAndContention(t *testing.T) {
+	data := buildTestPprof(t, [][2]string{{"goroutine", "count"}}, []testPprofSample{
+		{[]string{"runtime.gopark", "runtime.selectgo", "app.worker"}, []int64{12}},
+		{[]string{"runtime.gopark", "app.worker"}, []int64{1}},
+		{[]string{"runtime.gopark", "runtime.selectgo", "app.worker"}, []int64{3}},
+	})
+	profile, _ := decodePprofProfile(data)
+	analysis, err := analyzePprofProfile(profile, "stack_all_0.pprof", pprofOptions{top: 5, depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Kind != "goroutine" || analysis.Total != 16 || analysis.StackCount != 2 || analysis.Stacks[0].Value != 15 {
+		t.Errorf("Unexpected goroutine analysis %+v", analysis)
+	}
+	if analysis.Summary != "16 goroutines in 2 distinct stacks; the largest group has 15 at app.worker (app.worker.go:30)" {
+		t.Errorf("Unexpected summary %s", analysis.Summary)
+	}
+
+	data = buildTestPprof(t, [][2]string{{"contentions", "count"}, {"delay", "nanoseconds"}}, []testPprofSample{
+		{[]string{"sync.(*Mutex).Unlock", "app.cache"}, []int64{4, 3e9}},
+		{[]string{"sync.(*Mutex).Unlock", "app.store"}, []int64{1, 1e9}},
+	})
+	profile, _ = decodePprofProfile(data)
+	analysis, _ = analyzePprofProfile(profile, "stack_mutex_0.pprof", defaultPprofOptions)
+	if analysis.Kind != "mutex" || analysis.Summary != "4s mutex delay, 75.0% of it at app.cache (app.cache.go:20)" {
+		t.Errorf("Unexpected mutex analysis %+v", analysis)
+	}
+}
+
+func TestDiffPprofProfiles(t *testing.T) {
+	sampleTypes := [][2]string{{"inuse_space", "bytes"}}
+	base, _ := decodePprofProfile(buildTestPprof(t, sampleTypes, []testPprofSample{
+		{[]string{"app.load", "main.main"}, []int64{1000}},
+		{[]string{"app.cache", "main.main"}, []int64{500}},
+	}))
+	current, _ := decodePprofProfile(buildTestPprof(t, sampleTypes, []testPprofSample{
+		{[]string{"app.cache", "main.main"}, []int64{5000}},
+		{[]string{"app.index", "main.main"}, []int64{200}},
+	}))
+	diff, err := diffPprofProfiles(base, current, "inuse_space", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Delta != 3700 || diff.BaseTotal != 1500 || diff.Total != 5200 {
+		t.Errorf("Unexpected totals %+v", diff)
+	}
+	if len(diff.TopFlat) != 3 || diff.TopFlat[0].Function != "app.cache" || diff.TopFlat[0].DeltaFlat != 4500 || diff.TopFlat[1].Function != "app.load" || diff.TopFlat[1].DeltaFlat != -1000 {
+		t.Errorf("Unexpected flat diff %+v", diff.TopFlat)
+	}
+	if diff.TopCum[0].Function != "app.cache" || diff.TopCum[1].Function != "main.main" || diff.TopCum[1].DeltaCum != 3700 {
+		t.Errorf("Unexpected cum diff %+v", diff.TopCum)
+	}
+}
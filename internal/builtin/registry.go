@@ -3,6 +3,8 @@ package builtin
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/mark3labs/mcp-filesystem-server/filesystemserver"
@@ -73,33 +75,9 @@ func (r *Registry) ListServers() []string {
 // registerFilesystemServer registers the filesystem server
 func (r *Registry) registerFilesystemServer() {
 	r.servers["fs"] = func(options map[string]any, model model.ToolCallingChatModel) (*BuiltinServerWrapper, error) {
-		// Extract allowed directories from options
-		var allowedDirs []string
-		if dirs, ok := options["allowed_directories"]; ok {
-			switch v := dirs.(type) {
-			case []string:
-				allowedDirs = v
-			case []any:
-				allowedDirs = make([]string, len(v))
-				for i, dir := range v {
-					if s, ok := dir.(string); ok {
-						allowedDirs[i] = s
-					} else {
-						return nil, fmt.Errorf("allowed_directories must be an array of strings")
-					}
-				}
-			case string:
-				allowedDirs = []string{v}
-			default:
-				return nil, fmt.Errorf("allowed_directories must be a string or array of strings")
-			}
-		} else {
-			// Default to current working directory if no directories specified
-			cwd, err := os.Getwd()
-			if err != nil {
-				return nil, fmt.Errorf("failed to get current working directory: %v", err)
-			}
-			allowedDirs = []string{cwd}
+		allowedDirs, err := getAllowedDirectoriesOption(options)
+		if err != nil {
+			return nil, err
 		}
 
 		// Create the filesystem server
@@ -228,3 +206,85 @@ func (r *Registry) registerSSHServer() {
 		return &BuiltinServerWrapper{server: server}, nil
 	}
 }
+
+// getAllowedDirectoriesOption reads the allowed_directories option of the servers that touch local files, defaulting to the working directory
+func getAllowedDirectoriesOption(options map[string]any) ([]string, error) {
+	dirs, ok := options["allowed_directories"]
+	if !ok {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current working directory: %v", err)
+		}
+		return []string{cwd}, nil
+	}
+
+	switch v := dirs.(type) {
+	case []string:
+		return v, nil
+	case []any:
+		allowedDirs := make([]string, len(v))
+		for i, dir := range v {
+			s, ok := dir.(string)
+			if !ok {
+				return nil, fmt.Errorf("allowed_directories must be an array of strings")
+			}
+			allowedDirs[i] = s
+		}
+		return allowedDirs, nil
+	case string:
+		return []string{v}, nil
+	default:
+		return nil, fmt.Errorf("allowed_directories must be a string or array of strings")
+	}
+}
+
+// resolveAllowedLocalPath returns the absolute, symlink-free form of a local path after checking it lies
+// inside one of the allowed directories. Symlinks are resolved on the deepest existing ancestor, so a link
+// inside an allowed directory cannot point a write outside of it
+func resolveAllowedLocalPath(localPath string, allowedDirs []string) (string, error) {
+	absPath, err := filepath.Abs(localPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid local path: %v", err)
+	}
+	resolvedPath, err := resolveExistingSymlinks(absPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid local path: %v", err)
+	}
+
+	for _, dir := range allowedDirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if resolvedDir, err := resolveExistingSymlinks(absDir); err == nil {
+			absDir = resolvedDir
+		}
+		rel, err := filepath.Rel(absDir, resolvedPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolvedPath, nil
+		}
+	}
+
+	return "", fmt.Errorf("local path %s is outside the allowed directories: %s", absPath, strings.Join(allowedDirs, ", "))
+}
+
+// resolveExistingSymlinks resolves the symlinks of the deepest existing ancestor of an absolute path and
+// appends the components that do not exist yet
+func resolveExistingSymlinks(absPath string) (string, error) {
+	existing, rest := absPath, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return absPath, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
@@ -0,0 +1,712 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// requestLogIDSegment matches path segments that identify one object rather than an endpoint
+var requestLogIDSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{16,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// requestLogRepoAPIs are the /api/<type>/<repo>/... endpoints whose third segment is a repository key
+var requestLogRepoAPIs = map[string]bool{
+	"alpine": true, "ansible": true, "bower": true, "cargo": true, "chef": true, "cocoapods": true,
+	"composer": true, "conan": true, "conda": true, "cran": true, "deb": true, "debian": true,
+	"docker": true, "gems": true, "go": true, "helm": true, "huggingfaceml": true, "lfs": true,
+	"npm": true, "nuget": true, "opkg": true, "pub": true, "puppet": true, "pypi": true,
+	"repositories": true, "storage": true, "swift": true, "terraform": true, "vagrant": true, "vcs": true,
+}
+
+// requestLogHealthChecks are probe endpoints excluded by default, as they dominate request counts
+var requestLogHealthChecks = []string{"/system/readiness", "/system/liveness", "/system/ping", "/router/api/v1/system/health"}
+
+// RequestLogEntry is one parsed request from a request, request-out or router request log
+type RequestLogEntry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	Client     string    `json:"client,omitempty"`
+	Username   string    `json:"username,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	DurationMs float64   `json:"duration_ms"`
+	Endpoint   string    `json:"endpoint"`
+	Repository string    `json:"repository,omitempty"`
+	FilePath   string    `json:"file_path"`
+	LineNumber int       `json:"line_number"`
+}
+
+// RequestLatencyStats holds request counts and latency percentiles in milliseconds
+type RequestLatencyStats struct {
+	Name      string  `json:"name,omitempty"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50       float64 `json:"p50_ms"`
+	P95       float64 `json:"p95_ms"`
+	P99       float64 `json:"p99_ms"`
+	Max       float64 `json:"max_ms"`
+	Mean      float64 `json:"mean_ms"`
+}
+
+// RequestClientStats counts the requests of one client IP or user
+type RequestClientStats struct {
+	Name     string  `json:"name"`
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	TotalMs  float64 `json:"total_ms"`
+}
+
+// RequestErrorBurst is a run of time windows with an unusual number of server errors
+type RequestErrorBurst struct {
+	Start        time.Time      `json:"start"`
+	End          time.Time      `json:"end"`
+	Requests     int            `json:"requests"`
+	Errors       int            `json:"errors"`
+	ErrorRate    float64        `json:"error_rate"`
+	StatusCodes  map[string]int `json:"status_codes"`
+	TopEndpoints []string       `json:"top_endpoints"`
+}
+
+// RequestLogStats summarizes the requests of one kind of request log, e.g. artifactory-request
+type RequestLogStats struct {
+	Log          string                `json:"log"`
+	Files        []string              `json:"files"`
+	Requests     int                   `json:"requests"`
+	Unparsed     int                   `json:"unparsed_lines,omitempty"`
+	Start        time.Time             `json:"start"`
+	End          time.Time             `json:"end"`
+	StatusCodes  map[string]int        `json:"status_codes"`
+	StatusClass  map[string]int        `json:"status_classes"`
+	Latency      RequestLatencyStats   `json:"latency"`
+	Endpoints    []RequestLatencyStats `json:"endpoints"`
+	Repositories []RequestLatencyStats `json:"repositories,omitempty"`
+	TopClients   []RequestClientStats  `json:"top_clients"`
+	TopUsers     []RequestClientStats  `json:"top_users,omitempty"`
+	Slowest      []RequestLogEntry     `json:"slowest_requests"`
+	ErrorBursts  []RequestErrorBurst   `json:"error_bursts"`
+
+	entries []RequestLogEntry
+}
+
+// RequestLogReport is the result of request_log_stats
+type RequestLogReport struct {
+	SourcePath string             `json:"source_path"`
+	Since      string             `json:"since,omitempty"`
+	Until      string             `json:"until,omitempty"`
+	Window     string             `json:"burst_window"`
+	Excluded   int                `json:"excluded_health_checks,omitempty"`
+	Logs       []*RequestLogStats `json:"logs"`
+	EmptyLogs  []string           `json:"empty_logs,omitempty"`
+	Warnings   []string           `json:"warnings,omitempty"`
+	Duration   string             `json:"duration"`
+}
+
+// requestLogOptions holds the options of one request_log_stats call
+type requestLogOptions struct {
+	services           []string
+	since              time.Time
+	until              time.Time
+	top                int
+	window             time.Duration
+	burstMinErrors     int
+	includeHealthCheck bool
+}
+
+// addRequestLogTools registers the request log analytics tool
+func addRequestLogTools(s *server.MCPServer) {
+	statsTool := mcp.NewTool("request_log_stats",
+		mcp.WithDescription("Compute request analytics from the request logs in a support bundle (artifactory-request.log, artifactory-request-out.log, router-request.log and the other services' request logs): status code distribution, p50/p95/p99 latency per endpoint and per repository, top clients by IP and user, the slowest requests and bursts of server errors over time"),
+		mcp.WithString("source_path",
+			mcp.Description("Path to the extracted support bundle or log directory (default: ./support-bundle)"),
+		),
+		mcp.WithString("services",
+			mcp.Description("Comma-separated services whose request logs are read, or 'all' (default: 'artifactory,router')"),
+		),
+		mcp.WithString("since",
+			mcp.Description("Only include requests at or after this time: RFC3339, 'YYYY-MM-DD HH:MM:SS', 'YYYY-MM-DD' or a duration before now like '2h'"),
+		),
+		mcp.WithString("until",
+			mcp.Description("Only include requests before this time, in the same formats as since"),
+		),
+		mcp.WithNumber("top",
+			mcp.Description("Number of endpoints, repositories, clients and slowest requests to list (default: 10)"),
+		),
+		mcp.WithString("burst_window",
+			mcp.Description("Window used to detect error bursts, e.g. '1m' or '30s' (default: 1m)"),
+		),
+		mcp.WithNumber("burst_min_errors",
+			mcp.Description("Minimum 5xx responses in a window for it to count as a burst (default: 5)"),
+		),
+		mcp.WithBoolean("include_health_checks",
+			mcp.Description("Include readiness, liveness and ping probes (default: false)"),
+		),
+	)
+
+	s.AddTool(statsTool, executeRequestLogStats)
+}
+
+// executeRequestLogStats handles the request_log_stats tool execution
+func executeRequestLogStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	sourcePath := request.GetString("source_path", "./support-bundle")
+	options := requestLogOptions{
+		services:           parseCommaSeparated(request.GetString("services", "artifactory,router")),
+		top:                int(request.GetFloat("top", 10)),
+		burstMinErrors:     int(request.GetFloat("burst_min_errors", 5)),
+		includeHealthCheck: request.GetBool("include_health_checks", false),
+	}
+
+	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
+		return mcp.NewToolResultError(fmt.Sprintf("source path does not exist: %s", sourcePath)), nil
+	}
+	var err error
+	if options.since, err = parseLogTimeBound(request.GetString("since", ""), startTime); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid since: %v", err)), nil
+	}
+	if options.until, err = parseLogTimeBound(request.GetString("until", ""), startTime); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid until: %v", err)), nil
+	}
+	if options.window, err = time.ParseDuration(request.GetString("burst_window", "1m")); err != nil || options.window <= 0 {
+		return mcp.NewToolResultError("burst_window must be a positive duration such as '1m'"), nil
+	}
+	if options.top <= 0 {
+		options.top = 10
+	}
+
+	report, err := analyzeRequestLogs(ctx, sourcePath, options)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to analyze request logs: %v", err)), nil
+	}
+	if len(report.Logs) == 0 && len(report.EmptyLogs) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no request logs for services %s found under %s", strings.Join(options.services, ","), sourcePath)), nil
+	}
+	report.Duration = time.Since(startTime).String()
+
+	resultJSON, err := json.Marshal(report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// analyzeRequestLogs reads every matching request log under sourcePath and computes stats per log
+func analyzeRequestLogs(ctx context.Context, sourcePath string, options requestLogOptions) (*RequestLogReport, error) {
+	report := &RequestLogReport{SourcePath: sourcePath, Window: options.window.String(), Logs: []*RequestLogStats{}}
+	if !options.since.IsZero() {
+		report.Since = options.since.Format(time.RFC3339)
+	}
+	if !options.until.IsZero() {
+		report.Until = options.until.Format(time.RFC3339)
+	}
+	allServices := len(options.services) == 0 || containsFold(options.services, "all")
+
+	logs := map[string]*RequestLogStats{}
+	err := walkJFrogLogFiles(sourcePath, func(filePath string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		service, _, kind := jfrogLogFileInfo(filePath)
+		if kind != "request" && kind != "request_out" {
+			return nil
+		}
+		if !allServices && !containsFold(options.services, service) {
+			return nil
+		}
+
+		// Rotated files such as artifactory-request.2025-08-24T05-57-00.000.log.gz share the log name
+		name := strings.SplitN(filepath.Base(filePath), ".", 2)[0]
+		stats, ok := logs[name]
+		if !ok {
+			stats = &RequestLogStats{Log: name}
+			logs[name] = stats
+		}
+		stats.Files = append(stats.Files, filePath)
+		excluded, warning, err := readRequestLog(filePath, service, kind, options, stats)
+		report.Excluded += excluded
+		if warning != "" {
+			report.Warnings = append(report.Warnings, warning)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name, stats := range logs {
+		if len(stats.entries) == 0 && stats.Unparsed == 0 {
+			report.EmptyLogs = append(report.EmptyLogs, name)
+			continue
+		}
+		summarizeRequestLog(stats, options)
+		report.Logs = append(report.Logs, stats)
+	}
+	sort.Strings(report.EmptyLogs)
+	sort.Slice(report.Logs, func(i, j int) bool { return report.Logs[i].Log < report.Logs[j].Log })
+	return report, nil
+}
+
+// readRequestLog parses the requests of one file into stats, returning how many health checks were skipped
+// and a warning when the file could not be read completely
+func readRequestLog(filePath, service, kind string, options requestLogOptions, stats *RequestLogStats) (int, string, error) {
+	reader, err := openJFrogLogFile(filePath)
+	if err != nil {
+		return 0, fmt.Sprintf("%v; the file was skipped", err), nil
+	}
+	defer reader.Close()
+
+	excluded := 0
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxJFrogLogLineBytes)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		entry, ok := parseRequestLogLine(line, kind)
+		if !ok {
+			stats.Unparsed++
+			continue
+		}
+		if (!options.since.IsZero() && entry.Timestamp.Before(options.since)) || (!options.until.IsZero() && !entry.Timestamp.Before(options.until)) {
+			continue
+		}
+		if !options.includeHealthCheck && isRequestHealthCheck(entry.Path) {
+			excluded++
+			continue
+		}
+
+		// The router serves Artifactory under /artifactory; strip it so endpoints match artifactory-request.log
+		path := entry.Path
+		if service == "router" {
+			path = strings.TrimPrefix(path, "/artifactory")
+		}
+		if kind == "request_out" {
+			// Outbound requests are grouped per remote host; the repository comes from the log itself
+			entry.Endpoint = entry.Method + " " + entry.Client
+		} else {
+			entry.Endpoint, entry.Repository = requestLogEndpoint(entry.Method, path)
+		}
+		entry.FilePath = filePath
+		entry.LineNumber = lineNumber
+		stats.entries = append(stats.entries, entry)
+	}
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return excluded, fmt.Sprintf("stopped reading %s after line %d: the next line is longer than %s; the requests after it are missing", filePath, lineNumber, formatBytes(maxJFrogLogLineBytes)), nil
+	} else if err != nil {
+		return excluded, "", fmt.Errorf("failed to read %s: %v", filePath, err)
+	}
+	return excluded, "", nil
+}
+
+// parseRequestLogLine parses a pipe-delimited request log line or a JSON router access log line
+func parseRequestLogLine(line, kind string) (RequestLogEntry, bool) {
+	if strings.HasPrefix(line, "{") {
+		return parseRouterRequestLine(line)
+	}
+
+	fields := strings.Split(line, "|")
+	if len(fields) < 6 {
+		return RequestLogEntry{}, false
+	}
+	timestamp, err := parseJFrogLogTime(fields[0])
+	if err != nil {
+		return RequestLogEntry{}, false
+	}
+	entry := RequestLogEntry{Timestamp: timestamp, TraceID: fields[1]}
+
+	if kind == "request" {
+		// timestamp|trace|remote address|user|method|path|status|request length|response length|duration|user agent
+		if len(fields) < 10 {
+			return RequestLogEntry{}, false
+		}
+		entry.Client, entry.Username, entry.Method = fields[2], fields[3], fields[4]
+		entry.Path = strings.SplitN(fields[5], "?", 2)[0]
+		entry.Status, err = strconv.Atoi(fields[6])
+		if err != nil {
+			return RequestLogEntry{}, false
+		}
+		entry.DurationMs, _ = strconv.ParseFloat(fields[9], 64)
+		return entry, true
+	}
+
+	// Outbound formats differ per service; all have the URL, then or after the method, then the
+	// status, and end with the duration. Artifactory also logs the remote repository and user
+	urlIndex := -1
+	for i := 2; i < len(fields); i++ {
+		if strings.Contains(fields[i], "://") {
+			urlIndex = i
+			break
+		}
+	}
+	if urlIndex < 0 {
+		return RequestLogEntry{}, false
+	}
+	statusIndex := urlIndex + 1
+	if isHTTPMethod(fields[urlIndex+1:]) {
+		entry.Method = fields[urlIndex+1]
+		statusIndex++
+	} else if isHTTPMethod(fields[urlIndex-1 : urlIndex]) {
+		entry.Method = fields[urlIndex-1]
+		if urlIndex >= 4 {
+			entry.Username, entry.Repository = fields[urlIndex-2], fields[urlIndex-3]
+		}
+	}
+	if statusIndex >= len(fields) {
+		return RequestLogEntry{}, false
+	}
+	entry.Status, err = strconv.Atoi(fields[statusIndex])
+	if err != nil {
+		return RequestLogEntry{}, false
+	}
+	entry.DurationMs, _ = strconv.ParseFloat(fields[len(fields)-1], 64)
+
+	if target, err := url.Parse(fields[urlIndex]); err == nil {
+		entry.Client, entry.Path = target.Host, target.Path
+	} else {
+		entry.Path = fields[urlIndex]
+	}
+	return entry, true
+}
+
+// parseRouterRequestLine parses a router-request.log line, which is a JSON Traefik access log entry
+func parseRouterRequestLine(line string) (RequestLogEntry, bool) {
+	var record struct {
+		ClientHost       string  `json:"ClientHost"`
+		ClientUsername   string  `json:"ClientUsername"`
+		DownstreamStatus int     `json:"DownstreamStatus"`
+		Duration         float64 `json:"Duration"`
+		RequestMethod    string  `json:"RequestMethod"`
+		RequestPath      string  `json:"RequestPath"`
+		StartUTC         string  `json:"StartUTC"`
+		Time             string  `json:"time"`
+		TraceID          string  `json:"request_Uber-Trace-Id"`
+		JFrogTraceID     string  `json:"request_X-Jfrog-Trace-Id"`
+	}
+	if err := json.Unmarshal([]byte(line), &record); err != nil || record.RequestMethod == "" {
+		return RequestLogEntry{}, false
+	}
+
+	timestamp, err := parseJFrogLogTime(record.StartUTC)
+	if err != nil {
+		if timestamp, err = parseJFrogLogTime(record.Time); err != nil {
+			return RequestLogEntry{}, false
+		}
+	}
+	entry := RequestLogEntry{
+		Timestamp:  timestamp,
+		TraceID:    record.JFrogTraceID,
+		Client:     record.ClientHost,
+		Method:     record.RequestMethod,
+		Path:       strings.SplitN(record.RequestPath, "?", 2)[0],
+		Status:     record.DownstreamStatus,
+		DurationMs: record.Duration / float64(time.Millisecond),
+	}
+	if entry.TraceID == "" {
+		// Uber trace IDs are trace:span:parent:flags
+		entry.TraceID = strings.SplitN(record.TraceID, ":", 2)[0]
+	}
+	if record.ClientUsername != "-" {
+		entry.Username = record.ClientUsername
+	}
+	return entry, true
+}
+
+// isHTTPMethod reports whether the first field is an HTTP method
+func isHTTPMethod(fields []string) bool {
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// isRequestHealthCheck reports whether a path is a readiness, liveness or ping probe
+func isRequestHealthCheck(path string) bool {
+	for _, suffix := range requestLogHealthChecks {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestLogEndpoint groups a request path into an endpoint and extracts its repository. API paths
+// keep their shape with IDs replaced; artifact paths are grouped per repository
+func requestLogEndpoint(method, path string) (string, string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return method + " /", ""
+	}
+
+	repository := ""
+	switch segments[0] {
+	case "api":
+		if len(segments) > 2 && requestLogRepoAPIs[segments[1]] {
+			repository = segments[2]
+			return fmt.Sprintf("%s /api/%s/{repo}", method, segments[1]), repository
+		}
+	case "ui", "webapp", "access", "router", "mc", "xray", "distribution", "metadata", "event", "v1":
+	default:
+		// Artifact paths: /<repo>/<path>
+		return method + " /{repo}/**", segments[0]
+	}
+
+	const maxEndpointSegments = 5
+	for i, segment := range segments {
+		if requestLogIDSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	if len(segments) > maxEndpointSegments {
+		segments = append(segments[:maxEndpointSegments], "**")
+	}
+	return method + " /" + strings.Join(segments, "/"), repository
+}
+
+// summarizeRequestLog computes the statistics of the collected requests
+func summarizeRequestLog(stats *RequestLogStats, options requestLogOptions) {
+	entries := stats.entries
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+
+	stats.Requests = len(entries)
+	stats.StatusCodes = map[string]int{}
+	stats.StatusClass = map[string]int{}
+	stats.Endpoints = []RequestLatencyStats{}
+	stats.TopClients = []RequestClientStats{}
+	stats.Slowest = []RequestLogEntry{}
+	stats.ErrorBursts = []RequestErrorBurst{}
+	if len(entries) == 0 {
+		return
+	}
+	stats.Start = entries[0].Timestamp
+	stats.End = entries[len(entries)-1].Timestamp
+
+	endpoints := map[string][]RequestLogEntry{}
+	repositories := map[string][]RequestLogEntry{}
+	clients := map[string]*RequestClientStats{}
+	users := map[string]*RequestClientStats{}
+	for _, entry := range entries {
+		stats.StatusCodes[strconv.Itoa(entry.Status)]++
+		stats.StatusClass[fmt.Sprintf("%dxx", entry.Status/100)]++
+		endpoints[entry.Endpoint] = append(endpoints[entry.Endpoint], entry)
+		if entry.Repository != "" {
+			repositories[entry.Repository] = append(repositories[entry.Repository], entry)
+		}
+		addRequestClient(clients, requestClientName(entry.Client), entry)
+		if entry.Username != "" {
+			addRequestClient(users, entry.Username, entry)
+		}
+	}
+
+	stats.Latency = requestLatency("", entries)
+	stats.Endpoints = topRequestLatencies(endpoints, options.top)
+	stats.Repositories = topRequestLatencies(repositories, options.top)
+	stats.TopClients = topRequestClients(clients, options.top)
+	stats.TopUsers = topRequestClients(users, options.top)
+
+	slowest := append([]RequestLogEntry(nil), entries...)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].DurationMs > slowest[j].DurationMs })
+	if len(slowest) > options.top {
+		slowest = slowest[:options.top]
+	}
+	stats.Slowest = slowest
+	stats.ErrorBursts = findRequestErrorBursts(entries, options.window, options.burstMinErrors)
+}
+
+// requestClientName strips the port from a client address
+func requestClientName(client string) string {
+	if host, _, err := net.SplitHostPort(client); err == nil {
+		return host
+	}
+	return client
+}
+
+// addRequestClient counts a request for a client or user
+func addRequestClient(clients map[string]*RequestClientStats, name string, entry RequestLogEntry) {
+	if name == "" {
+		name = "-"
+	}
+	client, ok := clients[name]
+	if !ok {
+		client = &RequestClientStats{Name: name}
+		clients[name] = client
+	}
+	client.Requests++
+	client.TotalMs += entry.DurationMs
+	if entry.Status >= 500 {
+		client.Errors++
+	}
+}
+
+// topRequestClients returns the clients with the most requests
+func topRequestClients(clients map[string]*RequestClientStats, top int) []RequestClientStats {
+	result := make([]RequestClientStats, 0, len(clients))
+	for _, client := range clients {
+		client.TotalMs = math.Round(client.TotalMs*100) / 100
+		result = append(result, *client)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// topRequestLatencies returns latency stats for the groups with the most requests
+func topRequestLatencies(groups map[string][]RequestLogEntry, top int) []RequestLatencyStats {
+	result := make([]RequestLatencyStats, 0, len(groups))
+	for name, entries := range groups {
+		result = append(result, requestLatency(name, entries))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// requestLatency computes counts and nearest-rank latency percentiles for a group of requests
+func requestLatency(name string, entries []RequestLogEntry) RequestLatencyStats {
+	stats := RequestLatencyStats{Name: name, Requests: len(entries)}
+	if len(entries) == 0 {
+		return stats
+	}
+
+	durations := make([]float64, len(entries))
+	total := 0.0
+	for i, entry := range entries {
+		durations[i] = entry.DurationMs
+		total += entry.DurationMs
+		if entry.Status >= 500 {
+			stats.Errors++
+		}
+	}
+	sort.Float64s(durations)
+
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p/100*float64(len(durations)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return durations[rank]
+	}
+	stats.P50, stats.P95, stats.P99 = percentile(50), percentile(95), percentile(99)
+	stats.Max = durations[len(durations)-1]
+	stats.Mean = math.Round(total/float64(len(durations))*100) / 100
+	stats.ErrorRate = math.Round(float64(stats.Errors)/float64(len(entries))*10000) / 10000
+	return stats
+}
+
+// findRequestErrorBursts buckets time-ordered requests into windows and merges adjacent windows with
+// at least minErrors server errors into bursts
+func findRequestErrorBursts(entries []RequestLogEntry, window time.Duration, minErrors int) []RequestErrorBurst {
+	if minErrors <= 0 {
+		minErrors = 1
+	}
+
+	type bucket struct {
+		start   time.Time
+		entries []RequestLogEntry
+		errors  int
+	}
+	var buckets []*bucket
+	for _, entry := range entries {
+		start := entry.Timestamp.Truncate(window)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].start.Equal(start) {
+			buckets = append(buckets, &bucket{start: start})
+		}
+		current := buckets[len(buckets)-1]
+		current.entries = append(current.entries, entry)
+		if entry.Status >= 500 {
+			current.errors++
+		}
+	}
+
+	bursts := []RequestErrorBurst{}
+	var current *RequestErrorBurst
+	endpoints := map[string]int{}
+	finish := func() {
+		if current == nil {
+			return
+		}
+		current.ErrorRate = math.Round(float64(current.Errors)/float64(current.Requests)*10000) / 10000
+		current.TopEndpoints = topCounts(endpoints, 3)
+		bursts = append(bursts, *current)
+		current, endpoints = nil, map[string]int{}
+	}
+
+	for _, b := range buckets {
+		if b.errors < minErrors {
+			finish()
+			continue
+		}
+		if current != nil && !current.End.Equal(b.start) {
+			finish()
+		}
+		if current == nil {
+			current = &RequestErrorBurst{Start: b.start, StatusCodes: map[string]int{}}
+		}
+		current.End = b.start.Add(window)
+		current.Requests += len(b.entries)
+		current.Errors += b.errors
+		for _, entry := range b.entries {
+			if entry.Status >= 500 {
+				current.StatusCodes[strconv.Itoa(entry.Status)]++
+				endpoints[entry.Endpoint]++
+			}
+		}
+	}
+	finish()
+	return bursts
+}
+
+// topCounts returns the keys with the highest counts
+func topCounts(counts map[string]int, top int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > top {
+		keys = keys[:top]
+	}
+	return keys
+}
@@ -0,0 +1,124 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRequestLogLine(t *testing.T) {
+	entry, ok := parseRequestLogLine("2025-08-24T06:01:54.253Z|0000000000000000367|192.168.65.3|admin|GET|/api/npm/npm-remote/lodash?meta=1|200|-1|512|501|npm/10", "request")
+	if !ok || entry.Client != "192.168.65.3" || entry.Username != "admin" || entry.Path != "/api/npm/npm-remote/lodash" || entry.Status != 200 || entry.DurationMs != 501 {
+		t.Errorf("Unexpected request entry %+v", entry)
+	}
+	if endpoint, repo := requestLogEndpoint(entry.Method, entry.Path); endpoint != "GET /api/npm/{repo}" || repo != "npm-remote" {
+		t.Errorf("Unexpected endpoint %s and repo %s", endpoint, repo)
+	}
+
+	// Artifactory logs the remote repository and user; other services only the URL
+	entry, ok = parseRequestLogLine("2025-08-24T06:02:00.000Z|abc|maven-remote|admin|GET|https://repo1.maven.org/maven2/junit/junit/4.13/junit-4.13.pom|200|0|1200|2300", "request_out")
+	if !ok || entry.Repository != "maven-remote" || entry.Client != "repo1.maven.org" || entry.Method != "GET" || entry.DurationMs != 2300 {
+		t.Errorf("Unexpected artifactory outbound entry %+v", entry)
+	}
+	entry, ok = parseRequestLogLine("2025-08-24T06:01:54.906Z|33a435ea12ba1ea1|https://jes.jfrog.io/api/v1/register|POST|201|-1|946|529", "request_out")
+	if !ok || entry.Method != "POST" || entry.Status != 201 || entry.DurationMs != 529 || entry.Path != "/api/v1/register" {
+		t.Errorf("Unexpected jfconnect outbound entry %+v", entry)
+	}
+
+	entry, ok = parseRequestLogLine(`{"ClientHost":"10.0.0.7","DownstreamStatus":502,"Duration":1500000000,"RequestMethod":"PUT","RequestPath":"/artifactory/libs-release/a.jar","StartUTC":"2025-08-24T06:00:00.5Z","request_Uber-Trace-Id":"5e2dd0f6b5c1adf8:1:0:1"}`, "request")
+	if !ok || entry.DurationMs != 1500 || entry.Status != 502 || entry.TraceID != "5e2dd0f6b5c1adf8" || entry.Client != "10.0.0.7" {
+		t.Errorf("Unexpected router entry %+v", entry)
+	}
+
+	if endpoint, repo := requestLogEndpoint("GET", "/libs-release/org/foo/1.0/foo-1.0.jar"); endpoint != "GET /{repo}/**" || repo != "libs-release" {
+		t.Errorf("Artifact paths must be grouped per repository, got %s %s", endpoint, repo)
+	}
+	if endpoint, _ := requestLogEndpoint("DELETE", "/api/security/token/3738b641-bf93-440a-8745-2e00fe08236a"); endpoint != "DELETE /api/security/token/{id}" {
+		t.Errorf("IDs must be replaced, got %s", endpoint)
+	}
+	if _, ok := parseRequestLogLine("not a request", "request"); ok {
+		t.Error("Expected garbage to be rejected")
+	}
+}
+
+func TestAnalyzeRequestLogs(t *testing.T) {
+	bundle := t.TempDir()
+	dir := filepath.Join(bundle, "artifactory", "jfrt", "artifactory-0", "artifactory", "logs")
+	os.MkdirAll(dir, 0755)
+
+	var lines []string
+	start := time.Date(2025, 8, 24, 6, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		// One request per second: durations 1..100 ms, with a run of 503s in the third minute
+		status := 200
+		if i >= 60 && i < 70 {
+			status = 503
+		}
+		client := "10.0.0.1"
+		if i%4 == 0 {
+			client = "10.0.0.2"
+		}
+		lines = append(lines, fmt.Sprintf("%s|trace%d|%s|user%d|GET|/libs-release/pkg/%d.jar|%d|-1|10|%d|curl", start.Add(time.Duration(i)*time.Second).Format("2006-01-02T15:04:05.000Z"), i, client, i%2, i, status, i+1))
+	}
+	lines = append(lines, start.Format("2006-01-02T15:04:05.000Z")+"|probe|127.0.0.1|anonymous|GET|/api/v1/system/readiness|200|-1|0|1|curl", "garbage")
+	os.WriteFile(filepath.Join(dir, "artifactory-request.log"), []byte(strings.Join(lines, "\n")), 0644)
+	os.WriteFile(filepath.Join(dir, "artifactory-request-out.log"), nil, 0644)
+
+	options := requestLogOptions{services: []string{"artifactory"}, top: 3, window: time.Minute, burstMinErrors: 5}
+	report, err := analyzeRequestLogs(context.Background(), bundle, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Logs) != 1 || report.Excluded != 1 || len(report.EmptyLogs) != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	stats := report.Logs[0]
+	if stats.Requests != 100 || stats.Unparsed != 1 || stats.StatusCodes["503"] != 10 || stats.StatusClass["2xx"] != 90 {
+		t.Errorf("Unexpected counts %+v", stats)
+	}
+	if stats.Latency.P50 != 50 || stats.Latency.P95 != 95 || stats.Latency.P99 != 99 || stats.Latency.Max != 100 || stats.Latency.ErrorRate != 0.1 {
+		t.Errorf("Unexpected latency %+v", stats.Latency)
+	}
+	if len(stats.Repositories) != 1 || stats.Repositories[0].Name != "libs-release" || stats.Endpoints[0].Name != "GET /{repo}/**" {
+		t.Errorf("Unexpected groups %+v %+v", stats.Repositories, stats.Endpoints)
+	}
+	if stats.TopClients[0].Name != "10.0.0.1" || stats.TopClients[0].Requests != 75 || len(stats.TopUsers) != 2 {
+		t.Errorf("Unexpected clients %+v users %+v", stats.TopClients, stats.TopUsers)
+	}
+	if len(stats.Slowest) != 3 || stats.Slowest[0].DurationMs != 100 {
+		t.Errorf("Unexpected slowest %+v", stats.Slowest)
+	}
+	if len(stats.ErrorBursts) != 1 || stats.ErrorBursts[0].Errors != 10 || !stats.ErrorBursts[0].Start.Equal(start.Add(time.Minute)) || stats.ErrorBursts[0].StatusCodes["503"] != 10 {
+		t.Errorf("Unexpected bursts %+v", stats.ErrorBursts)
+	}
+
+	// Time filtering keeps the first minute only
+	options.until = start.Add(time.Minute)
+	report, _ = analyzeRequestLogs(context.Background(), bundle, options)
+	if report.Logs[0].Requests != 60 || len(report.Logs[0].ErrorBursts) != 0 {
+		t.Errorf("Expected 60 requests without bursts, got %+v", report.Logs[0])
+	}
+}
+
+func TestAnalyzeRequestLogsUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	line := "2025-08-24T06:00:00.000Z|trace|10.0.0.1|admin|GET|/libs-release/pkg.jar|200|-1|10|5|curl"
+	long := strings.Repeat("x", maxJFrogLogLineBytes+1)
+	os.WriteFile(filepath.Join(dir, "artifactory-request.log"), []byte(line+"\n"+long+"\n"+line+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "artifactory-request.2025-08-23T00-00-00.000.log.gz"), []byte("not gzip"), 0644)
+
+	report, err := analyzeRequestLogs(context.Background(), dir, requestLogOptions{top: 3, window: time.Minute, burstMinErrors: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Logs) != 1 || report.Logs[0].Requests != 1 {
+		t.Fatalf("Expected the request before the long line, got %+v", report)
+	}
+	if len(report.Warnings) != 2 || !strings.Contains(report.Warnings[0], "failed to decompress") || !strings.Contains(report.Warnings[1], "stopped reading") || !strings.Contains(report.Warnings[1], "after line 1") {
+		t.Errorf("Expected warnings for both files, got %q", report.Warnings)
+	}
+}
@@ -0,0 +1,434 @@
+package builtin
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Authentication method names accepted in SSHServerConfig.AuthMethods
+const (
+	sshAuthPublicKey           = "publickey"
+	sshAuthAgent               = "agent"
+	sshAuthPassword            = "password"
+	sshAuthKeyboardInteractive = "keyboard-interactive"
+)
+
+// defaultSSHAuthMethods is the order methods are tried in when none are configured
+var defaultSSHAuthMethods = []string{sshAuthPublicKey, sshAuthAgent, sshAuthPassword, sshAuthKeyboardInteractive}
+
+// maxSSHJumpDepth bounds ProxyJump chains, including jump hosts that have their own proxy_jump
+const maxSSHJumpDepth = 8
+
+// sshAuthMethods builds the ordered authentication methods for an instance. The returned cleanup
+// function releases the ssh-agent connection and must be called once the handshake is done.
+func sshAuthMethods(config *SSHServerConfig) ([]ssh.AuthMethod, func(), error) {
+	order := config.AuthMethods
+	explicit := len(order) > 0
+	if !explicit {
+		order = defaultSSHAuthMethods
+	}
+
+	cleanup := func() {}
+	var methods []ssh.AuthMethod
+	var signers []ssh.Signer
+	publicKeyAdded := false
+
+	// Keys and agent identities are both "publickey" auth, and the SSH client only tries each
+	// method name once, so their signers are merged into a single method in configured order
+	addPublicKeyMethod := func() {
+		if !publicKeyAdded {
+			publicKeyAdded = true
+			methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				return signers, nil
+			}))
+		}
+	}
+
+	for _, method := range order {
+		switch strings.ToLower(strings.TrimSpace(method)) {
+		case sshAuthPublicKey:
+			keySigners, err := sshKeySigners(config)
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			if len(keySigners) == 0 {
+				if explicit {
+					cleanup()
+					return nil, nil, fmt.Errorf("auth method 'publickey' requires private_key or key_path")
+				}
+				continue
+			}
+			signers = append(signers, keySigners...)
+			addPublicKeyMethod()
+		case sshAuthAgent:
+			agentSigners, closeAgent, err := sshAgentSigners(config)
+			if err != nil {
+				if explicit {
+					cleanup()
+					return nil, nil, err
+				}
+				continue
+			}
+			previous := cleanup
+			cleanup = func() {
+				previous()
+				closeAgent()
+			}
+			if len(agentSigners) == 0 {
+				continue
+			}
+			signers = append(signers, agentSigners...)
+			addPublicKeyMethod()
+		case sshAuthPassword:
+			if config.Password == "" {
+				if explicit {
+					cleanup()
+					return nil, nil, fmt.Errorf("auth method 'password' requires a password")
+				}
+				continue
+			}
+			methods = append(methods, ssh.Password(config.Password))
+		case sshAuthKeyboardInteractive:
+			if config.Password == "" {
+				continue
+			}
+			methods = append(methods, ssh.KeyboardInteractive(sshPasswordChallenge(config.Password)))
+		default:
+			cleanup()
+			return nil, nil, fmt.Errorf("unknown auth method '%s' (expected publickey, agent, password or keyboard-interactive)", method)
+		}
+	}
+
+	if len(methods) == 0 {
+		cleanup()
+		return nil, nil, fmt.Errorf("no authentication method available (configure a password, private key, or an ssh-agent via SSH_AUTH_SOCK)")
+	}
+
+	return methods, cleanup, nil
+}
+
+// sshKeySigners loads the configured private key, decrypting it and attaching a user certificate when present
+func sshKeySigners(config *SSHServerConfig) ([]ssh.Signer, error) {
+	var keyBytes []byte
+	source := "private_key"
+
+	if config.PrivateKey != "" {
+		keyBytes = []byte(config.PrivateKey)
+	} else if config.KeyPath != "" {
+		keyPath, err := expandSSHPath(config.KeyPath)
+		if err != nil {
+			return nil, err
+		}
+		keyBytes, err = os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key file: %v", err)
+		}
+		source = keyPath
+	} else {
+		return nil, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase := sshKeyPassphrase(config)
+		if passphrase == "" {
+			return nil, fmt.Errorf("private key %s is encrypted; set passphrase or passphrase_env", source)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key %s: %v", source, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+
+	certPath := config.CertificatePath
+	if certPath == "" && config.KeyPath != "" && config.PrivateKey == "" {
+		// Follow the OpenSSH convention of looking for <key>-cert.pub next to the key
+		if candidate, err := expandSSHPath(config.KeyPath + "-cert.pub"); err == nil {
+			if _, err := os.Stat(candidate); err == nil {
+				certPath = candidate
+			}
+		}
+	}
+	if certPath == "" {
+		return []ssh.Signer{signer}, nil
+	}
+
+	certSigner, err := sshCertificateSigner(certPath, signer)
+	if err != nil {
+		return nil, err
+	}
+	// Offer the certificate first and fall back to the bare key for hosts that do not trust the CA
+	return []ssh.Signer{certSigner, signer}, nil
+}
+
+// sshKeyPassphrase returns the key passphrase from the config or the named environment variable
+func sshKeyPassphrase(config *SSHServerConfig) string {
+	if config.Passphrase != "" {
+		return config.Passphrase
+	}
+	if config.PassphraseEnv != "" {
+		return os.Getenv(config.PassphraseEnv)
+	}
+	return ""
+}
+
+// sshCertificateSigner wraps a signer with the OpenSSH user certificate at certPath
+func sshCertificateSigner(certPath string, signer ssh.Signer) (ssh.Signer, error) {
+	path, err := expandSSHPath(certPath)
+	if err != nil {
+		return nil, err
+	}
+	certBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %v", err)
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(certBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %v", path, err)
+	}
+	cert, ok := publicKey.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is a public key, not an OpenSSH certificate", path)
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("%s is not a user certificate", path)
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && time.Now().Unix() >= int64(cert.ValidBefore) {
+		return nil, fmt.Errorf("certificate %s expired at %s", path, time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.RFC3339))
+	}
+
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate %s does not match the private key: %v", path, err)
+	}
+	return certSigner, nil
+}
+
+// sshAgentSigners returns the identities held by the ssh-agent at agent_socket or SSH_AUTH_SOCK
+func sshAgentSigners(config *SSHServerConfig) ([]ssh.Signer, func(), error) {
+	socket := config.AgentSocket
+	if socket == "" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if socket == "" {
+		return nil, nil, fmt.Errorf("auth method 'agent' requires SSH_AUTH_SOCK or agent_socket")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to ssh-agent at %s: %v", socket, err)
+	}
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to list ssh-agent identities: %v", err)
+	}
+	return signers, func() { conn.Close() }, nil
+}
+
+// sshPasswordChallenge answers keyboard-interactive prompts with the configured password
+func sshPasswordChallenge(password string) ssh.KeyboardInteractiveChallenge {
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i := range questions {
+			if !echos[i] {
+				answers[i] = password
+			}
+		}
+		return answers, nil
+	}
+}
+
+// sshClientConfig builds the client configuration (auth and host key verification) for an instance
+func sshClientConfig(config *SSHServerConfig, address string) (*ssh.ClientConfig, func(), error) {
+	authMethods, cleanup, err := sshAuthMethods(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hostKeyCallback, err := sshHostKeyCallback(config)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return &ssh.ClientConfig{
+		User:              config.Username,
+		Auth:              authMethods,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: sshKnownHostKeyAlgorithms(config, address),
+		Timeout:           time.Duration(config.Timeout) * time.Second,
+	}, cleanup, nil
+}
+
+// sshAddress returns the host:port dial address for an instance
+func sshAddress(config *SSHServerConfig) string {
+	port := config.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(config.Host, strconv.Itoa(port))
+}
+
+// dialSSHClient connects to an instance, hopping through its proxy_jump chain when configured
+func dialSSHClient(config *SSHServerConfig, depth int) (*ssh.Client, error) {
+	if depth > maxSSHJumpDepth {
+		return nil, fmt.Errorf("proxy_jump chain is longer than %d hops (is there a loop?)", maxSSHJumpDepth)
+	}
+
+	if strings.TrimSpace(config.ProxyJump) == "" {
+		address := sshAddress(config)
+		clientConfig, cleanup, err := sshClientConfig(config, address)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		return ssh.Dial("tcp", address, clientConfig)
+	}
+
+	hops, err := resolveSSHJumpHosts(config)
+	if err != nil {
+		return nil, err
+	}
+
+	bastion, err := dialSSHClient(hops[0], depth+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to jump host %s: %v", sshAddress(hops[0]), err)
+	}
+	for _, hop := range hops[1:] {
+		bastion, err = dialSSHThrough(bastion, hop)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to jump host %s: %v", sshAddress(hop), err)
+		}
+	}
+	return dialSSHThrough(bastion, config)
+}
+
+// dialSSHThrough opens an SSH connection to config tunnelled through bastion. The bastion is
+// closed when the returned client closes, or immediately if the connection fails.
+func dialSSHThrough(bastion *ssh.Client, config *SSHServerConfig) (*ssh.Client, error) {
+	address := sshAddress(config)
+	clientConfig, cleanup, err := sshClientConfig(config, address)
+	if err != nil {
+		bastion.Close()
+		return nil, err
+	}
+	defer cleanup()
+
+	conn, err := bastion.Dial("tcp", address)
+	if err != nil {
+		bastion.Close()
+		return nil, fmt.Errorf("jump host could not reach %s: %v", address, err)
+	}
+
+	type handshake struct {
+		conn  ssh.Conn
+		chans <-chan ssh.NewChannel
+		reqs  <-chan *ssh.Request
+		err   error
+	}
+	done := make(chan handshake, 1)
+	go func() {
+		c, chans, reqs, err := ssh.NewClientConn(conn, address, clientConfig)
+		done <- handshake{c, chans, reqs, err}
+	}()
+
+	// Tunnelled connections do not support deadlines, so enforce the dial timeout here
+	var timeout <-chan time.Time
+	if clientConfig.Timeout > 0 {
+		timer := time.NewTimer(clientConfig.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			conn.Close()
+			bastion.Close()
+			return nil, result.err
+		}
+		client := ssh.NewClient(result.conn, result.chans, result.reqs)
+		go func() {
+			client.Wait()
+			bastion.Close()
+		}()
+		return client, nil
+	case <-timeout:
+		conn.Close()
+		bastion.Close()
+		return nil, fmt.Errorf("timed out connecting to %s through jump host", address)
+	}
+}
+
+// resolveSSHJumpHosts turns a comma-separated proxy_jump into host configs. Each entry is either
+// the name of another SSH instance or an inline [user@]host[:port] that reuses the target's credentials.
+func resolveSSHJumpHosts(config *SSHServerConfig) ([]*SSHServerConfig, error) {
+	var hops []*SSHServerConfig
+	for _, entry := range strings.Split(config.ProxyJump, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if globalSSHConfig != nil {
+			if instance, ok := globalSSHConfig.Instances[entry]; ok {
+				hop := instance
+				hops = append(hops, &hop)
+				continue
+			}
+		}
+
+		hop, err := parseSSHJumpHost(entry, config)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(hops, hop)
+	}
+
+	if len(hops) == 0 {
+		return nil, fmt.Errorf("proxy_jump '%s' does not name any hosts", config.ProxyJump)
+	}
+	return hops, nil
+}
+
+// parseSSHJumpHost parses an inline [user@]host[:port] jump host using the target's credentials
+func parseSSHJumpHost(entry string, target *SSHServerConfig) (*SSHServerConfig, error) {
+	hop := *target
+	hop.ProxyJump = ""
+	hop.HostKeyFingerprints = nil
+	hop.Port = 22
+
+	if at := strings.LastIndex(entry, "@"); at >= 0 {
+		hop.Username = entry[:at]
+		entry = entry[at+1:]
+	}
+
+	if host, port, err := net.SplitHostPort(entry); err == nil {
+		parsed, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in jump host '%s'", entry)
+		}
+		hop.Host = host
+		hop.Port = parsed
+	} else {
+		hop.Host = strings.Trim(entry, "[]")
+	}
+
+	if hop.Host == "" {
+		return nil, fmt.Errorf("invalid jump host '%s'", entry)
+	}
+	return &hop, nil
+}
@@ -0,0 +1,172 @@
+package builtin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newTestUserKey generates an ed25519 user key and its signer
+func newTestUserKey(t *testing.T) (ed25519.PrivateKey, ssh.Signer) {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return private, signer
+}
+
+// runTestSSHCommand connects with config and runs a command, returning its output
+func runTestSSHCommand(t *testing.T, config SSHServerConfig, command string) (string, error) {
+	t.Helper()
+
+	client, err := createSSHClient(&config)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	output, err := executeCommandString(client, command, 5)
+	return output, err
+}
+
+func TestSSHAuth_EncryptedKeyAndCertificate(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+	dir := t.TempDir()
+
+	private, signer := newTestUserKey(t)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(private, "", []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt key: %v", err)
+	}
+	keyPath := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600)
+
+	config := server.instance()
+	config.KeyPath = keyPath
+	if _, err := runTestSSHCommand(t, config, "true"); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Fatalf("Expected a missing passphrase error, got %v", err)
+	}
+
+	// The key is not authorized, so only a CA-signed certificate can log in
+	_, caSigner := newTestUserKey(t)
+	server.trustCertificateAuthority(caSigner.PublicKey())
+	cert := &ssh.Certificate{
+		Key:             signer.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"tester"},
+		ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatalf("Failed to sign certificate: %v", err)
+	}
+	os.WriteFile(keyPath+"-cert.pub", ssh.MarshalAuthorizedKey(cert), 0644)
+
+	t.Setenv("TEST_SSH_PASSPHRASE", "hunter2")
+	config.PassphraseEnv = "TEST_SSH_PASSPHRASE"
+	output, err := runTestSSHCommand(t, config, "hostname")
+	if err != nil {
+		t.Fatalf("Certificate login failed: %v", err)
+	}
+	if output != "ran: hostname\n" {
+		t.Errorf("Unexpected output: %q", output)
+	}
+}
+
+func TestSSHAuth_MethodOrderAndAgent(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+
+	// An unauthorized key is tried first, then the password succeeds
+	private, signer := newTestUserKey(t)
+	block, _ := ssh.MarshalPrivateKey(private, "")
+	config := server.instance()
+	config.PrivateKey = string(pem.EncodeToMemory(block))
+	config.Password = "secret"
+	config.AuthMethods = []string{"publickey", "password"}
+	if _, err := runTestSSHCommand(t, config, "true"); err != nil {
+		t.Fatalf("Expected password fallback to succeed: %v", err)
+	}
+
+	config.AuthMethods = []string{"publickey"}
+	if _, err := runTestSSHCommand(t, config, "true"); err == nil {
+		t.Fatal("Expected publickey-only auth to fail for an unauthorized key")
+	}
+
+	// Serve the key from an in-process agent instead
+	keyring := agent.NewKeyring()
+	keyring.Add(agent.AddedKey{PrivateKey: private})
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	server.authorize(signer.PublicKey())
+	agentConfig := server.instance()
+	agentConfig.AgentSocket = socket
+	agentConfig.AuthMethods = []string{"agent"}
+	if _, err := runTestSSHCommand(t, agentConfig, "true"); err != nil {
+		t.Errorf("Agent login failed: %v", err)
+	}
+}
+
+func TestSSHAuth_ProxyJump(t *testing.T) {
+	bastion := newTestSSHServer(t, echoSSHHandler)
+	target := newTestSSHServer(t, func(command string, stdout, stderr io.Writer) int {
+		stdout.Write([]byte("behind the bastion\n"))
+		return 0
+	})
+
+	bastionConfig := bastion.instance()
+	bastionConfig.Password = "secret"
+	targetConfig := target.instance()
+	targetConfig.Password = "secret"
+	targetConfig.ProxyJump = "bastion"
+	useTestSSHConfig(t, map[string]SSHServerConfig{"bastion": bastionConfig, "target": targetConfig})
+
+	output, err := runTestSSHCommand(t, targetConfig, "hostname")
+	if err != nil {
+		t.Fatalf("Jump connection failed: %v", err)
+	}
+	if output != "behind the bastion\n" || target.connections.Load() != 1 || bastion.connections.Load() != 1 {
+		t.Errorf("Unexpected jump result %q (connections: bastion %d, target %d)",
+			output, bastion.connections.Load(), target.connections.Load())
+	}
+
+	hop, err := parseSSHJumpHost("ops@jump.example.com:2222", &targetConfig)
+	if err != nil || hop.Username != "ops" || hop.Host != "jump.example.com" || hop.Port != 2222 || hop.ProxyJump != "" {
+		t.Errorf("Unexpected inline jump host: %+v (%v)", hop, err)
+	}
+
+	looping := targetConfig
+	looping.ProxyJump = "loop"
+	useTestSSHConfig(t, map[string]SSHServerConfig{"loop": looping})
+	if _, err := runTestSSHCommand(t, looping, "true"); err == nil || !strings.Contains(err.Error(), "loop") {
+		t.Errorf("Expected a loop error, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// defaultSSHMaxOutputBytes caps stdout and stderr separately
+	defaultSSHMaxOutputBytes = 64 * 1024
+	// sshSignalGracePeriod is how long a timed-out command gets to exit after SIGTERM before the session is closed
+	sshSignalGracePeriod = 2 * time.Second
+	// sshProgressInterval is how often partial output is streamed for long-running commands
+	sshProgressInterval = 2 * time.Second
+	// sshProgressChunkBytes bounds the partial output sent per progress notification
+	sshProgressChunkBytes = 2048
+)
+
+// sshCommandOptions controls how a remote command is run
+type sshCommandOptions struct {
+	Timeout        time.Duration
+	MaxOutputBytes int
+	PTY            bool
+	// Progress, when set, is called periodically with the output produced since the previous call
+	Progress func(elapsed time.Duration, stdout, stderr string)
+}
+
+// sshOutputBuffer keeps the head and tail of a stream up to a byte limit and remembers the
+// most recent output for progress notifications
+type sshOutputBuffer struct {
+	mu     sync.Mutex
+	limit  int
+	head   []byte
+	tail   []byte
+	total  int64
+	recent []byte
+}
+
+// newSSHOutputBuffer creates a buffer that keeps at most limit bytes
+func newSSHOutputBuffer(limit int) *sshOutputBuffer {
+	if limit <= 0 {
+		limit = defaultSSHMaxOutputBytes
+	}
+	return &sshOutputBuffer{limit: limit}
+}
+
+// Write implements io.Writer
+func (b *sshOutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.total += int64(len(p))
+
+	data := p
+	headLimit := b.limit / 2
+	if room := headLimit - len(b.head); room > 0 {
+		if room > len(data) {
+			room = len(data)
+		}
+		b.head = append(b.head, data[:room]...)
+		data = data[room:]
+	}
+	if len(data) > 0 {
+		b.tail = appendBounded(b.tail, data, b.limit-headLimit)
+	}
+	b.recent = appendBounded(b.recent, p, sshProgressChunkBytes)
+
+	return len(p), nil
+}
+
+// appendBounded appends data and drops the oldest bytes beyond limit
+func appendBounded(buffer, data []byte, limit int) []byte {
+	buffer = append(buffer, data...)
+	if len(buffer) > limit {
+		buffer = append(buffer[:0], buffer[len(buffer)-limit:]...)
+	}
+	return buffer
+}
+
+// String returns the captured output with a marker where bytes were dropped
+func (b *sshOutputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	kept := int64(len(b.head) + len(b.tail))
+	if kept == b.total {
+		return string(b.head) + string(b.tail)
+	}
+	return fmt.Sprintf("%s\n... [output truncated: %d of %d bytes omitted] ...\n%s",
+		b.head, b.total-kept, b.total, b.tail)
+}
+
+// Truncated reports whether any output was dropped
+func (b *sshOutputBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.head)+len(b.tail)) < b.total
+}
+
+// drainRecent returns the output written since the last drain
+func (b *sshOutputBuffer) drainRecent() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	recent := string(b.recent)
+	b.recent = b.recent[:0]
+	return recent
+}
+
+// runSSHCommand runs a command in a new session, enforcing the timeout by sending SIGTERM and
+// then closing the session if the command does not exit within the grace period
+func runSSHCommand(ctx context.Context, client *ssh.Client, command string, options sshCommandOptions) (*CommandResult, error) {
+	startTime := time.Now()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	defer session.Close()
+
+	if options.PTY {
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          0,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+		if err := session.RequestPty("xterm", 50, 200, modes); err != nil {
+			return nil, fmt.Errorf("failed to allocate pty: %v", err)
+		}
+	}
+
+	stdout := newSSHOutputBuffer(options.MaxOutputBytes)
+	stderr := newSSHOutputBuffer(options.MaxOutputBytes)
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	if err := session.Start(command); err != nil {
+		return nil, fmt.Errorf("failed to start command: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	var timeout <-chan time.Time
+	if options.Timeout > 0 {
+		timer := time.NewTimer(options.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var progress <-chan time.Time
+	if options.Progress != nil {
+		ticker := time.NewTicker(sshProgressInterval)
+		defer ticker.Stop()
+		progress = ticker.C
+	}
+
+	var waitErr error
+	stopReason, timedOut := "", false
+wait:
+	for {
+		select {
+		case waitErr = <-done:
+			break wait
+		case <-progress:
+			options.Progress(time.Since(startTime), stdout.drainRecent(), stderr.drainRecent())
+		case <-timeout:
+			stopReason, timedOut = fmt.Sprintf("command timed out after %s", options.Timeout), true
+			waitErr = stopSSHCommand(session, done)
+			break wait
+		case <-ctx.Done():
+			// A deadline on the caller's context is a timeout; anything else is the client giving up
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				stopReason, timedOut = "command timed out: the request deadline passed", true
+			} else {
+				stopReason = fmt.Sprintf("command cancelled: %v", ctx.Err())
+			}
+			waitErr = stopSSHCommand(session, done)
+			break wait
+		}
+	}
+
+	result := &CommandResult{
+		Command:   command,
+		Output:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.Truncated() || stderr.Truncated(),
+		Duration:  time.Since(startTime).String(),
+		Timestamp: time.Now(),
+	}
+
+	switch {
+	case stopReason != "":
+		result.TimedOut = timedOut
+		result.Cancelled = !timedOut
+		result.Error = stopReason
+		result.ExitCode = -1
+		if exitError, ok := waitErr.(*ssh.ExitError); ok && exitError.Signal() != "" {
+			result.Signal = exitError.Signal()
+		}
+	case waitErr != nil:
+		result.Error = waitErr.Error()
+		if exitError, ok := waitErr.(*ssh.ExitError); ok {
+			result.ExitCode = exitError.ExitStatus()
+			result.Signal = exitError.Signal()
+		} else {
+			result.ExitCode = -1
+		}
+	}
+
+	return result, nil
+}
+
+// stopSSHCommand asks the remote command to terminate and closes the session if it does not
+func stopSSHCommand(session *ssh.Session, done <-chan error) error {
+	// Not every server honours signal requests, closing the channel is the fallback
+	_ = session.Signal(ssh.SIGTERM)
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(sshSignalGracePeriod):
+	}
+
+	session.Close()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(sshSignalGracePeriod):
+		return fmt.Errorf("session did not close")
+	}
+}
@@ -0,0 +1,113 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSHOutputBuffer(t *testing.T) {
+	buffer := newSSHOutputBuffer(10)
+	fmt.Fprint(buffer, "0123456789")
+	if buffer.String() != "0123456789" || buffer.Truncated() {
+		t.Errorf("Output within the limit must be kept intact, got %q", buffer.String())
+	}
+
+	buffer = newSSHOutputBuffer(10)
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(buffer, "line%d\n", i)
+	}
+	output := buffer.String()
+	if !buffer.Truncated() || !strings.HasPrefix(output, "line0") || !strings.HasSuffix(output, "ine9\n") ||
+		!strings.Contains(output, "[output truncated: 50 of 60 bytes omitted]") {
+		t.Errorf("Expected head, marker and tail, got %q", output)
+	}
+	if recent := buffer.drainRecent(); !strings.HasSuffix(recent, "line9\n") || buffer.drainRecent() != "" {
+		t.Errorf("Unexpected recent output %q", recent)
+	}
+}
+
+func TestRunSSHCommand(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	server := newTestSSHServer(t, func(command string, stdout, stderr io.Writer) int {
+		switch command {
+		case "tail -f /var/log/app.log":
+			fmt.Fprintln(stdout, "first line")
+			select {
+			case <-stop:
+			case <-time.After(10 * time.Second):
+			}
+			return 0
+		case "split":
+			fmt.Fprintln(stdout, "to stdout")
+			fmt.Fprintln(stderr, "to stderr")
+			return 3
+		default:
+			fmt.Fprint(stdout, strings.Repeat("x", 1000))
+			return 0
+		}
+	})
+	config := server.instance()
+	config.Password = "secret"
+	client, err := createSSHClient(&config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	result, err := runSSHCommand(context.Background(), client, "split", sshCommandOptions{Timeout: 5 * time.Second, PTY: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Output != "to stdout\n" || result.Stderr != "to stderr\n" || result.ExitCode != 3 {
+		t.Errorf("Unexpected split result: %+v", result)
+	}
+	if server.ptyRequests.Load() != 1 {
+		t.Error("Expected a pty to be requested")
+	}
+
+	result, _ = runSSHCommand(context.Background(), client, "big", sshCommandOptions{Timeout: 5 * time.Second, MaxOutputBytes: 100})
+	if !result.Truncated || len(result.Output) > 200 {
+		t.Errorf("Expected truncated output, got %d bytes", len(result.Output))
+	}
+
+	started := time.Now()
+	result, err = runSSHCommand(context.Background(), client, "tail -f /var/log/app.log", sshCommandOptions{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.TimedOut || result.ExitCode != -1 || result.Output != "first line\n" {
+		t.Errorf("Expected a timed out result with partial output, got %+v", result)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Timeout was not enforced, took %s", elapsed)
+	}
+	if !reflect.DeepEqual(server.receivedSignals(), []string{"TERM"}) {
+		t.Errorf("Expected SIGTERM before closing, got %v", server.receivedSignals())
+	}
+
+	// A client abort is reported as a cancellation, a deadline on the context as a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	result, _ = runSSHCommand(ctx, client, "tail -f /var/log/app.log", sshCommandOptions{Timeout: 5 * time.Second})
+	if result == nil || result.TimedOut || !result.Cancelled || !strings.Contains(result.Error, "cancelled") {
+		t.Errorf("Expected a cancelled result, got %+v", result)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	result, _ = runSSHCommand(ctx, client, "tail -f /var/log/app.log", sshCommandOptions{Timeout: 5 * time.Second})
+	if result == nil || !result.TimedOut || result.Cancelled {
+		t.Errorf("Expected a timed out result for the context deadline, got %+v", result)
+	}
+
+	// The pooled connection stays usable after a timed out session
+	if output, err := executeCommandString(client, "big", 5); err != nil || len(output) != 1000 {
+		t.Errorf("Connection unusable after timeout: %v", err)
+	}
+}
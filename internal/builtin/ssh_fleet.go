@@ -0,0 +1,389 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// defaultSSHFleetConcurrency is how many instances a fleet command runs on at once
+	defaultSSHFleetConcurrency = 10
+	// maxSSHFleetConcurrency caps the concurrency a caller can ask for
+	maxSSHFleetConcurrency = 50
+	// defaultSSHFleetMaxOutputBytes caps each host's stdout and stderr, which are kept per output group
+	defaultSSHFleetMaxOutputBytes = 16 * 1024
+)
+
+// Fleet host statuses
+const (
+	sshFleetOK      = "ok"
+	sshFleetFailed  = "failed"
+	sshFleetTimeout = "timeout"
+	sshFleetError   = "error"
+	sshFleetBlocked = "blocked"
+)
+
+// SSHFleetHostResult is the outcome of a fleet command on one instance
+type SSHFleetHostResult struct {
+	ServerName  string `json:"server_name"`
+	Status      string `json:"status"`
+	ExitCode    int    `json:"exit_code"`
+	Duration    string `json:"duration"`
+	Error       string `json:"error,omitempty"`
+	OutputGroup int    `json:"output_group,omitempty"`
+}
+
+// SSHFleetOutputGroup is a set of instances that produced identical output
+type SSHFleetOutputGroup struct {
+	ID        int      `json:"id"`
+	Hosts     []string `json:"hosts"`
+	ExitCode  int      `json:"exit_code"`
+	Output    string   `json:"output"`
+	Stderr    string   `json:"stderr,omitempty"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// SSHFleetResult is the aggregated result of a command run across instances
+type SSHFleetResult struct {
+	Command      string                `json:"command"`
+	Success      bool                  `json:"success"`
+	Message      string                `json:"message"`
+	Duration     string                `json:"duration"`
+	Timestamp    time.Time             `json:"timestamp"`
+	Summary      map[string]int        `json:"summary"`
+	ExitCodes    map[string][]string   `json:"exit_codes,omitempty"`
+	Table        string                `json:"table,omitempty"`
+	Hosts        []SSHFleetHostResult  `json:"hosts"`
+	OutputGroups []SSHFleetOutputGroup `json:"output_groups,omitempty"`
+	Policy       *SSHPolicyDecision    `json:"policy,omitempty"`
+}
+
+// addSSHFleetTools registers the fleet execution tool
+func addSSHFleetTools(s *server.MCPServer) {
+	fleetTool := mcp.NewTool("ssh_fleet_execute",
+		mcp.WithDescription("Run a command on many SSH instances in parallel, selected by name or by a group/tag from the configuration. Returns a table of exit codes and groups hosts with identical output together. Each host's policy is applied; commands needing confirmation return a confirmation_token"+sshConfirmationNotice),
+		mcp.WithString("command",
+			mcp.Required(),
+			mcp.Description("Command to run on every instance"),
+		),
+		mcp.WithString("servers",
+			mcp.Description("Comma-separated instance names"),
+		),
+		mcp.WithString("group",
+			mcp.Description("Group from the configuration, or a tag set on instances; 'all' targets every instance"),
+		),
+		mcp.WithNumber("concurrency",
+			mcp.Description("Maximum instances to run on at once (default: 10, max: 50)"),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description("Per-host command timeout in seconds (default: 30)"),
+		),
+		mcp.WithNumber("max_output_bytes",
+			mcp.Description("Maximum bytes kept for each of stdout and stderr per host (default: 16384)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Token returned when the command required confirmation. Only pass it after the user approved the command"),
+		),
+	)
+
+	s.AddTool(fleetTool, executeSSHFleetExecute)
+}
+
+// resolveSSHFleet returns the sorted instance names selected by a comma-separated list and a group or tag
+func resolveSSHFleet(servers, group string) ([]string, error) {
+	if globalSSHConfig == nil {
+		return nil, fmt.Errorf("SSH configuration not loaded")
+	}
+
+	selected := map[string]bool{}
+	for _, name := range strings.Split(servers, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, ok := globalSSHConfig.Instances[name]; !ok {
+			return nil, fmt.Errorf("SSH instance '%s' not found in configuration", name)
+		}
+		selected[name] = true
+	}
+
+	if group = strings.TrimSpace(group); group != "" {
+		members, err := sshGroupMembers(group)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range members {
+			selected[name] = true
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("servers or group is required")
+	}
+	names := make([]string, 0, len(selected))
+	for name := range selected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// sshGroupMembers returns the instances of a configured group, or the instances carrying a tag
+func sshGroupMembers(group string) ([]string, error) {
+	if members, ok := globalSSHConfig.Groups[group]; ok {
+		for _, name := range members {
+			if _, ok := globalSSHConfig.Instances[name]; !ok {
+				return nil, fmt.Errorf("group '%s' references unknown SSH instance '%s'", group, name)
+			}
+		}
+		return members, nil
+	}
+
+	var members []string
+	for name, instance := range globalSSHConfig.Instances {
+		if group == "all" || containsSSHArg(instance.Tags, group) {
+			members = append(members, name)
+		}
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no SSH group or tagged instances named '%s'", group)
+	}
+	return members, nil
+}
+
+// executeSSHFleetExecute handles running a command across instances
+func executeSSHFleetExecute(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	command := strings.TrimSpace(request.GetString("command", ""))
+	if command == "" {
+		return mcp.NewToolResultError("command is required"), nil
+	}
+	concurrency := int(request.GetFloat("concurrency", defaultSSHFleetConcurrency))
+	if concurrency < 1 {
+		concurrency = defaultSSHFleetConcurrency
+	}
+	if concurrency > maxSSHFleetConcurrency {
+		concurrency = maxSSHFleetConcurrency
+	}
+	timeout := time.Duration(request.GetFloat("timeout", 30)) * time.Second
+	maxOutputBytes := int(request.GetFloat("max_output_bytes", defaultSSHFleetMaxOutputBytes))
+
+	names, err := resolveSSHFleet(request.GetString("servers", ""), request.GetString("group", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Each host is checked against its own policy; hosts that deny the command are skipped, and
+	// confirmation covers the whole fleet
+	blocked := map[string]*SSHPolicyDecision{}
+	pending := &SSHPolicyDecision{Action: sshPolicyAllow}
+	seen := map[SSHPolicyViolation]bool{}
+	confirmations := 0
+	for _, name := range names {
+		instance := globalSSHConfig.Instances[name]
+		decision := evaluateSSHCommand(sshInstancePolicy(&instance), command)
+		switch decision.Action {
+		case sshPolicyDeny:
+			blocked[name] = decision
+		case sshPolicyConfirm:
+			confirmations++
+			for _, violation := range decision.Violations {
+				if !seen[violation] {
+					seen[violation] = true
+					pending.add(violation)
+				}
+			}
+		}
+	}
+	fleetKey := "fleet:" + strings.Join(names, ",")
+	if !confirmSSHPolicyDecision(pending, fleetKey, command, request.GetString("confirmation_token", "")) {
+		result := &SSHFleetResult{
+			Command:   command,
+			Success:   false,
+			Message:   fmt.Sprintf("Command requires confirmation on %d instance(s): %s. Ask the user to approve it, then call again with the same command, targets and confirmation_token", confirmations, pending.Summary()),
+			Duration:  time.Since(startTime).String(),
+			Timestamp: time.Now(),
+			Summary:   map[string]int{},
+			Hosts:     []SSHFleetHostResult{},
+			Policy:    pending,
+		}
+		resultJSON, _ := json.Marshal(result)
+		return mcp.NewToolResultText(string(resultJSON)), nil
+	}
+
+	hosts := make([]SSHFleetHostResult, len(names))
+	outputs := make([]*CommandResult, len(names))
+	var completed int
+	var progressMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+
+	for i, name := range names {
+		if decision, ok := blocked[name]; ok {
+			hosts[i] = SSHFleetHostResult{ServerName: name, Status: sshFleetBlocked, ExitCode: -1, Duration: "0s", Error: "blocked by policy: " + decision.Summary()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				hosts[i] = SSHFleetHostResult{ServerName: name, Status: sshFleetError, ExitCode: -1, Duration: "0s", Error: ctx.Err().Error()}
+				return
+			}
+
+			hosts[i], outputs[i] = runSSHFleetHost(ctx, name, command, timeout, maxOutputBytes)
+
+			progressMu.Lock()
+			completed++
+			notifyToolProgress(ctx, request, completed, len(names)-len(blocked), fmt.Sprintf("%s: %s", name, hosts[i].Status))
+			progressMu.Unlock()
+		}(i, name)
+	}
+	wg.Wait()
+
+	result := aggregateSSHFleetResults(command, hosts, outputs)
+	result.Duration = time.Since(startTime).String()
+	result.Timestamp = time.Now()
+	if len(blocked) > 0 {
+		result.Policy = &SSHPolicyDecision{Action: sshPolicyDeny}
+		for _, name := range names {
+			if decision, ok := blocked[name]; ok {
+				result.Policy.merge(decision)
+			}
+		}
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// runSSHFleetHost runs the fleet command on one instance
+func runSSHFleetHost(ctx context.Context, name, command string, timeout time.Duration, maxOutputBytes int) (SSHFleetHostResult, *CommandResult) {
+	startTime := time.Now()
+	host := SSHFleetHostResult{ServerName: name, Status: sshFleetError, ExitCode: -1}
+
+	cmdResult, err := runSSHFleetCommand(ctx, name, command, sshCommandOptions{Timeout: timeout, MaxOutputBytes: maxOutputBytes})
+	host.Duration = time.Since(startTime).Round(time.Millisecond).String()
+	if err != nil {
+		host.Error = err.Error()
+		return host, nil
+	}
+
+	host.ExitCode = cmdResult.ExitCode
+	host.Error = cmdResult.Error
+	switch {
+	case cmdResult.TimedOut:
+		host.Status = sshFleetTimeout
+	case cmdResult.Cancelled:
+		host.Status = sshFleetError
+	case cmdResult.ExitCode != 0:
+		host.Status = sshFleetFailed
+	default:
+		host.Status = sshFleetOK
+	}
+	return host, cmdResult
+}
+
+// runSSHFleetCommand connects to an instance through the pool and runs the command
+func runSSHFleetCommand(ctx context.Context, name, command string, options sshCommandOptions) (*CommandResult, error) {
+	instanceConfig, err := getSSHInstanceConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	client, release, err := acquireSSHClient(name, instanceConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return runSSHCommand(ctx, client, command, options)
+}
+
+// aggregateSSHFleetResults builds the summary, exit code table and output groups
+func aggregateSSHFleetResults(command string, hosts []SSHFleetHostResult, outputs []*CommandResult) *SSHFleetResult {
+	result := &SSHFleetResult{
+		Command:   command,
+		Summary:   map[string]int{},
+		ExitCodes: map[string][]string{},
+		Hosts:     hosts,
+	}
+
+	groupIndex := map[string]int{}
+	for i := range hosts {
+		host := &hosts[i]
+		result.Summary[host.Status]++
+
+		output := outputs[i]
+		if output == nil {
+			continue
+		}
+		code := strconv.Itoa(host.ExitCode)
+		result.ExitCodes[code] = append(result.ExitCodes[code], host.ServerName)
+
+		key := fmt.Sprintf("%d\x00%s\x00%s", output.ExitCode, strings.TrimRight(output.Output, "\n"), strings.TrimRight(output.Stderr, "\n"))
+		index, ok := groupIndex[key]
+		if !ok {
+			index = len(result.OutputGroups)
+			groupIndex[key] = index
+			result.OutputGroups = append(result.OutputGroups, SSHFleetOutputGroup{
+				ID:        index + 1,
+				ExitCode:  output.ExitCode,
+				Output:    output.Output,
+				Stderr:    output.Stderr,
+				Truncated: output.Truncated,
+			})
+		}
+		result.OutputGroups[index].Hosts = append(result.OutputGroups[index].Hosts, host.ServerName)
+		host.OutputGroup = index + 1
+	}
+
+	// Largest groups first, so the common output leads and outliers follow
+	sort.SliceStable(result.OutputGroups, func(i, j int) bool {
+		return len(result.OutputGroups[i].Hosts) > len(result.OutputGroups[j].Hosts)
+	})
+	renumbered := map[int]int{}
+	for i := range result.OutputGroups {
+		renumbered[result.OutputGroups[i].ID] = i + 1
+		result.OutputGroups[i].ID = i + 1
+	}
+	for i := range hosts {
+		hosts[i].OutputGroup = renumbered[hosts[i].OutputGroup]
+	}
+
+	result.Table = formatSSHFleetTable(hosts)
+	result.Success = result.Summary[sshFleetOK] == len(hosts)
+	result.Message = fmt.Sprintf("Ran on %d instance(s): %d ok, %d failed, %d timed out, %d unreachable, %d blocked; %d distinct output(s)",
+		len(hosts), result.Summary[sshFleetOK], result.Summary[sshFleetFailed], result.Summary[sshFleetTimeout],
+		result.Summary[sshFleetError], result.Summary[sshFleetBlocked], len(result.OutputGroups))
+	return result
+}
+
+// formatSSHFleetTable renders the per-host exit codes as an aligned text table
+func formatSSHFleetTable(hosts []SSHFleetHostResult) string {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "HOST\tSTATUS\tEXIT\tGROUP\tDURATION")
+	for _, host := range hosts {
+		group := "-"
+		if host.OutputGroup > 0 {
+			group = strconv.Itoa(host.OutputGroup)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\n", host.ServerName, host.Status, host.ExitCode, group, host.Duration)
+	}
+	writer.Flush()
+	return table.String()
+}
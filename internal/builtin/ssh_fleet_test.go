@@ -0,0 +1,88 @@
+package builtin
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSSHFleetExecute(t *testing.T) {
+	healthy := func(command string, stdout, stderr io.Writer) int {
+		fmt.Fprintln(stdout, "/dev/sda1 40%")
+		return 0
+	}
+	full := func(command string, stdout, stderr io.Writer) int {
+		fmt.Fprintln(stdout, "/dev/sda1 98%")
+		fmt.Fprintln(stderr, "disk almost full")
+		return 1
+	}
+
+	instances := map[string]SSHServerConfig{}
+	for name, handler := range map[string]testSSHHandler{"art-1": healthy, "art-2": healthy, "art-3": full} {
+		config := newTestSSHServer(t, handler).instance()
+		config.Password = "secret"
+		config.Tags = []string{"artifactory"}
+		instances[name] = config
+	}
+
+	// An instance whose port is closed is reported as unreachable
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	unreachable := instances["art-1"]
+	unreachable.Port = listener.Addr().(*net.TCPAddr).Port
+	unreachable.Tags = []string{"edge"}
+	listener.Close()
+	instances["edge-1"] = unreachable
+
+	useTestSSHConfig(t, instances)
+	globalSSHConfig.Groups = map[string][]string{"primary": {"art-1", "art-2"}}
+
+	if names, _ := resolveSSHFleet("edge-1", "primary"); !reflect.DeepEqual(names, []string{"art-1", "art-2", "edge-1"}) {
+		t.Errorf("Unexpected names for servers and group: %v", names)
+	}
+	if names, _ := resolveSSHFleet("", "artifactory"); !reflect.DeepEqual(names, []string{"art-1", "art-2", "art-3"}) {
+		t.Errorf("Unexpected names for tag: %v", names)
+	}
+	if _, err := resolveSSHFleet("", "missing"); err == nil {
+		t.Error("Unknown groups must be rejected")
+	}
+
+	response, result := callSSHTool(t, executeSSHFleetExecute, map[string]any{"group": "all", "command": "df -P / | tail -1", "concurrency": 2, "timeout": 5})
+	if result.IsError {
+		t.Fatalf("Unexpected error: %v", result.Content)
+	}
+	summary := response["summary"].(map[string]any)
+	if summary["ok"] != float64(2) || summary["failed"] != float64(1) || summary["error"] != float64(1) || response["success"] != false {
+		t.Errorf("Unexpected summary %v", summary)
+	}
+	if codes := response["exit_codes"].(map[string]any); len(codes["0"].([]any)) != 2 || len(codes["1"].([]any)) != 1 {
+		t.Errorf("Unexpected exit codes %v", codes)
+	}
+
+	groups := response["output_groups"].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("Expected two output groups, got %v", groups)
+	}
+	first := groups[0].(map[string]any)
+	if fmt.Sprint(first["hosts"]) != "[art-1 art-2]" || first["output"] != "/dev/sda1 40%\n" {
+		t.Errorf("Identical output must be grouped, largest group first: %v", first)
+	}
+	if second := groups[1].(map[string]any); second["stderr"] != "disk almost full\n" || second["exit_code"] != float64(1) {
+		t.Errorf("Unexpected outlier group %v", second)
+	}
+	if table := response["table"].(string); !strings.HasPrefix(table, "HOST") || !strings.Contains(table, "edge-1  error") {
+		t.Errorf("Unexpected table:\n%s", table)
+	}
+
+	response, _ = callSSHTool(t, executeSSHFleetExecute, map[string]any{"group": "artifactory", "command": "rm -rf /tmp/cache"})
+	if response["summary"].(map[string]any)["blocked"] != float64(3) || response["policy"].(map[string]any)["action"] != sshPolicyDeny {
+		t.Errorf("Expected the command to be blocked on every host: %v", response)
+	}
+
+	response, _ = callSSHTool(t, executeSSHFleetExecute, map[string]any{"servers": "art-1,art-2", "command": "systemctl restart artifactory"})
+	if token := response["policy"].(map[string]any)["confirmation_token"]; token == nil || len(response["hosts"].([]any)) != 0 {
+		t.Errorf("Expected a confirmation before running anywhere: %v", response)
+	}
+}
@@ -0,0 +1,222 @@
+package builtin
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key policies for SSHServerConfig.HostKeyPolicy
+const (
+	sshHostKeyPolicyStrict   = "strict"
+	sshHostKeyPolicyTOFU     = "tofu"
+	sshHostKeyPolicyInsecure = "insecure"
+)
+
+// sshKnownHostsMu serializes trust-on-first-use writes to known_hosts files
+var sshKnownHostsMu sync.Mutex
+
+// sshHostKeyCallback builds the host key verification callback for an instance
+func sshHostKeyCallback(config *SSHServerConfig) (ssh.HostKeyCallback, error) {
+	policy := strings.ToLower(strings.TrimSpace(config.HostKeyPolicy))
+	if policy == "" {
+		policy = sshHostKeyPolicyStrict
+	}
+
+	switch policy {
+	case sshHostKeyPolicyInsecure:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case sshHostKeyPolicyStrict, sshHostKeyPolicyTOFU:
+	default:
+		return nil, fmt.Errorf("invalid host_key_policy '%s' (expected strict, tofu or insecure)", config.HostKeyPolicy)
+	}
+
+	// Pinned fingerprints are authoritative and bypass known_hosts entirely
+	if len(config.HostKeyFingerprints) > 0 {
+		return pinnedHostKeyCallback(config.HostKeyFingerprints), nil
+	}
+
+	path, err := sshKnownHostsPath(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return verifyKnownHostKey(path, policy == sshHostKeyPolicyTOFU, hostname, remote, key)
+	}, nil
+}
+
+// sshKnownHostsPath resolves the known_hosts file for an instance, defaulting to ~/.ssh/known_hosts
+func sshKnownHostsPath(config *SSHServerConfig) (string, error) {
+	if config.KnownHostsPath != "" {
+		return expandSSHPath(config.KnownHostsPath)
+	}
+	return expandSSHPath("~/.ssh/known_hosts")
+}
+
+// expandSSHPath expands a leading ~ to the current user's home directory
+func expandSSHPath(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve home directory for %s: %v", path, err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// pinnedHostKeyCallback accepts only host keys matching one of the pinned fingerprints
+func pinnedHostKeyCallback(fingerprints []string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		for _, pinned := range fingerprints {
+			if hostKeyFingerprintMatches(pinned, key) {
+				return nil
+			}
+		}
+		return fmt.Errorf("host key mismatch for %s: server presented %s %s, which matches none of the pinned host_key_fingerprints",
+			hostname, key.Type(), ssh.FingerprintSHA256(key))
+	}
+}
+
+// hostKeyFingerprintMatches compares a pinned SHA256 or MD5 fingerprint against a key
+func hostKeyFingerprintMatches(pinned string, key ssh.PublicKey) bool {
+	pinned = strings.TrimSpace(pinned)
+	switch {
+	case strings.HasPrefix(pinned, "SHA256:"):
+		return strings.TrimRight(pinned, "=") == ssh.FingerprintSHA256(key)
+	case strings.HasPrefix(strings.ToUpper(pinned), "MD5:"):
+		return strings.EqualFold(pinned[4:], ssh.FingerprintLegacyMD5(key))
+	case strings.Count(pinned, ":") == 15:
+		return strings.EqualFold(pinned, ssh.FingerprintLegacyMD5(key))
+	default:
+		return "SHA256:"+strings.TrimRight(pinned, "=") == ssh.FingerprintSHA256(key)
+	}
+}
+
+// verifyKnownHostKey checks a host key against a known_hosts file, recording unknown hosts in TOFU mode
+func verifyKnownHostKey(path string, tofu bool, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	sshKnownHostsMu.Lock()
+	defer sshKnownHostsMu.Unlock()
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if !tofu {
+			return fmt.Errorf("host key for %s (%s %s) cannot be verified: known_hosts file %s does not exist; add the host with ssh-keyscan, pin host_key_fingerprints or set host_key_policy to \"tofu\"",
+				hostname, key.Type(), ssh.FingerprintSHA256(key), path)
+		}
+		return recordKnownHostKey(path, hostname, remote, key)
+	}
+
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return fmt.Errorf("failed to read known_hosts file %s: %v", path, err)
+	}
+
+	err = callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	var revokedErr *knownhosts.RevokedError
+	if errors.As(err, &revokedErr) {
+		return fmt.Errorf("host key for %s is marked as revoked in %s:%d", hostname, revokedErr.Revoked.Filename, revokedErr.Revoked.Line)
+	}
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return err
+	}
+
+	if len(keyErr.Want) > 0 {
+		known := make([]string, 0, len(keyErr.Want))
+		for _, want := range keyErr.Want {
+			known = append(known, fmt.Sprintf("%s %s (%s:%d)", want.Key.Type(), ssh.FingerprintSHA256(want.Key), want.Filename, want.Line))
+		}
+		return fmt.Errorf("host key mismatch for %s: server presented %s %s but known_hosts expects %s; the host key may have been changed or the connection intercepted, remove the stale entry only if the change is expected",
+			hostname, key.Type(), ssh.FingerprintSHA256(key), strings.Join(known, ", "))
+	}
+
+	if !tofu {
+		return fmt.Errorf("host key for %s (%s %s) is not in %s; add it with ssh-keyscan, pin host_key_fingerprints or set host_key_policy to \"tofu\"",
+			hostname, key.Type(), ssh.FingerprintSHA256(key), path)
+	}
+	return recordKnownHostKey(path, hostname, remote, key)
+}
+
+// sshKnownHostKeyAlgorithms lists the host key algorithms already recorded for an address so the
+// server is asked for a key type we can verify rather than one that would look like a mismatch
+func sshKnownHostKeyAlgorithms(config *SSHServerConfig, address string) []string {
+	policy := strings.ToLower(strings.TrimSpace(config.HostKeyPolicy))
+	if policy == sshHostKeyPolicyInsecure || len(config.HostKeyFingerprints) > 0 {
+		return nil
+	}
+
+	path, err := sshKnownHostsPath(config)
+	if err != nil {
+		return nil
+	}
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		return nil
+	}
+
+	// A throwaway key never matches, so the returned KeyError lists every known key for the host
+	probe, err := ssh.NewPublicKey(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public())
+	if err != nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	placeholder := &net.TCPAddr{IP: net.IPv4zero}
+	if !errors.As(callback(address, placeholder, probe), &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, want := range keyErr.Want {
+		types := []string{want.Key.Type()}
+		if want.Key.Type() == ssh.KeyAlgoRSA {
+			types = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, algorithm := range types {
+			if !seen[algorithm] {
+				seen[algorithm] = true
+				algorithms = append(algorithms, algorithm)
+			}
+		}
+	}
+	return algorithms
+}
+
+// recordKnownHostKey appends a trusted-on-first-use host key to a known_hosts file
+func recordKnownHostKey(path string, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create known_hosts directory: %v", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open known_hosts file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil {
+		if remoteAddress := knownhosts.Normalize(remote.String()); remoteAddress != addresses[0] {
+			addresses = append(addresses, remoteAddress)
+		}
+	}
+
+	if _, err := file.WriteString(knownhosts.Line(addresses, key) + "\n"); err != nil {
+		return fmt.Errorf("failed to record host key in %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package builtin
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestHostKey generates a random ed25519 public key
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatalf("Failed to convert key: %v", err)
+	}
+	return key
+}
+
+func TestSSHHostKeyCallback_KnownHosts(t *testing.T) {
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 22}
+	key := newTestHostKey(t)
+	other := newTestHostKey(t)
+
+	strict, err := sshHostKeyCallback(&SSHServerConfig{KnownHostsPath: knownHosts})
+	if err != nil {
+		t.Fatalf("Unexpected callback error: %v", err)
+	}
+	if err := strict("build.example.com:22", remote, key); err == nil {
+		t.Fatal("Strict mode must reject hosts without a known_hosts entry")
+	}
+
+	tofu, err := sshHostKeyCallback(&SSHServerConfig{KnownHostsPath: knownHosts, HostKeyPolicy: "tofu"})
+	if err != nil {
+		t.Fatalf("Unexpected callback error: %v", err)
+	}
+	if err := tofu("build.example.com:22", remote, key); err != nil {
+		t.Fatalf("TOFU should record a new host: %v", err)
+	}
+	content, _ := os.ReadFile(knownHosts)
+	if !strings.HasPrefix(string(content), "build.example.com,10.0.0.5 ssh-ed25519 ") {
+		t.Errorf("Unexpected known_hosts entry: %q", content)
+	}
+
+	if err := strict("build.example.com:22", remote, key); err != nil {
+		t.Errorf("Recorded key should now verify in strict mode: %v", err)
+	}
+	err = tofu("build.example.com:22", remote, other)
+	if err == nil || !strings.Contains(err.Error(), "host key mismatch") {
+		t.Errorf("TOFU must still reject a changed key, got %v", err)
+	}
+
+	algorithms := sshKnownHostKeyAlgorithms(&SSHServerConfig{KnownHostsPath: knownHosts}, "build.example.com:22")
+	if !reflect.DeepEqual(algorithms, []string{ssh.KeyAlgoED25519}) {
+		t.Errorf("Expected the recorded key type, got %v", algorithms)
+	}
+}
+
+func TestSSHHostKeyCallback_PinnedFingerprints(t *testing.T) {
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 22}
+	key := newTestHostKey(t)
+
+	callback, err := sshHostKeyCallback(&SSHServerConfig{
+		HostKeyFingerprints: []string{strings.TrimPrefix(ssh.FingerprintSHA256(key), "SHA256:")},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected callback error: %v", err)
+	}
+	if err := callback("build.example.com:22", remote, key); err != nil {
+		t.Errorf("Pinned key should verify: %v", err)
+	}
+	if err := callback("build.example.com:22", remote, newTestHostKey(t)); err == nil {
+		t.Error("Unpinned key must be rejected")
+	}
+
+	callback, _ = sshHostKeyCallback(&SSHServerConfig{HostKeyFingerprints: []string{"MD5:" + ssh.FingerprintLegacyMD5(key)}})
+	if err := callback("build.example.com:22", remote, key); err != nil {
+		t.Errorf("MD5 pin should verify: %v", err)
+	}
+
+	if _, err := sshHostKeyCallback(&SSHServerConfig{HostKeyPolicy: "sometimes"}); err == nil {
+		t.Error("Expected an invalid policy error")
+	}
+}
@@ -0,0 +1,921 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultImportedSSHTimeout is the connect timeout for imported hosts without ConnectTimeout
+	defaultImportedSSHTimeout = 30
+	// maxSSHConfigIncludeDepth bounds nested Include directives, as OpenSSH does
+	maxSSHConfigIncludeDepth = 16
+)
+
+// SSHInstanceSummary describes one instance of the merged catalog without its secrets
+type SSHInstanceSummary struct {
+	Instance    string   `json:"instance"`
+	Name        string   `json:"name,omitempty"`
+	Host        string   `json:"host"`
+	Port        int      `json:"port"`
+	Username    string   `json:"username,omitempty"`
+	Auth        []string `json:"auth,omitempty"`
+	ProxyJump   string   `json:"proxy_jump,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+	Source      string   `json:"source"`
+	ReadOnly    bool     `json:"read_only,omitempty"`
+	Default     bool     `json:"default,omitempty"`
+}
+
+// SSHInstanceCatalog is the result of ssh_list_instances
+type SSHInstanceCatalog struct {
+	Count           int                  `json:"count"`
+	DefaultInstance string               `json:"default_instance,omitempty"`
+	Instances       []SSHInstanceSummary `json:"instances"`
+	Groups          map[string][]string  `json:"groups,omitempty"`
+	Sources         []string             `json:"sources"`
+	Warnings        []string             `json:"warnings,omitempty"`
+}
+
+// sshConfigBlock is one Host section of an OpenSSH client config
+type sshConfigBlock struct {
+	patterns []string
+	options  map[string]string
+}
+
+// ansibleGroup is one inventory group with its direct hosts, child groups and variables
+type ansibleGroup struct {
+	hosts    []string
+	children []string
+	vars     map[string]string
+}
+
+// ansibleInventory is a parsed INI or YAML inventory
+type ansibleInventory struct {
+	hosts  map[string]map[string]string
+	groups map[string]*ansibleGroup
+	order  []string
+}
+
+// addSSHInventoryTools registers the instance catalog tool
+func addSSHInventoryTools(s *server.MCPServer) {
+	listTool := mcp.NewTool("ssh_list_instances",
+		mcp.WithDescription("List the configured SSH instances: those defined in JSON plus hosts imported from OpenSSH client configs and Ansible inventories, with their groups, tags and where each came from. Credentials are never shown, only which authentication is configured"),
+		mcp.WithString("group",
+			mcp.Description("Only list instances in this group or with this tag (optional)"),
+		),
+		mcp.WithString("source",
+			mcp.Description("Only list instances whose source contains this text, e.g. 'json', 'ssh_config' or 'inventory' (optional)"),
+		),
+	)
+
+	s.AddTool(listTool, executeSSHListInstances)
+}
+
+// executeSSHListInstances handles listing the merged instance catalog
+func executeSSHListInstances(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if globalSSHConfig == nil {
+		return mcp.NewToolResultError("SSH configuration not loaded"), nil
+	}
+	group := request.GetString("group", "")
+	source := request.GetString("source", "")
+
+	names := make([]string, 0, len(globalSSHConfig.Instances))
+	if group != "" {
+		members, err := sshGroupMembers(group)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		names = append(names, members...)
+	} else {
+		for name := range globalSSHConfig.Instances {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	memberOf := map[string][]string{}
+	for groupName, members := range globalSSHConfig.Groups {
+		for _, member := range members {
+			memberOf[member] = append(memberOf[member], groupName)
+		}
+	}
+
+	catalog := &SSHInstanceCatalog{
+		DefaultInstance: globalSSHConfig.DefaultInstance,
+		Instances:       []SSHInstanceSummary{},
+		Groups:          globalSSHConfig.Groups,
+		Sources:         globalSSHConfig.ImportSources,
+		Warnings:        globalSSHConfig.ImportWarnings,
+	}
+	if catalog.Sources == nil {
+		catalog.Sources = []string{}
+	}
+	for _, name := range names {
+		instance, ok := globalSSHConfig.Instances[name]
+		if !ok || (source != "" && !strings.Contains(instance.Source, source)) {
+			continue
+		}
+		groups := memberOf[name]
+		sort.Strings(groups)
+		catalog.Instances = append(catalog.Instances, summarizeSSHInstance(name, instance, groups))
+	}
+	catalog.Count = len(catalog.Instances)
+
+	resultJSON, err := json.Marshal(catalog)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// summarizeSSHInstance builds a catalog entry, listing only which credentials are configured
+func summarizeSSHInstance(name string, instance SSHServerConfig, groups []string) SSHInstanceSummary {
+	port := instance.Port
+	if port == 0 {
+		port = 22
+	}
+	source := instance.Source
+	if source == "" {
+		source = "json"
+	}
+
+	var auth []string
+	if instance.PrivateKey != "" {
+		auth = append(auth, "private_key")
+	}
+	if instance.KeyPath != "" {
+		auth = append(auth, "key_path")
+	}
+	if instance.CertificatePath != "" {
+		auth = append(auth, "certificate")
+	}
+	if instance.AgentSocket != "" || os.Getenv("SSH_AUTH_SOCK") != "" {
+		auth = append(auth, "agent")
+	}
+	if instance.Password != "" {
+		auth = append(auth, "password")
+	}
+
+	return SSHInstanceSummary{
+		Instance:    name,
+		Name:        instance.Name,
+		Host:        instance.Host,
+		Port:        port,
+		Username:    instance.Username,
+		Auth:        auth,
+		ProxyJump:   instance.ProxyJump,
+		Description: instance.Description,
+		Tags:        instance.Tags,
+		Groups:      groups,
+		Source:      source,
+		ReadOnly:    sshInstancePolicy(&instance).ReadOnly,
+		Default:     name == globalSSHConfig.DefaultInstance,
+	}
+}
+
+// importSSHInstances merges hosts from the configured OpenSSH client configs and inventories into
+// the config. Later sources override earlier ones, and instances defined in JSON override them all
+func importSSHInstances(config *SSHConfig) error {
+	if len(config.SSHConfigFiles) == 0 && len(config.Inventories) == 0 {
+		return nil
+	}
+
+	imported := map[string]SSHServerConfig{}
+	importedGroups := map[string][]string{}
+
+	for _, file := range config.SSHConfigFiles {
+		filePath, err := expandSSHPath(file)
+		if err != nil {
+			return err
+		}
+		instances, err := loadSSHClientConfig(filePath)
+		if err != nil {
+			return fmt.Errorf("sshConfigFiles: %v", err)
+		}
+		for name, instance := range instances {
+			if existing, ok := imported[name]; ok {
+				instance = mergeSSHInstance(instance, existing)
+			}
+			imported[name] = instance
+		}
+		config.ImportSources = append(config.ImportSources, "ssh_config "+filePath)
+	}
+
+	for _, file := range config.Inventories {
+		filePath, err := expandSSHPath(file)
+		if err != nil {
+			return err
+		}
+		inventory, err := loadAnsibleInventory(filePath)
+		if err != nil {
+			return fmt.Errorf("inventories: %v", err)
+		}
+		instances, groups, warnings := inventory.instances("inventory " + filePath)
+		for name, instance := range instances {
+			// Inventory hosts without connection variables fall back to their ssh_config entry, as ansible does
+			if existing, ok := imported[name]; ok {
+				instance = mergeSSHInstance(instance, existing)
+			}
+			imported[name] = instance
+		}
+		for group, members := range groups {
+			importedGroups[group] = appendUniqueStrings(importedGroups[group], members...)
+		}
+		config.ImportSources = append(config.ImportSources, "inventory "+filePath)
+		config.ImportWarnings = append(config.ImportWarnings, warnings...)
+	}
+
+	localUser := ""
+	if current, err := user.Current(); err == nil {
+		localUser = current.Username
+	}
+	if config.Instances == nil {
+		config.Instances = map[string]SSHServerConfig{}
+	}
+	for name, instance := range imported {
+		if instance.Host == "" {
+			instance.Host = name
+		}
+		if instance.Username == "" {
+			instance.Username = localUser
+		}
+		if instance.Timeout == 0 {
+			instance.Timeout = defaultImportedSSHTimeout
+		}
+		if instance.Name == "" {
+			instance.Name = name
+		}
+		if defined, ok := config.Instances[name]; ok {
+			instance = mergeSSHInstance(defined, instance)
+		}
+		config.Instances[name] = instance
+	}
+
+	if config.Groups == nil && len(importedGroups) > 0 {
+		config.Groups = map[string][]string{}
+	}
+	for group, members := range importedGroups {
+		if _, defined := config.Groups[group]; !defined {
+			sort.Strings(members)
+			config.Groups[group] = members
+		}
+	}
+	return nil
+}
+
+// mergeSSHInstance fills the connection settings missing from override with those of base
+func mergeSSHInstance(override, base SSHServerConfig) SSHServerConfig {
+	merged := override
+	fill := func(value *string, fallback string) {
+		if *value == "" {
+			*value = fallback
+		}
+	}
+	fill(&merged.Name, base.Name)
+	fill(&merged.Host, base.Host)
+	fill(&merged.Username, base.Username)
+	fill(&merged.KeyPath, base.KeyPath)
+	fill(&merged.ProxyJump, base.ProxyJump)
+	fill(&merged.CertificatePath, base.CertificatePath)
+	fill(&merged.AgentSocket, base.AgentSocket)
+	fill(&merged.KnownHostsPath, base.KnownHostsPath)
+	fill(&merged.HostKeyPolicy, base.HostKeyPolicy)
+	fill(&merged.Description, base.Description)
+	if merged.Port == 0 {
+		merged.Port = base.Port
+	}
+	if merged.Timeout == 0 {
+		merged.Timeout = base.Timeout
+	}
+
+	overrideSource := override.Source
+	if overrideSource == "" {
+		overrideSource = "json"
+	}
+	merged.Source = overrideSource + ", " + base.Source
+	return merged
+}
+
+// loadSSHClientConfig reads an OpenSSH client config and returns an instance for every concrete Host alias
+func loadSSHClientConfig(filePath string) (map[string]SSHServerConfig, error) {
+	// Options before the first Host line apply to every host
+	blocks := []*sshConfigBlock{{patterns: []string{"*"}, options: map[string]string{}}}
+	if err := parseSSHClientConfig(filePath, &blocks, 0); err != nil {
+		return nil, err
+	}
+
+	var aliases []string
+	seen := map[string]bool{}
+	for _, block := range blocks[1:] {
+		for _, pattern := range block.patterns {
+			if strings.ContainsAny(pattern, "*?!") || seen[pattern] {
+				continue
+			}
+			seen[pattern] = true
+			aliases = append(aliases, pattern)
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	instances := make(map[string]SSHServerConfig, len(aliases))
+	for _, alias := range aliases {
+		// The first value found for each option wins, so specific hosts listed before Host * take precedence
+		options := map[string]string{}
+		for _, block := range blocks {
+			if !matchSSHHostPatterns(alias, block.patterns) {
+				continue
+			}
+			for key, value := range block.options {
+				if _, set := options[key]; !set {
+					options[key] = value
+				}
+			}
+		}
+
+		instance := SSHServerConfig{
+			Name:        alias,
+			Host:        alias,
+			Description: "Imported from " + filePath,
+			Source:      "ssh_config " + filePath,
+		}
+		if hostName := options["hostname"]; hostName != "" {
+			instance.Host = strings.ReplaceAll(hostName, "%h", alias)
+		}
+		instance.Username = options["user"]
+		if port, err := strconv.Atoi(options["port"]); err == nil {
+			instance.Port = port
+		}
+		if timeout, err := strconv.Atoi(options["connecttimeout"]); err == nil {
+			instance.Timeout = timeout
+		}
+		expandTokens := strings.NewReplacer("%d", home, "%h", instance.Host, "%r", instance.Username, "%%", "%")
+		if identity := options["identityfile"]; identity != "" && !strings.EqualFold(identity, "none") {
+			instance.KeyPath = expandTokens.Replace(identity)
+		}
+		if certificate := options["certificatefile"]; certificate != "" && !strings.EqualFold(certificate, "none") {
+			instance.CertificatePath = expandTokens.Replace(certificate)
+		}
+		if agent := options["identityagent"]; agent != "" && !strings.EqualFold(agent, "none") && agent != "SSH_AUTH_SOCK" {
+			instance.AgentSocket = expandTokens.Replace(agent)
+		}
+		if knownHosts := options["userknownhostsfile"]; knownHosts != "" && !strings.EqualFold(knownHosts, "none") {
+			instance.KnownHostsPath = expandTokens.Replace(strings.Fields(knownHosts)[0])
+		}
+		if jump := options["proxyjump"]; jump != "" && !strings.EqualFold(jump, "none") {
+			instance.ProxyJump = jump
+		}
+		if strings.EqualFold(options["stricthostkeychecking"], "accept-new") {
+			instance.HostKeyPolicy = "tofu"
+		}
+		instances[alias] = instance
+	}
+	return instances, nil
+}
+
+// parseSSHClientConfig appends the Host blocks of one config file, following Include directives
+func parseSSHClientConfig(filePath string, blocks *[]*sshConfigBlock, depth int) error {
+	if depth > maxSSHConfigIncludeDepth {
+		return fmt.Errorf("%s: too many nested Include directives", filePath)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", filePath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := splitInventoryFields(strings.TrimSpace(scanner.Text()))
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		// Keywords are separated from their arguments by whitespace or a single '='
+		keyword, args := fields[0], fields[1:]
+		if key, value, ok := strings.Cut(keyword, "="); ok {
+			keyword = key
+			if value != "" {
+				args = append([]string{value}, args...)
+			}
+		} else if len(args) > 0 && strings.HasPrefix(args[0], "=") {
+			if args[0] = strings.TrimPrefix(args[0], "="); args[0] == "" {
+				args = args[1:]
+			}
+		}
+		keyword = strings.ToLower(keyword)
+		if len(args) == 0 {
+			return fmt.Errorf("%s:%d: missing argument for %s", filePath, lineNumber, keyword)
+		}
+
+		switch keyword {
+		case "host":
+			*blocks = append(*blocks, &sshConfigBlock{patterns: args, options: map[string]string{}})
+		case "match":
+			// Match criteria cannot be evaluated here; its options are ignored
+			*blocks = append(*blocks, &sshConfigBlock{options: map[string]string{}})
+		case "include":
+			for _, pattern := range args {
+				pattern, err := expandSSHPath(pattern)
+				if err != nil {
+					return err
+				}
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(filePath), pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					if err := parseSSHClientConfig(match, blocks, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			current := (*blocks)[len(*blocks)-1]
+			if _, set := current.options[keyword]; !set {
+				current.options[keyword] = strings.Join(args, " ")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", filePath, err)
+	}
+	return nil
+}
+
+// matchSSHHostPatterns reports whether an alias matches a Host line: any pattern matches and no negated one does
+func matchSSHHostPatterns(alias string, patterns []string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			if ok, _ := path.Match(negated, alias); ok {
+				return false
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, alias); ok {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// loadAnsibleInventory reads an INI or YAML Ansible inventory
+func loadAnsibleInventory(filePath string) (*ansibleInventory, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", filePath, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yml", ".yaml":
+		return parseAnsibleYAMLInventory(data, filePath)
+	case ".json":
+		return nil, fmt.Errorf("%s: JSON inventories are not supported; use INI or YAML", filePath)
+	}
+	return parseAnsibleINIInventory(string(data), filePath)
+}
+
+// newAnsibleInventory creates an empty inventory with the implicit all and ungrouped groups
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		hosts: map[string]map[string]string{},
+		groups: map[string]*ansibleGroup{
+			"all":       {vars: map[string]string{}},
+			"ungrouped": {vars: map[string]string{}},
+		},
+	}
+}
+
+// group returns the named group, creating it when needed
+func (inventory *ansibleInventory) group(name string) *ansibleGroup {
+	group, ok := inventory.groups[name]
+	if !ok {
+		group = &ansibleGroup{vars: map[string]string{}}
+		inventory.groups[name] = group
+	}
+	return group
+}
+
+// addHost adds a host, or host range, with its variables to a group
+func (inventory *ansibleInventory) addHost(groupName, pattern string, vars map[string]string) error {
+	names, err := expandAnsibleHostRange(pattern)
+	if err != nil {
+		return err
+	}
+	group := inventory.group(groupName)
+	for _, name := range names {
+		hostVars, ok := inventory.hosts[name]
+		if !ok {
+			hostVars = map[string]string{}
+			inventory.hosts[name] = hostVars
+			inventory.order = append(inventory.order, name)
+		}
+		for key, value := range vars {
+			hostVars[key] = value
+		}
+		group.hosts = appendUniqueStrings(group.hosts, name)
+	}
+	return nil
+}
+
+// parseAnsibleINIInventory parses an INI inventory with [group], [group:vars] and [group:children] sections
+func parseAnsibleINIInventory(data, filePath string) (*ansibleInventory, error) {
+	inventory := newAnsibleInventory()
+	section, kind := "ungrouped", "hosts"
+
+	for lineNumber, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section, kind = strings.TrimSpace(line[1:len(line)-1]), "hosts"
+			if name, suffix, ok := strings.Cut(section, ":"); ok {
+				section, kind = name, suffix
+			}
+			if kind != "hosts" && kind != "vars" && kind != "children" {
+				return nil, fmt.Errorf("%s:%d: unknown section type '%s'", filePath, lineNumber+1, kind)
+			}
+			inventory.group(section)
+			continue
+		}
+
+		fields := splitInventoryFields(line)
+		switch kind {
+		case "vars":
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected key=value in [%s:vars]", filePath, lineNumber+1, section)
+			}
+			inventory.group(section).vars[strings.TrimSpace(key)] = unquoteInventoryValue(strings.TrimSpace(value))
+		case "children":
+			inventory.group(fields[0])
+			inventory.group(section).children = appendUniqueStrings(inventory.group(section).children, fields[0])
+		default:
+			vars := map[string]string{}
+			for _, field := range fields[1:] {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					return nil, fmt.Errorf("%s:%d: expected key=value after host '%s', got '%s'", filePath, lineNumber+1, fields[0], field)
+				}
+				vars[key] = value
+			}
+			if err := inventory.addHost(section, fields[0], vars); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", filePath, lineNumber+1, err)
+			}
+		}
+	}
+	return inventory, nil
+}
+
+// parseAnsibleYAMLInventory parses a YAML inventory of nested groups with hosts, vars and children
+func parseAnsibleYAMLInventory(data []byte, filePath string) (*ansibleInventory, error) {
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filePath, err)
+	}
+
+	inventory := newAnsibleInventory()
+	var walk func(name string, node any) error
+	walk = func(name string, node any) error {
+		group := inventory.group(name)
+		if node == nil {
+			return nil
+		}
+		entries, ok := node.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: group '%s' must be a mapping", filePath, name)
+		}
+		for key, value := range entries {
+			switch key {
+			case "hosts":
+				hosts, _ := value.(map[string]any)
+				for host, hostVars := range hosts {
+					if err := inventory.addHost(name, host, inventoryVars(hostVars)); err != nil {
+						return fmt.Errorf("%s: %v", filePath, err)
+					}
+				}
+			case "vars":
+				for varName, varValue := range inventoryVars(value) {
+					group.vars[varName] = varValue
+				}
+			case "children":
+				children, _ := value.(map[string]any)
+				for child, childNode := range children {
+					group.children = appendUniqueStrings(group.children, child)
+					if err := walk(child, childNode); err != nil {
+						return err
+					}
+				}
+			default:
+				return fmt.Errorf("%s: unexpected key '%s' in group '%s' (expected hosts, vars or children)", filePath, key, name)
+			}
+		}
+		return nil
+	}
+
+	for name, node := range root {
+		if err := walk(name, node); err != nil {
+			return nil, err
+		}
+		if name != "all" {
+			inventory.groups["all"].children = appendUniqueStrings(inventory.groups["all"].children, name)
+		}
+	}
+	sort.Strings(inventory.order)
+	return inventory, nil
+}
+
+// inventoryVars converts YAML host or group variables to strings
+func inventoryVars(node any) map[string]string {
+	vars := map[string]string{}
+	entries, _ := node.(map[string]any)
+	for key, value := range entries {
+		if value != nil {
+			vars[key] = fmt.Sprint(value)
+		}
+	}
+	return vars
+}
+
+// instances converts inventory hosts to SSH instances and flattens the group tree into member lists
+func (inventory *ansibleInventory) instances(source string) (map[string]SSHServerConfig, map[string][]string, []string) {
+	// Depth orders group variables: all first, then parents before their children
+	depth := map[string]int{}
+	var visit func(name string, level int, path map[string]bool)
+	visit = func(name string, level int, path map[string]bool) {
+		if path[name] || depth[name] > level {
+			return
+		}
+		depth[name] = level
+		path[name] = true
+		for _, child := range inventory.groups[name].children {
+			visit(child, level+1, path)
+		}
+		delete(path, name)
+	}
+	for name := range inventory.groups {
+		visit(name, 0, map[string]bool{})
+	}
+	for _, child := range inventory.groups["all"].children {
+		visit(child, 1, map[string]bool{"all": true})
+	}
+
+	members := map[string][]string{}
+	var collect func(name string, path map[string]bool) []string
+	collect = func(name string, path map[string]bool) []string {
+		if path[name] {
+			return nil
+		}
+		path[name] = true
+		defer delete(path, name)
+		hosts := append([]string(nil), inventory.groups[name].hosts...)
+		for _, child := range inventory.groups[name].children {
+			hosts = appendUniqueStrings(hosts, collect(child, path)...)
+		}
+		return hosts
+	}
+	hostGroups := map[string][]string{}
+	for name := range inventory.groups {
+		hosts := collect(name, map[string]bool{})
+		for _, host := range hosts {
+			hostGroups[host] = append(hostGroups[host], name)
+		}
+		if name != "all" && name != "ungrouped" && len(hosts) > 0 {
+			sort.Strings(hosts)
+			members[name] = hosts
+		}
+	}
+
+	var warnings []string
+	instances := map[string]SSHServerConfig{}
+	for _, name := range inventory.order {
+		groups := hostGroups[name]
+		sort.Slice(groups, func(i, j int) bool {
+			if depth[groups[i]] != depth[groups[j]] {
+				return depth[groups[i]] < depth[groups[j]]
+			}
+			return groups[i] < groups[j]
+		})
+
+		vars := map[string]string{}
+		for key, value := range inventory.groups["all"].vars {
+			vars[key] = value
+		}
+		for _, group := range groups {
+			for key, value := range inventory.groups[group].vars {
+				vars[key] = value
+			}
+		}
+		for key, value := range inventory.hosts[name] {
+			vars[key] = value
+		}
+
+		if connection := vars["ansible_connection"]; connection != "" && connection != "ssh" && connection != "paramiko" && connection != "smart" {
+			warnings = append(warnings, fmt.Sprintf("%s: skipped host '%s' with ansible_connection=%s", source, name, connection))
+			for group, hosts := range members {
+				members[group] = removeString(hosts, name)
+			}
+			continue
+		}
+
+		instance := SSHServerConfig{
+			Host:        firstInventoryVar(vars, "ansible_host", "ansible_ssh_host"),
+			Username:    firstInventoryVar(vars, "ansible_user", "ansible_ssh_user"),
+			KeyPath:     firstInventoryVar(vars, "ansible_ssh_private_key_file", "ansible_private_key_file"),
+			ProxyJump:   sshArgsProxyJump(vars["ansible_ssh_common_args"] + " " + vars["ansible_ssh_extra_args"]),
+			Description: "Imported from " + strings.TrimPrefix(source, "inventory "),
+			Source:      source,
+		}
+		if port := firstInventoryVar(vars, "ansible_port", "ansible_ssh_port"); port != "" {
+			parsed, err := strconv.Atoi(port)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: ignoring invalid port '%s' for host '%s'", source, port, name))
+			}
+			instance.Port = parsed
+		}
+		if firstInventoryVar(vars, "ansible_password", "ansible_ssh_pass") != "" {
+			warnings = append(warnings, fmt.Sprintf("%s: ignoring the inventory password of host '%s'; use a key, the agent or a JSON instance", source, name))
+		}
+		instances[name] = instance
+	}
+
+	for group, hosts := range members {
+		if len(hosts) == 0 {
+			delete(members, group)
+		}
+	}
+	return instances, members, warnings
+}
+
+// firstInventoryVar returns the first non-empty variable, supporting the legacy ansible_ssh_* names
+func firstInventoryVar(vars map[string]string, names ...string) string {
+	for _, name := range names {
+		if value := vars[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// sshArgsProxyJump extracts the jump hosts from ssh arguments such as "-J bastion" or "-o ProxyJump=bastion"
+func sshArgsProxyJump(args string) string {
+	fields := splitInventoryFields(args)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch {
+		case field == "-J" && i+1 < len(fields):
+			return fields[i+1]
+		case strings.HasPrefix(field, "-J"):
+			return strings.TrimPrefix(field, "-J")
+		case field == "-o" && i+1 < len(fields):
+			i++
+			field = "-o" + fields[i]
+			fallthrough
+		case strings.HasPrefix(field, "-o"):
+			option := strings.TrimPrefix(field, "-o")
+			key, value, ok := strings.Cut(option, "=")
+			if !ok {
+				key, value, _ = strings.Cut(option, " ")
+			}
+			if strings.EqualFold(strings.TrimSpace(key), "ProxyJump") {
+				return strings.TrimSpace(value)
+			}
+		}
+	}
+	return ""
+}
+
+// expandAnsibleHostRange expands host patterns such as web[01:03].example.com or db-[a:c]
+func expandAnsibleHostRange(pattern string) ([]string, error) {
+	start := strings.Index(pattern, "[")
+	if start < 0 {
+		return []string{pattern}, nil
+	}
+	end := strings.Index(pattern[start:], "]")
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated range in host '%s'", pattern)
+	}
+	end += start
+	first, last, ok := strings.Cut(pattern[start+1:end], ":")
+	if !ok || first == "" || last == "" {
+		return nil, fmt.Errorf("invalid range in host '%s'", pattern)
+	}
+
+	var values []string
+	if from, err := strconv.Atoi(first); err == nil {
+		to, err := strconv.Atoi(last)
+		if err != nil || to < from {
+			return nil, fmt.Errorf("invalid range in host '%s'", pattern)
+		}
+		for i := from; i <= to; i++ {
+			values = append(values, fmt.Sprintf("%0*d", len(first), i))
+		}
+	} else if len(first) == 1 && len(last) == 1 && first[0] <= last[0] {
+		for c := first[0]; c <= last[0]; c++ {
+			values = append(values, string(c))
+		}
+	} else {
+		return nil, fmt.Errorf("invalid range in host '%s'", pattern)
+	}
+
+	var names []string
+	for _, value := range values {
+		rest, err := expandAnsibleHostRange(pattern[end+1:])
+		if err != nil {
+			return nil, err
+		}
+		for _, suffix := range rest {
+			names = append(names, pattern[:start]+value+suffix)
+		}
+	}
+	return names, nil
+}
+
+// splitInventoryFields splits a line on whitespace, keeping quoted strings together and removing the quotes
+func splitInventoryFields(line string) []string {
+	var fields []string
+	var current strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+// unquoteInventoryValue removes matching quotes around an inventory variable value
+func unquoteInventoryValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// appendUniqueStrings appends the values not already present
+func appendUniqueStrings(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		found := false
+		for _, value := range values {
+			if value == addition {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
+
+// removeString returns values without the given value
+func removeString(values []string, remove string) []string {
+	kept := values[:0]
+	for _, value := range values {
+		if value != remove {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,204 @@
+package builtin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSSHClientConfig = `# Jump host
+Host bastion
+    HostName bastion.example.com
+    User jump
+    Port 2222
+
+Host art-* !art-test
+    ProxyJump bastion
+    IdentityFile ~/.ssh/artifactory
+
+Host art-1 art-2
+    HostName %h.internal
+    ConnectTimeout=10
+
+Host art-test
+    HostName 10.0.0.99
+
+Include conf.d/*
+
+Host *
+    User ops
+    IdentityFile ~/.ssh/id_ed25519
+    StrictHostKeyChecking accept-new
+`
+
+const testINIInventory = `jumpbox ansible_host=10.0.0.5
+
+[artifactory]
+art-1 ansible_port=2200
+art-[2:3] ansible_host=10.0.1.10
+
+[xray]
+xray-[01:02].example.com ansible_user="scan user"
+windows-1 ansible_connection=winrm
+
+[jfrog:children]
+artifactory
+xray
+
+[jfrog:vars]
+ansible_user=deploy
+ansible_ssh_common_args='-o ProxyJump=bastion -o StrictHostKeyChecking=no'
+`
+
+const testYAMLInventory = `all:
+  vars:
+    ansible_user: root
+  children:
+    db:
+      hosts:
+        pg-1:
+          ansible_host: 10.0.2.1
+          ansible_port: 5022
+        pg-2:
+      vars:
+        ansible_ssh_private_key_file: ~/.ssh/db
+`
+
+func writeInventoryFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSSHClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := writeInventoryFile(t, dir, "config", testSSHClientConfig)
+	writeInventoryFile(t, dir, "conf.d/extra", "Host edge\n  HostName edge.example.com\n")
+
+	instances, err := loadSSHClientConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 5 {
+		t.Fatalf("Expected bastion, art-1, art-2, art-test and edge, got %v", instances)
+	}
+
+	bastion := instances["bastion"]
+	if bastion.Host != "bastion.example.com" || bastion.Username != "jump" || bastion.Port != 2222 || bastion.KeyPath != "~/.ssh/id_ed25519" || bastion.HostKeyPolicy != "tofu" {
+		t.Errorf("Unexpected bastion %+v", bastion)
+	}
+	// The first matching value wins, so art-* settings come before the Host * defaults
+	art := instances["art-2"]
+	if art.Host != "art-2.internal" || art.Username != "ops" || art.ProxyJump != "bastion" || art.KeyPath != "~/.ssh/artifactory" || art.Timeout != 10 {
+		t.Errorf("Unexpected art-2 %+v", art)
+	}
+	if test := instances["art-test"]; test.ProxyJump != "" || test.KeyPath != "~/.ssh/id_ed25519" {
+		t.Errorf("Negated patterns must not apply to art-test: %+v", test)
+	}
+	if edge := instances["edge"]; edge.Host != "edge.example.com" {
+		t.Errorf("Expected the included host, got %+v", edge)
+	}
+}
+
+func TestAnsibleInventory(t *testing.T) {
+	inventory, err := parseAnsibleINIInventory(testINIInventory, "hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	instances, groups, warnings := inventory.instances("inventory hosts")
+
+	if len(instances) != 6 || len(warnings) != 1 {
+		t.Fatalf("Expected six SSH hosts and a warning for the winrm host, got %v %v", instances, warnings)
+	}
+	if art := instances["art-1"]; art.Port != 2200 || art.Username != "deploy" || art.ProxyJump != "bastion" || art.Host != "" {
+		t.Errorf("Unexpected art-1 %+v", art)
+	}
+	if art := instances["art-3"]; art.Host != "10.0.1.10" {
+		t.Errorf("Unexpected art-3 %+v", art)
+	}
+	// Host variables override group variables
+	if xray := instances["xray-02.example.com"]; xray.Username != "scan user" {
+		t.Errorf("Unexpected xray-02 %+v", xray)
+	}
+	if !reflect.DeepEqual(groups["jfrog"], []string{"art-1", "art-2", "art-3", "xray-01.example.com", "xray-02.example.com"}) {
+		t.Errorf("Child groups must be flattened, got %v", groups)
+	}
+	if _, ok := groups["ungrouped"]; ok {
+		t.Error("The implicit ungrouped group must not be imported")
+	}
+
+	inventory, err = parseAnsibleYAMLInventory([]byte(testYAMLInventory), "hosts.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	instances, groups, _ = inventory.instances("inventory hosts.yml")
+	if pg := instances["pg-1"]; pg.Host != "10.0.2.1" || pg.Port != 5022 || pg.Username != "root" || pg.KeyPath != "~/.ssh/db" {
+		t.Errorf("Unexpected pg-1 %+v", pg)
+	}
+	if !reflect.DeepEqual(groups["db"], []string{"pg-1", "pg-2"}) {
+		t.Errorf("Unexpected groups %v", groups)
+	}
+
+	if _, err := parseAnsibleINIInventory("[web]\nweb[3:1]\n", "hosts"); err == nil {
+		t.Error("Expected an error for an invalid range")
+	}
+}
+
+func TestLoadSSHConfig_Imports(t *testing.T) {
+	dir := t.TempDir()
+	sshConfig := writeInventoryFile(t, dir, "config", testSSHClientConfig)
+	inventory := writeInventoryFile(t, dir, "hosts.ini", testINIInventory)
+
+	config, err := LoadSSHConfig(map[string]any{"config": map[string]any{
+		"sshConfigFiles": []any{sshConfig},
+		"inventories":    []any{inventory},
+		"instances": map[string]any{
+			"art-2": map[string]any{"username": "admin", "tags": []any{"primary"}},
+		},
+		"groups": map[string]any{"jfrog": []any{"art-1"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The inventory host falls back to its ssh_config entry for settings it does not set
+	art1 := config.Instances["art-1"]
+	if art1.Host != "art-1.internal" || art1.Port != 2200 || art1.Username != "deploy" || art1.KeyPath != "~/.ssh/artifactory" {
+		t.Errorf("Unexpected art-1 %+v", art1)
+	}
+	// JSON settings win, the rest comes from the imports
+	art2 := config.Instances["art-2"]
+	if art2.Username != "admin" || art2.Host != "10.0.1.10" || !reflect.DeepEqual(art2.Tags, []string{"primary"}) || art2.Source == "" {
+		t.Errorf("Unexpected art-2 %+v", art2)
+	}
+	if !reflect.DeepEqual(config.Groups["jfrog"], []string{"art-1"}) || len(config.Groups["xray"]) != 2 {
+		t.Errorf("JSON groups must override imported ones: %v", config.Groups)
+	}
+	if jumpbox := config.Instances["jumpbox"]; jumpbox.Host != "10.0.0.5" || jumpbox.Username == "" || jumpbox.Timeout != defaultImportedSSHTimeout {
+		t.Errorf("Unexpected defaults for jumpbox %+v", jumpbox)
+	}
+
+	useTestSSHConfig(t, config.Instances)
+	globalSSHConfig.Groups = config.Groups
+	globalSSHConfig.ImportSources = config.ImportSources
+	response, result := callSSHTool(t, executeSSHListInstances, map[string]any{"group": "artifactory"})
+	if result.IsError {
+		t.Fatalf("Unexpected error: %v", result.Content)
+	}
+	if response["count"] != float64(3) || len(response["sources"].([]any)) != 2 {
+		t.Errorf("Unexpected catalog %v", response)
+	}
+	first := response["instances"].([]any)[0].(map[string]any)
+	if first["instance"] != "art-1" || first["password"] != nil || !reflect.DeepEqual(first["groups"], []any{"artifactory", "jfrog"}) {
+		t.Errorf("Unexpected entry %v", first)
+	}
+
+	if _, err := LoadSSHConfig(map[string]any{"config": map[string]any{"inventories": []any{filepath.Join(dir, "missing")}}}); err == nil {
+		t.Error("Expected an error for a missing inventory")
+	}
+}
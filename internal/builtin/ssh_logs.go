@@ -0,0 +1,444 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// defaultSSHLogMaxFiles bounds how many files one call searches
+	defaultSSHLogMaxFiles = 50
+	// sshLogMaxOutputBytes caps the grep output transferred from the server
+	sshLogMaxOutputBytes = 4 * 1024 * 1024
+)
+
+// sshLogWindowFilter is an awk program that drops grep -n matches outside the time window before
+// the output is tailed, so lines outside the window cannot crowd it out. Timestamps are compared
+// as YYYYMMDDhhmmss strings and lines without one are kept, as in inLogTimeWindow. Matches outside
+// the window become context lines, which are only printed around kept matches. The program sticks
+// to POSIX awk so that mawk and busybox run it too
+const sshLogWindowFilter = `function ts(s, t) {
+  if (match(s, /[0-9][0-9][0-9][0-9][-\/][0-9][0-9][-\/][0-9][0-9][ T][0-9][0-9]:[0-9][0-9]:[0-9][0-9]/)) {
+    t = substr(s, RSTART, RLENGTH); return substr(t, 1, 4) substr(t, 6, 2) substr(t, 9, 2) substr(t, 12, 2) substr(t, 15, 2) substr(t, 18, 2)
+  }
+  if (match(s, /[0-9][0-9]\/[0-9][0-9]\/[0-9][0-9][0-9][0-9] [0-9][0-9]:[0-9][0-9]:[0-9][0-9]/)) {
+    t = substr(s, RSTART, RLENGTH); return substr(t, 7, 4) substr(t, 1, 2) substr(t, 4, 2) substr(t, 12, 2) substr(t, 15, 2) substr(t, 18, 2)
+  }
+  return ""
+}
+/^[0-9]+:/ {
+  t = ts($0)
+  if (t == "" || (t >= since && (until == "" || t < until))) { printf "%s", buf; buf = ""; held = 0; print; after = context; next }
+  sub(/:/, "-")
+}
+/^[0-9]+-/ {
+  if (after > 0) { print; after--; next }
+  buf = buf $0 "\n"; held++
+  if (held > context) { buf = substr(buf, index(buf, "\n") + 1); held-- }
+}`
+
+// sshRemoteGlobPattern limits remote globs to characters that cannot break out of the shell word
+var sshRemoteGlobPattern = regexp.MustCompile(`^/[A-Za-z0-9_./*?\[\]@+=:,~-]*$`)
+
+// logTimestampLayouts parses the timestamps found by extractTimestamp
+var logTimestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	"01/02/2006 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// SSHRemoteLogAnalysis is the analyze_logs summary for logs searched on a remote instance
+type SSHRemoteLogAnalysis struct {
+	ServerName string `json:"server_name"`
+	*LogAnalysisSummary
+	Since        string   `json:"since,omitempty"`
+	Until        string   `json:"until,omitempty"`
+	SkippedFiles []string `json:"skipped_files,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// sshGrepLine is one line of grep -n output: a match ("12:text") or context ("11-text")
+type sshGrepLine struct {
+	number int
+	match  bool
+	text   string
+}
+
+// sshRemoteLogSearch holds the options of one remote log search
+type sshRemoteLogSearch struct {
+	globs         []string
+	patterns      []string
+	caseSensitive bool
+	contextLines  int
+	maxResults    int
+	maxFiles      int
+	since         time.Time
+	until         time.Time
+}
+
+// addSSHLogTools registers the remote log analysis tool
+func addSSHLogTools(s *server.MCPServer) {
+	analyzeTool := mcp.NewTool("ssh_analyze_remote_logs",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Search log files on a remote instance with grep/zgrep running server-side and return the matches in the same structure as analyze_logs (errors, warnings and info with context and timestamps). Only the matched lines are transferred, so large logs can be triaged in place. The search is checked against the instance policy like the grep, zgrep, stat, awk and tail commands it runs" + sshConfirmationNotice),
+			mcp.WithString("paths",
+				mcp.Required(),
+				mcp.Description("Comma-separated absolute remote globs (e.g. '/var/opt/jfrog/artifactory/log/*.log,/var/opt/jfrog/artifactory/log/archived/*.log.gz'); .gz files are searched with zgrep"),
+			),
+			mcp.WithString("search_patterns",
+				mcp.Description("Comma-separated extended regular expressions (default: 'ERROR,WARN,Exception,failed,CRITICAL,FATAL')"),
+			),
+			mcp.WithBoolean("case_sensitive",
+				mcp.Description("Case sensitive search (default: false)"),
+			),
+			mcp.WithString("since",
+				mcp.Description("Only include lines at or after this time: RFC3339, 'YYYY-MM-DD HH:MM:SS', 'YYYY-MM-DD' or a relative duration like '30m', '6h' or '2d'. Files last modified earlier are skipped"),
+			),
+			mcp.WithString("until",
+				mcp.Description("Only include lines before this time, in the same formats as since"),
+			),
+			mcp.WithNumber("max_results",
+				mcp.Description("Maximum matches kept per file; the most recent are kept (default: 100)"),
+			),
+			mcp.WithNumber("max_files",
+				mcp.Description("Maximum files searched (default: 50)"),
+			),
+			mcp.WithNumber("context_lines",
+				mcp.Description("Number of context lines to include around matches (default: 2)"),
+			),
+			mcp.WithString("severity_levels",
+				mcp.Description("Comma-separated severity levels used to categorize matches (default: 'ERROR,WARNING,WARN,INFO,DEBUG,CRITICAL,FATAL')"),
+			),
+			mcp.WithNumber("timeout",
+				mcp.Description("Search timeout in seconds (default: 120)"),
+			),
+			mcp.WithString("confirmation_token",
+				mcp.Description("Token returned when the search required confirmation. Only pass it after the user approved the search"),
+			),
+		}, sshConnectionOptions()...)...,
+	)
+
+	s.AddTool(analyzeTool, executeSSHAnalyzeRemoteLogs)
+}
+
+// executeSSHAnalyzeRemoteLogs handles the remote log analysis tool execution
+func executeSSHAnalyzeRemoteLogs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	serverName, instanceConfig, err := sshInstanceFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	search := sshRemoteLogSearch{
+		globs:         parseCommaSeparated(request.GetString("paths", "")),
+		patterns:      parseCommaSeparated(request.GetString("search_patterns", "ERROR,WARN,Exception,failed,CRITICAL,FATAL")),
+		caseSensitive: request.GetBool("case_sensitive", false),
+		contextLines:  int(request.GetFloat("context_lines", 2)),
+		maxResults:    int(request.GetFloat("max_results", 100)),
+		maxFiles:      int(request.GetFloat("max_files", defaultSSHLogMaxFiles)),
+	}
+	severityLevels := parseCommaSeparated(request.GetString("severity_levels", "ERROR,WARNING,WARN,INFO,DEBUG,CRITICAL,FATAL"))
+	timeout := time.Duration(request.GetFloat("timeout", 120)) * time.Second
+
+	if len(search.globs) == 0 {
+		return mcp.NewToolResultError("paths is required"), nil
+	}
+	if len(search.patterns) == 0 {
+		return mcp.NewToolResultError("search_patterns must not be empty"), nil
+	}
+	if search.since, err = parseLogTimeBound(request.GetString("since", ""), startTime); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid since: %v", err)), nil
+	}
+	if search.until, err = parseLogTimeBound(request.GetString("until", ""), startTime); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid until: %v", err)), nil
+	}
+	script, err := buildSSHLogSearchScript(search)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	decision := evaluateSSHLogSearch(sshInstancePolicy(instanceConfig), search)
+	operation := fmt.Sprintf("analyze_remote_logs %s %s", strings.Join(search.globs, ","), strings.Join(search.patterns, ","))
+	if !confirmSSHPolicyDecision(decision, serverName, operation, request.GetString("confirmation_token", "")) {
+		return sshPolicyResult(serverName, "analyze_remote_logs", decision, startTime), nil
+	}
+
+	client, release, err := acquireSSHClient(serverName, instanceConfig)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to connect: %v", err)), nil
+	}
+	defer release()
+
+	notifyToolProgress(ctx, request, 0, 1, fmt.Sprintf("Searching %s on %s", strings.Join(search.globs, ", "), serverName))
+	cmdResult, err := runSSHCommand(ctx, client, "sh -c "+shellQuote(script), sshCommandOptions{
+		Timeout:        timeout,
+		MaxOutputBytes: sshLogMaxOutputBytes,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to search logs: %v", err)), nil
+	}
+
+	analysis := analyzeSSHGrepOutput(cmdResult.Output, search, compileLogPatterns(search.patterns, search.caseSensitive), compileSeverityPatterns(severityLevels))
+	analysis.ServerName = serverName
+	analysis.SourcePath = fmt.Sprintf("%s:%s", serverName, strings.Join(search.globs, ","))
+	analysis.SearchPatterns = search.patterns
+	analysis.AnalysisTime = time.Now()
+	analysis.Duration = time.Since(startTime).String()
+	if !search.since.IsZero() {
+		analysis.Since = search.since.Format(time.RFC3339)
+	}
+	if !search.until.IsZero() {
+		analysis.Until = search.until.Format(time.RFC3339)
+	}
+	if cmdResult.stopped() {
+		analysis.Warnings = append(analysis.Warnings, cmdResult.Error+"; results are partial")
+	}
+	if cmdResult.Truncated {
+		analysis.Warnings = append(analysis.Warnings, "grep output was truncated; lower max_results or narrow the paths")
+	}
+	if analysis.TotalFiles == 0 && len(analysis.SkippedFiles) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no files match %s on %s", strings.Join(search.globs, ", "), serverName)), nil
+	}
+
+	resultJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// buildSSHLogSearchScript builds the shell script that greps each matching file and keeps the most
+// recent matches. Every file section starts with a marker line, which grep -n output cannot produce
+func buildSSHLogSearchScript(search sshRemoteLogSearch) (string, error) {
+	for _, glob := range search.globs {
+		if !sshRemoteGlobPattern.MatchString(glob) {
+			return "", fmt.Errorf("invalid remote path '%s': use an absolute path with glob characters only (no spaces or shell syntax)", glob)
+		}
+	}
+	if search.maxResults <= 0 {
+		search.maxResults = 100
+	}
+	if search.maxFiles <= 0 {
+		search.maxFiles = defaultSSHLogMaxFiles
+	}
+
+	flags := []string{"-n", "-h", "-E"}
+	if !search.caseSensitive {
+		flags = append(flags, "-i")
+	}
+	if search.contextLines > 0 {
+		flags = append(flags, fmt.Sprintf("-C %d", search.contextLines))
+	}
+	for _, pattern := range search.patterns {
+		flags = append(flags, "-e "+shellQuote(pattern))
+	}
+	grepArgs := strings.Join(flags, " ")
+	// Context lines count against the limit too
+	limit := search.maxResults * (2*search.contextLines + 2)
+
+	var script strings.Builder
+	script.WriteString("n=0\n")
+	fmt.Fprintf(&script, "for f in %s; do\n", strings.Join(search.globs, " "))
+	script.WriteString("  [ -f \"$f\" ] || continue\n")
+	if !search.since.IsZero() {
+		// Files last written before the window cannot contain lines in it; keep them when stat is unavailable
+		script.WriteString("  m=$(stat -c %Y \"$f\" 2>/dev/null || echo 0)\n")
+		fmt.Fprintf(&script, "  if [ \"$m\" -gt 0 ] && [ \"$m\" -lt %d ]; then echo \"@@@skip $f\"; continue; fi\n", search.since.Unix())
+	}
+	fmt.Fprintf(&script, "  n=$((n + 1)); if [ $n -gt %d ]; then echo \"@@@limit\"; break; fi\n", search.maxFiles)
+	script.WriteString("  echo \"@@@file $f\"\n")
+	window := ""
+	if !search.since.IsZero() || !search.until.IsZero() {
+		window = fmt.Sprintf(" | awk -v since=%s -v until=%s -v context=%d %s", sshLogWindowBound(search.since), sshLogWindowBound(search.until), search.contextLines, shellQuote(sshLogWindowFilter))
+	}
+	fmt.Fprintf(&script, "  case \"$f\" in *.gz) zgrep %s -- \"$f\" ;; *) grep %s -- \"$f\" ;; esac%s | tail -n %d\n", grepArgs, grepArgs, window, limit)
+	script.WriteString("done\n")
+	return script.String(), nil
+}
+
+// evaluateSSHLogSearch checks a search like the commands its script runs on each path, so read-only
+// mode, protected paths and the instance allow, deny and confirm lists apply to it. A glob ending in
+// .gz is only read by zgrep, one ending in * may match either kind of file
+func evaluateSSHLogSearch(policy *SSHCommandPolicy, search sshRemoteLogSearch) *SSHPolicyDecision {
+	checker := &sshPolicyChecker{policy: policy, decision: &SSHPolicyDecision{Action: sshPolicyAllow}}
+	check := func(args ...string) {
+		checker.checkArgs(strings.Join(args, " "), args, 0)
+	}
+
+	grepArgs := []string{"-n", "-h", "-E"}
+	for _, pattern := range search.patterns {
+		grepArgs = append(grepArgs, "-e", pattern)
+	}
+	for _, glob := range search.globs {
+		compressed := strings.HasSuffix(glob, ".gz")
+		if !compressed {
+			check(append(append([]string{"grep"}, grepArgs...), "--", glob)...)
+		}
+		if compressed || strings.HasSuffix(glob, "*") {
+			check(append(append([]string{"zgrep"}, grepArgs...), "--", glob)...)
+		}
+		if !search.since.IsZero() {
+			check("stat", "-c", "%Y", glob)
+		}
+	}
+	if !search.since.IsZero() || !search.until.IsZero() {
+		checker.checkArgs("awk <time window filter>", []string{"awk", sshLogWindowFilter}, 0)
+	}
+	check("tail")
+	return checker.decision
+}
+
+// sshLogWindowBound formats a window bound the way sshLogWindowFilter compares timestamps
+func sshLogWindowBound(bound time.Time) string {
+	if bound.IsZero() {
+		return `""`
+	}
+	return bound.UTC().Format("20060102150405")
+}
+
+// analyzeSSHGrepOutput turns the per-file grep output into an analyze_logs summary
+func analyzeSSHGrepOutput(output string, search sshRemoteLogSearch, patterns []*regexp.Regexp, severityPatterns map[string]*regexp.Regexp) *SSHRemoteLogAnalysis {
+	analysis := &SSHRemoteLogAnalysis{LogAnalysisSummary: newLogAnalysisSummary()}
+
+	var file string
+	var lines []sshGrepLine
+	flush := func() {
+		if file == "" {
+			return
+		}
+		results := sshGrepResults(file, lines, search, patterns, severityPatterns)
+		for _, result := range results {
+			addLogAnalysisResult(analysis.LogAnalysisSummary, result)
+		}
+		analysis.FileStats[file] = len(results)
+		file, lines = "", nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@@file "):
+			flush()
+			file = strings.TrimPrefix(line, "@@@file ")
+			analysis.TotalFiles++
+		case strings.HasPrefix(line, "@@@skip "):
+			analysis.SkippedFiles = append(analysis.SkippedFiles, strings.TrimPrefix(line, "@@@skip "))
+		case line == "@@@limit":
+			analysis.Warnings = append(analysis.Warnings, fmt.Sprintf("only the first %d files were searched; raise max_files or narrow the paths", search.maxFiles))
+		case file != "":
+			if grepLine, ok := parseSSHGrepLine(line); ok {
+				lines = append(lines, grepLine)
+			}
+		}
+	}
+	flush()
+	return analysis
+}
+
+// parseSSHGrepLine parses "12:text" (match), "12-text" (context); "--" group separators are dropped
+func parseSSHGrepLine(line string) (sshGrepLine, bool) {
+	end := 0
+	for end < len(line) && line[end] >= '0' && line[end] <= '9' {
+		end++
+	}
+	if end == 0 || end == len(line) || (line[end] != ':' && line[end] != '-') {
+		return sshGrepLine{}, false
+	}
+	number, _ := strconv.Atoi(line[:end])
+	return sshGrepLine{number: number, match: line[end] == ':', text: line[end+1:]}, true
+}
+
+// sshGrepResults builds analysis results for the matches of one file, applying the time window
+func sshGrepResults(file string, lines []sshGrepLine, search sshRemoteLogSearch, patterns []*regexp.Regexp, severityPatterns map[string]*regexp.Regexp) []LogAnalysisResult {
+	var results []LogAnalysisResult
+	for i, line := range lines {
+		if !line.match {
+			continue
+		}
+		timestamp := extractTimestamp(line.text)
+		if !inLogTimeWindow(timestamp, search.since, search.until) {
+			continue
+		}
+
+		// Context comes from the neighbouring lines that grep printed for the same group
+		var group []string
+		index := 0
+		for j := i - 1; j >= 0 && lines[j].number >= line.number-search.contextLines && lines[j].number < line.number; j-- {
+			group = append([]string{lines[j].text}, group...)
+			index++
+		}
+		group = append(group, line.text)
+		for j := i + 1; j < len(lines) && lines[j].number <= line.number+search.contextLines && lines[j].number > line.number; j++ {
+			group = append(group, lines[j].text)
+		}
+
+		matched := line.text
+		for _, pattern := range patterns {
+			if match := pattern.FindString(line.text); match != "" {
+				matched = match
+				break
+			}
+		}
+
+		results = append(results, LogAnalysisResult{
+			FilePath:    file,
+			LineNumber:  line.number,
+			FullLine:    line.text,
+			MatchedText: matched,
+			Severity:    logLineSeverity(line.text, severityPatterns),
+			Timestamp:   timestamp,
+			Context:     getLogContextLines(group, index, search.contextLines),
+		})
+	}
+
+	if search.maxResults > 0 && len(results) > search.maxResults {
+		results = results[len(results)-search.maxResults:]
+	}
+	return results
+}
+
+// inLogTimeWindow reports whether a line timestamp falls within the window. Lines without a full
+// timestamp, such as stack trace lines, are kept
+func inLogTimeWindow(timestamp string, since, until time.Time) bool {
+	if since.IsZero() && until.IsZero() {
+		return true
+	}
+	for _, layout := range logTimestampLayouts {
+		// Log timestamps without a zone are taken as UTC, which is what JFrog services write
+		if parsed, err := time.Parse(layout, timestamp); err == nil {
+			return (since.IsZero() || !parsed.Before(since)) && (until.IsZero() || parsed.Before(until))
+		}
+	}
+	return true
+}
+
+// parseLogTimeBound parses an absolute time or a duration before now such as "90m" or "2d"
+func parseLogTimeBound(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if count, err := strconv.Atoi(days); err == nil {
+			return now.Add(-time.Duration(count) * 24 * time.Hour), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a time or duration", value)
+}
@@ -0,0 +1,156 @@
+package builtin
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeSSHGrepOutput(t *testing.T) {
+	output := strings.Join([]string{
+		"@@@skip /var/log/old.log",
+		"@@@file /var/log/app.log",
+		"9-2024-03-01 10:00:00 INFO starting",
+		"10:2024-03-01 10:00:01 ERROR connection refused",
+		"11-\tat com.example.Db.connect",
+		"--",
+		"40:2024-03-01 12:30:00 WARN slow query",
+		"@@@file /var/log/other.log",
+		"@@@limit",
+	}, "\n")
+
+	search := sshRemoteLogSearch{contextLines: 1, maxResults: 10, maxFiles: 2}
+	analysis := analyzeSSHGrepOutput(output, search, compileLogPatterns([]string{"error", "warn"}, false), compileSeverityPatterns([]string{"ERROR", "WARN"}))
+	if analysis.TotalFiles != 2 || analysis.TotalErrors != 1 || analysis.TotalWarnings != 1 || len(analysis.ErrorLogs) != 1 {
+		t.Fatalf("Unexpected summary %+v", analysis.LogAnalysisSummary)
+	}
+	result := analysis.ErrorLogs[0]
+	if result.LineNumber != 10 || result.MatchedText != "ERROR" || result.Timestamp != "2024-03-01 10:00:01" || result.FilePath != "/var/log/app.log" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if !strings.Contains(result.Context, "starting") || !strings.Contains(result.Context, ">>> 2024-03-01 10:00:01 ERROR") || !strings.Contains(result.Context, "Db.connect") {
+		t.Errorf("Context must come from the grep context lines, got %q", result.Context)
+	}
+	if analysis.FileStats["/var/log/other.log"] != 0 || len(analysis.SkippedFiles) != 1 || len(analysis.Warnings) != 1 {
+		t.Errorf("Unexpected file bookkeeping %+v", analysis)
+	}
+
+	// The window drops the earlier error but keeps the later warning
+	search.since = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	analysis = analyzeSSHGrepOutput(output, search, nil, compileSeverityPatterns([]string{"ERROR", "WARN"}))
+	if analysis.TotalErrors != 0 || len(analysis.WarningLogs) != 1 {
+		t.Errorf("Expected only the warning inside the window, got %+v", analysis.LogAnalysisSummary)
+	}
+}
+
+func TestParseLogTimeBound(t *testing.T) {
+	now := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	for value, expected := range map[string]time.Time{
+		"":                     {},
+		"30m":                  now.Add(-30 * time.Minute),
+		"2d":                   now.Add(-48 * time.Hour),
+		"2024-03-01":           time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"2024-03-01 10:20:30":  time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC),
+		"2024-03-01T10:20:30Z": time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC),
+	} {
+		if parsed, err := parseLogTimeBound(value, now); err != nil || !parsed.Equal(expected) {
+			t.Errorf("parseLogTimeBound(%q) = %v, %v; want %v", value, parsed, err, expected)
+		}
+	}
+	if _, err := parseLogTimeBound("yesterday", now); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestSSHAnalyzeRemoteLogs(t *testing.T) {
+	if _, err := exec.LookPath("zgrep"); err != nil {
+		t.Skip("zgrep is not installed")
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "console.log"), []byte("2024-03-01 10:00:00 INFO up\n2024-03-01 10:00:01 ERROR disk full\n2024-03-01 10:00:02 INFO retry\n"), 0644)
+	archive, _ := os.Create(filepath.Join(dir, "console.1.log.gz"))
+	writer := gzip.NewWriter(archive)
+	writer.Write([]byte("2024-02-29 09:00:00 WARN heap at 90%\n"))
+	writer.Close()
+	archive.Close()
+
+	// Run the search script locally against the temp directory
+	server := newTestSSHServer(t, func(command string, stdout, stderr io.Writer) int {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		if err := cmd.Run(); err != nil {
+			return 1
+		}
+		return 0
+	})
+	config := server.instance()
+	config.Password = "secret"
+	useTestSSHConfig(t, map[string]SSHServerConfig{"test": config})
+
+	response, result := callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{"server_name": "test", "paths": dir + "/*.log," + dir + "/*.log.gz", "context_lines": 1})
+	if result.IsError {
+		t.Fatalf("Unexpected error: %v", result.Content)
+	}
+	if response["total_files"] != float64(2) || response["total_errors"] != float64(1) || response["total_warnings"] != float64(1) {
+		t.Errorf("Unexpected analysis %v", response)
+	}
+	if context := response["error_logs"].([]any)[0].(map[string]any)["context"].(string); !strings.Contains(context, "INFO up") || !strings.Contains(context, "INFO retry") {
+		t.Errorf("Expected context lines around the match, got %q", context)
+	}
+
+	// Later lines outside the window must not crowd the window out of the tail
+	var busy strings.Builder
+	busy.WriteString("2024-03-01 09:00:00 INFO booting\n2024-03-01 09:59:59 WARN early\n2024-03-01 10:00:01 ERROR in window\n2024-03-01 10:00:02 INFO after\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&busy, "2024-03-01 11:%02d:00 ERROR later\n", i)
+	}
+	os.WriteFile(filepath.Join(dir, "busy.log"), []byte(busy.String()), 0644)
+	response, result = callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{
+		"server_name":   "test",
+		"paths":         dir + "/busy.log",
+		"since":         "2024-03-01 10:00:00",
+		"until":         "2024-03-01 10:30:00",
+		"max_results":   1,
+		"context_lines": 1,
+	})
+	if result.IsError {
+		t.Fatalf("Unexpected error: %v", result.Content)
+	}
+	if response["total_errors"] != float64(1) || response["total_warnings"] != float64(0) {
+		t.Fatalf("Expected only the error inside the window, got %v", response)
+	}
+	if context := response["error_logs"].([]any)[0].(map[string]any)["context"].(string); !strings.Contains(context, "WARN early") || !strings.Contains(context, "INFO after") {
+		t.Errorf("Expected the context of the kept match, got %q", context)
+	}
+
+	if _, result := callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{"server_name": "test", "paths": dir + "/*.log; reboot"}); !result.IsError {
+		t.Error("Paths with shell syntax must be rejected")
+	}
+	if _, result := callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{"server_name": "test", "paths": dir + "/missing/*.log"}); !result.IsError {
+		t.Error("Expected an error when no files match")
+	}
+
+	// The instance policy applies to the commands the search runs
+	globalSSHConfig.DefaultPolicy = &SSHCommandPolicy{Deny: []string{"zgrep"}}
+	response, _ = callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{"server_name": "test", "paths": dir + "/*.log.gz"})
+	if response["success"] != false || response["policy"].(map[string]any)["action"] != sshPolicyDeny {
+		t.Errorf("Expected the zgrep deny rule to block the search, got %v", response)
+	}
+	globalSSHConfig.DefaultPolicy = &SSHCommandPolicy{Confirm: []string{"grep"}}
+	response, _ = callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{"server_name": "test", "paths": dir + "/console.log"})
+	token, _ := response["policy"].(map[string]any)["confirmation_token"].(string)
+	if token == "" {
+		t.Fatalf("Expected a confirmation token, got %v", response)
+	}
+	response, result = callSSHTool(t, executeSSHAnalyzeRemoteLogs, map[string]any{"server_name": "test", "paths": dir + "/console.log", "confirmation_token": token})
+	if result.IsError || response["total_errors"] != float64(1) {
+		t.Errorf("Expected the confirmed search to run, got %v", response)
+	}
+}
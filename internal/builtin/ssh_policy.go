@@ -0,0 +1,1017 @@
+package builtin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Policy actions, also used as the tier of each violation
+const (
+	sshPolicyAllow   = "allow"
+	sshPolicyDeny    = "deny"
+	sshPolicyConfirm = "confirm"
+)
+
+// Policy rules reported in violations
+const (
+	sshRuleParseError     = "parse_error"
+	sshRuleDynamicCommand = "dynamic_command"
+	sshRuleDynamicTarget  = "dynamic_target"
+	sshRuleDenyList       = "deny_list"
+	sshRuleNotAllowed     = "not_allowed"
+	sshRuleConfirmList    = "confirm_list"
+	sshRuleDestructive    = "destructive"
+	sshRuleSensitive      = "sensitive"
+	sshRuleReadOnly       = "read_only"
+	sshRuleProtectedPath  = "protected_path"
+	sshRuleSudo           = "sudo"
+	sshRuleNesting        = "nesting"
+)
+
+// Command categories used by the built-in rules
+const (
+	// sshCommandDestructive commands are denied unless the instance allow list names them
+	sshCommandDestructive = "destructive"
+	// sshCommandSensitive commands need confirmation unless the instance allow list names them
+	sshCommandSensitive = "sensitive"
+	// sshCommandModifying commands change the system and are only denied in read-only mode
+	sshCommandModifying = "modifying"
+)
+
+const (
+	// sshConfirmationTTL is how long a confirmation token can be used after it was issued
+	sshConfirmationTTL = 10 * time.Minute
+	// maxSSHPolicyDepth bounds how deeply nested shells, eval and find -exec are followed
+	maxSSHPolicyDepth = 4
+	// sshDynamicWord stands in for words whose value is only known at runtime
+	sshDynamicWord = "\x00dynamic"
+	// sshConfirmationNotice ends the descriptions of tools that return confirmation tokens. Tokens
+	// come back through the model, so they show intent but cannot prove that a person approved
+	sshConfirmationNotice = ". The token is returned to the caller, so the server cannot tell whether a person approved; configure a PreToolUse hook on this tool to enforce approval"
+)
+
+// SSHCommandPolicy restricts the commands that can be run on an instance
+type SSHCommandPolicy struct {
+	// ReadOnly denies commands and redirections that modify the remote system
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Allow, when set, lists the only commands that may run. Entries are a command name optionally
+	// followed by leading arguments ("systemctl status"), and may use glob patterns. Listed commands
+	// are exempt from the built-in destructive and sensitive rules
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists commands that are always rejected, in the same format as Allow
+	Deny []string `json:"deny,omitempty"`
+	// Confirm lists commands that need user confirmation, in the same format as Allow
+	Confirm []string `json:"confirm,omitempty"`
+	// Sudo is "deny", "confirm" (default) or "allow"
+	Sudo string `json:"sudo,omitempty"`
+}
+
+// SSHPolicyViolation explains why part of a command was denied or needs confirmation
+type SSHPolicyViolation struct {
+	Tier    string `json:"tier"`
+	Command string `json:"command"`
+	Rule    string `json:"rule"`
+	Reason  string `json:"reason"`
+}
+
+// SSHPolicyDecision is the outcome of checking a command against an instance policy
+type SSHPolicyDecision struct {
+	Action            string               `json:"action"`
+	Violations        []SSHPolicyViolation `json:"violations,omitempty"`
+	ConfirmationToken string               `json:"confirmation_token,omitempty"`
+	ConfirmationError string               `json:"confirmation_error,omitempty"`
+}
+
+// sshConfirmation is a pending confirmation for one command on one instance
+type sshConfirmation struct {
+	serverName string
+	command    string
+	expiresAt  time.Time
+}
+
+var (
+	sshConfirmationsMu sync.Mutex
+	sshConfirmations   = map[string]sshConfirmation{}
+)
+
+// sshDestructiveCommands are denied by default, with the reason reported to the caller
+var sshDestructiveCommands = map[string]string{
+	"rm":       "deletes files",
+	"rmdir":    "deletes directories",
+	"unlink":   "deletes files",
+	"shred":    "destroys file contents",
+	"truncate": "truncates files",
+	"dd":       "writes raw data to files and devices",
+	"mkfs":     "creates filesystems",
+	"mke2fs":   "creates filesystems",
+	"mkswap":   "formats swap space",
+	"wipefs":   "erases filesystem signatures",
+	"fdisk":    "changes partition tables",
+	"sfdisk":   "changes partition tables",
+	"gdisk":    "changes partition tables",
+	"parted":   "changes partition tables",
+	"format":   "formats disks",
+	"shutdown": "stops the host",
+	"reboot":   "restarts the host",
+	"halt":     "stops the host",
+	"poweroff": "stops the host",
+	"init":     "changes the runlevel",
+	"telinit":  "changes the runlevel",
+}
+
+// sshSensitiveCommands need confirmation by default
+var sshSensitiveCommands = map[string]string{
+	"kill":     "signals processes",
+	"pkill":    "signals processes",
+	"killall":  "signals processes",
+	"chmod":    "changes file permissions",
+	"chown":    "changes file ownership",
+	"chgrp":    "changes file ownership",
+	"chattr":   "changes file attributes",
+	"useradd":  "changes user accounts",
+	"userdel":  "changes user accounts",
+	"usermod":  "changes user accounts",
+	"groupadd": "changes groups",
+	"groupdel": "changes groups",
+	"groupmod": "changes groups",
+	"passwd":   "changes passwords",
+	"chpasswd": "changes passwords",
+	"mount":    "changes mounted filesystems",
+	"umount":   "changes mounted filesystems",
+	"swapon":   "changes swap space",
+	"swapoff":  "changes swap space",
+	"chroot":   "runs commands in another root",
+	"ssh":      "runs commands on another host",
+	"python":   "runs code that cannot be inspected",
+	"python2":  "runs code that cannot be inspected",
+	"python3":  "runs code that cannot be inspected",
+	"perl":     "runs code that cannot be inspected",
+	"ruby":     "runs code that cannot be inspected",
+	"node":     "runs code that cannot be inspected",
+	"php":      "runs code that cannot be inspected",
+	"lua":      "runs code that cannot be inspected",
+}
+
+// sshModifyingCommands change files but are allowed outside read-only mode
+var sshModifyingCommands = map[string]string{
+	"cp":      "copies files",
+	"mv":      "moves files",
+	"ln":      "creates links",
+	"touch":   "creates files",
+	"mkdir":   "creates directories",
+	"install": "installs files",
+	"rsync":   "copies files",
+	"scp":     "copies files",
+	"patch":   "changes files",
+	"setfacl": "changes file permissions",
+	"mkfifo":  "creates files",
+	"mknod":   "creates device files",
+	"zip":     "writes archives",
+	"vi":      "edits files",
+	"vim":     "edits files",
+	"nano":    "edits files",
+	"emacs":   "edits files",
+}
+
+// sshAwkCommandPattern matches awk programs that run shell commands through system(), pipes or
+// getline from a command; "||" is a logical or
+var sshAwkCommandPattern = regexp.MustCompile(`system\s*\(|(^|[^|])\|([^|]|$)`)
+
+// sshSubcommandRules classify tools whose subcommand decides whether they change anything
+var sshSubcommandRules = map[string]struct {
+	category   string
+	readOnly   []string
+	valueFlags []string
+}{
+	"systemctl": {sshCommandSensitive, []string{"status", "show", "cat", "list-units", "list-unit-files", "list-timers", "list-sockets", "list-dependencies", "is-active", "is-enabled", "is-failed", "is-system-running", "get-default", "help"}, []string{"-H", "--host", "-M", "--machine", "-t", "--type", "-p", "--property", "-n", "--lines", "-o", "--output", "--state"}},
+	"docker":    {sshCommandSensitive, []string{"ps", "logs", "inspect", "images", "stats", "top", "version", "info", "events", "port", "diff", "history", "search"}, []string{"-H", "--host", "--context", "-c", "--config", "-l", "--log-level"}},
+	"podman":    {sshCommandSensitive, []string{"ps", "logs", "inspect", "images", "stats", "top", "version", "info", "events", "port", "diff", "history", "search"}, nil},
+	"kubectl":   {sshCommandSensitive, []string{"get", "describe", "logs", "top", "explain", "version", "api-resources", "api-versions", "cluster-info"}, []string{"-n", "--namespace", "--context", "--kubeconfig", "-l", "--selector", "-o", "--output", "-c", "--container"}},
+	"apt":       {sshCommandSensitive, []string{"list", "search", "show", "policy", "depends", "rdepends"}, nil},
+	"apt-get":   {sshCommandSensitive, []string{"check", "changelog"}, nil},
+	"yum":       {sshCommandSensitive, []string{"list", "info", "search", "check-update", "repolist", "history", "provides", "deplist"}, nil},
+	"dnf":       {sshCommandSensitive, []string{"list", "info", "search", "check-update", "repolist", "history", "provides", "repoquery"}, nil},
+	"zypper":    {sshCommandSensitive, []string{"search", "se", "info", "if", "list-updates", "lu", "repos", "lr"}, nil},
+	"snap":      {sshCommandSensitive, []string{"list", "info", "find", "version", "services", "changes"}, nil},
+	"pip":       {sshCommandSensitive, []string{"list", "show", "freeze", "check"}, nil},
+	"pip3":      {sshCommandSensitive, []string{"list", "show", "freeze", "check"}, nil},
+	"npm":       {sshCommandSensitive, []string{"ls", "list", "view", "outdated"}, nil},
+	"nft":       {sshCommandSensitive, []string{"list"}, nil},
+	"ufw":       {sshCommandSensitive, []string{"status", "show"}, nil},
+	"git":       {sshCommandModifying, []string{"status", "log", "diff", "show", "rev-parse", "describe", "blame", "ls-files", "ls-remote", "grep", "shortlog"}, []string{"-C", "-c"}},
+}
+
+// sshNeutralCommands are shell builtins that are permitted even when an allow list is set
+var sshNeutralCommands = map[string]bool{
+	"cd": true, "pwd": true, "echo": true, "printf": true, "true": true, "false": true,
+	"test": true, "[": true, ":": true, "exit": true,
+}
+
+// sshShellCommands run their -c argument or a script
+var sshShellCommands = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "ash": true,
+}
+
+// sshProtectedPaths cannot be written to by redirections or file-writing commands
+var sshProtectedPaths = []string{"/etc", "/boot", "/dev", "/proc", "/sys", "/usr", "/bin", "/sbin", "/lib", "/lib64", "/root/.ssh"}
+
+// sshDiscardTargets are device files that are safe to write to
+var sshDiscardTargets = map[string]bool{
+	"/dev/null": true, "/dev/stdout": true, "/dev/stderr": true, "/dev/tty": true,
+}
+
+// sshPolicyChecker accumulates violations while walking a command
+type sshPolicyChecker struct {
+	policy   *SSHCommandPolicy
+	decision *SSHPolicyDecision
+}
+
+// evaluateSSHCommand checks every simple command, redirection and nested script in a shell command
+// against a policy
+func evaluateSSHCommand(policy *SSHCommandPolicy, command string) *SSHPolicyDecision {
+	if policy == nil {
+		policy = &SSHCommandPolicy{}
+	}
+	checker := &sshPolicyChecker{policy: policy, decision: &SSHPolicyDecision{Action: sshPolicyAllow}}
+	checker.checkScript(command, 0)
+	return checker.decision
+}
+
+// sshInstancePolicy returns the policy of an instance, falling back to the configured default
+func sshInstancePolicy(config *SSHServerConfig) *SSHCommandPolicy {
+	if config != nil && config.Policy != nil {
+		return config.Policy
+	}
+	if globalSSHConfig != nil && globalSSHConfig.DefaultPolicy != nil {
+		return globalSSHConfig.DefaultPolicy
+	}
+	return &SSHCommandPolicy{}
+}
+
+// validateSSHCommandPolicy rejects policies with unknown settings
+func validateSSHCommandPolicy(policy *SSHCommandPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	switch policy.Sudo {
+	case "", sshPolicyDeny, sshPolicyConfirm, sshPolicyAllow:
+	default:
+		return fmt.Errorf("invalid sudo policy '%s': expected deny, confirm or allow", policy.Sudo)
+	}
+	for _, rules := range [][]string{policy.Allow, policy.Deny, policy.Confirm} {
+		for _, rule := range rules {
+			for _, word := range strings.Fields(rule) {
+				if _, err := path.Match(word, ""); err != nil {
+					return fmt.Errorf("invalid policy rule '%s': %v", rule, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Summary joins the violation reasons into one line
+func (d *SSHPolicyDecision) Summary() string {
+	reasons := make([]string, 0, len(d.Violations))
+	for _, violation := range d.Violations {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", violation.Command, violation.Reason))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// merge adds the violations of another decision
+func (d *SSHPolicyDecision) merge(other *SSHPolicyDecision) {
+	for _, violation := range other.Violations {
+		d.add(violation)
+	}
+}
+
+// add records a violation and escalates the action to its tier
+func (d *SSHPolicyDecision) add(violation SSHPolicyViolation) {
+	d.Violations = append(d.Violations, violation)
+	if violation.Tier == sshPolicyDeny {
+		d.Action = sshPolicyDeny
+	} else if d.Action == sshPolicyAllow {
+		d.Action = sshPolicyConfirm
+	}
+}
+
+// violate records a violation for part of the command
+func (c *sshPolicyChecker) violate(tier, command, rule, reason string) {
+	c.decision.add(SSHPolicyViolation{Tier: tier, Command: command, Rule: rule, Reason: reason})
+}
+
+// checkScript parses a shell script and checks every command and redirection in it, including
+// those in pipelines, subshells, command substitutions and function bodies
+func (c *sshPolicyChecker) checkScript(script string, depth int) {
+	if depth > maxSSHPolicyDepth {
+		c.violate(sshPolicyDeny, script, sshRuleNesting, "commands are nested too deeply to be checked")
+		return
+	}
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "")
+	if err != nil {
+		c.violate(sshPolicyDeny, script, sshRuleParseError, fmt.Sprintf("command could not be parsed: %v", err))
+		return
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.CallExpr:
+			if len(node.Args) > 0 {
+				args := make([]string, len(node.Args))
+				for i, word := range node.Args {
+					args[i] = literalSSHWord(word)
+				}
+				c.checkArgs(printSSHNode(node), args, depth)
+			}
+		case *syntax.Redirect:
+			c.checkRedirect(node)
+		}
+		return true
+	})
+}
+
+// checkArgs checks one simple command given as its expanded words
+func (c *sshPolicyChecker) checkArgs(text string, args []string, depth int) {
+	if len(args) == 0 {
+		return
+	}
+	if args[0] == sshDynamicWord || strings.ContainsAny(args[0], "*?[{") {
+		c.violate(sshPolicyDeny, text, sshRuleDynamicCommand, "the command name is computed at runtime and cannot be checked")
+		return
+	}
+	name := path.Base(args[0])
+	args = append([]string{name}, args[1:]...)
+
+	if rule, ok := matchSSHCommandRules(c.policy.Deny, args); ok {
+		c.violate(sshPolicyDeny, text, sshRuleDenyList, fmt.Sprintf("'%s' is on the instance deny list", rule))
+		return
+	}
+
+	// Wrappers run another command, which is what gets checked
+	switch {
+	case name == "sudo" || name == "doas":
+		c.checkSudo(text)
+		c.checkArgs(text, skipSSHOptions(args[1:], "-u", "-g", "-C", "-D", "-h", "-p", "-r", "-t", "-U", "-R", "--user", "--group"), depth)
+		return
+	case name == "env":
+		rest := skipSSHOptions(args[1:], "-u", "-C", "--unset", "--chdir")
+		for len(rest) > 0 && strings.Contains(rest[0], "=") {
+			rest = rest[1:]
+		}
+		c.checkArgs(text, rest, depth)
+		return
+	case name == "nice":
+		c.checkArgs(text, skipSSHOptions(args[1:], "-n", "--adjustment"), depth)
+		return
+	case name == "ionice":
+		c.checkArgs(text, skipSSHOptions(args[1:], "-c", "-n", "--class", "--classdata"), depth)
+		return
+	case name == "timeout":
+		rest := skipSSHOptions(args[1:], "-s", "-k", "--signal", "--kill-after")
+		if len(rest) > 0 {
+			c.checkArgs(text, rest[1:], depth)
+		}
+		return
+	case name == "nohup" || name == "setsid" || name == "time" || name == "exec" || name == "builtin" || name == "stdbuf" || name == "busybox":
+		c.checkArgs(text, skipSSHOptions(args[1:]), depth)
+		return
+	case name == "command":
+		if containsSSHArg(args[1:], "-v", "-V") {
+			return
+		}
+		c.checkArgs(text, skipSSHOptions(args[1:]), depth)
+		return
+	case name == "xargs":
+		c.checkArgs(text, skipSSHOptions(args[1:], "-I", "-i", "-n", "-P", "-L", "-l", "-s", "-d", "-E", "-e", "-a", "--max-args", "--max-procs", "--delimiter", "--arg-file"), depth)
+		return
+	case name == "watch":
+		c.checkNestedScript(text, skipSSHOptions(args[1:], "-n", "--interval", "-d", "--differences"), depth)
+		return
+	case name == "eval":
+		c.checkNestedScript(text, args[1:], depth)
+		return
+	case sshShellCommands[name]:
+		c.checkShell(text, args, depth)
+		return
+	case name == "source" || name == ".":
+		c.violateUnlessAllowed(text, args, sshCommandSensitive, "runs a script that cannot be inspected")
+		return
+	}
+
+	explicit := false
+	if len(c.policy.Allow) > 0 {
+		if _, explicit = matchSSHCommandRules(c.policy.Allow, args); !explicit && !sshNeutralCommands[name] {
+			c.violate(sshPolicyDeny, text, sshRuleNotAllowed, fmt.Sprintf("'%s' is not on the instance allow list", name))
+			return
+		}
+	}
+
+	category, reason := classifySSHCommand(args)
+	if category != "" {
+		c.applyCategory(text, category, reason, explicit)
+	}
+	for _, target := range sshWriteTargets(args) {
+		if target == sshDynamicWord {
+			c.violate(sshPolicyDeny, text, sshRuleDynamicTarget, "the file written is computed at runtime and cannot be checked")
+		} else if isSSHProtectedPath(target) {
+			c.violate(sshPolicyDeny, text, sshRuleProtectedPath, fmt.Sprintf("writes to %s, which is a protected system path", target))
+		}
+	}
+	if rule, ok := matchSSHCommandRules(c.policy.Confirm, args); ok {
+		c.violate(sshPolicyConfirm, text, sshRuleConfirmList, fmt.Sprintf("'%s' requires confirmation on this instance", rule))
+	}
+
+	if name == "find" {
+		c.checkFindActions(text, args, depth)
+	}
+}
+
+// applyCategory reports a classified command according to the read-only setting and allow list
+func (c *sshPolicyChecker) applyCategory(text, category, reason string, explicit bool) {
+	switch {
+	case c.policy.ReadOnly:
+		c.violate(sshPolicyDeny, text, sshRuleReadOnly, fmt.Sprintf("%s, which is not permitted in read-only mode", reason))
+	case explicit:
+	case category == sshCommandDestructive:
+		c.violate(sshPolicyDeny, text, sshRuleDestructive, reason)
+	case category == sshCommandSensitive:
+		c.violate(sshPolicyConfirm, text, sshRuleSensitive, reason)
+	}
+}
+
+// violateUnlessAllowed applies a category to a command that the checker cannot look into
+func (c *sshPolicyChecker) violateUnlessAllowed(text string, args []string, category, reason string) {
+	explicit := false
+	if len(c.policy.Allow) > 0 {
+		if _, explicit = matchSSHCommandRules(c.policy.Allow, args); !explicit {
+			c.violate(sshPolicyDeny, text, sshRuleNotAllowed, fmt.Sprintf("'%s' is not on the instance allow list", args[0]))
+			return
+		}
+	}
+	c.applyCategory(text, category, reason, explicit)
+}
+
+// checkSudo applies the instance sudo policy
+func (c *sshPolicyChecker) checkSudo(text string) {
+	switch c.policy.Sudo {
+	case sshPolicyAllow:
+	case sshPolicyDeny:
+		c.violate(sshPolicyDeny, text, sshRuleSudo, "sudo is not permitted on this instance")
+	default:
+		c.violate(sshPolicyConfirm, text, sshRuleSudo, "runs with elevated privileges")
+	}
+}
+
+// checkShell checks the script passed to a shell with -c; scripts read from files or stdin
+// cannot be inspected
+func (c *sshPolicyChecker) checkShell(text string, args []string, depth int) {
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+		if !strings.HasPrefix(arg, "--") && strings.Contains(arg[1:], "c") {
+			if i+1 >= len(args) {
+				return
+			}
+			c.checkNestedScript(text, args[i+1:i+2], depth)
+			return
+		}
+	}
+	c.violateUnlessAllowed(text, args, sshCommandSensitive, "runs a script that cannot be inspected")
+}
+
+// checkNestedScript checks words that are joined and run as a script, as eval and watch do
+func (c *sshPolicyChecker) checkNestedScript(text string, words []string, depth int) {
+	for _, word := range words {
+		if word == sshDynamicWord {
+			c.violate(sshPolicyDeny, text, sshRuleDynamicCommand, "the script is computed at runtime and cannot be checked")
+			return
+		}
+	}
+	if len(words) > 0 {
+		c.checkScript(strings.Join(words, " "), depth+1)
+	}
+}
+
+// checkFindActions checks the commands run by find -exec and friends
+func (c *sshPolicyChecker) checkFindActions(text string, args []string, depth int) {
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "-exec", "-execdir", "-ok", "-okdir":
+			end := i + 1
+			for end < len(args) && args[end] != ";" && args[end] != "+" {
+				end++
+			}
+			if depth >= maxSSHPolicyDepth {
+				c.violate(sshPolicyDeny, text, sshRuleNesting, "commands are nested too deeply to be checked")
+				return
+			}
+			c.checkArgs(text, args[i+1:end], depth+1)
+			i = end
+		}
+	}
+}
+
+// checkRedirect checks that output redirections do not write to protected paths, or to any file in
+// read-only mode
+func (c *sshPolicyChecker) checkRedirect(redirect *syntax.Redirect) {
+	switch redirect.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll, syntax.RdrInOut:
+	case syntax.DplOut:
+		// >&2 duplicates a descriptor, >&file writes to a file
+		if target := literalSSHWord(redirect.Word); target == "-" || strings.Trim(target, "0123456789") == "" {
+			return
+		}
+	default:
+		return
+	}
+
+	target := literalSSHWord(redirect.Word)
+	text := redirect.Op.String() + " " + printSSHNode(redirect.Word)
+	switch {
+	case target == sshDynamicWord:
+		c.violate(sshPolicyDeny, text, sshRuleDynamicTarget, "the redirection target is computed at runtime and cannot be checked")
+	case sshDiscardTargets[path.Clean(target)]:
+	case isSSHProtectedPath(target):
+		c.violate(sshPolicyDeny, text, sshRuleProtectedPath, fmt.Sprintf("writes to %s, which is a protected system path", target))
+	case c.policy.ReadOnly:
+		c.violate(sshPolicyDeny, text, sshRuleReadOnly, "redirection writes to a file, which is not permitted in read-only mode")
+	}
+}
+
+// classifySSHCommand returns the built-in category of a command and why, or "" for commands that
+// only read
+func classifySSHCommand(args []string) (string, string) {
+	name := args[0]
+	if reason, ok := sshDestructiveCommands[name]; ok {
+		return sshCommandDestructive, fmt.Sprintf("%s %s", name, reason)
+	}
+	if strings.HasPrefix(name, "mkfs.") {
+		return sshCommandDestructive, fmt.Sprintf("%s creates filesystems", name)
+	}
+	if reason, ok := sshSensitiveCommands[name]; ok {
+		// Interpreters and ssh asked only for their version run nothing
+		if len(args) == 2 && containsSSHArg(args[1:], "--version", "-V", "-v") && (name == "ssh" || strings.HasPrefix(reason, "runs code")) {
+			return "", ""
+		}
+		return sshCommandSensitive, fmt.Sprintf("%s %s", name, reason)
+	}
+	if reason, ok := sshModifyingCommands[name]; ok {
+		return sshCommandModifying, fmt.Sprintf("%s %s", name, reason)
+	}
+
+	if rule, ok := sshSubcommandRules[name]; ok {
+		rest := skipSSHOptions(args[1:], rule.valueFlags...)
+		// Without a subcommand these tools only print usage, status or their version
+		if len(rest) == 0 || containsSSHArg(rule.readOnly, rest[0]) {
+			return "", ""
+		}
+		return rule.category, fmt.Sprintf("%s %s changes the system", name, rest[0])
+	}
+
+	flags := args[1:]
+	switch name {
+	case "find":
+		if containsSSHArg(flags, "-delete") {
+			return sshCommandDestructive, "find -delete deletes files"
+		}
+		if containsSSHArg(flags, "-fprint", "-fprint0", "-fprintf", "-fls") {
+			return sshCommandModifying, "find writes its results to a file"
+		}
+	case "service":
+		rest := skipSSHOptions(flags)
+		if len(rest) >= 2 && rest[1] != "status" {
+			return sshCommandSensitive, fmt.Sprintf("service %s %s changes a service", rest[0], rest[1])
+		}
+	case "crontab":
+		if !containsSSHArg(flags, "-l") {
+			return sshCommandSensitive, "crontab changes scheduled jobs"
+		}
+	case "sysctl":
+		for _, arg := range flags {
+			if arg == "-w" || arg == "-p" || arg == "--system" || strings.Contains(arg, "=") {
+				return sshCommandSensitive, "sysctl changes kernel parameters"
+			}
+		}
+	case "iptables", "ip6tables":
+		if !containsSSHArg(flags, "-L", "-S", "--list", "--list-rules") {
+			return sshCommandSensitive, fmt.Sprintf("%s changes firewall rules", name)
+		}
+	case "firewall-cmd":
+		for _, arg := range flags {
+			if !strings.HasPrefix(arg, "--list") && !strings.HasPrefix(arg, "--get") && !strings.HasPrefix(arg, "--query") && arg != "--state" && !strings.HasPrefix(arg, "--zone") {
+				return sshCommandSensitive, "firewall-cmd changes firewall rules"
+			}
+		}
+	case "rpm":
+		for _, arg := range flags {
+			if arg == "--install" || arg == "--upgrade" || arg == "--freshen" || arg == "--erase" ||
+				(len(arg) > 1 && arg[0] == '-' && strings.ContainsRune("iUFe", rune(arg[1]))) {
+				return sshCommandSensitive, "rpm changes installed packages"
+			}
+		}
+	case "dpkg":
+		if containsSSHArg(flags, "-i", "-r", "-P", "--install", "--remove", "--purge", "--configure", "--unpack") {
+			return sshCommandSensitive, "dpkg changes installed packages"
+		}
+	case "awk", "gawk", "mawk", "nawk":
+		if reason := sshAwkProgramRisk(flags); reason != "" {
+			return sshCommandSensitive, fmt.Sprintf("%s %s", name, reason)
+		}
+	case "sed":
+		for _, arg := range flags {
+			if strings.HasPrefix(arg, "-i") || strings.HasPrefix(arg, "--in-place") ||
+				(isSSHShortOptions(arg) && strings.ContainsRune(arg, 'i')) {
+				return sshCommandModifying, "sed -i edits files in place"
+			}
+		}
+	case "tee":
+		if len(sshWriteTargets(args)) > 0 {
+			return sshCommandModifying, "tee writes to files"
+		}
+	case "tar":
+		if !isSSHTarListing(flags) {
+			return sshCommandModifying, "tar writes archives or extracts files"
+		}
+	case "gzip", "gunzip", "bzip2", "bunzip2", "xz", "unxz", "zstd", "unzstd":
+		if !containsSSHArg(flags, "--stdout", "--to-stdout", "--list", "--test") && !hasSSHShortOption(flags, "clt") {
+			return sshCommandModifying, fmt.Sprintf("%s replaces files", name)
+		}
+	case "unzip":
+		if !hasSSHShortOption(flags, "ltpZv") {
+			return sshCommandModifying, "unzip extracts files"
+		}
+	case "curl":
+		if containsSSHArg(flags, "--output", "--remote-name", "--remote-name-all", "--upload-file") || hasSSHShortOption(flags, "oOT") {
+			return sshCommandModifying, "curl writes to files"
+		}
+	case "wget":
+		if !containsSSHArg(flags, "--spider", "-O-", "--output-document=-", "-qO-") && !isSSHWgetToStdout(flags) {
+			return sshCommandModifying, "wget writes to files"
+		}
+	}
+	return "", ""
+}
+
+// sshAwkProgramRisk explains why an awk program may run shell commands, or returns "" for programs
+// that only process text
+func sshAwkProgramRisk(flags []string) string {
+	var program string
+	for i := 0; i < len(flags); i++ {
+		arg := flags[i]
+		switch {
+		case arg == "-f" || arg == "--file" || strings.HasPrefix(arg, "--file="):
+			return "runs a program file that cannot be inspected"
+		case arg == "-e" || arg == "--source":
+			if i+1 < len(flags) {
+				i++
+				program += flags[i] + "\n"
+			}
+		case strings.HasPrefix(arg, "--source="):
+			program += strings.TrimPrefix(arg, "--source=") + "\n"
+		case arg == "-F" || arg == "-v" || arg == "--field-separator" || arg == "--assign":
+			i++
+		case arg == "--":
+			if program == "" && i+1 < len(flags) {
+				program = flags[i+1]
+			}
+			i = len(flags)
+		case strings.HasPrefix(arg, "-"):
+		case program == "":
+			program = arg
+			i = len(flags)
+		}
+	}
+	switch {
+	case program == sshDynamicWord || strings.Contains(program, sshDynamicWord):
+		return "runs a program computed at runtime"
+	case sshAwkCommandPattern.MatchString(program):
+		return "runs shell commands"
+	}
+	return ""
+}
+
+// sshWriteTargets returns the files a command writes to, for the commands where that is known
+func sshWriteTargets(args []string) []string {
+	operands := func() []string {
+		var files []string
+		for _, arg := range args[1:] {
+			if arg == sshDynamicWord || !strings.HasPrefix(arg, "-") {
+				files = append(files, arg)
+			}
+		}
+		return files
+	}
+
+	var targets []string
+	switch args[0] {
+	case "tee":
+		for _, file := range operands() {
+			if !sshDiscardTargets[file] {
+				targets = append(targets, file)
+			}
+		}
+	case "cp", "mv", "ln", "install", "rsync":
+		files := operands()
+		for i, arg := range args {
+			if (arg == "-t" || arg == "--target-directory") && i+1 < len(args) {
+				targets = append(targets, args[i+1])
+			} else if strings.HasPrefix(arg, "--target-directory=") {
+				targets = append(targets, strings.TrimPrefix(arg, "--target-directory="))
+			}
+		}
+		switch {
+		case args[0] == "mv":
+			// Moving a file removes it from its source, so sources are written to as well
+			targets = append(targets, files...)
+		case len(targets) == 0 && len(files) > 1:
+			targets = append(targets, files[len(files)-1])
+		}
+	case "sed":
+		if category, _ := classifySSHCommand(args); category != "" {
+			targets = append(targets, operands()...)
+		}
+	case "dd":
+		for _, arg := range args[1:] {
+			if strings.HasPrefix(arg, "of=") {
+				targets = append(targets, strings.TrimPrefix(arg, "of="))
+			}
+		}
+	case "truncate", "shred", "touch":
+		targets = append(targets, operands()...)
+	}
+	return targets
+}
+
+// isSSHProtectedPath reports whether an absolute path is inside a protected system directory
+func isSSHProtectedPath(target string) bool {
+	if !strings.HasPrefix(target, "/") {
+		return false
+	}
+	cleaned := path.Clean(target)
+	if sshDiscardTargets[cleaned] || strings.HasPrefix(cleaned, "/dev/fd/") {
+		return false
+	}
+	for _, protected := range sshProtectedPaths {
+		if cleaned == protected || strings.HasPrefix(cleaned, protected+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isSSHTarListing reports whether tar is only listing an archive
+func isSSHTarListing(flags []string) bool {
+	if containsSSHArg(flags, "--list") {
+		return true
+	}
+	for i, arg := range flags {
+		// The first argument may be a bundle of options without a dash, as in "tf archive.tar"
+		if isSSHShortOptions(arg) || (i == 0 && !strings.HasPrefix(arg, "-")) {
+			letters := strings.TrimPrefix(arg, "-")
+			if strings.ContainsRune(letters, 't') && !strings.ContainsAny(letters, "cxruA") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isSSHWgetToStdout reports whether wget writes its download to stdout
+func isSSHWgetToStdout(flags []string) bool {
+	for i, arg := range flags {
+		if isSSHShortOptions(arg) && strings.HasSuffix(arg, "O") && i+1 < len(flags) && flags[i+1] == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// isSSHShortOptions reports whether an argument is a bundle of single-letter options like -xzf
+func isSSHShortOptions(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' || arg[1] == '-' {
+		return false
+	}
+	for _, r := range arg[1:] {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// hasSSHShortOption reports whether any short option bundle contains one of the letters
+func hasSSHShortOption(flags []string, letters string) bool {
+	for _, arg := range flags {
+		if isSSHShortOptions(arg) && strings.ContainsAny(arg[1:], letters) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSSHArg reports whether any argument equals one of the values or starts with value=
+func containsSSHArg(args []string, values ...string) bool {
+	for _, arg := range args {
+		for _, value := range values {
+			if arg == value || strings.HasPrefix(value, "--") && strings.HasPrefix(arg, value+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipSSHOptions drops leading options, and the values of options listed in withValue, returning
+// the remaining arguments
+func skipSSHOptions(args []string, withValue ...string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "--":
+			return args[1:]
+		case arg == sshDynamicWord || !strings.HasPrefix(arg, "-") || arg == "-":
+			return args
+		case containsSSHArg(withValue, arg) && !strings.Contains(arg, "="):
+			if len(args) < 2 {
+				return nil
+			}
+			args = args[2:]
+		default:
+			args = args[1:]
+		}
+	}
+	return args
+}
+
+// matchSSHCommandRules returns the first rule whose words match the leading arguments
+func matchSSHCommandRules(rules []string, args []string) (string, bool) {
+	for _, rule := range rules {
+		words := strings.Fields(rule)
+		if len(words) == 0 || len(words) > len(args) {
+			continue
+		}
+		matched := true
+		for i, word := range words {
+			if i == 0 {
+				word = path.Base(word)
+			}
+			if ok, _ := path.Match(word, args[i]); !ok || args[i] == sshDynamicWord {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return rule, true
+		}
+	}
+	return "", false
+}
+
+// literalSSHWord returns the value of a word after quote removal, or sshDynamicWord when it
+// contains expansions
+func literalSSHWord(word *syntax.Word) string {
+	if word == nil {
+		return sshDynamicWord
+	}
+	var value strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			value.WriteString(unescapeSSHLiteral(part.Value))
+		case *syntax.SglQuoted:
+			if part.Dollar {
+				return sshDynamicWord
+			}
+			value.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return sshDynamicWord
+				}
+				value.WriteString(lit.Value)
+			}
+		default:
+			return sshDynamicWord
+		}
+	}
+	return value.String()
+}
+
+// unescapeSSHLiteral removes the backslashes the shell strips from unquoted words, so r\m is seen as rm
+func unescapeSSHLiteral(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var unescaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		unescaped.WriteByte(value[i])
+	}
+	return unescaped.String()
+}
+
+// printSSHNode renders a syntax node on one line for violation reports
+func printSSHNode(node syntax.Node) string {
+	var text strings.Builder
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&text, node); err != nil {
+		return fmt.Sprintf("%v", node)
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// confirmSSHPolicyDecision lets a decision that needs confirmation through when a valid token for
+// the same instance and command is given, and otherwise issues a new token. It reports whether the
+// command may run
+func confirmSSHPolicyDecision(decision *SSHPolicyDecision, serverName, command, token string) bool {
+	switch decision.Action {
+	case sshPolicyAllow:
+		return true
+	case sshPolicyDeny:
+		return false
+	}
+	if token != "" {
+		err := claimSSHConfirmation(token, serverName, command)
+		if err == nil {
+			return true
+		}
+		decision.ConfirmationError = err.Error()
+	}
+	decision.ConfirmationToken = issueSSHConfirmation(serverName, command)
+	return false
+}
+
+// issueSSHConfirmation stores a single-use confirmation token for a command and drops expired ones
+func issueSSHConfirmation(serverName, command string) string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	token := "confirm-" + hex.EncodeToString(bytes)
+
+	sshConfirmationsMu.Lock()
+	defer sshConfirmationsMu.Unlock()
+
+	now := time.Now()
+	for id, confirmation := range sshConfirmations {
+		if now.After(confirmation.expiresAt) {
+			delete(sshConfirmations, id)
+		}
+	}
+	sshConfirmations[token] = sshConfirmation{serverName: serverName, command: command, expiresAt: now.Add(sshConfirmationTTL)}
+	return token
+}
+
+// claimSSHConfirmation consumes a confirmation token issued for exactly this instance and command
+func claimSSHConfirmation(token, serverName, command string) error {
+	sshConfirmationsMu.Lock()
+	defer sshConfirmationsMu.Unlock()
+
+	confirmation, ok := sshConfirmations[token]
+	switch {
+	case !ok:
+		return fmt.Errorf("confirmation token '%s' not found or already used", token)
+	case time.Now().After(confirmation.expiresAt):
+		delete(sshConfirmations, token)
+		return fmt.Errorf("confirmation token '%s' expired", token)
+	case confirmation.serverName != serverName || confirmation.command != command:
+		return fmt.Errorf("confirmation token '%s' was issued for a different command", token)
+	}
+	delete(sshConfirmations, token)
+	return nil
+}
+
+// sshPolicyResult reports a command that was denied or is waiting for confirmation
+func sshPolicyResult(serverName, operation string, decision *SSHPolicyDecision, startTime time.Time) *mcp.CallToolResult {
+	message := "Command blocked by policy: " + decision.Summary()
+	if decision.Action == sshPolicyConfirm {
+		message = "Command requires confirmation: " + decision.Summary() +
+			". Ask the user to approve it, and only after they did, call again with the same command and confirmation_token"
+	}
+
+	result := &SSHOperationResult{
+		ServerName: serverName,
+		Operation:  operation,
+		Success:    false,
+		Message:    message,
+		Duration:   time.Since(startTime).String(),
+		Timestamp:  time.Now(),
+		Policy:     decision,
+	}
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON))
+}
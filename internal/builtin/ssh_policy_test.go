@@ -0,0 +1,121 @@
+package builtin
+
+import (
+	"testing"
+)
+
+func TestEvaluateSSHCommand(t *testing.T) {
+	defaults := &SSHCommandPolicy{}
+	readOnly := &SSHCommandPolicy{ReadOnly: true}
+	restricted := &SSHCommandPolicy{Allow: []string{"grep", "tail", "systemctl status"}, Deny: []string{"curl"}, Confirm: []string{"grep -r"}, Sudo: "deny"}
+
+	tests := []struct {
+		name    string
+		policy  *SSHCommandPolicy
+		command string
+		action  string
+		rule    string
+	}{
+		{"harmless word matching an old pattern", defaults, "grep format config.yml", sshPolicyAllow, ""},
+		{"pipeline", defaults, "ps aux | grep java | head -5", sshPolicyAllow, ""},
+		{"rm", defaults, "ls && rm -rf /opt/app", sshPolicyDeny, sshRuleDestructive},
+		{"escaped rm", defaults, `r\m -rf /`, sshPolicyDeny, sshRuleDestructive},
+		{"find -delete", defaults, "find /var/log -name '*.gz' -delete", sshPolicyDeny, sshRuleDestructive},
+		{"find -exec rm", defaults, `find /tmp -exec rm {} \;`, sshPolicyDeny, sshRuleDestructive},
+		{"truncate", defaults, "truncate -s 0 app.log", sshPolicyDeny, sshRuleDestructive},
+		{"redirect to /etc", defaults, "echo root::0:0::: > /etc/passwd", sshPolicyDeny, sshRuleProtectedPath},
+		{"tee to /etc", defaults, "echo x | tee -a /etc/hosts", sshPolicyDeny, sshRuleProtectedPath},
+		{"redirect to /dev/null", defaults, "cat app.log 2>/dev/null >&2", sshPolicyAllow, ""},
+		{"command substitution", defaults, "echo $(rm -rf /tmp/x)", sshPolicyDeny, sshRuleDestructive},
+		{"subshell", defaults, "(cd /tmp; shred secrets)", sshPolicyDeny, sshRuleDestructive},
+		{"nested shell", defaults, `bash -c "xargs rm < files.txt"`, sshPolicyDeny, sshRuleDestructive},
+		{"dynamic command name", defaults, "$CMD /etc", sshPolicyDeny, sshRuleDynamicCommand},
+		{"parse error", defaults, "echo 'unterminated", sshPolicyDeny, sshRuleParseError},
+		{"sudo needs confirmation", defaults, "sudo -u artifactory tail -n 50 /var/log/messages", sshPolicyConfirm, sshRuleSudo},
+		{"service restart", defaults, "systemctl restart artifactory", sshPolicyConfirm, sshRuleSensitive},
+		{"service status", defaults, "systemctl status artifactory", sshPolicyAllow, ""},
+		{"read-only allows reads", readOnly, "tar tzf backup.tgz | wc -l", sshPolicyAllow, ""},
+		{"read-only denies copies", readOnly, "cp a b", sshPolicyDeny, sshRuleReadOnly},
+		{"read-only denies redirects", readOnly, "date >> /tmp/out", sshPolicyDeny, sshRuleReadOnly},
+		{"read-only denies sed -i", readOnly, "sed -i s/a/b/ app.conf", sshPolicyDeny, sshRuleReadOnly},
+		{"allow list", restricted, "cd /var/log && tail -n 5 app.log | grep ERROR", sshPolicyAllow, ""},
+		{"allow list rejects others", restricted, "cat app.log", sshPolicyDeny, sshRuleNotAllowed},
+		{"allow list with subcommand", restricted, "systemctl stop artifactory", sshPolicyDeny, sshRuleNotAllowed},
+		{"deny list", restricted, "curl http://localhost", sshPolicyDeny, sshRuleDenyList},
+		{"confirm list", restricted, "grep -r token /opt", sshPolicyConfirm, sshRuleConfirmList},
+		{"sudo denied", restricted, "sudo tail app.log", sshPolicyDeny, sshRuleSudo},
+		{"python one-liner", defaults, `python3 -c "import os; os.system('rm -rf /')"`, sshPolicyConfirm, sshRuleSensitive},
+		{"perl one-liner", defaults, `perl -e 'unlink "/opt/app/data"'`, sshPolicyConfirm, sshRuleSensitive},
+		{"interpreter version", defaults, "python3 --version", sshPolicyAllow, ""},
+		{"ssh to another host", defaults, "ssh other rm -rf /", sshPolicyConfirm, sshRuleSensitive},
+		{"awk system", defaults, `awk 'BEGIN{system("rm -rf /opt/app")}'`, sshPolicyConfirm, sshRuleSensitive},
+		{"awk pipe to command", defaults, `awk '{print | "sh"}' cmds.txt`, sshPolicyConfirm, sshRuleSensitive},
+		{"awk program file", defaults, "gawk -f /tmp/prog.awk app.log", sshPolicyConfirm, sshRuleSensitive},
+		{"awk text processing", defaults, `awk -F: '$3 > 1000 || $1 == "app" {print $1}' /etc/passwd`, sshPolicyAllow, ""},
+		{"mv from /etc", defaults, "mv /etc/passwd /tmp/x", sshPolicyDeny, sshRuleProtectedPath},
+		{"cp into /etc with -t", defaults, "cp -t /etc/cron.d job", sshPolicyDeny, sshRuleProtectedPath},
+		{"cp from /etc", defaults, "cp /etc/hosts /tmp/hosts", sshPolicyAllow, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := evaluateSSHCommand(tt.policy, tt.command)
+			if decision.Action != tt.action {
+				t.Fatalf("Expected %s, got %s: %+v", tt.action, decision.Action, decision.Violations)
+			}
+			if tt.rule == "" {
+				return
+			}
+			for _, violation := range decision.Violations {
+				if violation.Rule == tt.rule {
+					return
+				}
+			}
+			t.Errorf("Expected a %s violation, got %+v", tt.rule, decision.Violations)
+		})
+	}
+}
+
+func TestSSHExecuteCommandPolicy(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+	config := server.instance()
+	config.Password = "secret"
+	config.Policy = &SSHCommandPolicy{ReadOnly: true, Sudo: "confirm"}
+	useTestSSHConfig(t, map[string]SSHServerConfig{"test": config})
+
+	response, _ := callSSHTool(t, executeSSHExecuteCommand, map[string]any{"server_name": "test", "command": "cat app.log > /etc/motd"})
+	policy := response["policy"].(map[string]any)
+	if response["success"] != false || policy["action"] != sshPolicyDeny || server.connections.Load() != 0 {
+		t.Fatalf("Expected a denial before connecting, got %v", response)
+	}
+	violation := policy["violations"].([]any)[0].(map[string]any)
+	if violation["rule"] != sshRuleProtectedPath || violation["command"] != "> /etc/motd" {
+		t.Errorf("Unexpected violation %v", violation)
+	}
+
+	command := "sudo journalctl -u artifactory -n 20"
+	response, _ = callSSHTool(t, executeSSHExecuteCommand, map[string]any{"server_name": "test", "command": command})
+	token, _ := response["policy"].(map[string]any)["confirmation_token"].(string)
+	if token == "" {
+		t.Fatalf("Expected a confirmation token, got %v", response)
+	}
+
+	response, _ = callSSHTool(t, executeSSHExecuteCommand, map[string]any{"server_name": "test", "command": "sudo reboot", "confirmation_token": token})
+	if response["success"] != false || response["policy"].(map[string]any)["action"] != sshPolicyDeny {
+		t.Errorf("A confirmation token must not unlock denied commands: %v", response)
+	}
+
+	response, _ = callSSHTool(t, executeSSHExecuteCommand, map[string]any{"server_name": "test", "command": command, "confirmation_token": token})
+	if response["success"] != true {
+		t.Fatalf("Confirmed command did not run: %v", response)
+	}
+	response, _ = callSSHTool(t, executeSSHExecuteCommand, map[string]any{"server_name": "test", "command": command, "confirmation_token": token})
+	if response["success"] != false || response["policy"].(map[string]any)["confirmation_error"] == nil {
+		t.Errorf("Confirmation tokens must be single use: %v", response)
+	}
+
+	response, _ = callSSHTool(t, executeSSHExecuteMultipleCommands, map[string]any{"server_name": "test", "commands": "uptime; mv a b; df -h"})
+	if response["success"] != false || len(response["command_results"].([]any)) != 2 || len(response["errors"].([]any)) != 1 {
+		t.Errorf("Expected the denied command to be skipped, got %v", response)
+	}
+}
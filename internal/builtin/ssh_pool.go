@@ -0,0 +1,385 @@
+package builtin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"golang.org/x/crypto/ssh"
+)
+
+// Default pool settings, overridable through commonSettings
+const (
+	defaultSSHPoolIdleTimeout       = 5 * time.Minute
+	defaultSSHPoolKeepaliveInterval = 30 * time.Second
+	sshPoolPingTimeout              = 5 * time.Second
+)
+
+// SSHPooledConnection describes a pooled SSH connection
+type SSHPooledConnection struct {
+	ServerName string    `json:"server_name"`
+	Address    string    `json:"address"`
+	Username   string    `json:"username"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Idle       string    `json:"idle"`
+	InUse      int       `json:"in_use"`
+	Uses       int       `json:"uses"`
+}
+
+// sshPooledClient is a pool entry
+type sshPooledClient struct {
+	key        string
+	serverName string
+	address    string
+	username   string
+	client     *ssh.Client
+	created    time.Time
+	lastUsed   time.Time
+	inUse      int
+	uses       int
+	closing    bool
+}
+
+// sshClientPool shares SSH connections across tool calls, keyed by instance and effective credentials
+type sshClientPool struct {
+	mu                sync.Mutex
+	clients           map[string]*sshPooledClient
+	idleTimeout       time.Duration
+	keepaliveInterval time.Duration
+	maintaining       bool
+	dial              func(config *SSHServerConfig) (*ssh.Client, error)
+	ping              func(client *ssh.Client, timeout time.Duration) error
+}
+
+// globalSSHPool is shared by all SSH tools
+var globalSSHPool = newSSHClientPool(defaultSSHPoolIdleTimeout, defaultSSHPoolKeepaliveInterval)
+
+// newSSHClientPool creates an empty pool
+func newSSHClientPool(idleTimeout, keepaliveInterval time.Duration) *sshClientPool {
+	return &sshClientPool{
+		clients:           make(map[string]*sshPooledClient),
+		idleTimeout:       idleTimeout,
+		keepaliveInterval: keepaliveInterval,
+		dial:              createSSHClient,
+		ping:              pingSSHClient,
+	}
+}
+
+// configure applies pool timings from commonSettings (in seconds); zero keeps the default
+func (p *sshClientPool) configure(idleTimeoutSeconds, keepaliveSeconds int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if idleTimeoutSeconds > 0 {
+		p.idleTimeout = time.Duration(idleTimeoutSeconds) * time.Second
+	}
+	if keepaliveSeconds > 0 {
+		p.keepaliveInterval = time.Duration(keepaliveSeconds) * time.Second
+	}
+}
+
+// sshPoolKey identifies a connection by instance, address and the credentials actually used
+func sshPoolKey(serverName string, config *SSHServerConfig) string {
+	secret := sha256.Sum256([]byte(config.Password + "\x00" + config.PrivateKey + "\x00" + config.KeyPath))
+	return fmt.Sprintf("%s|%s|%s|%s|%x", serverName, sshAddress(config), config.Username, config.ProxyJump, secret[:8])
+}
+
+// acquire returns a healthy client for the instance, reusing a pooled connection when possible.
+// The release function must be called when the caller is done with the client.
+func (p *sshClientPool) acquire(serverName string, config *SSHServerConfig) (*ssh.Client, func(), error) {
+	key := sshPoolKey(serverName, config)
+
+	p.mu.Lock()
+	entry := p.clients[key]
+	if entry != nil {
+		entry.inUse++
+	}
+	p.mu.Unlock()
+
+	if entry != nil {
+		if err := p.ping(entry.client, sshPoolPingTimeout); err == nil {
+			p.mu.Lock()
+			entry.uses++
+			entry.lastUsed = time.Now()
+			p.mu.Unlock()
+			return entry.client, p.releaseFunc(entry), nil
+		}
+
+		// The pooled connection looks dead; drop it and reconnect. Other callers may still be
+		// running commands on it, so it is only closed once the last of them releases it.
+		p.mu.Lock()
+		entry.inUse--
+		closeNow := p.retireLocked(entry)
+		p.mu.Unlock()
+		if closeNow {
+			entry.client.Close()
+		}
+	}
+
+	client, err := p.dial(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	entry = &sshPooledClient{
+		key:        key,
+		serverName: serverName,
+		address:    sshAddress(config),
+		username:   config.Username,
+		client:     client,
+		created:    now,
+		lastUsed:   now,
+		inUse:      1,
+		uses:       1,
+	}
+
+	p.mu.Lock()
+	if _, exists := p.clients[key]; exists {
+		// Another call connected concurrently; keep this client private to the caller
+		p.mu.Unlock()
+		return client, func() { client.Close() }, nil
+	}
+	p.clients[key] = entry
+	startMaintenance := !p.maintaining
+	p.maintaining = true
+	p.mu.Unlock()
+
+	go func() {
+		client.Wait()
+		p.mu.Lock()
+		p.removeLocked(entry)
+		p.mu.Unlock()
+	}()
+	if startMaintenance {
+		go p.maintain()
+	}
+
+	return client, p.releaseFunc(entry), nil
+}
+
+// releaseFunc returns an idempotent release for a pool entry
+func (p *sshClientPool) releaseFunc(entry *sshPooledClient) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			entry.inUse--
+			entry.lastUsed = time.Now()
+			closeNow := entry.closing && entry.inUse == 0
+			p.mu.Unlock()
+
+			if closeNow {
+				entry.client.Close()
+			}
+		})
+	}
+}
+
+// removeLocked drops an entry from the pool if it is still the current one for its key
+func (p *sshClientPool) removeLocked(entry *sshPooledClient) {
+	if p.clients[entry.key] == entry {
+		delete(p.clients, entry.key)
+	}
+}
+
+// retireLocked removes an entry from the pool and marks it for closing. It reports whether the
+// caller should close the client now; otherwise the last release closes it.
+func (p *sshClientPool) retireLocked(entry *sshPooledClient) bool {
+	p.removeLocked(entry)
+	if entry.closing {
+		return false
+	}
+	entry.closing = true
+	return entry.inUse == 0
+}
+
+// maintain sends keepalives to idle connections and evicts those idle for too long or unresponsive.
+// It stops once the pool is empty and is restarted by the next new connection.
+func (p *sshClientPool) maintain() {
+	for {
+		p.mu.Lock()
+		interval := p.keepaliveInterval
+		p.mu.Unlock()
+		time.Sleep(interval)
+
+		p.mu.Lock()
+		if len(p.clients) == 0 {
+			p.maintaining = false
+			p.mu.Unlock()
+			return
+		}
+
+		var idle, expired []*sshPooledClient
+		for _, entry := range p.clients {
+			if entry.inUse > 0 {
+				continue
+			}
+			if time.Since(entry.lastUsed) >= p.idleTimeout {
+				if p.retireLocked(entry) {
+					expired = append(expired, entry)
+				}
+			} else {
+				idle = append(idle, entry)
+			}
+		}
+		p.mu.Unlock()
+
+		for _, entry := range expired {
+			entry.client.Close()
+		}
+		// Idle entries may be acquired while the ping runs, so they are retired rather than closed
+		for _, entry := range idle {
+			if err := p.ping(entry.client, sshPoolPingTimeout); err != nil {
+				p.mu.Lock()
+				closeNow := p.retireLocked(entry)
+				p.mu.Unlock()
+				if closeNow {
+					entry.client.Close()
+				}
+			}
+		}
+	}
+}
+
+// list returns the pooled connections, optionally filtered by instance
+func (p *sshClientPool) list(serverName string) []SSHPooledConnection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	connections := []SSHPooledConnection{}
+	for _, entry := range p.clients {
+		if serverName != "" && entry.serverName != serverName {
+			continue
+		}
+		connections = append(connections, SSHPooledConnection{
+			ServerName: entry.serverName,
+			Address:    entry.address,
+			Username:   entry.username,
+			CreatedAt:  entry.created,
+			LastUsedAt: entry.lastUsed,
+			Idle:       time.Since(entry.lastUsed).Round(time.Second).String(),
+			InUse:      entry.inUse,
+			Uses:       entry.uses,
+		})
+	}
+
+	sort.Slice(connections, func(i, j int) bool {
+		if connections[i].ServerName != connections[j].ServerName {
+			return connections[i].ServerName < connections[j].ServerName
+		}
+		return connections[i].CreatedAt.Before(connections[j].CreatedAt)
+	})
+	return connections
+}
+
+// close removes pooled connections for an instance (or all when serverName is empty). Connections
+// currently running a command are closed as soon as they are released.
+func (p *sshClientPool) close(serverName string) []SSHPooledConnection {
+	closed := p.list(serverName)
+
+	p.mu.Lock()
+	var closeNow []*ssh.Client
+	for _, entry := range p.clients {
+		if serverName != "" && entry.serverName != serverName {
+			continue
+		}
+		if p.retireLocked(entry) {
+			closeNow = append(closeNow, entry.client)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, client := range closeNow {
+		client.Close()
+	}
+	return closed
+}
+
+// pingSSHClient sends an OpenSSH keepalive request and waits for any reply
+func pingSSHClient(client *ssh.Client, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("keepalive timed out after %s", timeout)
+	}
+}
+
+// acquireSSHClient returns a pooled client for an instance; call release when done
+func acquireSSHClient(serverName string, config *SSHServerConfig) (*ssh.Client, func(), error) {
+	return globalSSHPool.acquire(serverName, config)
+}
+
+// addSSHPoolTools registers the connection pool tools
+func addSSHPoolTools(s *server.MCPServer) {
+	listConnectionsTool := mcp.NewTool("ssh_list_connections",
+		mcp.WithDescription("List pooled SSH connections that are reused across SSH tool calls"),
+		mcp.WithString("server_name",
+			mcp.Description("Only list connections for this server (optional)"),
+		),
+	)
+
+	closeConnectionsTool := mcp.NewTool("ssh_close_connections",
+		mcp.WithDescription("Close pooled SSH connections so the next call reconnects"),
+		mcp.WithString("server_name",
+			mcp.Description("Only close connections for this server (default: close all)"),
+		),
+	)
+
+	s.AddTool(listConnectionsTool, executeSSHListConnections)
+	s.AddTool(closeConnectionsTool, executeSSHCloseConnections)
+}
+
+// executeSSHListConnections handles listing pooled connections
+func executeSSHListConnections(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+	serverName := request.GetString("server_name", "")
+
+	connections := globalSSHPool.list(serverName)
+	result := &SSHOperationResult{
+		ServerName:  serverName,
+		Operation:   "list_connections",
+		Success:     true,
+		Message:     fmt.Sprintf("%d pooled connection(s)", len(connections)),
+		Duration:    time.Since(startTime).String(),
+		Timestamp:   time.Now(),
+		Connections: connections,
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// executeSSHCloseConnections handles closing pooled connections
+func executeSSHCloseConnections(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+	serverName := request.GetString("server_name", "")
+
+	closed := globalSSHPool.close(serverName)
+	result := &SSHOperationResult{
+		ServerName:  serverName,
+		Operation:   "close_connections",
+		Success:     true,
+		Message:     fmt.Sprintf("Closed %d pooled connection(s)", len(closed)),
+		Duration:    time.Since(startTime).String(),
+		Timestamp:   time.Now(),
+		Connections: closed,
+	}
+
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,139 @@
+package builtin
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHClientPool_ReuseAndReconnect(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+	config := server.instance()
+	config.Password = "secret"
+
+	pool := newSSHClientPool(time.Minute, time.Minute)
+	defer pool.close("")
+
+	first, release, err := pool.acquire("test", &config)
+	if err != nil {
+		t.Fatalf("Unexpected acquire error: %v", err)
+	}
+	release()
+	release()
+
+	second, release, err := pool.acquire("test", &config)
+	if err != nil {
+		t.Fatalf("Unexpected acquire error: %v", err)
+	}
+	release()
+	if first != second || server.connections.Load() != 1 {
+		t.Fatalf("Expected the connection to be reused, got %d connections", server.connections.Load())
+	}
+	if connections := pool.list(""); len(connections) != 1 || connections[0].Uses != 2 || connections[0].InUse != 0 {
+		t.Errorf("Unexpected pool state: %+v", connections)
+	}
+
+	// A dropped connection is replaced on the next acquire
+	first.Close()
+	third, release, err := pool.acquire("test", &config)
+	if err != nil {
+		t.Fatalf("Expected a reconnect, got %v", err)
+	}
+	defer release()
+	if third == first || server.connections.Load() != 2 {
+		t.Errorf("Expected a new connection, got %d connections", server.connections.Load())
+	}
+
+	// Different credentials never share a connection
+	other := config
+	other.Username = "someone-else"
+	if key, otherKey := sshPoolKey("test", &config), sshPoolKey("test", &other); key == otherKey {
+		t.Error("Expected different pool keys for different users")
+	}
+}
+
+func TestSSHClientPool_FailedPingKeepsBusyClient(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+	config := server.instance()
+	config.Password = "secret"
+
+	pool := newSSHClientPool(time.Minute, time.Minute)
+	defer pool.close("")
+
+	busy, releaseBusy, err := pool.acquire("test", &config)
+	if err != nil {
+		t.Fatalf("Unexpected acquire error: %v", err)
+	}
+
+	// A keepalive that times out must not close the connection under a running command
+	pool.ping = func(*ssh.Client, time.Duration) error { return fmt.Errorf("keepalive timed out") }
+	fresh, release, err := pool.acquire("test", &config)
+	if err != nil {
+		t.Fatalf("Expected a reconnect, got %v", err)
+	}
+	defer release()
+	if fresh == busy || server.connections.Load() != 2 {
+		t.Fatalf("Expected a new connection, got %d connections", server.connections.Load())
+	}
+	if _, _, err := busy.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+		t.Fatalf("The busy connection must stay open until released: %v", err)
+	}
+
+	releaseBusy()
+	if _, _, err := busy.SendRequest("keepalive@openssh.com", true, nil); err == nil {
+		t.Error("Expected the retired connection to be closed on release")
+	}
+}
+
+func TestSSHClientPool_IdleEviction(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+	config := server.instance()
+	config.Password = "secret"
+
+	pool := newSSHClientPool(50*time.Millisecond, 20*time.Millisecond)
+	defer pool.close("")
+
+	_, release, err := pool.acquire("test", &config)
+	if err != nil {
+		t.Fatalf("Unexpected acquire error: %v", err)
+	}
+	release()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(pool.list("")) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if connections := pool.list(""); len(connections) != 0 {
+		t.Errorf("Expected the idle connection to be evicted, got %+v", connections)
+	}
+}
+
+func TestSSHPoolTools(t *testing.T) {
+	server := newTestSSHServer(t, echoSSHHandler)
+	config := server.instance()
+	config.Password = "secret"
+	useTestSSHConfig(t, map[string]SSHServerConfig{"test": config})
+
+	for i := 0; i < 3; i++ {
+		response, _ := callSSHTool(t, executeSSHExecuteCommand, map[string]any{"server_name": "test", "command": "uptime"})
+		if response["success"] != true {
+			t.Fatalf("Command failed: %v", response)
+		}
+	}
+	if server.connections.Load() != 1 {
+		t.Errorf("Expected one connection for three commands, got %d", server.connections.Load())
+	}
+
+	response, _ := callSSHTool(t, executeSSHListConnections, map[string]any{})
+	connections := response["connections"].([]any)
+	if len(connections) != 1 || connections[0].(map[string]any)["uses"] != float64(3) {
+		t.Errorf("Unexpected pooled connections: %v", connections)
+	}
+
+	response, _ = callSSHTool(t, executeSSHCloseConnections, map[string]any{"server_name": "test"})
+	if response["message"] != "Closed 1 pooled connection(s)" || len(globalSSHPool.list("")) != 0 {
+		t.Errorf("Unexpected close result: %v", response)
+	}
+}
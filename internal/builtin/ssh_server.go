@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +23,30 @@ type SSHServerConfig struct {
 	KeyPath     string `json:"key_path,omitempty"`
 	Timeout     int    `json:"timeout"`
 	Description string `json:"description"`
+
+	// Host key verification: "strict" (default), "tofu" or "insecure"
+	HostKeyPolicy       string   `json:"host_key_policy,omitempty"`
+	KnownHostsPath      string   `json:"known_hosts_path,omitempty"`
+	HostKeyFingerprints []string `json:"host_key_fingerprints,omitempty"`
+
+	// Authentication: methods are tried in AuthMethods order (default: publickey, agent, password, keyboard-interactive)
+	AuthMethods     []string `json:"auth_methods,omitempty"`
+	Passphrase      string   `json:"passphrase,omitempty"`
+	PassphraseEnv   string   `json:"passphrase_env,omitempty"`
+	CertificatePath string   `json:"certificate_path,omitempty"`
+	AgentSocket     string   `json:"agent_socket,omitempty"`
+
+	// ProxyJump is a comma-separated chain of jump hosts: instance names or [user@]host[:port]
+	ProxyJump string `json:"proxy_jump,omitempty"`
+
+	// Policy restricts the commands that can be run; defaultPolicy applies when it is omitted
+	Policy *SSHCommandPolicy `json:"policy,omitempty"`
+
+	// Tags select the instance in fleet commands, alongside the groups in SSHConfig
+	Tags []string `json:"tags,omitempty"`
+
+	// Source records where an imported instance came from; it is empty for JSON instances
+	Source string `json:"-"`
 }
 
 // SSHConfig represents the overall SSH configuration
@@ -36,7 +58,27 @@ type SSHConfig struct {
 		RetryDelay int    `json:"retryDelay"`
 		LogLevel   string `json:"logLevel"`
 		UserAgent  string `json:"userAgent"`
+
+		// Connection pool settings in seconds (defaults: 300 idle, 30 keepalive)
+		PoolIdleTimeout   int `json:"poolIdleTimeout"`
+		KeepaliveInterval int `json:"keepaliveInterval"`
 	} `json:"commonSettings"`
+
+	// Groups name sets of instances that fleet commands can target
+	Groups map[string][]string `json:"groups,omitempty"`
+
+	// DefaultPolicy applies to instances without their own command policy
+	DefaultPolicy *SSHCommandPolicy `json:"defaultPolicy,omitempty"`
+
+	// SSHConfigFiles and Inventories import hosts from OpenSSH client configs and Ansible INI/YAML
+	// inventories; instances defined in JSON override imported ones
+	SSHConfigFiles []string `json:"sshConfigFiles,omitempty"`
+	Inventories    []string `json:"inventories,omitempty"`
+	ImportSources  []string `json:"-"`
+	ImportWarnings []string `json:"-"`
+
+	// AllowedDirectories restricts where SFTP downloads are written and uploads are read from
+	AllowedDirectories []string `json:"allowedDirectories,omitempty"`
 }
 
 // SystemResourceInfo represents system resource information
@@ -51,29 +93,53 @@ type SystemResourceInfo struct {
 	DiskFree    int64     `json:"disk_free_gb"`
 	LoadAverage []float64 `json:"load_average"`
 	Uptime      string    `json:"uptime"`
+
+	UptimeSeconds      float64              `json:"uptime_seconds,omitempty"`
+	CPU                *SystemCPUInfo       `json:"cpu,omitempty"`
+	Memory             *SystemMemoryInfo    `json:"memory,omitempty"`
+	Filesystems        []SystemFilesystem   `json:"filesystems,omitempty"`
+	TopCPUProcesses    []SystemProcess      `json:"top_cpu_processes,omitempty"`
+	TopMemoryProcesses []SystemProcess      `json:"top_memory_processes,omitempty"`
+	OpenFiles          *SystemOpenFiles     `json:"open_files,omitempty"`
+	Sockets            *SystemSocketSummary `json:"sockets,omitempty"`
+	// Warnings lists the probes that failed; the fields they feed are left empty
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // CommandResult represents the result of a command execution
 type CommandResult struct {
 	Command   string    `json:"command"`
 	Output    string    `json:"output"`
+	Stderr    string    `json:"stderr,omitempty"`
 	Error     string    `json:"error,omitempty"`
 	ExitCode  int       `json:"exit_code"`
+	Signal    string    `json:"signal,omitempty"`
+	TimedOut  bool      `json:"timed_out,omitempty"`
+	Cancelled bool      `json:"cancelled,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
 	Duration  string    `json:"duration"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// stopped reports whether the command was stopped before it exited, by a timeout or a cancellation
+func (r *CommandResult) stopped() bool {
+	return r.TimedOut || r.Cancelled
+}
+
 // SSHOperationResult represents the result of SSH operations
 type SSHOperationResult struct {
-	ServerName    string              `json:"server_name"`
-	Operation     string              `json:"operation"`
-	Success       bool                `json:"success"`
-	Message       string              `json:"message"`
-	Duration      string              `json:"duration"`
-	Timestamp     time.Time           `json:"timestamp"`
-	SystemInfo    *SystemResourceInfo `json:"system_info,omitempty"`
-	CommandResult *CommandResult      `json:"command_result,omitempty"`
-	Errors        []string            `json:"errors,omitempty"`
+	ServerName     string                `json:"server_name"`
+	Operation      string                `json:"operation"`
+	Success        bool                  `json:"success"`
+	Message        string                `json:"message"`
+	Duration       string                `json:"duration"`
+	Timestamp      time.Time             `json:"timestamp"`
+	SystemInfo     *SystemResourceInfo   `json:"system_info,omitempty"`
+	CommandResult  *CommandResult        `json:"command_result,omitempty"`
+	CommandResults []*CommandResult      `json:"command_results,omitempty"`
+	Connections    []SSHPooledConnection `json:"connections,omitempty"`
+	Policy         *SSHPolicyDecision    `json:"policy,omitempty"`
+	Errors         []string              `json:"errors,omitempty"`
 }
 
 // Global SSH configuration
@@ -89,6 +155,7 @@ func NewSSHServer(options map[string]any) (*server.MCPServer, error) {
 		return nil, fmt.Errorf("failed to load SSH config: %v", err)
 	}
 	globalSSHConfig = config
+	globalSSHPool.configure(config.CommonSettings.PoolIdleTimeout, config.CommonSettings.KeepaliveInterval)
 
 	// Register SSH tools
 	sshConnectTool := mcp.NewTool("ssh_connect",
@@ -108,10 +175,13 @@ func NewSSHServer(options map[string]any) (*server.MCPServer, error) {
 	)
 
 	sshSystemInfoTool := mcp.NewTool("ssh_system_info",
-		mcp.WithDescription("Get system resource information (CPU, memory, disk) from remote SSH server"),
+		mcp.WithDescription("Get system resource information from remote SSH server: CPU utilisation, memory, all mounted filesystems, load, uptime, top CPU/memory/open-file processes and socket summaries. Probes that fail are reported as warnings alongside the rest of the data. The probe commands (cat, grep, df, ps, find, awk, ...) are checked against the instance policy like any other command"+sshConfirmationNotice),
 		mcp.WithString("server_name",
 			mcp.Description("Name of the server from configuration"),
 		),
+		mcp.WithNumber("top_processes",
+			mcp.Description("Number of processes listed per ranking (default: 10)"),
+		),
 		mcp.WithString("host",
 			mcp.Description("Override host IP/domain (optional)"),
 		),
@@ -121,15 +191,18 @@ func NewSSHServer(options map[string]any) (*server.MCPServer, error) {
 		mcp.WithString("password",
 			mcp.Description("Override password (optional)"),
 		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Token returned when a probe command required confirmation. Only pass it after the user approved it"),
+		),
 	)
 
 	sshExecuteCommandTool := mcp.NewTool("ssh_execute_command",
-		mcp.WithDescription("Execute a command on remote SSH server (rm command is blocked for safety)"),
+		mcp.WithDescription("Execute a command on remote SSH server. The command is checked against the instance policy: destructive commands are denied and sensitive ones (sudo, kill, service restarts, ...) return a confirmation_token that must be approved by the user"+sshConfirmationNotice),
 		mcp.WithString("server_name",
 			mcp.Description("Name of the server from configuration"),
 		),
 		mcp.WithString("command",
-			mcp.Description("Command to execute (rm commands are blocked for safety)"),
+			mcp.Description("Command to execute; pipelines, subshells and command substitutions are checked too"),
 		),
 		mcp.WithString("host",
 			mcp.Description("Override host IP/domain (optional)"),
@@ -141,12 +214,21 @@ func NewSSHServer(options map[string]any) (*server.MCPServer, error) {
 			mcp.Description("Override password (optional)"),
 		),
 		mcp.WithNumber("timeout",
-			mcp.Description("Command timeout in seconds (default: 30)"),
+			mcp.Description("Command timeout in seconds (default: 30). The command is sent SIGTERM and its session closed when it expires"),
+		),
+		mcp.WithNumber("max_output_bytes",
+			mcp.Description("Maximum bytes kept for each of stdout and stderr; the middle of longer output is replaced by a truncation marker (default: 65536)"),
+		),
+		mcp.WithBoolean("pty",
+			mcp.Description("Allocate a pseudo-terminal for commands that require one (stderr is merged into stdout)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Token returned when the command required confirmation. Only pass it after the user approved the command"),
 		),
 	)
 
 	sshExecuteMultipleCommandsTool := mcp.NewTool("ssh_execute_multiple_commands",
-		mcp.WithDescription("Execute multiple commands on remote SSH server (rm commands are blocked for safety)"),
+		mcp.WithDescription("Execute multiple commands on remote SSH server. Commands denied by the instance policy are skipped; if any needs confirmation, none run until the returned confirmation_token is passed back"+sshConfirmationNotice),
 		mcp.WithString("server_name",
 			mcp.Description("Name of the server from configuration"),
 		),
@@ -163,7 +245,13 @@ func NewSSHServer(options map[string]any) (*server.MCPServer, error) {
 			mcp.Description("Override password (optional)"),
 		),
 		mcp.WithNumber("timeout",
-			mcp.Description("Command timeout in seconds (default: 30)"),
+			mcp.Description("Timeout in seconds for each command (default: 30)"),
+		),
+		mcp.WithNumber("max_output_bytes",
+			mcp.Description("Maximum bytes kept for each of stdout and stderr per command (default: 65536)"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("Token returned when the commands required confirmation. Only pass it after the user approved the commands"),
 		),
 	)
 
@@ -172,6 +260,12 @@ func NewSSHServer(options map[string]any) (*server.MCPServer, error) {
 	s.AddTool(sshExecuteCommandTool, executeSSHExecuteCommand)
 	s.AddTool(sshExecuteMultipleCommandsTool, executeSSHExecuteMultipleCommands)
 
+	addSSHPoolTools(s)
+	addSSHFileTools(s)
+	addSSHFleetTools(s)
+	addSSHLogTools(s)
+	addSSHInventoryTools(s)
+
 	return s, nil
 }
 
@@ -196,6 +290,25 @@ func LoadSSHConfig(options map[string]any) (*SSHConfig, error) {
 		return nil, fmt.Errorf("failed to parse SSH config: %v", err)
 	}
 
+	allowedDirs, err := getAllowedDirectoriesOption(options)
+	if err != nil {
+		return nil, err
+	}
+	config.AllowedDirectories = allowedDirs
+
+	if err := importSSHInstances(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateSSHCommandPolicy(config.DefaultPolicy); err != nil {
+		return nil, fmt.Errorf("defaultPolicy: %v", err)
+	}
+	for name, instance := range config.Instances {
+		if err := validateSSHCommandPolicy(instance.Policy); err != nil {
+			return nil, fmt.Errorf("instance '%s' policy: %v", name, err)
+		}
+	}
+
 	return &config, nil
 }
 
@@ -215,39 +328,7 @@ func getSSHInstanceConfig(instanceName string) (*SSHServerConfig, error) {
 
 // createSSHClient creates an SSH client connection
 func createSSHClient(config *SSHServerConfig) (*ssh.Client, error) {
-	var authMethod ssh.AuthMethod
-
-	// Try private key first
-	if config.PrivateKey != "" {
-		signer, err := ssh.ParsePrivateKey([]byte(config.PrivateKey))
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse private key: %v", err)
-		}
-		authMethod = ssh.PublicKeys(signer)
-	} else if config.KeyPath != "" {
-		keyBytes, err := os.ReadFile(config.KeyPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read private key file: %v", err)
-		}
-		signer, err := ssh.ParsePrivateKey(keyBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse private key: %v", err)
-		}
-		authMethod = ssh.PublicKeys(signer)
-	} else if config.Password != "" {
-		authMethod = ssh.Password(config.Password)
-	} else {
-		return nil, fmt.Errorf("no authentication method provided (password or private key required)")
-	}
-
-	sshConfig := &ssh.ClientConfig{
-		User:            config.Username,
-		Auth:            []ssh.AuthMethod{authMethod},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         time.Duration(config.Timeout) * time.Second,
-	}
-
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", config.Host, config.Port), sshConfig)
+	client, err := dialSSHClient(config, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to SSH server: %v", err)
 	}
@@ -286,7 +367,7 @@ func executeSSHConnect(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 	}
 
 	// Test connection
-	client, err := createSSHClient(instanceConfig)
+	_, release, err := acquireSSHClient(serverName, instanceConfig)
 	if err != nil {
 		result := &SSHOperationResult{
 			ServerName: serverName,
@@ -300,7 +381,7 @@ func executeSSHConnect(ctx context.Context, request mcp.CallToolRequest) (*mcp.C
 		resultJSON, _ := json.Marshal(result)
 		return mcp.NewToolResultText(string(resultJSON)), nil
 	}
-	defer client.Close()
+	defer release()
 
 	result := &SSHOperationResult{
 		ServerName: serverName,
@@ -323,6 +404,7 @@ func executeSSHSystemInfo(ctx context.Context, request mcp.CallToolRequest) (*mc
 	host := request.GetString("host", "")
 	username := request.GetString("username", "")
 	password := request.GetString("password", "")
+	topProcesses := int(request.GetFloat("top_processes", defaultSystemInfoTopProcesses))
 
 	if serverName == "" {
 		return mcp.NewToolResultError("server_name is required"), nil
@@ -334,6 +416,12 @@ func executeSSHSystemInfo(ctx context.Context, request mcp.CallToolRequest) (*mc
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get SSH config: %v", err)), nil
 	}
 
+	// Check the probe commands against the instance policy
+	decision := evaluateSystemInfoProbes(sshInstancePolicy(instanceConfig))
+	if !confirmSSHPolicyDecision(decision, serverName, "system_info", request.GetString("confirmation_token", "")) {
+		return sshPolicyResult(serverName, "system_info", decision, startTime), nil
+	}
+
 	// Override with provided parameters
 	if host != "" {
 		instanceConfig.Host = host
@@ -346,7 +434,7 @@ func executeSSHSystemInfo(ctx context.Context, request mcp.CallToolRequest) (*mc
 	}
 
 	// Create SSH client
-	client, err := createSSHClient(instanceConfig)
+	client, release, err := acquireSSHClient(serverName, instanceConfig)
 	if err != nil {
 		result := &SSHOperationResult{
 			ServerName: serverName,
@@ -360,10 +448,10 @@ func executeSSHSystemInfo(ctx context.Context, request mcp.CallToolRequest) (*mc
 		resultJSON, _ := json.Marshal(result)
 		return mcp.NewToolResultText(string(resultJSON)), nil
 	}
-	defer client.Close()
+	defer release()
 
 	// Get system information
-	systemInfo, err := getSystemInfo(client)
+	systemInfo, err := getSystemInfo(client, topProcesses)
 	if err != nil {
 		result := &SSHOperationResult{
 			ServerName: serverName,
@@ -378,11 +466,16 @@ func executeSSHSystemInfo(ctx context.Context, request mcp.CallToolRequest) (*mc
 		return mcp.NewToolResultText(string(resultJSON)), nil
 	}
 
+	message := "Successfully retrieved system information"
+	if len(systemInfo.Warnings) > 0 {
+		message = fmt.Sprintf("Retrieved system information with %d warning(s)", len(systemInfo.Warnings))
+	}
+
 	result := &SSHOperationResult{
 		ServerName: serverName,
 		Operation:  "system_info",
 		Success:    true,
-		Message:    "Successfully retrieved system information",
+		Message:    message,
 		Duration:   time.Since(startTime).String(),
 		Timestamp:  time.Now(),
 		SystemInfo: systemInfo,
@@ -402,6 +495,9 @@ func executeSSHExecuteCommand(ctx context.Context, request mcp.CallToolRequest)
 	username := request.GetString("username", "")
 	password := request.GetString("password", "")
 	timeout := int(request.GetFloat("timeout", 30))
+	maxOutputBytes := int(request.GetFloat("max_output_bytes", defaultSSHMaxOutputBytes))
+	pty := request.GetBool("pty", false)
+	confirmationToken := request.GetString("confirmation_token", "")
 
 	if serverName == "" {
 		return mcp.NewToolResultError("server_name is required"), nil
@@ -410,17 +506,18 @@ func executeSSHExecuteCommand(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError("command is required"), nil
 	}
 
-	// Check for dangerous commands
-	if isDangerousCommand(command) {
-		return mcp.NewToolResultError("Command contains dangerous operations (rm, format, etc.) and is blocked for safety"), nil
-	}
-
 	// Get instance configuration
 	instanceConfig, err := getSSHInstanceConfig(serverName)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to get SSH config: %v", err)), nil
 	}
 
+	// Check the command against the instance policy
+	decision := evaluateSSHCommand(sshInstancePolicy(instanceConfig), command)
+	if !confirmSSHPolicyDecision(decision, serverName, command, confirmationToken) {
+		return sshPolicyResult(serverName, "execute_command", decision, startTime), nil
+	}
+
 	// Override with provided parameters
 	if host != "" {
 		instanceConfig.Host = host
@@ -433,7 +530,7 @@ func executeSSHExecuteCommand(ctx context.Context, request mcp.CallToolRequest)
 	}
 
 	// Create SSH client
-	client, err := createSSHClient(instanceConfig)
+	client, release, err := acquireSSHClient(serverName, instanceConfig)
 	if err != nil {
 		result := &SSHOperationResult{
 			ServerName: serverName,
@@ -447,10 +544,17 @@ func executeSSHExecuteCommand(ctx context.Context, request mcp.CallToolRequest)
 		resultJSON, _ := json.Marshal(result)
 		return mcp.NewToolResultText(string(resultJSON)), nil
 	}
-	defer client.Close()
-
-	// Execute command
-	cmdResult, err := executeCommand(client, command, timeout)
+	defer release()
+
+	// Execute command, streaming partial output to clients that asked for progress
+	cmdResult, err := runSSHCommand(ctx, client, command, sshCommandOptions{
+		Timeout:        time.Duration(timeout) * time.Second,
+		MaxOutputBytes: maxOutputBytes,
+		PTY:            pty,
+		Progress: func(elapsed time.Duration, stdout, stderr string) {
+			notifyToolProgress(ctx, request, int(elapsed.Seconds()), timeout, stdout+stderr)
+		},
+	})
 	if err != nil {
 		result := &SSHOperationResult{
 			ServerName: serverName,
@@ -465,11 +569,16 @@ func executeSSHExecuteCommand(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultText(string(resultJSON)), nil
 	}
 
+	message := "Command executed successfully"
+	if cmdResult.stopped() {
+		message = cmdResult.Error
+	}
+
 	result := &SSHOperationResult{
 		ServerName:    serverName,
 		Operation:     "execute_command",
-		Success:       true,
-		Message:       "Command executed successfully",
+		Success:       !cmdResult.stopped(),
+		Message:       message,
 		Duration:      time.Since(startTime).String(),
 		Timestamp:     time.Now(),
 		CommandResult: cmdResult,
@@ -489,6 +598,8 @@ func executeSSHExecuteMultipleCommands(ctx context.Context, request mcp.CallTool
 	username := request.GetString("username", "")
 	password := request.GetString("password", "")
 	timeout := int(request.GetFloat("timeout", 30))
+	maxOutputBytes := int(request.GetFloat("max_output_bytes", defaultSSHMaxOutputBytes))
+	confirmationToken := request.GetString("confirmation_token", "")
 
 	if serverName == "" {
 		return mcp.NewToolResultError("server_name is required"), nil
@@ -519,8 +630,22 @@ func executeSSHExecuteMultipleCommands(ctx context.Context, request mcp.CallTool
 		instanceConfig.Password = password
 	}
 
+	// Check every command up front so nothing runs while confirmation is pending
+	policy := sshInstancePolicy(instanceConfig)
+	decisions := make([]*SSHPolicyDecision, len(commandList))
+	pending := &SSHPolicyDecision{Action: sshPolicyAllow}
+	for i, cmd := range commandList {
+		decisions[i] = evaluateSSHCommand(policy, strings.TrimSpace(cmd))
+		if decisions[i].Action == sshPolicyConfirm {
+			pending.merge(decisions[i])
+		}
+	}
+	if !confirmSSHPolicyDecision(pending, serverName, commands, confirmationToken) {
+		return sshPolicyResult(serverName, "execute_multiple_commands", pending, startTime), nil
+	}
+
 	// Create SSH client
-	client, err := createSSHClient(instanceConfig)
+	client, release, err := acquireSSHClient(serverName, instanceConfig)
 	if err != nil {
 		result := &SSHOperationResult{
 			ServerName: serverName,
@@ -534,7 +659,7 @@ func executeSSHExecuteMultipleCommands(ctx context.Context, request mcp.CallTool
 		resultJSON, _ := json.Marshal(result)
 		return mcp.NewToolResultText(string(resultJSON)), nil
 	}
-	defer client.Close()
+	defer release()
 
 	// Execute each command
 	for i, cmd := range commandList {
@@ -543,15 +668,21 @@ func executeSSHExecuteMultipleCommands(ctx context.Context, request mcp.CallTool
 			continue
 		}
 
-		// Check for dangerous commands
-		if isDangerousCommand(cmd) {
-			errors = append(errors, fmt.Sprintf("Command %d contains dangerous operations and is blocked: %s", i+1, cmd))
+		if decisions[i].Action == sshPolicyDeny {
+			errors = append(errors, fmt.Sprintf("Command %d blocked by policy: %s", i+1, decisions[i].Summary()))
 			continue
 		}
 
-		cmdResult, err := executeCommand(client, cmd, timeout)
+		notifyToolProgress(ctx, request, i, len(commandList), fmt.Sprintf("Running command %d: %s", i+1, cmd))
+		cmdResult, err := runSSHCommand(ctx, client, cmd, sshCommandOptions{
+			Timeout:        time.Duration(timeout) * time.Second,
+			MaxOutputBytes: maxOutputBytes,
+		})
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("Command %d failed: %v", i+1, err))
+		} else if cmdResult.stopped() {
+			errors = append(errors, fmt.Sprintf("Command %d %s", i+1, strings.TrimPrefix(cmdResult.Error, "command ")))
+			results = append(results, cmdResult)
 		} else {
 			results = append(results, cmdResult)
 		}
@@ -564,131 +695,26 @@ func executeSSHExecuteMultipleCommands(ctx context.Context, request mcp.CallTool
 	}
 
 	result := &SSHOperationResult{
-		ServerName: serverName,
-		Operation:  "execute_multiple_commands",
-		Success:    success,
-		Message:    message,
-		Duration:   time.Since(startTime).String(),
-		Timestamp:  time.Now(),
-		Errors:     errors,
+		ServerName:     serverName,
+		Operation:      "execute_multiple_commands",
+		Success:        success,
+		Message:        message,
+		Duration:       time.Since(startTime).String(),
+		Timestamp:      time.Now(),
+		CommandResults: results,
+		Errors:         errors,
 	}
 
 	resultJSON, _ := json.Marshal(result)
 	return mcp.NewToolResultText(string(resultJSON)), nil
 }
 
-// getSystemInfo retrieves system resource information
-func getSystemInfo(client *ssh.Client) (*SystemResourceInfo, error) {
-	// Get CPU and memory info
-	cpuMemCmd := "top -bn1 | grep 'Cpu(s)' | awk '{print $2}' | cut -d'%' -f1"
-	cpuOutput, err := executeCommandString(client, cpuMemCmd, 10)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get CPU info: %v", err)
-	}
-
-	// Get memory info
-	memCmd := "free -m | grep '^Mem:' | awk '{print $2, $3, $4}'"
-	memOutput, err := executeCommandString(client, memCmd, 10)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get memory info: %v", err)
-	}
-
-	// Get disk info
-	diskCmd := "df -h / | tail -1 | awk '{print $2, $3, $4}'"
-	diskOutput, err := executeCommandString(client, diskCmd, 10)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get disk info: %v", err)
-	}
-
-	// Get load average
-	loadCmd := "uptime | awk -F'load average:' '{print $2}' | awk '{print $1, $2, $3}'"
-	loadOutput, err := executeCommandString(client, loadCmd, 10)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get load average: %v", err)
-	}
-
-	// Get uptime
-	uptimeCmd := "uptime -p"
-	uptimeOutput, err := executeCommandString(client, uptimeCmd, 10)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get uptime: %v", err)
-	}
-
-	// Parse CPU usage
-	cpuUsage, _ := strconv.ParseFloat(strings.TrimSpace(cpuOutput), 64)
-
-	// Parse memory info
-	memParts := strings.Fields(strings.TrimSpace(memOutput))
-	memoryTotal, _ := strconv.ParseInt(memParts[0], 10, 64)
-	memoryUsed, _ := strconv.ParseInt(memParts[1], 10, 64)
-	memoryFree, _ := strconv.ParseInt(memParts[2], 10, 64)
-
-	// Parse disk info (convert GB to bytes)
-	diskParts := strings.Fields(strings.TrimSpace(diskOutput))
-	diskTotalStr := strings.TrimSuffix(diskParts[0], "G")
-	diskUsedStr := strings.TrimSuffix(diskParts[1], "G")
-	diskFreeStr := strings.TrimSuffix(diskParts[2], "G")
-
-	diskTotal, _ := strconv.ParseInt(diskTotalStr, 10, 64)
-	diskUsed, _ := strconv.ParseInt(diskUsedStr, 10, 64)
-	diskFree, _ := strconv.ParseInt(diskFreeStr, 10, 64)
-
-	// Parse load average
-	loadParts := strings.Fields(strings.TrimSpace(loadOutput))
-	var loadAverage []float64
-	for _, load := range loadParts {
-		if loadFloat, err := strconv.ParseFloat(strings.TrimSuffix(load, ","), 64); err == nil {
-			loadAverage = append(loadAverage, loadFloat)
-		}
-	}
-
-	return &SystemResourceInfo{
-		Timestamp:   time.Now(),
-		CPUUsage:    cpuUsage,
-		MemoryTotal: memoryTotal,
-		MemoryUsed:  memoryUsed,
-		MemoryFree:  memoryFree,
-		DiskTotal:   diskTotal,
-		DiskUsed:    diskUsed,
-		DiskFree:    diskFree,
-		LoadAverage: loadAverage,
-		Uptime:      strings.TrimSpace(uptimeOutput),
-	}, nil
-}
-
-// executeCommand executes a command on the SSH client
+// executeCommand executes a command on the SSH client, giving up after timeout seconds
 func executeCommand(client *ssh.Client, command string, timeout int) (*CommandResult, error) {
-	startTime := time.Now()
-
-	session, err := client.NewSession()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %v", err)
-	}
-	defer session.Close()
-
-	// Execute command
-	output, err := session.CombinedOutput(command)
-	duration := time.Since(startTime)
-
-	result := &CommandResult{
-		Command:   command,
-		Output:    string(output),
-		Duration:  duration.String(),
-		Timestamp: time.Now(),
-	}
-
-	if err != nil {
-		result.Error = err.Error()
-		if exitError, ok := err.(*ssh.ExitError); ok {
-			result.ExitCode = exitError.ExitStatus()
-		} else {
-			result.ExitCode = -1
-		}
-	} else {
-		result.ExitCode = 0
-	}
-
-	return result, nil
+	return runSSHCommand(context.Background(), client, command, sshCommandOptions{
+		Timeout:        time.Duration(timeout) * time.Second,
+		MaxOutputBytes: defaultSSHMaxOutputBytes,
+	})
 }
 
 // executeCommandString executes a command and returns the output as string
@@ -702,24 +728,3 @@ func executeCommandString(client *ssh.Client, command string, timeout int) (stri
 	}
 	return result.Output, nil
 }
-
-// isDangerousCommand checks if a command contains dangerous operations
-func isDangerousCommand(command string) bool {
-	dangerousPatterns := []string{
-		"rm ", "rm -rf", "rm -r", "rm -f",
-		"format", "mkfs", "dd if=",
-		"shutdown", "halt", "reboot",
-		"> /dev/", "> /proc/", "> /sys/",
-		"chmod 000", "chmod 777",
-		"sudo rm", "sudo format", "sudo mkfs",
-	}
-
-	commandLower := strings.ToLower(command)
-	for _, pattern := range dangerousPatterns {
-		if strings.Contains(commandLower, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
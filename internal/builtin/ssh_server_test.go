@@ -0,0 +1,269 @@
+package builtin
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHHandler runs an exec request on the test server and returns its exit status
+type testSSHHandler func(command string, stdout, stderr io.Writer) int
+
+// testSSHServer is an in-process SSH server that runs commands through a handler, serves SFTP from
+// the local filesystem and forwards direct-tcpip channels, so it can also act as a jump host
+type testSSHServer struct {
+	listener    net.Listener
+	hostKey     ssh.Signer
+	password    string
+	handler     testSSHHandler
+	connections atomic.Int32
+	ptyRequests atomic.Int32
+
+	mu            sync.Mutex
+	authorized    []ssh.PublicKey
+	certAuthority ssh.PublicKey
+	signals       []string
+}
+
+// newTestSSHServer starts a test server accepting user "tester" with password "secret"
+func newTestSSHServer(t *testing.T, handler testSSHHandler) *testSSHServer {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate host key: %v", err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatalf("Failed to create host signer: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := &testSSHServer{listener: listener, hostKey: hostKey, password: "secret", handler: handler}
+	go server.serve()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+// authorize allows a public key to log in
+func (s *testSSHServer) authorize(key ssh.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authorized = append(s.authorized, key)
+}
+
+// trustCertificateAuthority accepts user certificates signed by ca
+func (s *testSSHServer) trustCertificateAuthority(ca ssh.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.certAuthority = ca
+}
+
+// instance returns an SSHServerConfig for the server with its host key pinned
+func (s *testSSHServer) instance() SSHServerConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return SSHServerConfig{
+		Name:                "test",
+		Host:                host,
+		Port:                portNumber,
+		Username:            "tester",
+		Timeout:             5,
+		HostKeyFingerprints: []string{ssh.FingerprintSHA256(s.hostKey.PublicKey())},
+	}
+}
+
+func (s *testSSHServer) serve() {
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.certAuthority != nil && string(auth.Marshal()) == string(s.certAuthority.Marshal())
+		},
+		UserKeyFallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, authorized := range s.authorized {
+				if string(authorized.Marshal()) == string(key.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == s.password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+		PublicKeyCallback: checker.Authenticate,
+	}
+	config.AddHostKey(s.hostKey)
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn, config)
+	}
+}
+
+func (s *testSSHServer) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer serverConn.Close()
+	s.connections.Add(1)
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "session":
+			go s.handleSession(newChannel)
+		case "direct-tcpip":
+			go handleTestDirectTCPIP(newChannel)
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (s *testSSHServer) handleSession(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	// Requests keep being read while the command runs so signals can be observed
+	for request := range requests {
+		switch request.Type {
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(request.Payload, &payload); err != nil {
+				request.Reply(false, nil)
+				return
+			}
+			request.Reply(true, nil)
+
+			go func() {
+				status := s.handler(payload.Command, channel, channel.Stderr())
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+				channel.Close()
+			}()
+		case "subsystem":
+			var payload struct{ Name string }
+			ssh.Unmarshal(request.Payload, &payload)
+			if payload.Name != "sftp" {
+				request.Reply(false, nil)
+				continue
+			}
+			request.Reply(true, nil)
+
+			go func() {
+				if sftpServer, err := sftp.NewServer(channel); err == nil {
+					sftpServer.Serve()
+				}
+				channel.Close()
+			}()
+		case "signal":
+			var payload struct{ Signal string }
+			ssh.Unmarshal(request.Payload, &payload)
+			s.mu.Lock()
+			s.signals = append(s.signals, payload.Signal)
+			s.mu.Unlock()
+		case "pty-req":
+			s.ptyRequests.Add(1)
+			request.Reply(true, nil)
+		default:
+			request.Reply(request.Type == "env", nil)
+		}
+	}
+}
+
+// receivedSignals returns the signal names sent to the server's sessions
+func (s *testSSHServer) receivedSignals() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.signals...)
+}
+
+// handleTestDirectTCPIP forwards a jump host channel to its destination
+func handleTestDirectTCPIP(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, "bad payload")
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	go func() {
+		io.Copy(target, channel)
+		target.Close()
+	}()
+	io.Copy(channel, target)
+	channel.Close()
+}
+
+// useTestSSHConfig installs the given instances as the global SSH configuration for a test,
+// with a fresh connection pool
+func useTestSSHConfig(t *testing.T, instances map[string]SSHServerConfig, allowedDirs ...string) {
+	t.Helper()
+
+	previousConfig, previousPool := globalSSHConfig, globalSSHPool
+	globalSSHConfig = &SSHConfig{Instances: instances, AllowedDirectories: allowedDirs}
+	globalSSHPool = newSSHClientPool(defaultSSHPoolIdleTimeout, defaultSSHPoolKeepaliveInterval)
+	pool := globalSSHPool
+	t.Cleanup(func() {
+		pool.close("")
+		globalSSHConfig, globalSSHPool = previousConfig, previousPool
+	})
+}
+
+// callSSHTool invokes an SSH tool handler and decodes its JSON result
+func callSSHTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (map[string]any, *mcp.CallToolResult) {
+	t.Helper()
+	return callArtifactoryTool(t, handler, args)
+}
+
+// echoSSHHandler writes the command back to stdout
+func echoSSHHandler(command string, stdout, stderr io.Writer) int {
+	fmt.Fprintf(stdout, "ran: %s\n", command)
+	return 0
+}
@@ -0,0 +1,837 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/sftp"
+)
+
+const (
+	// defaultSSHReadLimit is how many bytes ssh_read_file returns when no limit is given
+	defaultSSHReadLimit = 64 * 1024
+	// maxSSHReadLimit bounds a single ssh_read_file call; larger files should be downloaded
+	maxSSHReadLimit = 1024 * 1024
+	// sshTailChunkSize is how far tail mode reads backwards per step
+	sshTailChunkSize = 64 * 1024
+	// defaultSSHListLimit bounds the entries returned by ssh_list_dir
+	defaultSSHListLimit = 1000
+)
+
+// SSHFileInfo describes a remote file
+type SSHFileInfo struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"`
+	Modified   time.Time `json:"modified"`
+	UID        *uint32   `json:"uid,omitempty"`
+	GID        *uint32   `json:"gid,omitempty"`
+	LinkTarget string    `json:"link_target,omitempty"`
+}
+
+// SSHFileContent is the result of ssh_read_file
+type SSHFileContent struct {
+	ServerName string    `json:"server_name"`
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	Offset     int64     `json:"offset"`
+	BytesRead  int       `json:"bytes_read"`
+	NextOffset int64     `json:"next_offset"`
+	EOF        bool      `json:"eof"`
+	Encoding   string    `json:"encoding"`
+	Content    string    `json:"content"`
+	Lines      int       `json:"lines,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// SSHTransferredFile is one file copied by ssh_download or ssh_upload
+type SSHTransferredFile struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// SSHTransferResult is the result of ssh_download and ssh_upload
+type SSHTransferResult struct {
+	ServerName string               `json:"server_name"`
+	Operation  string               `json:"operation"`
+	Files      []SSHTransferredFile `json:"files"`
+	TotalBytes int64                `json:"total_bytes"`
+	Skipped    []string             `json:"skipped,omitempty"`
+	Duration   string               `json:"duration"`
+	Timestamp  time.Time            `json:"timestamp"`
+}
+
+// sshConnectionOptions returns the tool options shared by tools that connect to an instance
+func sshConnectionOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("server_name",
+			mcp.Required(),
+			mcp.Description("Name of the server from configuration"),
+		),
+		mcp.WithString("host",
+			mcp.Description("Override host IP/domain (optional)"),
+		),
+		mcp.WithString("username",
+			mcp.Description("Override username (optional)"),
+		),
+		mcp.WithString("password",
+			mcp.Description("Override password (optional)"),
+		),
+	}
+}
+
+// sshInstanceFromRequest resolves the instance named by server_name and applies request overrides
+func sshInstanceFromRequest(request mcp.CallToolRequest) (string, *SSHServerConfig, error) {
+	serverName := request.GetString("server_name", "")
+	if serverName == "" {
+		return "", nil, fmt.Errorf("server_name is required")
+	}
+
+	instanceConfig, err := getSSHInstanceConfig(serverName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get SSH config: %v", err)
+	}
+
+	if host := request.GetString("host", ""); host != "" {
+		instanceConfig.Host = host
+	}
+	if username := request.GetString("username", ""); username != "" {
+		instanceConfig.Username = username
+	}
+	if password := request.GetString("password", ""); password != "" {
+		instanceConfig.Password = password
+	}
+
+	return serverName, instanceConfig, nil
+}
+
+// openSFTPClient starts an SFTP session on a pooled connection; call release when done
+func openSFTPClient(serverName string, config *SSHServerConfig) (*sftp.Client, func(), error) {
+	client, release, err := acquireSSHClient(serverName, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to start SFTP session: %v", err)
+	}
+
+	return sftpClient, func() {
+		sftpClient.Close()
+		release()
+	}, nil
+}
+
+// resolveSSHLocalPath returns the absolute form of a local path after checking it lies inside an allowed directory
+func resolveSSHLocalPath(localPath string) (string, error) {
+	if globalSSHConfig == nil || len(globalSSHConfig.AllowedDirectories) == 0 {
+		return "", fmt.Errorf("no allowed directories configured for SSH file transfers")
+	}
+	return resolveAllowedLocalPath(localPath, globalSSHConfig.AllowedDirectories)
+}
+
+// addSSHFileTools registers the SFTP-backed file tools
+func addSSHFileTools(s *server.MCPServer) {
+	readFileTool := mcp.NewTool("ssh_read_file",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Read part of a remote file over SFTP, by byte range or as the last N lines (tail). Binary content is returned base64-encoded"),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Absolute path of the remote file"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("Byte offset to start reading from (default: 0)"),
+				mcp.Min(0),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum bytes to return (default: 65536, max: 1048576)"),
+				mcp.Min(1),
+				mcp.Max(maxSSHReadLimit),
+			),
+			mcp.WithNumber("tail_lines",
+				mcp.Description("Return the last N lines of the file instead of a byte range"),
+				mcp.Min(1),
+			),
+		}, sshConnectionOptions()...)...,
+	)
+
+	listDirTool := mcp.NewTool("ssh_list_dir",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("List a remote directory over SFTP"),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Absolute path of the remote directory"),
+			),
+			mcp.WithString("pattern",
+				mcp.Description("Only include entries whose name matches this glob (e.g. '*.log')"),
+			),
+			mcp.WithBoolean("show_hidden",
+				mcp.Description("Include entries starting with '.' (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum entries to return (default: 1000)"),
+				mcp.Min(1),
+			),
+		}, sshConnectionOptions()...)...,
+	)
+
+	statTool := mcp.NewTool("ssh_stat",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Get size, type, permissions, owner and modification time of a remote path"),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Absolute remote path"),
+			),
+		}, sshConnectionOptions()...)...,
+	)
+
+	downloadTool := mcp.NewTool("ssh_download",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Download a remote file or directory over SFTP into an allowed local directory, e.g. to analyze logs or support bundles locally"),
+			mcp.WithString("remote_path",
+				mcp.Required(),
+				mcp.Description("Absolute path of the remote file or directory"),
+			),
+			mcp.WithString("local_path",
+				mcp.Description("Local file or directory inside the allowed directories (default: ssh-downloads/<server_name> in the first allowed directory)"),
+			),
+			mcp.WithBoolean("recursive",
+				mcp.Description("Download directories recursively (default: false)"),
+			),
+			mcp.WithBoolean("overwrite",
+				mcp.Description("Replace existing local files (default: false, existing files are skipped)"),
+			),
+		}, sshConnectionOptions()...)...,
+	)
+
+	uploadTool := mcp.NewTool("ssh_upload",
+		append([]mcp.ToolOption{
+			mcp.WithDescription("Upload a local file from an allowed directory to the remote server over SFTP. The upload is checked against the instance policy like the equivalent cp command: protected system paths are denied and uploads matching the confirm list return a confirmation_token" + sshConfirmationNotice),
+			mcp.WithString("local_path",
+				mcp.Required(),
+				mcp.Description("Local file inside the allowed directories"),
+			),
+			mcp.WithString("remote_path",
+				mcp.Required(),
+				mcp.Description("Remote destination file, or an existing remote directory to upload into"),
+			),
+			mcp.WithBoolean("overwrite",
+				mcp.Description("Replace an existing remote file (default: false)"),
+			),
+			mcp.WithString("confirmation_token",
+				mcp.Description("Token returned when the upload required confirmation. Only pass it after the user approved the upload"),
+			),
+		}, sshConnectionOptions()...)...,
+	)
+
+	s.AddTool(readFileTool, executeSSHReadFile)
+	s.AddTool(listDirTool, executeSSHListDir)
+	s.AddTool(statTool, executeSSHStat)
+	s.AddTool(downloadTool, executeSSHDownload)
+	s.AddTool(uploadTool, executeSSHUpload)
+}
+
+// executeSSHReadFile handles reading a remote file
+func executeSSHReadFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serverName, instanceConfig, err := sshInstanceFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	remotePath := request.GetString("path", "")
+	if remotePath == "" {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+	offset := int64(request.GetFloat("offset", 0))
+	limit := int(request.GetFloat("limit", defaultSSHReadLimit))
+	tailLines := int(request.GetFloat("tail_lines", 0))
+	if offset < 0 {
+		return mcp.NewToolResultError("offset must not be negative"), nil
+	}
+	if limit <= 0 || limit > maxSSHReadLimit {
+		limit = maxSSHReadLimit
+	}
+
+	client, release, err := openSFTPClient(serverName, instanceConfig)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to connect: %v", err)), nil
+	}
+	defer release()
+
+	file, err := client.Open(remotePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to open %s: %v", remotePath, err)), nil
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to stat %s: %v", remotePath, err)), nil
+	}
+	if info.IsDir() {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is a directory, use ssh_list_dir", remotePath)), nil
+	}
+
+	var data []byte
+	if tailLines > 0 {
+		data, offset, err = readSFTPTail(file, info.Size(), tailLines, limit)
+	} else {
+		data, err = readSFTPRange(file, offset, limit)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to read %s: %v", remotePath, err)), nil
+	}
+
+	result := SSHFileContent{
+		ServerName: serverName,
+		Path:       remotePath,
+		Size:       info.Size(),
+		Offset:     offset,
+		Timestamp:  time.Now(),
+	}
+	if isBinaryContent(data) {
+		result.Encoding = "base64"
+		result.Content = base64.StdEncoding.EncodeToString(data)
+	} else {
+		// Do not split a multi-byte character at the end of the window; the next read picks it up
+		if tailLines == 0 {
+			data = trimPartialRune(data)
+		}
+		result.Encoding = "utf-8"
+		result.Content = string(data)
+	}
+	if tailLines > 0 {
+		result.Lines = strings.Count(strings.TrimSuffix(string(data), "\n"), "\n") + 1
+		if len(data) == 0 {
+			result.Lines = 0
+		}
+	}
+	result.BytesRead = len(data)
+	result.NextOffset = offset + int64(len(data))
+	result.EOF = result.NextOffset >= info.Size()
+
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// readSFTPRange reads up to limit bytes starting at offset
+func readSFTPRange(file *sftp.File, offset int64, limit int) ([]byte, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readSFTPTail reads backwards from the end of the file until it has lines lines (or limit bytes),
+// returning the content and the offset it starts at
+func readSFTPTail(file *sftp.File, size int64, lines, limit int) ([]byte, int64, error) {
+	var data []byte
+	position := size
+
+	for position > 0 && len(data) < limit {
+		chunk := int64(sshTailChunkSize)
+		if chunk > position {
+			chunk = position
+		}
+		position -= chunk
+
+		buffer := make([]byte, chunk)
+		if _, err := file.ReadAt(buffer, position); err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		data = append(buffer, data...)
+
+		// A trailing newline terminates the last line rather than starting a new one
+		if bytes.Count(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) >= lines {
+			break
+		}
+	}
+
+	// Start just after the newline that precedes the last lines lines
+	body := bytes.TrimSuffix(data, []byte("\n"))
+	start, found := 0, 0
+	for i := len(body) - 1; i >= 0; i-- {
+		if body[i] == '\n' {
+			found++
+			if found == lines {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	data = data[start:]
+	if len(data) > limit {
+		data = data[len(data)-limit:]
+	}
+	return data, size - int64(len(data)), nil
+}
+
+// isBinaryContent reports whether data looks like binary rather than text
+func isBinaryContent(data []byte) bool {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return true
+	}
+	return !utf8.Valid(trimPartialRune(data))
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence at the end of data
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// sftpFileInfo converts an SFTP FileInfo into an SSHFileInfo
+func sftpFileInfo(remotePath string, info os.FileInfo) SSHFileInfo {
+	fileInfo := SSHFileInfo{
+		Path:     remotePath,
+		Name:     info.Name(),
+		Type:     sftpFileType(info.Mode()),
+		Size:     info.Size(),
+		Mode:     info.Mode().Perm().String(),
+		Modified: info.ModTime(),
+	}
+	if stat, ok := info.Sys().(*sftp.FileStat); ok {
+		uid, gid := stat.UID, stat.GID
+		fileInfo.UID = &uid
+		fileInfo.GID = &gid
+	}
+	return fileInfo
+}
+
+// sftpFileType names the type of a file mode
+func sftpFileType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+// executeSSHListDir handles listing a remote directory
+func executeSSHListDir(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serverName, instanceConfig, err := sshInstanceFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	remotePath := request.GetString("path", "")
+	if remotePath == "" {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+	pattern := request.GetString("pattern", "")
+	showHidden := request.GetBool("show_hidden", false)
+	limit := int(request.GetFloat("limit", defaultSSHListLimit))
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid pattern: %v", err)), nil
+		}
+	}
+
+	client, release, err := openSFTPClient(serverName, instanceConfig)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to connect: %v", err)), nil
+	}
+	defer release()
+
+	infos, err := client.ReadDir(remotePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to list %s: %v", remotePath, err)), nil
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+	entries := []SSHFileInfo{}
+	matched := 0
+	var totalSize int64
+	for _, info := range infos {
+		if !showHidden && strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, info.Name()); !ok {
+				continue
+			}
+		}
+		matched++
+		totalSize += info.Size()
+		if len(entries) >= limit {
+			continue
+		}
+
+		entry := sftpFileInfo(path.Join(remotePath, info.Name()), info)
+		if entry.Type == "symlink" {
+			entry.LinkTarget, _ = client.ReadLink(entry.Path)
+		}
+		entries = append(entries, entry)
+	}
+
+	resultJSON, _ := json.Marshal(map[string]interface{}{
+		"server_name": serverName,
+		"path":        remotePath,
+		"entries":     entries,
+		"count":       len(entries),
+		"total":       matched,
+		"total_size":  totalSize,
+		"truncated":   matched > len(entries),
+		"timestamp":   time.Now(),
+	})
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// executeSSHStat handles stat of a remote path
+func executeSSHStat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serverName, instanceConfig, err := sshInstanceFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	remotePath := request.GetString("path", "")
+	if remotePath == "" {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+
+	client, release, err := openSFTPClient(serverName, instanceConfig)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to connect: %v", err)), nil
+	}
+	defer release()
+
+	info, err := client.Lstat(remotePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to stat %s: %v", remotePath, err)), nil
+	}
+
+	fileInfo := sftpFileInfo(remotePath, info)
+	response := map[string]interface{}{
+		"server_name": serverName,
+		"file":        fileInfo,
+		"timestamp":   time.Now(),
+	}
+	if fileInfo.Type == "symlink" {
+		fileInfo.LinkTarget, _ = client.ReadLink(remotePath)
+		response["file"] = fileInfo
+		if target, err := client.Stat(remotePath); err == nil {
+			response["target"] = sftpFileInfo(fileInfo.LinkTarget, target)
+		} else {
+			response["target_error"] = err.Error()
+		}
+	}
+
+	resultJSON, _ := json.Marshal(response)
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// executeSSHDownload handles downloading remote files into an allowed local directory
+func executeSSHDownload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	serverName, instanceConfig, err := sshInstanceFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	remotePath := request.GetString("remote_path", "")
+	if remotePath == "" {
+		return mcp.NewToolResultError("remote_path is required"), nil
+	}
+	localPath := request.GetString("local_path", "")
+	recursive := request.GetBool("recursive", false)
+	overwrite := request.GetBool("overwrite", false)
+
+	if localPath == "" {
+		if globalSSHConfig == nil || len(globalSSHConfig.AllowedDirectories) == 0 {
+			return mcp.NewToolResultError("local_path is required when no allowed directories are configured"), nil
+		}
+		localPath = filepath.Join(globalSSHConfig.AllowedDirectories[0], "ssh-downloads", serverName)
+		if err := os.MkdirAll(localPath, 0755); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to create download directory: %v", err)), nil
+		}
+	}
+	localPath, err = resolveSSHLocalPath(localPath)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	client, release, err := openSFTPClient(serverName, instanceConfig)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to connect: %v", err)), nil
+	}
+	defer release()
+
+	info, err := client.Stat(remotePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to stat %s: %v", remotePath, err)), nil
+	}
+
+	// Downloading into an existing directory keeps the remote name
+	if localInfo, err := os.Stat(localPath); err == nil && localInfo.IsDir() {
+		localPath = filepath.Join(localPath, path.Base(remotePath))
+	}
+
+	type transfer struct{ remote, local string }
+	var transfers []transfer
+	if info.IsDir() {
+		if !recursive {
+			return mcp.NewToolResultError(fmt.Sprintf("%s is a directory; set recursive to download it", remotePath)), nil
+		}
+		walker := client.Walk(remotePath)
+		for walker.Step() {
+			if walker.Err() != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to walk %s: %v", walker.Path(), walker.Err())), nil
+			}
+			if !walker.Stat().Mode().IsRegular() {
+				continue
+			}
+			rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), remotePath), "/")
+			transfers = append(transfers, transfer{walker.Path(), filepath.Join(localPath, filepath.FromSlash(rel))})
+		}
+	} else {
+		transfers = append(transfers, transfer{remotePath, localPath})
+	}
+
+	result := SSHTransferResult{ServerName: serverName, Operation: "download", Files: []SSHTransferredFile{}}
+	for i, t := range transfers {
+		if _, err := resolveSSHLocalPath(t.local); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if _, err := os.Stat(t.local); err == nil && !overwrite {
+			result.Skipped = append(result.Skipped, t.local)
+			continue
+		}
+
+		notifyToolProgress(ctx, request, i, len(transfers), fmt.Sprintf("Downloading %s", t.remote))
+		file, err := downloadSFTPFile(client, t.remote, t.local)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to download %s: %v", t.remote, err)), nil
+		}
+		result.Files = append(result.Files, file)
+		result.TotalBytes += file.Size
+	}
+
+	result.Duration = time.Since(startTime).String()
+	result.Timestamp = time.Now()
+	resultJSON, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// downloadSFTPFile copies one remote file to a local path via a temporary file, keeping its modification time
+func downloadSFTPFile(client *sftp.Client, remotePath, localPath string) (SSHTransferredFile, error) {
+	remote, err := client.Open(remotePath)
+	if err != nil {
+		return SSHTransferredFile{}, err
+	}
+	defer remote.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return SSHTransferredFile{}, err
+	}
+	partial := localPath + ".part"
+	local, err := os.Create(partial)
+	if err != nil {
+		return SSHTransferredFile{}, err
+	}
+
+	hash := sha256.New()
+	size, err := remote.WriteTo(io.MultiWriter(local, hash))
+	if closeErr := local.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		return SSHTransferredFile{}, err
+	}
+	if err := os.Rename(partial, localPath); err != nil {
+		os.Remove(partial)
+		return SSHTransferredFile{}, err
+	}
+
+	if info, err := remote.Stat(); err == nil {
+		os.Chtimes(localPath, info.ModTime(), info.ModTime())
+	}
+
+	return SSHTransferredFile{
+		Source:      remotePath,
+		Destination: localPath,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// executeSSHUpload handles uploading a local file to the remote server
+func executeSSHUpload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	serverName, instanceConfig, err := sshInstanceFromRequest(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	localPath := request.GetString("local_path", "")
+	remotePath := request.GetString("remote_path", "")
+	overwrite := request.GetBool("overwrite", false)
+	if localPath == "" || remotePath == "" {
+		return mcp.NewToolResultError("local_path and remote_path are required"), nil
+	}
+
+	if sshInstancePolicy(instanceConfig).ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("Uploads are not permitted: instance '%s' has a read-only command policy", serverName)), nil
+	}
+
+	localPath, err = resolveSSHLocalPath(localPath)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	localInfo, err := os.Stat(localPath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to stat %s: %v", localPath, err)), nil
+	}
+	if !localInfo.Mode().IsRegular() {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not a regular file", localPath)), nil
+	}
+
+	client, release, err := openSFTPClient(serverName, instanceConfig)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to connect: %v", err)), nil
+	}
+	defer release()
+
+	// The policy and the confirmation apply to the file actually written, so resolve a directory first
+	if remoteInfo, err := client.Stat(remotePath); err == nil && remoteInfo.IsDir() {
+		remotePath = path.Join(remotePath, filepath.Base(localPath))
+	}
+	decision := evaluateSSHUpload(sshInstancePolicy(instanceConfig), localPath, remotePath)
+	operation := fmt.Sprintf("upload %s %s overwrite=%t", localPath, remotePath, overwrite)
+	if !confirmSSHPolicyDecision(decision, serverName, operation, request.GetString("confirmation_token", "")) {
+		return sshPolicyResult(serverName, "upload", decision, startTime), nil
+	}
+	if _, err := client.Stat(remotePath); err == nil && !overwrite {
+		return mcp.NewToolResultError(fmt.Sprintf("Remote file %s already exists; set overwrite to replace it", remotePath)), nil
+	}
+
+	file, err := uploadSFTPFile(client, localPath, remotePath, localInfo.Mode().Perm())
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to upload %s: %v", localPath, err)), nil
+	}
+
+	resultJSON, _ := json.Marshal(SSHTransferResult{
+		ServerName: serverName,
+		Operation:  "upload",
+		Files:      []SSHTransferredFile{file},
+		TotalBytes: file.Size,
+		Duration:   time.Since(startTime).String(),
+		Timestamp:  time.Now(),
+	})
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// evaluateSSHUpload checks an upload like the equivalent cp command, so read-only mode, protected
+// paths and the instance allow, deny and confirm lists apply to it
+func evaluateSSHUpload(policy *SSHCommandPolicy, localPath, remotePath string) *SSHPolicyDecision {
+	checker := &sshPolicyChecker{policy: policy, decision: &SSHPolicyDecision{Action: sshPolicyAllow}}
+	checker.checkArgs(fmt.Sprintf("upload %s to %s", localPath, remotePath), []string{"cp", "--", localPath, remotePath}, 0)
+	return checker.decision
+}
+
+// sftpRenamer is the part of an SFTP client used to move an upload into place
+type sftpRenamer interface {
+	PosixRename(oldname, newname string) error
+	Rename(oldname, newname string) error
+	Remove(path string) error
+	Stat(path string) (os.FileInfo, error)
+}
+
+// replaceSFTPFile moves a fully written file over remotePath. posix-rename replaces atomically; servers
+// without it only get a plain rename, which refuses to overwrite, so an existing file is first moved
+// aside and only removed once the new file is in place
+func replaceSFTPFile(client sftpRenamer, partial, remotePath string) error {
+	err := client.PosixRename(partial, remotePath)
+	if statusErr, ok := err.(*sftp.StatusError); !ok || statusErr.FxCode() != sftp.ErrSSHFxOpUnsupported {
+		return err
+	}
+
+	if _, err := client.Stat(remotePath); err != nil {
+		return client.Rename(partial, remotePath)
+	}
+	backup := fmt.Sprintf("%s.%d.orig", remotePath, time.Now().UnixNano())
+	if err := client.Rename(remotePath, backup); err != nil {
+		return fmt.Errorf("failed to move the existing file aside: %v", err)
+	}
+	if err := client.Rename(partial, remotePath); err != nil {
+		if restoreErr := client.Rename(backup, remotePath); restoreErr != nil {
+			return fmt.Errorf("%v; the original file was left at %s", err, backup)
+		}
+		return err
+	}
+	client.Remove(backup)
+	return nil
+}
+
+// uploadSFTPFile copies a local file to a temporary remote name and renames it into place
+func uploadSFTPFile(client *sftp.Client, localPath, remotePath string, mode os.FileMode) (SSHTransferredFile, error) {
+	local, err := os.Open(localPath)
+	if err != nil {
+		return SSHTransferredFile{}, err
+	}
+	defer local.Close()
+
+	partial := remotePath + ".part"
+	remote, err := client.Create(partial)
+	if err != nil {
+		return SSHTransferredFile{}, err
+	}
+
+	hash := sha256.New()
+	size, err := remote.ReadFrom(io.TeeReader(local, hash))
+	if closeErr := remote.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = client.Chmod(partial, mode)
+	}
+	if err == nil {
+		err = replaceSFTPFile(client, partial, remotePath)
+	}
+	if err != nil {
+		client.Remove(partial)
+		return SSHTransferredFile{}, err
+	}
+
+	return SSHTransferredFile{
+		Source:      localPath,
+		Destination: remotePath,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
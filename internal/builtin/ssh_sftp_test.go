@@ -0,0 +1,248 @@
+package builtin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// useTestSFTPServer starts a test server with a remote directory of sample files
+func useTestSFTPServer(t *testing.T) (remoteDir, localDir string) {
+	t.Helper()
+
+	server := newTestSSHServer(t, echoSSHHandler)
+	config := server.instance()
+	config.Password = "secret"
+
+	remoteDir = t.TempDir()
+	localDir = t.TempDir()
+	useTestSSHConfig(t, map[string]SSHServerConfig{"test": config}, localDir)
+
+	var log strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&log, "2024-05-01 10:00:%02d INFO line %d\n", i%60, i)
+	}
+	os.WriteFile(filepath.Join(remoteDir, "app.log"), []byte(log.String()), 0644)
+	os.WriteFile(filepath.Join(remoteDir, "heap.bin"), []byte{0x00, 0x01, 0xff, 0x10}, 0644)
+	os.WriteFile(filepath.Join(remoteDir, ".hidden"), []byte("x"), 0644)
+	os.MkdirAll(filepath.Join(remoteDir, "archive"), 0755)
+	os.WriteFile(filepath.Join(remoteDir, "archive", "old.log"), []byte("old\n"), 0644)
+	return remoteDir, localDir
+}
+
+func TestSSHReadFile(t *testing.T) {
+	remoteDir, _ := useTestSFTPServer(t)
+	logPath := filepath.Join(remoteDir, "app.log")
+
+	response, result := callSSHTool(t, executeSSHReadFile, map[string]any{"server_name": "test", "path": logPath, "tail_lines": 2})
+	if result.IsError {
+		t.Fatalf("Unexpected read error: %v", result.Content)
+	}
+	if response["content"] != "2024-05-01 10:00:39 INFO line 99\n2024-05-01 10:00:40 INFO line 100\n" || response["lines"] != float64(2) {
+		t.Errorf("Unexpected tail: %v", response)
+	}
+	if response["eof"] != true {
+		t.Error("Tail should end at EOF")
+	}
+
+	response, _ = callSSHTool(t, executeSSHReadFile, map[string]any{"server_name": "test", "path": logPath, "offset": 11, "limit": 5})
+	if response["content"] != "10:00" || response["next_offset"] != float64(16) || response["eof"] != false {
+		t.Errorf("Unexpected range read: %v", response)
+	}
+
+	response, _ = callSSHTool(t, executeSSHReadFile, map[string]any{"server_name": "test", "path": filepath.Join(remoteDir, "heap.bin")})
+	decoded, _ := base64.StdEncoding.DecodeString(response["content"].(string))
+	if response["encoding"] != "base64" || string(decoded) != "\x00\x01\xff\x10" {
+		t.Errorf("Expected binary content as base64, got %v", response)
+	}
+}
+
+func TestSSHListDirAndStat(t *testing.T) {
+	remoteDir, _ := useTestSFTPServer(t)
+
+	response, result := callSSHTool(t, executeSSHListDir, map[string]any{"server_name": "test", "path": remoteDir})
+	if result.IsError {
+		t.Fatalf("Unexpected list error: %v", result.Content)
+	}
+	var names []string
+	for _, entry := range response["entries"].([]any) {
+		names = append(names, entry.(map[string]any)["name"].(string))
+	}
+	if strings.Join(names, ",") != "app.log,archive,heap.bin" {
+		t.Errorf("Unexpected entries: %v", names)
+	}
+
+	response, _ = callSSHTool(t, executeSSHListDir, map[string]any{"server_name": "test", "path": remoteDir, "pattern": "*.log"})
+	if response["count"] != float64(1) {
+		t.Errorf("Expected only app.log, got %v", response["entries"])
+	}
+
+	response, _ = callSSHTool(t, executeSSHStat, map[string]any{"server_name": "test", "path": filepath.Join(remoteDir, "archive")})
+	if file := response["file"].(map[string]any); file["type"] != "directory" {
+		t.Errorf("Unexpected stat result: %v", file)
+	}
+}
+
+func TestSSHDownloadAndUpload(t *testing.T) {
+	remoteDir, localDir := useTestSFTPServer(t)
+
+	response, result := callSSHTool(t, executeSSHDownload, map[string]any{"server_name": "test", "remote_path": filepath.Join(remoteDir, "app.log")})
+	if result.IsError {
+		t.Fatalf("Unexpected download error: %v", result.Content)
+	}
+	downloaded := filepath.Join(localDir, "ssh-downloads", "test", "app.log")
+	if content, err := os.ReadFile(downloaded); err != nil || len(content) != int(response["total_bytes"].(float64)) {
+		t.Errorf("Download mismatch: %v", err)
+	}
+
+	_, result = callSSHTool(t, executeSSHDownload, map[string]any{"server_name": "test", "remote_path": remoteDir})
+	if !result.IsError {
+		t.Error("Directories must require recursive")
+	}
+	response, _ = callSSHTool(t, executeSSHDownload, map[string]any{
+		"server_name": "test",
+		"remote_path": remoteDir,
+		"local_path":  filepath.Join(localDir, "mirror"),
+		"recursive":   true,
+	})
+	if len(response["files"].([]any)) != 4 {
+		t.Errorf("Expected all four files, got %v", response["files"])
+	}
+	if _, err := os.Stat(filepath.Join(localDir, "mirror", "archive", "old.log")); err != nil {
+		t.Errorf("Nested file not downloaded: %v", err)
+	}
+
+	_, result = callSSHTool(t, executeSSHDownload, map[string]any{"server_name": "test", "remote_path": filepath.Join(remoteDir, "app.log"), "local_path": t.TempDir()})
+	if !result.IsError {
+		t.Error("Downloads outside the allowed directories must be rejected")
+	}
+
+	upload := filepath.Join(localDir, "patch.sh")
+	os.WriteFile(upload, []byte("#!/bin/sh\necho patched\n"), 0755)
+	_, result = callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": remoteDir})
+	if result.IsError {
+		t.Fatalf("Unexpected upload error: %v", result.Content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(remoteDir, "patch.sh")); string(content) != "#!/bin/sh\necho patched\n" {
+		t.Errorf("Unexpected uploaded content %q", content)
+	}
+
+	_, result = callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": remoteDir})
+	if !result.IsError {
+		t.Error("Existing remote files must not be overwritten by default")
+	}
+	_, result = callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": remoteDir, "overwrite": true})
+	if result.IsError {
+		t.Errorf("Overwrite failed: %v", result.Content)
+	}
+}
+
+func TestSSHUploadPolicy(t *testing.T) {
+	remoteDir, localDir := useTestSFTPServer(t)
+	upload := filepath.Join(localDir, "motd")
+	os.WriteFile(upload, []byte("hello\n"), 0644)
+
+	response, _ := callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": "/etc/motd", "overwrite": true})
+	if response["success"] != false || response["policy"].(map[string]any)["action"] != sshPolicyDeny {
+		t.Errorf("Uploads to protected paths must be denied, got %v", response)
+	}
+
+	globalSSHConfig.DefaultPolicy = &SSHCommandPolicy{Confirm: []string{"cp"}}
+	response, _ = callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": remoteDir})
+	token, _ := response["policy"].(map[string]any)["confirmation_token"].(string)
+	if token == "" {
+		t.Fatalf("Expected a confirmation token, got %v", response)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "motd")); err == nil {
+		t.Fatal("Nothing may be uploaded before confirmation")
+	}
+	_, result := callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": remoteDir, "confirmation_token": token})
+	if result.IsError {
+		t.Fatalf("Unexpected upload error: %v", result.Content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(remoteDir, "motd")); string(content) != "hello\n" {
+		t.Errorf("Unexpected uploaded content %q", content)
+	}
+
+	// Rules apply to the file written into a directory, not to the directory named
+	os.Remove(filepath.Join(remoteDir, "motd"))
+	globalSSHConfig.DefaultPolicy = &SSHCommandPolicy{Deny: []string{"cp -- " + upload + " " + filepath.Join(remoteDir, "motd")}}
+	response, _ = callSSHTool(t, executeSSHUpload, map[string]any{"server_name": "test", "local_path": upload, "remote_path": remoteDir})
+	if response["success"] != false || response["policy"].(map[string]any)["action"] != sshPolicyDeny {
+		t.Errorf("Expected the deny rule for the final path to apply, got %v", response)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "motd")); err == nil {
+		t.Error("A denied upload must not be written")
+	}
+}
+
+// testSFTPRenamer is an in-memory remote file system without posix-rename
+type testSFTPRenamer struct {
+	files     map[string]string
+	posixCode uint32
+}
+
+func (r *testSFTPRenamer) PosixRename(oldname, newname string) error {
+	return &sftp.StatusError{Code: r.posixCode}
+}
+
+func (r *testSFTPRenamer) Rename(oldname, newname string) error {
+	if _, exists := r.files[newname]; exists {
+		return fmt.Errorf("rename %s failed", oldname)
+	}
+	r.files[newname] = r.files[oldname]
+	delete(r.files, oldname)
+	return nil
+}
+
+func (r *testSFTPRenamer) Remove(path string) error {
+	delete(r.files, path)
+	return nil
+}
+
+func (r *testSFTPRenamer) Stat(path string) (os.FileInfo, error) {
+	if _, ok := r.files[path]; !ok {
+		return nil, os.ErrNotExist
+	}
+	return nil, nil
+}
+
+func TestReplaceSFTPFile(t *testing.T) {
+	unsupported := uint32(sftp.ErrSSHFxOpUnsupported)
+	remote := &testSFTPRenamer{files: map[string]string{"/srv/app.conf": "old", "/srv/app.conf.part": "new"}, posixCode: unsupported}
+	if err := replaceSFTPFile(remote, "/srv/app.conf.part", "/srv/app.conf"); err != nil || len(remote.files) != 1 || remote.files["/srv/app.conf"] != "new" {
+		t.Errorf("Expected the file to be replaced, got %v %v", err, remote.files)
+	}
+
+	// A failed rename puts the original back
+	remote = &testSFTPRenamer{files: map[string]string{"/srv/app.conf": "old", "/srv/app.conf.part": "new"}, posixCode: unsupported}
+	failing := &failingSecondRename{testSFTPRenamer: remote}
+	if err := replaceSFTPFile(failing, "/srv/app.conf.part", "/srv/app.conf"); err == nil || remote.files["/srv/app.conf"] != "old" {
+		t.Errorf("Expected the original to be restored, got %v %v", err, remote.files)
+	}
+
+	// Other posix-rename failures are reported without touching the target
+	remote = &testSFTPRenamer{files: map[string]string{"/srv/app.conf": "old", "/srv/app.conf.part": "new"}, posixCode: uint32(sftp.ErrSSHFxPermissionDenied)}
+	if err := replaceSFTPFile(remote, "/srv/app.conf.part", "/srv/app.conf"); err == nil || remote.files["/srv/app.conf"] != "old" {
+		t.Errorf("Expected the permission error and an untouched target, got %v %v", err, remote.files)
+	}
+}
+
+// failingSecondRename fails the rename that moves the new file into place
+type failingSecondRename struct {
+	*testSFTPRenamer
+	renames int
+}
+
+func (r *failingSecondRename) Rename(oldname, newname string) error {
+	r.renames++
+	if r.renames == 2 {
+		return fmt.Errorf("rename %s failed", oldname)
+	}
+	return r.testSFTPRenamer.Rename(oldname, newname)
+}
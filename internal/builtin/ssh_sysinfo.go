@@ -0,0 +1,678 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// defaultSystemInfoTopProcesses is how many processes are listed per ranking
+	defaultSystemInfoTopProcesses = 10
+	// systemInfoTimeout bounds the collection script, which samples CPU counters one second apart
+	systemInfoTimeout = 30 * time.Second
+	// systemInfoMaxOutputBytes leaves room for the process list on busy hosts
+	systemInfoMaxOutputBytes = 1024 * 1024
+)
+
+// systemInfoProbes lists the collector commands in the order they run. Each is checked against the
+// instance policy; entries without a name run unframed, like the pause between the CPU samples
+var systemInfoProbes = []struct{ name, command string }{
+	{"meminfo", "cat /proc/meminfo"},
+	{"cpu_first", `grep "^cpu" /proc/stat`},
+	{"", "sleep 1"},
+	{"cpu_second", `grep "^cpu" /proc/stat`},
+	{"loadavg", "cat /proc/loadavg"},
+	{"uptime", "cat /proc/uptime"},
+	{"df", `sh -c 'out=$(df -P -B1); if test -n "$(echo "$out" | sed 1d)"; then echo "$out"; else df -Pk; fi'`},
+	{"processes", "ps -eo pid=,user=,pcpu=,pmem=,rss=,comm="},
+	{"file_nr", "cat /proc/sys/fs/file-nr"},
+	{"open_files", `sh -c "find /proc/[0-9]*/fd -mindepth 1 -maxdepth 1 | cut -d/ -f3 | uniq -c"`},
+	{"sockstat", "cat /proc/net/sockstat /proc/net/sockstat6"},
+	{"tcp", `awk 'FNR > 1 { states[$4]++; if ($4 == "0A") listen[$2] = 1 } END { for (s in states) print "state", s, states[s]; for (a in listen) print "listen", a }' /proc/net/tcp /proc/net/tcp6`},
+}
+
+// systemInfoScript collects every probe in one session. Each probe is framed by markers with its
+// exit status so one failing probe does not hide the others, and all parsing happens locally
+var systemInfoScript = buildSystemInfoScript()
+
+// buildSystemInfoScript frames each probe command with the probe function
+func buildSystemInfoScript() string {
+	var script strings.Builder
+	script.WriteString(`probe() { name=$1; shift; echo "@@@begin $name"; "$@" 2>/dev/null; echo "@@@end $name $?"; }` + "\n")
+	for _, probe := range systemInfoProbes {
+		if probe.name != "" {
+			script.WriteString("probe " + probe.name + " ")
+		}
+		script.WriteString(probe.command + "\n")
+	}
+	return script.String()
+}
+
+// evaluateSystemInfoProbes checks every probe command against the instance policy, so read-only
+// mode and the allow, deny and confirm lists apply to the collector as they do to commands
+func evaluateSystemInfoProbes(policy *SSHCommandPolicy) *SSHPolicyDecision {
+	checker := &sshPolicyChecker{policy: policy, decision: &SSHPolicyDecision{Action: sshPolicyAllow}}
+	for _, probe := range systemInfoProbes {
+		checker.checkScript(probe.command, 0)
+	}
+	return checker.decision
+}
+
+// tcpStateNames maps the hex states in /proc/net/tcp to their names
+var tcpStateNames = map[string]string{
+	"01": "ESTABLISHED", "02": "SYN_SENT", "03": "SYN_RECV", "04": "FIN_WAIT1", "05": "FIN_WAIT2",
+	"06": "TIME_WAIT", "07": "CLOSE", "08": "CLOSE_WAIT", "09": "LAST_ACK", "0A": "LISTEN", "0B": "CLOSING",
+}
+
+// SystemCPUInfo is CPU utilisation measured over a one second sample
+type SystemCPUInfo struct {
+	Cores         int     `json:"cores"`
+	UsagePercent  float64 `json:"usage_percent"`
+	UserPercent   float64 `json:"user_percent"`
+	SystemPercent float64 `json:"system_percent"`
+	IOWaitPercent float64 `json:"iowait_percent"`
+	StealPercent  float64 `json:"steal_percent"`
+	IdlePercent   float64 `json:"idle_percent"`
+}
+
+// SystemMemoryInfo is memory usage from /proc/meminfo
+type SystemMemoryInfo struct {
+	TotalBytes     int64   `json:"total_bytes"`
+	UsedBytes      int64   `json:"used_bytes"`
+	FreeBytes      int64   `json:"free_bytes"`
+	AvailableBytes int64   `json:"available_bytes"`
+	BuffersBytes   int64   `json:"buffers_bytes"`
+	CachedBytes    int64   `json:"cached_bytes"`
+	SwapTotalBytes int64   `json:"swap_total_bytes"`
+	SwapUsedBytes  int64   `json:"swap_used_bytes"`
+	UsedPercent    float64 `json:"used_percent"`
+}
+
+// SystemFilesystem is the usage of one mounted filesystem
+type SystemFilesystem struct {
+	Filesystem     string  `json:"filesystem"`
+	MountPoint     string  `json:"mount_point"`
+	SizeBytes      int64   `json:"size_bytes"`
+	UsedBytes      int64   `json:"used_bytes"`
+	AvailableBytes int64   `json:"available_bytes"`
+	UsedPercent    float64 `json:"used_percent"`
+}
+
+// SystemProcess is one process in the top consumer lists
+type SystemProcess struct {
+	PID           int     `json:"pid"`
+	User          string  `json:"user"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	MemoryPercent float64 `json:"memory_percent"`
+	RSSBytes      int64   `json:"rss_bytes"`
+	OpenFiles     int     `json:"open_files,omitempty"`
+	Command       string  `json:"command"`
+}
+
+// SystemOpenFiles summarises file descriptor usage
+type SystemOpenFiles struct {
+	Allocated    int64           `json:"allocated"`
+	Max          int64           `json:"max"`
+	TopProcesses []SystemProcess `json:"top_processes,omitempty"`
+}
+
+// SystemSocketSummary summarises sockets from /proc/net
+type SystemSocketSummary struct {
+	TCPInUse       int            `json:"tcp_in_use"`
+	TCP6InUse      int            `json:"tcp6_in_use"`
+	TCPTimeWait    int            `json:"tcp_time_wait"`
+	TCPOrphan      int            `json:"tcp_orphan"`
+	UDPInUse       int            `json:"udp_in_use"`
+	UDP6InUse      int            `json:"udp6_in_use"`
+	TCPStates      map[string]int `json:"tcp_states,omitempty"`
+	ListeningPorts []int          `json:"listening_ports,omitempty"`
+}
+
+// systemInfoProbe is the raw output of one probe
+type systemInfoProbe struct {
+	output   string
+	exitCode int
+}
+
+// getSystemInfo retrieves system resource information, returning whatever the probes that worked
+// produced along with a warning for each probe that did not
+func getSystemInfo(client *ssh.Client, topProcesses int) (*SystemResourceInfo, error) {
+	result, err := runSSHCommand(context.Background(), client, "sh -c "+shellQuote(systemInfoScript), sshCommandOptions{
+		Timeout:        systemInfoTimeout,
+		MaxOutputBytes: systemInfoMaxOutputBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.stopped() {
+		return nil, fmt.Errorf("system info collection %s", strings.TrimPrefix(result.Error, "command "))
+	}
+
+	probes := parseSystemInfoProbes(result.Output)
+	if len(probes) == 0 {
+		return nil, fmt.Errorf("system info collection produced no output (exit code %d): %s", result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+	info := buildSystemInfo(probes, topProcesses)
+	if result.Truncated {
+		info.Warnings = append(info.Warnings, "collector output was truncated; some probes may be incomplete")
+	}
+	return info, nil
+}
+
+// parseSystemInfoProbes splits the collector output into probes by their markers
+func parseSystemInfoProbes(output string) map[string]systemInfoProbe {
+	probes := map[string]systemInfoProbe{}
+	var name string
+	var body strings.Builder
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@@begin "):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "@@@begin "))
+			body.Reset()
+		case strings.HasPrefix(line, "@@@end ") && name != "":
+			fields := strings.Fields(strings.TrimPrefix(line, "@@@end "))
+			exitCode := -1
+			if len(fields) == 2 {
+				exitCode, _ = strconv.Atoi(fields[1])
+			}
+			probes[name] = systemInfoProbe{output: body.String(), exitCode: exitCode}
+			name = ""
+		case name != "":
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+	}
+	return probes
+}
+
+// buildSystemInfo parses each probe into the typed result
+func buildSystemInfo(probes map[string]systemInfoProbe, topProcesses int) *SystemResourceInfo {
+	if topProcesses <= 0 {
+		topProcesses = defaultSystemInfoTopProcesses
+	}
+	info := &SystemResourceInfo{Timestamp: time.Now()}
+	warn := func(probe string, err error) {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("%s: %v", probe, err))
+	}
+	// probeOutput returns a probe's output; probes that printed something are used even when they
+	// exited non-zero, such as cat with a missing IPv6 file
+	probeOutput := func(name string) (string, error) {
+		probe, ok := probes[name]
+		switch {
+		case !ok:
+			return "", fmt.Errorf("probe did not run")
+		case strings.TrimSpace(probe.output) == "":
+			return "", fmt.Errorf("no output (exit code %d)", probe.exitCode)
+		}
+		return probe.output, nil
+	}
+
+	if output, err := probeOutput("meminfo"); err != nil {
+		warn("memory", err)
+	} else if memory, err := parseMeminfo(output); err != nil {
+		warn("memory", err)
+	} else {
+		info.Memory = memory
+		info.MemoryTotal = memory.TotalBytes / (1024 * 1024)
+		info.MemoryUsed = memory.UsedBytes / (1024 * 1024)
+		info.MemoryFree = memory.AvailableBytes / (1024 * 1024)
+	}
+
+	first, err := probeOutput("cpu_first")
+	if err == nil {
+		var second string
+		if second, err = probeOutput("cpu_second"); err == nil {
+			var cpu *SystemCPUInfo
+			if cpu, err = parseProcStatSample(first, second); err == nil {
+				info.CPU = cpu
+				info.CPUUsage = cpu.UsagePercent
+			}
+		}
+	}
+	if err != nil {
+		warn("cpu", err)
+	}
+
+	if output, err := probeOutput("loadavg"); err != nil {
+		warn("load average", err)
+	} else if load, err := parseLoadavg(output); err != nil {
+		warn("load average", err)
+	} else {
+		info.LoadAverage = load
+	}
+
+	if output, err := probeOutput("uptime"); err != nil {
+		warn("uptime", err)
+	} else if seconds, err := parseProcUptime(output); err != nil {
+		warn("uptime", err)
+	} else {
+		info.UptimeSeconds = seconds
+		info.Uptime = formatUptime(time.Duration(seconds) * time.Second)
+	}
+
+	if output, err := probeOutput("df"); err != nil {
+		warn("disk", err)
+	} else if filesystems, err := parseDfOutput(output); err != nil {
+		warn("disk", err)
+	} else {
+		info.Filesystems = filesystems
+		for _, filesystem := range filesystems {
+			if filesystem.MountPoint == "/" {
+				info.DiskTotal = filesystem.SizeBytes / (1024 * 1024 * 1024)
+				info.DiskUsed = filesystem.UsedBytes / (1024 * 1024 * 1024)
+				info.DiskFree = filesystem.AvailableBytes / (1024 * 1024 * 1024)
+			}
+		}
+	}
+
+	var processes []SystemProcess
+	if output, err := probeOutput("processes"); err != nil {
+		warn("processes", err)
+	} else if processes, err = parsePsOutput(output); err != nil {
+		warn("processes", err)
+	}
+
+	openFiles := &SystemOpenFiles{}
+	if output, err := probeOutput("file_nr"); err != nil {
+		warn("open files", err)
+	} else if fields := strings.Fields(output); len(fields) < 3 {
+		warn("open files", fmt.Errorf("unexpected file-nr format %q", strings.TrimSpace(output)))
+	} else {
+		openFiles.Allocated, _ = strconv.ParseInt(fields[0], 10, 64)
+		openFiles.Max, _ = strconv.ParseInt(fields[2], 10, 64)
+		info.OpenFiles = openFiles
+	}
+	if output, err := probeOutput("open_files"); err != nil {
+		warn("per-process open files", err)
+	} else {
+		counts := parseOpenFileCounts(output)
+		for i := range processes {
+			processes[i].OpenFiles = counts[processes[i].PID]
+		}
+		openFiles.TopProcesses = topSystemProcesses(processes, topProcesses, func(a, b SystemProcess) bool { return a.OpenFiles > b.OpenFiles })
+		info.OpenFiles = openFiles
+	}
+
+	if len(processes) > 0 {
+		info.TopCPUProcesses = topSystemProcesses(processes, topProcesses, func(a, b SystemProcess) bool { return a.CPUPercent > b.CPUPercent })
+		info.TopMemoryProcesses = topSystemProcesses(processes, topProcesses, func(a, b SystemProcess) bool { return a.RSSBytes > b.RSSBytes })
+	}
+
+	sockets := &SystemSocketSummary{}
+	if output, err := probeOutput("sockstat"); err != nil {
+		warn("sockets", err)
+	} else {
+		parseSockstat(output, sockets)
+		info.Sockets = sockets
+	}
+	if output, err := probeOutput("tcp"); err != nil {
+		warn("tcp states", err)
+	} else {
+		parseTCPStates(output, sockets)
+		info.Sockets = sockets
+	}
+
+	return info
+}
+
+// parseMeminfo parses /proc/meminfo, whose values are in kB
+func parseMeminfo(output string) (*SystemMemoryInfo, error) {
+	values := map[string]int64{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		if kb, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			values[strings.TrimSpace(key)] = kb * 1024
+		}
+	}
+
+	total, ok := values["MemTotal"]
+	if !ok || total == 0 {
+		return nil, fmt.Errorf("MemTotal not found")
+	}
+	memory := &SystemMemoryInfo{
+		TotalBytes:     total,
+		FreeBytes:      values["MemFree"],
+		BuffersBytes:   values["Buffers"],
+		CachedBytes:    values["Cached"] + values["SReclaimable"],
+		SwapTotalBytes: values["SwapTotal"],
+		SwapUsedBytes:  values["SwapTotal"] - values["SwapFree"],
+	}
+	// MemAvailable is missing on kernels before 3.14
+	if available, ok := values["MemAvailable"]; ok {
+		memory.AvailableBytes = available
+	} else {
+		memory.AvailableBytes = memory.FreeBytes + memory.BuffersBytes + memory.CachedBytes
+	}
+	memory.UsedBytes = total - memory.AvailableBytes
+	memory.UsedPercent = roundPercent(float64(memory.UsedBytes) / float64(total) * 100)
+	return memory, nil
+}
+
+// parseProcStatSample computes CPU utilisation from two samples of the cpu lines of /proc/stat
+func parseProcStatSample(first, second string) (*SystemCPUInfo, error) {
+	before, _, err := parseProcStatCPU(first)
+	if err != nil {
+		return nil, err
+	}
+	after, cores, err := parseProcStatCPU(second)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := make([]float64, len(after))
+	var total float64
+	for i := range after {
+		delta[i] = after[i] - before[i]
+		// guest time is already included in user time
+		if i < 8 {
+			total += delta[i]
+		}
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("CPU counters did not advance between samples")
+	}
+	percent := func(value float64) float64 { return roundPercent(value / total * 100) }
+
+	idle := delta[3] + delta[4]
+	return &SystemCPUInfo{
+		Cores:         cores,
+		UsagePercent:  percent(total - idle),
+		UserPercent:   percent(delta[0] + delta[1]),
+		SystemPercent: percent(delta[2] + delta[5] + delta[6]),
+		IOWaitPercent: percent(delta[4]),
+		StealPercent:  percent(delta[7]),
+		IdlePercent:   percent(delta[3]),
+	}, nil
+}
+
+// parseProcStatCPU returns the aggregate cpu counters, padded to ten fields, and the number of cores
+func parseProcStatCPU(output string) ([]float64, int, error) {
+	var counters []float64
+	cores := 0
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] != "cpu" {
+			if strings.HasPrefix(fields[0], "cpu") {
+				cores++
+			}
+			continue
+		}
+		counters = make([]float64, 10)
+		for i, field := range fields[1:] {
+			if i >= len(counters) {
+				break
+			}
+			counters[i], _ = strconv.ParseFloat(field, 64)
+		}
+	}
+	if counters == nil {
+		return nil, 0, fmt.Errorf("aggregate cpu line not found")
+	}
+	return counters, cores, nil
+}
+
+// parseLoadavg parses the first three fields of /proc/loadavg
+func parseLoadavg(output string) ([]float64, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected loadavg format %q", strings.TrimSpace(output))
+	}
+	load := make([]float64, 3)
+	for i := range load {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid load average %q", fields[i])
+		}
+		load[i] = value
+	}
+	return load, nil
+}
+
+// parseProcUptime parses the seconds since boot from /proc/uptime
+func parseProcUptime(output string) (float64, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty uptime")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid uptime %q", fields[0])
+	}
+	return seconds, nil
+}
+
+// formatUptime renders an uptime like uptime -p does
+func formatUptime(uptime time.Duration) string {
+	days := int(uptime.Hours()) / 24
+	hours := int(uptime.Hours()) % 24
+	minutes := int(uptime.Minutes()) % 60
+
+	var parts []string
+	plural := func(value int, unit string) string {
+		if value == 1 {
+			return fmt.Sprintf("%d %s", value, unit)
+		}
+		return fmt.Sprintf("%d %ss", value, unit)
+	}
+	if days > 0 {
+		parts = append(parts, plural(days, "day"))
+	}
+	if hours > 0 {
+		parts = append(parts, plural(hours, "hour"))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, plural(minutes, "minute"))
+	}
+	return "up " + strings.Join(parts, ", ")
+}
+
+// parseDfOutput parses POSIX df output, taking the block size from each header so both -B1 and -k
+// work, even when a partial table from one is followed by the table from the other
+func parseDfOutput(output string) ([]SystemFilesystem, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("no filesystems listed")
+	}
+
+	header := strings.Fields(lines[0])
+	if len(header) < 2 {
+		return nil, fmt.Errorf("unexpected df header %q", lines[0])
+	}
+	blockSize := dfBlockSize(header)
+
+	var filesystems []SystemFilesystem
+	seen := make(map[string]bool)
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "Filesystem" {
+			blockSize = dfBlockSize(fields)
+			continue
+		}
+		if len(fields) < 6 {
+			continue
+		}
+		// Mount points may contain spaces, so the numeric columns are counted from the filesystem name
+		size, err1 := strconv.ParseInt(fields[1], 10, 64)
+		used, err2 := strconv.ParseInt(fields[2], 10, 64)
+		available, err3 := strconv.ParseInt(fields[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil || size == 0 {
+			continue
+		}
+		mountPoint := strings.Join(fields[5:], " ")
+		if seen[mountPoint] {
+			continue
+		}
+		seen[mountPoint] = true
+		filesystem := SystemFilesystem{
+			Filesystem:     fields[0],
+			MountPoint:     mountPoint,
+			SizeBytes:      size * blockSize,
+			UsedBytes:      used * blockSize,
+			AvailableBytes: available * blockSize,
+		}
+		if used+available > 0 {
+			filesystem.UsedPercent = roundPercent(float64(used) / float64(used+available) * 100)
+		}
+		filesystems = append(filesystems, filesystem)
+	}
+	if len(filesystems) == 0 {
+		return nil, fmt.Errorf("no filesystems could be parsed")
+	}
+	return filesystems, nil
+}
+
+// dfBlockSize reads the block size from a df header such as "1B-blocks" or "1024-blocks"
+func dfBlockSize(header []string) int64 {
+	if size, _, ok := strings.Cut(header[1], "-"); ok {
+		size = strings.TrimSuffix(size, "B")
+		if value, err := strconv.ParseInt(size, 10, 64); err == nil && value > 0 {
+			return value
+		}
+	}
+	return 1024
+}
+
+// parsePsOutput parses ps -eo pid=,user=,pcpu=,pmem=,rss=,comm= output
+func parsePsOutput(output string) ([]SystemProcess, error) {
+	var processes []SystemProcess
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[2], 64)
+		memory, _ := strconv.ParseFloat(fields[3], 64)
+		rss, _ := strconv.ParseInt(fields[4], 10, 64)
+		processes = append(processes, SystemProcess{
+			PID:           pid,
+			User:          fields[1],
+			CPUPercent:    cpu,
+			MemoryPercent: memory,
+			RSSBytes:      rss * 1024,
+			Command:       strings.Join(fields[5:], " "),
+		})
+	}
+	if len(processes) == 0 {
+		return nil, fmt.Errorf("no processes could be parsed")
+	}
+	return processes, nil
+}
+
+// parseOpenFileCounts parses uniq -c output of "count pid" lines
+func parseOpenFileCounts(output string) map[int]int {
+	counts := map[int]int{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		count, err1 := strconv.Atoi(fields[0])
+		pid, err2 := strconv.Atoi(fields[1])
+		if err1 == nil && err2 == nil {
+			counts[pid] += count
+		}
+	}
+	return counts
+}
+
+// topSystemProcesses returns the first n processes ordered by less, skipping those with nothing to rank
+func topSystemProcesses(processes []SystemProcess, n int, less func(a, b SystemProcess) bool) []SystemProcess {
+	sorted := append([]SystemProcess(nil), processes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	var zero SystemProcess
+	var top []SystemProcess
+	for _, process := range sorted {
+		if len(top) == n || !less(process, zero) {
+			break
+		}
+		top = append(top, process)
+	}
+	return top
+}
+
+// parseSockstat parses /proc/net/sockstat and sockstat6 lines like "TCP: inuse 5 orphan 0 tw 2"
+func parseSockstat(output string, sockets *SystemSocketSummary) {
+	for _, line := range strings.Split(output, "\n") {
+		protocol, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		values := map[string]int{}
+		for i := 0; i+1 < len(fields); i += 2 {
+			values[fields[i]], _ = strconv.Atoi(fields[i+1])
+		}
+		switch protocol {
+		case "TCP":
+			sockets.TCPInUse = values["inuse"]
+			sockets.TCPOrphan = values["orphan"]
+			sockets.TCPTimeWait = values["tw"]
+		case "TCP6":
+			sockets.TCP6InUse = values["inuse"]
+		case "UDP":
+			sockets.UDPInUse = values["inuse"]
+		case "UDP6":
+			sockets.UDP6InUse = values["inuse"]
+		}
+	}
+}
+
+// parseTCPStates parses the awk summary of /proc/net/tcp: "state 0A 3" and "listen 0100007F:1F90" lines
+func parseTCPStates(output string, sockets *SystemSocketSummary) {
+	ports := map[int]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == "state":
+			count, _ := strconv.Atoi(fields[2])
+			state := tcpStateNames[strings.ToUpper(fields[1])]
+			if state == "" {
+				state = fields[1]
+			}
+			if sockets.TCPStates == nil {
+				sockets.TCPStates = map[string]int{}
+			}
+			sockets.TCPStates[state] += count
+		case len(fields) == 2 && fields[0] == "listen":
+			if _, hexPort, ok := strings.Cut(fields[1], ":"); ok {
+				if port, err := strconv.ParseInt(hexPort, 16, 32); err == nil {
+					ports[int(port)] = true
+				}
+			}
+		}
+	}
+	for port := range ports {
+		sockets.ListeningPorts = append(sockets.ListeningPorts, port)
+	}
+	sort.Ints(sockets.ListeningPorts)
+}
+
+// roundPercent rounds a percentage to one decimal place
+func roundPercent(value float64) float64 {
+	return float64(int(value*10+0.5)) / 10
+}
+
+// shellQuote quotes a string for use as a single POSIX shell word
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
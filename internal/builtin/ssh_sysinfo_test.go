@@ -0,0 +1,174 @@
+package builtin
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testSystemInfoOutput = `@@@begin meminfo
+MemTotal:        8000000 kB
+MemFree:          500000 kB
+MemAvailable:    2000000 kB
+Buffers:          100000 kB
+Cached:          1000000 kB
+SwapTotal:       1000000 kB
+SwapFree:         750000 kB
+@@@end meminfo 0
+@@@begin cpu_first
+cpu  1000 0 500 8000 100 0 0 0 0 0
+cpu0 500 0 250 4000 50 0 0 0 0 0
+cpu1 500 0 250 4000 50 0 0 0 0 0
+@@@end cpu_first 0
+@@@begin cpu_second
+cpu  1060 0 520 8100 120 0 0 0 0 0
+cpu0 530 0 260 4050 60 0 0 0 0 0
+cpu1 530 0 260 4050 60 0 0 0 0 0
+@@@end cpu_second 0
+@@@begin loadavg
+1.50 0.75 0.25 2/345 6789
+@@@end loadavg 0
+@@@begin uptime
+443100.52 800000.00
+@@@end uptime 0
+@@@begin df
+Filesystem     1-blocks          Used     Available Capacity Mounted on
+/dev/sda1      1979120929792 1583296743834 395824185958      80% /
+tmpfs          0             0             0                  0% /proc/kcore
+/dev/sdb1      10737418240   1073741824    9663676416        10% /var/opt/jfrog data
+@@@end df 0
+@@@begin processes
+  101 artifac+ 85.5 40.2 3300000 java
+  202 postgres  5.0  8.1  650000 postgres: writer
+  303 root      0.0  0.1    4000 sshd
+@@@end processes 0
+@@@begin file_nr
+4096	0	9223372036854775807
+@@@end file_nr 0
+@@@begin open_files
+   1800 101
+     20 202
+@@@end open_files 1
+@@@begin sockstat
+sockets: used 512
+TCP: inuse 42 orphan 1 tw 7 alloc 50 mem 3
+UDP: inuse 4 mem 1
+@@@end sockstat 1
+@@@begin tcp
+state 01 40
+state 0A 3
+state 06 7
+listen 00000000:1F90
+listen 00000000:1F90
+listen 0100007F:1538
+@@@end tcp 0
+`
+
+func TestBuildSystemInfo(t *testing.T) {
+	info := buildSystemInfo(parseSystemInfoProbes(testSystemInfoOutput), 2)
+	if len(info.Warnings) != 0 {
+		t.Errorf("Unexpected warnings %v", info.Warnings)
+	}
+
+	if info.Memory.UsedBytes != 6000000*1024 || info.Memory.UsedPercent != 75 || info.Memory.SwapUsedBytes != 250000*1024 || info.MemoryTotal != 7812 {
+		t.Errorf("Unexpected memory %+v", info.Memory)
+	}
+	// 200 jiffies elapsed, 120 of them idle or iowait
+	if info.CPU.Cores != 2 || info.CPU.UsagePercent != 40 || info.CPU.IOWaitPercent != 10 || info.CPUUsage != 40 {
+		t.Errorf("Unexpected CPU %+v", info.CPU)
+	}
+	if !reflect.DeepEqual(info.LoadAverage, []float64{1.5, 0.75, 0.25}) || info.Uptime != "up 5 days, 3 hours, 5 minutes" {
+		t.Errorf("Unexpected load %v or uptime %q", info.LoadAverage, info.Uptime)
+	}
+
+	// Terabyte disks are no longer truncated, and pseudo filesystems without size are skipped
+	if len(info.Filesystems) != 2 || info.DiskTotal != 1843 || info.Filesystems[0].UsedPercent != 80 {
+		t.Errorf("Unexpected filesystems %+v (root %d GB)", info.Filesystems, info.DiskTotal)
+	}
+	if info.Filesystems[1].MountPoint != "/var/opt/jfrog data" {
+		t.Errorf("Mount points with spaces must be kept, got %q", info.Filesystems[1].MountPoint)
+	}
+
+	if len(info.TopCPUProcesses) != 2 || info.TopCPUProcesses[0].PID != 101 || info.TopCPUProcesses[1].Command != "postgres: writer" {
+		t.Errorf("Unexpected top CPU processes %+v", info.TopCPUProcesses)
+	}
+	if info.OpenFiles.Allocated != 4096 || info.OpenFiles.TopProcesses[0].OpenFiles != 1800 {
+		t.Errorf("Unexpected open files %+v", info.OpenFiles)
+	}
+	if info.Sockets.TCPInUse != 42 || info.Sockets.TCPStates["LISTEN"] != 3 || !reflect.DeepEqual(info.Sockets.ListeningPorts, []int{5432, 8080}) {
+		t.Errorf("Unexpected sockets %+v", info.Sockets)
+	}
+}
+
+func TestBuildSystemInfo_PartialData(t *testing.T) {
+	// A busybox host without /proc/net and with a df that prints nothing useful
+	output := strings.Replace(testSystemInfoOutput, "@@@begin tcp", "@@@begin ignored", 1)
+	start := strings.Index(output, "@@@begin df")
+	end := strings.Index(output, "@@@end df 0")
+	output = output[:start] + "@@@begin df\n" + output[end:]
+
+	info := buildSystemInfo(parseSystemInfoProbes(output), 0)
+	if len(info.Warnings) != 2 || !strings.HasPrefix(info.Warnings[0], "disk:") || !strings.HasPrefix(info.Warnings[1], "tcp states:") {
+		t.Errorf("Expected disk and tcp warnings, got %v", info.Warnings)
+	}
+	if info.Filesystems != nil || info.Memory == nil || info.Sockets.TCPInUse != 42 {
+		t.Errorf("Other probes must still be reported: %+v", info)
+	}
+}
+
+func TestBuildSystemInfo_DfFallback(t *testing.T) {
+	// df -B1 printed its whole table but exited non-zero, and a df -Pk table followed it
+	start := strings.Index(testSystemInfoOutput, "@@@begin df")
+	end := strings.Index(testSystemInfoOutput, "@@@end df 0")
+	df := testSystemInfoOutput[start:end] + `Filesystem     1024-blocks       Used  Available Capacity Mounted on
+/dev/sda1      1932735283 1546188226 386547057      80% /
+/dev/sdc1      1048576         1024    1047552       1% /data
+`
+	output := testSystemInfoOutput[:start] + df + "@@@end df 1" + testSystemInfoOutput[end+len("@@@end df 0"):]
+
+	info := buildSystemInfo(parseSystemInfoProbes(output), 2)
+	if len(info.Filesystems) != 3 || info.DiskTotal != 1843 || info.Filesystems[0].SizeBytes != 1979120929792 {
+		t.Errorf("Unexpected filesystems %+v (root %d GB)", info.Filesystems, info.DiskTotal)
+	}
+	if info.Filesystems[2].MountPoint != "/data" || info.Filesystems[2].SizeBytes != 1024*1024*1024 {
+		t.Errorf("Rows after the second header must use its block size, got %+v", info.Filesystems[2])
+	}
+}
+
+func TestSSHSystemInfo(t *testing.T) {
+	if _, err := os.Stat("/proc/meminfo"); runtime.GOOS != "linux" || err != nil {
+		t.Skip("the collector script needs /proc")
+	}
+
+	// Run the real collector script locally to check it against the parsers
+	server := newTestSSHServer(t, func(command string, stdout, stderr io.Writer) int {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		if err := cmd.Run(); err != nil {
+			return 1
+		}
+		return 0
+	})
+	config := server.instance()
+	config.Password = "secret"
+	useTestSSHConfig(t, map[string]SSHServerConfig{"test": config})
+
+	response, result := callSSHTool(t, executeSSHSystemInfo, map[string]any{"server_name": "test", "top_processes": 3})
+	if result.IsError || response["success"] != true {
+		t.Fatalf("Unexpected failure: %v", response)
+	}
+	info := response["system_info"].(map[string]any)
+	if info["memory"] == nil || info["cpu"] == nil || info["load_average"] == nil || info["uptime_seconds"] == nil {
+		t.Errorf("Expected the /proc probes to succeed, got warnings %v", info["warnings"])
+	}
+
+	globalSSHConfig.DefaultPolicy = &SSHCommandPolicy{Allow: []string{"cat", "grep"}}
+	response, _ = callSSHTool(t, executeSSHSystemInfo, map[string]any{"server_name": "test"})
+	if response["success"] != false || response["policy"].(map[string]any)["action"] != sshPolicyDeny {
+		t.Errorf("Probes outside the allow list must be blocked, got %v", response)
+	}
+}
@@ -39,6 +39,7 @@ type SupportBundleAnalysis struct {
 	ErrorLogs      []SupportBundleSearchResult `json:"error_logs"`
 	WarningLogs    []SupportBundleSearchResult `json:"warning_logs"`
 	ExceptionLogs  []SupportBundleSearchResult `json:"exception_logs"`
+	ThreadDumps    []ThreadDumpOverview        `json:"thread_dumps,omitempty"`
 	SearchPatterns []string                    `json:"search_patterns"`
 	AnalysisTime   time.Time                   `json:"analysis_time"`
 	Duration       time.Duration               `json:"duration"`
@@ -75,9 +76,15 @@ func NewSupportBundleServer() (*server.MCPServer, error) {
 		mcp.WithBoolean("extract_archives",
 			mcp.Description("Extract archives to temporary directory for analysis (default: true)"),
 		),
+		mcp.WithBoolean("include_thread_dumps",
+			mcp.Description("Analyze the Java thread dumps (.tdump files) in the bundle and add their findings to the report (default: true)"),
+		),
 	)
 
 	s.AddTool(supportBundleTool, executeSupportBundleAnalyze)
+	addThreadDumpTools(s)
+	addPprofTools(s)
+	addBundleInspectTools(s)
 	return s, nil
 }
 
@@ -94,6 +101,7 @@ func executeSupportBundleAnalyze(ctx context.Context, request mcp.CallToolReques
 	maxResults := int(request.GetFloat("max_results", 100))
 	contextLines := int(request.GetFloat("context_lines", 2))
 	extractArchives := request.GetBool("extract_archives", true)
+	includeThreadDumps := request.GetBool("include_thread_dumps", true)
 
 	// Validate bundle path
 	if _, err := os.Stat(bundlePath); os.IsNotExist(err) {
@@ -120,7 +128,16 @@ func executeSupportBundleAnalyze(ctx context.Context, request mcp.CallToolReques
 	}
 
 	// Perform the analysis
-	err := analyzeSupportBundle(ctx, bundlePath, searchPatterns, fileTypes, caseSensitive, includeArchives, extractArchives, maxResults, contextLines, analysis)
+	err := analyzeSupportBundle(ctx, bundlePath, supportBundleOptions{
+		searchPatterns:     searchPatterns,
+		fileTypes:          fileTypes,
+		caseSensitive:      caseSensitive,
+		includeArchives:    includeArchives,
+		extractArchives:    extractArchives,
+		includeThreadDumps: includeThreadDumps,
+		maxResults:         maxResults,
+		contextLines:       contextLines,
+	}, analysis)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to analyze support bundle: %v", err)), nil
 	}
@@ -143,12 +160,24 @@ func executeSupportBundleAnalyze(ctx context.Context, request mcp.CallToolReques
 	}, nil
 }
 
+// supportBundleOptions holds the options of one support bundle analysis
+type supportBundleOptions struct {
+	searchPatterns     []string
+	fileTypes          []string
+	caseSensitive      bool
+	includeArchives    bool
+	extractArchives    bool
+	includeThreadDumps bool
+	maxResults         int
+	contextLines       int
+}
+
 // analyzeSupportBundle performs the main analysis
-func analyzeSupportBundle(ctx context.Context, bundlePath string, searchPatterns, fileTypes []string, caseSensitive, includeArchives, extractArchives bool, maxResults, contextLines int, analysis *SupportBundleAnalysis) error {
+func analyzeSupportBundle(ctx context.Context, bundlePath string, options supportBundleOptions, analysis *SupportBundleAnalysis) error {
 	// Create temporary directory for extracted archives
 	var tempDir string
 	var err error
-	if extractArchives {
+	if options.extractArchives {
 		tempDir, err = os.MkdirTemp("", "support-bundle-analysis-*")
 		if err != nil {
 			return fmt.Errorf("failed to create temporary directory: %v", err)
@@ -157,7 +186,7 @@ func analyzeSupportBundle(ctx context.Context, bundlePath string, searchPatterns
 	}
 
 	// First pass: Extract all compressed files recursively
-	if extractArchives {
+	if options.extractArchives {
 		err = extractAllCompressedFiles(ctx, bundlePath, tempDir)
 		if err != nil {
 			return fmt.Errorf("failed to extract compressed files: %v", err)
@@ -166,7 +195,7 @@ func analyzeSupportBundle(ctx context.Context, bundlePath string, searchPatterns
 
 	// Second pass: Search through all files (including extracted ones)
 	searchPaths := []string{bundlePath}
-	if extractArchives {
+	if options.extractArchives {
 		searchPaths = append(searchPaths, tempDir)
 	}
 
@@ -187,19 +216,26 @@ func analyzeSupportBundle(ctx context.Context, bundlePath string, searchPatterns
 				return nil
 			}
 
+			// Thread dumps are analyzed as a whole rather than searched line by line
+			if options.includeThreadDumps && isThreadDumpFile(path) {
+				if dump, err := analyzeThreadDumpFile(path, defaultThreadDumpOptions); err == nil && len(dump.Dumps) > 0 {
+					analysis.ThreadDumps = append(analysis.ThreadDumps, dump.overview())
+				}
+			}
+
 			// Check if file type matches
-			if !isMatchingFileType(path, fileTypes) {
+			if !isMatchingFileType(path, options.fileTypes) {
 				return nil
 			}
 
 			// Skip archive files in the second pass since they've been extracted
-			if extractArchives && isArchiveFile(path) {
+			if options.extractArchives && isArchiveFile(path) {
 				return nil
 			}
 
 			// Process file
 			archiveContext := ""
-			if extractArchives && strings.HasPrefix(path, tempDir) {
+			if options.extractArchives && strings.HasPrefix(path, tempDir) {
 				// Determine the original archive name from the extracted path
 				relativePath, _ := filepath.Rel(tempDir, path)
 				parts := strings.Split(relativePath, string(filepath.Separator))
@@ -207,7 +243,7 @@ func analyzeSupportBundle(ctx context.Context, bundlePath string, searchPatterns
 					archiveContext = parts[0]
 				}
 			}
-			return processFile(ctx, path, archiveContext, searchPatterns, caseSensitive, maxResults, contextLines, analysis)
+			return processFile(ctx, path, archiveContext, options.searchPatterns, options.caseSensitive, options.maxResults, options.contextLines, analysis)
 		})
 
 		if err != nil {
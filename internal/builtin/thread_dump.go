@@ -0,0 +1,1021 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var (
+	// threadDumpStartPattern matches the first line of a dump; a file may hold several dumps
+	threadDumpStartPattern     = regexp.MustCompile(`^Full (?:Java )?thread dump`)
+	threadDumpTimestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}`)
+	// threadInfoHeaderPattern matches the JFrog and ThreadInfo.toString format:
+	// "name" Id=12 in BLOCKED on lock=java.lang.Object@1a2b owned by "other" Id=13
+	threadInfoHeaderPattern = regexp.MustCompile(`^"(.*?)"( daemon)?(?: prio=\d+)? Id=(\d+) (?:in )?([A-Z_]+)(?: \((?:running )?in native\))?(?: on (?:lock=)?(\S+))?(?: owned by "(.*?)" Id=(\d+))?`)
+	// jstackHeaderPattern matches the jstack and jcmd format:
+	// "name" #12 [345] daemon prio=5 os_prio=0 tid=0x00007f nid=0x1a waiting for monitor entry  [0x00007f]
+	jstackHeaderPattern = regexp.MustCompile(`^"(.*?)"(?: #(\d+))?(?: \[\d+\])?( daemon)?.*? nid=(\S+) (.*?)(?:\s+\[0x[0-9a-fA-F]+\])?\s*$`)
+	jstackStatePattern  = regexp.MustCompile(`^java\.lang\.Thread\.State: ([A-Z_]+)`)
+	jstackLockPattern   = regexp.MustCompile(`<(0x[0-9a-fA-F]+)>\s*\(a ([^)]+)\)`)
+	jvmDeadlockPattern  = regexp.MustCompile(`^Found (one|\d+) Java-level deadlocks?`)
+	// threadFrameModulePattern matches the module or class loader prefix of a frame, e.g. java.base@21.0.7/ or app//
+	threadFrameModulePattern       = regexp.MustCompile(`^(?:[\w.\-]+@[^/(]*/|[\w.\-]*//|(?:java|jdk)\.[\w.]+/)`)
+	threadFrameSourceModulePattern = regexp.MustCompile(`\((?:[\w.\-]+@[^/)]*/|[\w.\-]*//)`)
+	threadPoolSuffixPattern        = regexp.MustCompile(`[-_#. ]*\d+$`)
+	threadQueueWaitPattern         = regexp.MustCompile(`\.(?:take|poll)\(`)
+)
+
+// threadIdleFrames mark threads that wait for work, connections or references rather than doing work
+var threadIdleFrames = []string{
+	"ThreadPoolExecutor.getTask", "ForkJoinPool.awaitWork", "ReferenceQueue.remove",
+	"Reference.waitForReferencePendingList", "sun.nio.ch.EPoll.wait", "sun.nio.ch.KQueue.poll",
+	"sun.nio.ch.WEPoll.wait", "SelectorImpl.lockAndDoSelect", "epollWait", "sun.nio.ch.Net.accept",
+}
+
+// threadDBDriverPrefixes are the packages of JDBC drivers; a thread inside one is talking to the database
+var threadDBDriverPrefixes = []string{
+	"org.postgresql.", "com.mysql.", "org.mariadb.", "oracle.jdbc.", "com.microsoft.sqlserver.",
+	"org.apache.derby.", "org.h2.", "com.ibm.db2.",
+}
+
+// threadDBPoolFrames are the frames of a thread waiting for a connection from a pool
+var threadDBPoolFrames = []string{
+	"HikariPool.getConnection", "ConnectionPool.borrowConnection", "PoolingDataSource.getConnection",
+	"GenericObjectPool.borrowObject", "BasicResourcePool.awaitAvailable",
+}
+
+// threadDBInfrastructurePrefixes are skipped when looking for the code that called into the database
+var threadDBInfrastructurePrefixes = []string{
+	"com.zaxxer.hikari.", "org.apache.tomcat.jdbc.", "org.apache.commons.dbcp", "org.apache.commons.pool",
+	"com.mchange.", "java.", "javax.", "jdk.", "sun.",
+}
+
+// threadJDKPrefixes are the packages skipped when looking for the application frame of a stack
+var threadJDKPrefixes = []string{"java.", "javax.", "jdk.", "sun.", "com.sun."}
+
+// JavaThread is one thread of a Java thread dump
+type JavaThread struct {
+	Name      string   `json:"name"`
+	ID        string   `json:"id,omitempty"`
+	State     string   `json:"state"`
+	Daemon    bool     `json:"daemon,omitempty"`
+	WaitingOn string   `json:"waiting_on,omitempty"`
+	LockOwner string   `json:"lock_owner,omitempty"`
+	LocksHeld []string `json:"locks_held,omitempty"`
+	Frames    []string `json:"frames,omitempty"`
+
+	// nid is the native thread id of the jstack format; unlike the name it is unique within a dump
+	nid string
+	// ownerID is the id of the lock owner named by the ThreadInfo format
+	ownerID string
+	// owner is the thread holding the lock this thread waits for, once resolved
+	owner *JavaThread
+}
+
+// javaThreadDump is one dump of a thread dump file
+type javaThreadDump struct {
+	timestamp         string
+	threads           []*JavaThread
+	reportedDeadlocks int
+}
+
+// ThreadDumpSnapshot counts the threads of one dump in a file
+type ThreadDumpSnapshot struct {
+	Index             int            `json:"index"`
+	Timestamp         string         `json:"timestamp,omitempty"`
+	Threads           int            `json:"threads"`
+	States            map[string]int `json:"states"`
+	Idle              int            `json:"idle"`
+	Blocked           int            `json:"blocked"`
+	DBWaiting         int            `json:"db_waiting"`
+	ReportedDeadlocks int            `json:"jvm_reported_deadlocks,omitempty"`
+}
+
+// ThreadDeadlock is a cycle of threads each waiting for a lock held by the next
+type ThreadDeadlock struct {
+	Dumps   []int    `json:"dumps"`
+	Threads []string `json:"threads"`
+	Locks   []string `json:"locks"`
+}
+
+// ThreadLockContention lists the threads blocked on one lock and the thread holding it
+type ThreadLockContention struct {
+	Lock           string   `json:"lock"`
+	Owner          string   `json:"owner,omitempty"`
+	OwnerState     string   `json:"owner_state,omitempty"`
+	OwnerFrame     string   `json:"owner_frame,omitempty"`
+	Waiters        int      `json:"waiters"`
+	WaitingThreads []string `json:"waiting_threads"`
+}
+
+// ThreadDBWaits summarizes the threads waiting on the database
+type ThreadDBWaits struct {
+	InJDBCCall           int                `json:"in_jdbc_call"`
+	WaitingForConnection int                `json:"waiting_for_connection"`
+	Threads              []string           `json:"threads,omitempty"`
+	Callers              []ThreadFrameCount `json:"callers,omitempty"`
+}
+
+// ThreadPoolSummary counts the threads of one pool, recognized by a common name with a numeric suffix
+type ThreadPoolSummary struct {
+	Name      string `json:"name"`
+	Threads   int    `json:"threads"`
+	Idle      int    `json:"idle"`
+	Busy      int    `json:"busy"`
+	Blocked   int    `json:"blocked"`
+	DBWaiting int    `json:"db_waiting"`
+	Saturated bool   `json:"saturated"`
+}
+
+// ThreadStackGroup is a set of threads with the same state and stack
+type ThreadStackGroup struct {
+	Threads int      `json:"threads"`
+	State   string   `json:"state"`
+	Idle    bool     `json:"idle"`
+	Names   []string `json:"names"`
+	Frames  []string `json:"frames"`
+}
+
+// ThreadFrameCount counts the busy threads running a frame
+type ThreadFrameCount struct {
+	Frame   string `json:"frame"`
+	Threads int    `json:"threads"`
+	Dumps   int    `json:"dumps,omitempty"`
+}
+
+// ThreadStuckStack is a busy thread whose stack did not change between the last dumps
+type ThreadStuckStack struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Frame string `json:"frame"`
+	Dumps int    `json:"dumps"`
+}
+
+// ThreadDumpAnalysis is the analysis of one thread dump file. Lock contention, database waits,
+// pools and stack groups describe the last dump; hot frames and stuck threads span all dumps
+type ThreadDumpAnalysis struct {
+	FilePath     string                 `json:"file_path"`
+	Service      string                 `json:"service,omitempty"`
+	Node         string                 `json:"node,omitempty"`
+	Dumps        []ThreadDumpSnapshot   `json:"dumps"`
+	Findings     []string               `json:"findings"`
+	Deadlocks    []ThreadDeadlock       `json:"deadlocks"`
+	Contention   []ThreadLockContention `json:"lock_contention"`
+	DBWaiting    ThreadDBWaits          `json:"db_waiting"`
+	Pools        []ThreadPoolSummary    `json:"thread_pools"`
+	StackGroups  []ThreadStackGroup     `json:"stack_groups"`
+	HotFrames    []ThreadFrameCount     `json:"hot_frames"`
+	StuckThreads []ThreadStuckStack     `json:"stuck_threads,omitempty"`
+}
+
+// ThreadDumpReport is the result of thread_dump_analyze
+type ThreadDumpReport struct {
+	SourcePath string                `json:"source_path"`
+	Files      []*ThreadDumpAnalysis `json:"files"`
+	Duration   string                `json:"duration"`
+}
+
+// ThreadDumpOverview is the short form of a thread dump analysis included in support_bundle_analyze
+type ThreadDumpOverview struct {
+	FilePath  string   `json:"file_path"`
+	Service   string   `json:"service,omitempty"`
+	Dumps     int      `json:"dumps"`
+	Threads   int      `json:"threads"`
+	Blocked   int      `json:"blocked"`
+	DBWaiting int      `json:"db_waiting"`
+	Deadlocks int      `json:"deadlocks"`
+	Findings  []string `json:"findings"`
+}
+
+// threadDumpOptions holds the options of one thread_dump_analyze call
+type threadDumpOptions struct {
+	top   int
+	depth int
+}
+
+// defaultThreadDumpOptions are used when thread dumps are analyzed as part of support_bundle_analyze
+var defaultThreadDumpOptions = threadDumpOptions{top: 5, depth: 10}
+
+// addThreadDumpTools registers the thread dump analysis tool
+func addThreadDumpTools(s *server.MCPServer) {
+	threadDumpTool := mcp.NewTool("thread_dump_analyze",
+		mcp.WithDescription("Analyze Java thread dumps (thread_dumps/*.tdump in a support bundle, jstack or jcmd output): thread states, lock owners and waiters, deadlock cycles, identical-stack groups, thread pool saturation, threads waiting on the database, and hot frames and stuck threads across the dumps in a file"),
+		mcp.WithString("source_path",
+			mcp.Description("Path to a thread dump file, or a support bundle directory searched for .tdump files (default: ./support-bundle)"),
+		),
+		mcp.WithNumber("top",
+			mcp.Description("Number of lock contentions, pools, stack groups, hot frames and stuck threads to list per file (default: 10)"),
+		),
+		mcp.WithNumber("stack_depth",
+			mcp.Description("Number of frames shown per stack group (default: 10)"),
+		),
+	)
+
+	s.AddTool(threadDumpTool, executeThreadDumpAnalyze)
+}
+
+// executeThreadDumpAnalyze handles the thread_dump_analyze tool execution
+func executeThreadDumpAnalyze(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startTime := time.Now()
+
+	sourcePath := request.GetString("source_path", "./support-bundle")
+	options := threadDumpOptions{
+		top:   int(request.GetFloat("top", 10)),
+		depth: int(request.GetFloat("stack_depth", 10)),
+	}
+	if options.top <= 0 {
+		options.top = 10
+	}
+	if options.depth <= 0 {
+		options.depth = 10
+	}
+
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("source path does not exist: %s", sourcePath)), nil
+	}
+
+	report := &ThreadDumpReport{SourcePath: sourcePath, Files: []*ThreadDumpAnalysis{}}
+	files := []string{sourcePath}
+	if info.IsDir() {
+		files = nil
+		err = filepath.WalkDir(sourcePath, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip unreadable directories
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if !entry.IsDir() && isThreadDumpFile(entry.Name()) {
+				files = append(files, filePath)
+			}
+			return nil
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to search for thread dumps: %v", err)), nil
+		}
+	}
+
+	for _, filePath := range files {
+		analysis, err := analyzeThreadDumpFile(filePath, options)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to analyze thread dump: %v", err)), nil
+		}
+		if len(analysis.Dumps) > 0 {
+			report.Files = append(report.Files, analysis)
+		}
+	}
+	if len(report.Files) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no Java thread dumps found under %s", sourcePath)), nil
+	}
+	report.Duration = time.Since(startTime).String()
+
+	resultJSON, err := json.Marshal(report)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// isThreadDumpFile reports whether a file name looks like a plain or gzipped thread dump
+func isThreadDumpFile(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(strings.ToLower(name), ".gz"), ".tdump")
+}
+
+// analyzeThreadDumpFile parses and analyzes every dump in a thread dump file
+func analyzeThreadDumpFile(filePath string, options threadDumpOptions) (*ThreadDumpAnalysis, error) {
+	reader, err := openJFrogLogFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	dumps, err := parseJavaThreadDumps(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", filePath, err)
+	}
+	analysis := analyzeJavaThreadDumps(dumps, options)
+	analysis.FilePath = filePath
+
+	// Bundles lay thread dumps out as <node>/<service>/thread_dumps/<file>
+	if dir := filepath.Dir(filePath); filepath.Base(dir) == "thread_dumps" {
+		analysis.Service = filepath.Base(filepath.Dir(dir))
+		analysis.Node = filepath.Base(filepath.Dir(filepath.Dir(dir)))
+	}
+	return analysis, nil
+}
+
+// parseJavaThreadDumps parses the dumps of a file in the JFrog, ThreadInfo.toString or jstack format
+func parseJavaThreadDumps(reader io.Reader) ([]*javaThreadDump, error) {
+	var dumps []*javaThreadDump
+	var current *javaThreadDump
+	var thread *JavaThread
+	timestamp := ""
+	inSynchronizers := false
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case threadDumpStartPattern.MatchString(trimmed):
+			current = &javaThreadDump{timestamp: timestamp}
+			dumps = append(dumps, current)
+			thread, timestamp = nil, ""
+			continue
+		case threadDumpTimestampPattern.MatchString(trimmed):
+			timestamp = trimmed
+			continue
+		}
+
+		// The deadlock report repeats the stacks of the threads involved; skip it
+		if match := jvmDeadlockPattern.FindStringSubmatch(trimmed); match != nil {
+			if current != nil {
+				count, err := strconv.Atoi(match[1])
+				if err != nil {
+					count = 1
+				}
+				current.reportedDeadlocks += count
+			}
+			thread = nil
+			continue
+		}
+
+		if strings.HasPrefix(line, `"`) {
+			if parsed := parseJavaThreadHeader(line); parsed != nil {
+				if current == nil {
+					current = &javaThreadDump{timestamp: timestamp}
+					dumps = append(dumps, current)
+				}
+				current.threads = append(current.threads, parsed)
+				thread, inSynchronizers = parsed, false
+				continue
+			}
+			thread = nil
+		}
+		if thread != nil {
+			parseJavaThreadLine(thread, trimmed, &inSynchronizers)
+		}
+	}
+	return dumps, scanner.Err()
+}
+
+// parseJavaThreadHeader parses the first line of a thread, or returns nil
+func parseJavaThreadHeader(line string) *JavaThread {
+	if match := threadInfoHeaderPattern.FindStringSubmatch(line); match != nil {
+		return &JavaThread{
+			Name:      match[1],
+			Daemon:    match[2] != "",
+			ID:        match[3],
+			State:     match[4],
+			WaitingOn: match[5],
+			LockOwner: match[6],
+			ownerID:   match[7],
+		}
+	}
+	if match := jstackHeaderPattern.FindStringSubmatch(line); match != nil {
+		// VM threads have no Thread.State line, so the state comes from the description
+		state := "UNKNOWN"
+		description := strings.ToLower(match[5])
+		switch {
+		case strings.Contains(description, "runnable"):
+			state = "RUNNABLE"
+		case strings.Contains(description, "monitor entry"):
+			state = "BLOCKED"
+		case strings.Contains(description, "sleeping"):
+			state = "TIMED_WAITING"
+		case strings.Contains(description, "waiting"), strings.Contains(description, "object.wait"):
+			state = "WAITING"
+		}
+		return &JavaThread{Name: match[1], ID: match[2], Daemon: match[3] != "", State: state, nid: match[4]}
+	}
+	return nil
+}
+
+// parseJavaThreadLine applies one state, frame or lock line to a thread
+func parseJavaThreadLine(thread *JavaThread, line string, inSynchronizers *bool) {
+	switch {
+	case strings.HasPrefix(line, "java.lang.Thread.State:"):
+		if match := jstackStatePattern.FindStringSubmatch(line); match != nil {
+			thread.State = match[1]
+		}
+	case strings.HasPrefix(line, "at "):
+		thread.Frames = append(thread.Frames, normalizeThreadFrame(line[3:]))
+	case strings.HasPrefix(line, "Locked ownable synchronizers:"), strings.HasPrefix(line, "Number of locked synchronizers"):
+		*inSynchronizers = true
+	case strings.HasPrefix(line, "- "):
+		detail := strings.TrimPrefix(line, "- ")
+		switch {
+		case *inSynchronizers:
+			if lock := parseThreadLock(detail); lock != "" {
+				thread.LocksHeld = appendUniqueStrings(thread.LocksHeld, lock)
+			}
+		case strings.HasPrefix(detail, "locked "):
+			// A thread in Object.wait() is listed as holding the monitor it released
+			if lock := parseThreadLock(strings.TrimPrefix(detail, "locked ")); lock != "" && lock != thread.WaitingOn {
+				thread.LocksHeld = appendUniqueStrings(thread.LocksHeld, lock)
+			}
+		case strings.HasPrefix(detail, "waiting to lock "), strings.HasPrefix(detail, "blocked on "):
+			thread.WaitingOn = parseThreadLock(detail)
+		case strings.HasPrefix(detail, "waiting on "), strings.HasPrefix(detail, "parking to wait for "):
+			if thread.WaitingOn == "" {
+				thread.WaitingOn = parseThreadLock(detail)
+			}
+		}
+	}
+}
+
+// parseThreadLock returns a lock as class@address from "<0x1234> (a java.lang.Object)" or
+// "java.lang.Object@1234"
+func parseThreadLock(detail string) string {
+	if match := jstackLockPattern.FindStringSubmatch(detail); match != nil {
+		return match[2] + "@" + match[1]
+	}
+	fields := strings.Fields(detail)
+	if len(fields) == 0 || !strings.Contains(fields[len(fields)-1], "@") {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// normalizeThreadFrame strips the module and class loader names from a frame so that frames from
+// different dump formats and JVM versions compare equal
+func normalizeThreadFrame(frame string) string {
+	frame = threadFrameModulePattern.ReplaceAllString(strings.TrimSpace(frame), "")
+	return threadFrameSourceModulePattern.ReplaceAllString(frame, "(")
+}
+
+// isIdleJavaThread reports whether a thread waits for work, such as a pool thread waiting for a
+// task or a selector waiting for connections
+func isIdleJavaThread(thread *JavaThread) bool {
+	if len(thread.Frames) == 0 {
+		return true
+	}
+	if thread.LockOwner != "" {
+		return false
+	}
+	// Sleeping housekeeping threads and timers waiting for their next task are idle too
+	for _, frame := range thread.Frames[:min(2, len(thread.Frames))] {
+		if strings.Contains(frame, "Thread.sleep") {
+			return true
+		}
+	}
+	// So are timers and worker loops waiting in their own run method, such as Quartz workers
+	for i, frame := range thread.Frames {
+		if strings.Contains(frame, "Object.wait") {
+			continue
+		}
+		if i > 0 && (strings.Contains(frame, "TimerThread.mainLoop") || strings.Contains(frame, ".run(")) {
+			return true
+		}
+		break
+	}
+	for _, frame := range thread.Frames {
+		if containsAny(frame, threadIdleFrames) {
+			return true
+		}
+	}
+	// Consumers waiting for the next item of a queue
+	if thread.State == "WAITING" || thread.State == "TIMED_WAITING" {
+		for _, frame := range thread.Frames {
+			if threadQueueWaitPattern.MatchString(frame) {
+				return true
+			}
+			if !hasAnyPrefix(frame, threadJDKPrefixes) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// javaThreadDBWait reports whether a busy thread is in a JDBC call ("query") or waiting for a
+// pooled connection ("connection"), and the frame that called into the database
+func javaThreadDBWait(thread *JavaThread) (kind, caller string) {
+	for i, frame := range thread.Frames {
+		switch {
+		case containsAny(frame, threadDBPoolFrames):
+			kind = "connection"
+		case hasAnyPrefix(frame, threadDBDriverPrefixes):
+			kind = "query"
+		default:
+			continue
+		}
+		for _, next := range thread.Frames[i+1:] {
+			if !hasAnyPrefix(next, threadDBDriverPrefixes) && !hasAnyPrefix(next, threadDBInfrastructurePrefixes) {
+				return kind, next
+			}
+		}
+		return kind, ""
+	}
+	return "", ""
+}
+
+// javaThreadAppFrame returns the topmost frame outside the JDK, where a thread spends its time
+func javaThreadAppFrame(thread *JavaThread) string {
+	for _, frame := range thread.Frames {
+		if !hasAnyPrefix(frame, threadJDKPrefixes) {
+			return frame
+		}
+	}
+	if len(thread.Frames) > 0 {
+		return thread.Frames[0]
+	}
+	return ""
+}
+
+// javaThreadPool returns the pool name of a thread, e.g. http-nio-8081-exec for http-nio-8081-exec-12.
+// Artifactory prefixes the names of threads serving a request with the time and trace ID
+func javaThreadPool(name string) string {
+	if pipe := strings.LastIndex(name, "|"); pipe >= 0 {
+		name = name[pipe+1:]
+	}
+	return threadPoolSuffixPattern.ReplaceAllString(name, "")
+}
+
+// javaThreadStackKey identifies threads with the same state and stack
+func javaThreadStackKey(thread *JavaThread) string {
+	return thread.State + "\n" + strings.Join(thread.Frames, "\n")
+}
+
+// javaThreadKey identifies a thread within a dump by its id or native id; thread names are not
+// unique, so threads without either are told apart by pointer only
+func javaThreadKey(thread *JavaThread) string {
+	switch {
+	case thread.ID != "":
+		return "#" + thread.ID
+	case thread.nid != "":
+		return "nid=" + thread.nid
+	}
+	return ""
+}
+
+// resolveJavaLockOwners links each waiting thread to the thread holding its lock: by the owner id
+// of the ThreadInfo format, otherwise by the locks the other threads hold. The owner name is filled
+// in for formats that do not name it
+func resolveJavaLockOwners(threads []*JavaThread) {
+	holders := make(map[string]*JavaThread)
+	byKey := make(map[string]*JavaThread)
+	for _, thread := range threads {
+		for _, lock := range thread.LocksHeld {
+			holders[lock] = thread
+		}
+		if key := javaThreadKey(thread); key != "" {
+			byKey[key] = thread
+		}
+	}
+	for _, thread := range threads {
+		thread.owner = nil
+		if thread.ownerID != "" {
+			thread.owner = byKey["#"+thread.ownerID]
+		}
+		if thread.owner == nil && thread.WaitingOn != "" {
+			thread.owner = holders[thread.WaitingOn]
+		}
+		if thread.owner != nil && thread.owner != thread && thread.LockOwner == "" {
+			thread.LockOwner = thread.owner.Name
+		}
+	}
+}
+
+// isBlockedJavaThread reports whether a thread waits for a lock held by another thread
+func isBlockedJavaThread(thread *JavaThread) bool {
+	if thread.State == "BLOCKED" {
+		return true
+	}
+	if thread.owner != nil {
+		return thread.owner != thread
+	}
+	return thread.LockOwner != "" && thread.LockOwner != thread.Name
+}
+
+// findJavaDeadlocks returns the cycles in the graph of threads waiting for lock owners
+func findJavaDeadlocks(threads []*JavaThread) [][]*JavaThread {
+	next := func(thread *JavaThread) *JavaThread {
+		if thread.owner == thread {
+			return nil
+		}
+		return thread.owner
+	}
+
+	const visiting, visited = 1, 2
+	marks := make(map[*JavaThread]int)
+	var cycles [][]*JavaThread
+	for _, start := range threads {
+		var path []*JavaThread
+		thread := start
+		for thread != nil && marks[thread] == 0 {
+			marks[thread] = visiting
+			path = append(path, thread)
+			thread = next(thread)
+		}
+		if thread != nil && marks[thread] == visiting {
+			for i, member := range path {
+				if member == thread {
+					cycles = append(cycles, path[i:])
+					break
+				}
+			}
+		}
+		for _, member := range path {
+			marks[member] = visited
+		}
+	}
+	return cycles
+}
+
+// analyzeJavaThreadDumps analyzes the dumps of one file
+func analyzeJavaThreadDumps(dumps []*javaThreadDump, options threadDumpOptions) *ThreadDumpAnalysis {
+	analysis := &ThreadDumpAnalysis{
+		Dumps:       []ThreadDumpSnapshot{},
+		Findings:    []string{},
+		Deadlocks:   []ThreadDeadlock{},
+		Contention:  []ThreadLockContention{},
+		Pools:       []ThreadPoolSummary{},
+		StackGroups: []ThreadStackGroup{},
+		HotFrames:   []ThreadFrameCount{},
+	}
+
+	deadlocks := make(map[string]int)
+	hotThreads := make(map[string]int)
+	hotDumps := make(map[string]int)
+	for i, dump := range dumps {
+		if len(dump.threads) == 0 {
+			continue
+		}
+		resolveJavaLockOwners(dump.threads)
+
+		snapshot := ThreadDumpSnapshot{Index: i + 1, Timestamp: dump.timestamp, Threads: len(dump.threads), States: make(map[string]int), ReportedDeadlocks: dump.reportedDeadlocks}
+		frames := make(map[string]bool)
+		for _, thread := range dump.threads {
+			snapshot.States[thread.State]++
+			if isIdleJavaThread(thread) {
+				snapshot.Idle++
+				continue
+			}
+			if isBlockedJavaThread(thread) {
+				snapshot.Blocked++
+			}
+			if kind, _ := javaThreadDBWait(thread); kind != "" {
+				snapshot.DBWaiting++
+			}
+			frame := javaThreadAppFrame(thread)
+			hotThreads[frame]++
+			if !frames[frame] {
+				frames[frame] = true
+				hotDumps[frame]++
+			}
+		}
+		analysis.Dumps = append(analysis.Dumps, snapshot)
+
+		// The same deadlock shows up in every dump taken while it lasts
+		for _, cycle := range findJavaDeadlocks(dump.threads) {
+			// Threads are labeled by name and id so that threads sharing a name stay apart
+			labels := make([]string, len(cycle))
+			first := 0
+			for j, thread := range cycle {
+				labels[j] = thread.Name + " " + javaThreadKey(thread)
+				if labels[j] < labels[first] {
+					first = j
+				}
+			}
+			deadlock := ThreadDeadlock{}
+			var members []string
+			for j := range cycle {
+				k := (first + j) % len(cycle)
+				deadlock.Threads = append(deadlock.Threads, cycle[k].Name)
+				deadlock.Locks = append(deadlock.Locks, cycle[k].WaitingOn)
+				members = append(members, labels[k])
+			}
+			key := strings.Join(members, "\n")
+			if index, ok := deadlocks[key]; ok {
+				analysis.Deadlocks[index].Dumps = append(analysis.Deadlocks[index].Dumps, i+1)
+				continue
+			}
+			deadlock.Dumps = []int{i + 1}
+			deadlocks[key] = len(analysis.Deadlocks)
+			analysis.Deadlocks = append(analysis.Deadlocks, deadlock)
+		}
+	}
+	if len(analysis.Dumps) == 0 {
+		return analysis
+	}
+
+	var last *javaThreadDump
+	for i := len(dumps) - 1; i >= 0 && last == nil; i-- {
+		if len(dumps[i].threads) > 0 {
+			last = dumps[i]
+		}
+	}
+	analysis.Contention = javaLockContention(last.threads, options.top)
+	analysis.DBWaiting = javaDBWaits(last.threads, options.top)
+	analysis.Pools = javaThreadPools(last.threads, options.top)
+	analysis.StackGroups = javaStackGroups(last.threads, options)
+	for _, frame := range topCounts(hotThreads, options.top) {
+		analysis.HotFrames = append(analysis.HotFrames, ThreadFrameCount{Frame: frame, Threads: hotThreads[frame], Dumps: hotDumps[frame]})
+	}
+	analysis.StuckThreads = javaStuckThreads(dumps, options.top)
+	analysis.Findings = threadDumpFindings(analysis)
+	return analysis
+}
+
+// javaLockContention groups the blocked threads of a dump by the lock they wait for
+func javaLockContention(threads []*JavaThread, top int) []ThreadLockContention {
+	index := make(map[string]int)
+	contention := []ThreadLockContention{}
+	for _, thread := range threads {
+		if !isBlockedJavaThread(thread) || isIdleJavaThread(thread) {
+			continue
+		}
+		lock := thread.WaitingOn
+		if lock == "" {
+			lock = "unknown"
+		}
+		i, ok := index[lock]
+		if !ok {
+			i = len(contention)
+			index[lock] = i
+			entry := ThreadLockContention{Lock: lock, Owner: thread.LockOwner}
+			if owner := thread.owner; owner != nil {
+				entry.OwnerState = owner.State
+				if len(owner.Frames) > 0 {
+					entry.OwnerFrame = owner.Frames[0]
+				}
+			}
+			contention = append(contention, entry)
+		}
+		contention[i].Waiters++
+		if len(contention[i].WaitingThreads) < 10 {
+			contention[i].WaitingThreads = append(contention[i].WaitingThreads, thread.Name)
+		}
+	}
+	sort.SliceStable(contention, func(i, j int) bool { return contention[i].Waiters > contention[j].Waiters })
+	if len(contention) > top {
+		contention = contention[:top]
+	}
+	return contention
+}
+
+// javaDBWaits summarizes the threads of a dump that wait on the database
+func javaDBWaits(threads []*JavaThread, top int) ThreadDBWaits {
+	waits := ThreadDBWaits{}
+	callers := make(map[string]int)
+	for _, thread := range threads {
+		if isIdleJavaThread(thread) {
+			continue
+		}
+		kind, caller := javaThreadDBWait(thread)
+		switch kind {
+		case "query":
+			waits.InJDBCCall++
+		case "connection":
+			waits.WaitingForConnection++
+		default:
+			continue
+		}
+		if len(waits.Threads) < 20 {
+			waits.Threads = append(waits.Threads, thread.Name)
+		}
+		if caller != "" {
+			callers[caller]++
+		}
+	}
+	for _, caller := range topCounts(callers, top) {
+		waits.Callers = append(waits.Callers, ThreadFrameCount{Frame: caller, Threads: callers[caller]})
+	}
+	return waits
+}
+
+// javaThreadPools counts the threads of each pool in a dump. A pool with no idle thread left is
+// saturated: new work queues up until one of its threads finishes
+func javaThreadPools(threads []*JavaThread, top int) []ThreadPoolSummary {
+	index := make(map[string]int)
+	var pools []ThreadPoolSummary
+	for _, thread := range threads {
+		// Unnamed threads (Thread-12) do not belong to a pool
+		name := javaThreadPool(thread.Name)
+		if name == thread.Name || name == "" || name == "Thread" {
+			continue
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(pools)
+			index[name] = i
+			pools = append(pools, ThreadPoolSummary{Name: name})
+		}
+		pool := &pools[i]
+		pool.Threads++
+		switch {
+		case isIdleJavaThread(thread):
+			pool.Idle++
+			continue
+		case isBlockedJavaThread(thread):
+			pool.Blocked++
+		}
+		pool.Busy++
+		if kind, _ := javaThreadDBWait(thread); kind != "" {
+			pool.DBWaiting++
+		}
+	}
+
+	result := []ThreadPoolSummary{}
+	for _, pool := range pools {
+		if pool.Threads < 2 {
+			continue
+		}
+		pool.Saturated = pool.Idle == 0
+		result = append(result, pool)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Saturated != result[j].Saturated {
+			return result[i].Saturated
+		}
+		if result[i].Busy != result[j].Busy {
+			return result[i].Busy > result[j].Busy
+		}
+		return result[i].Threads > result[j].Threads
+	})
+	if len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// javaStackGroups groups the threads of a dump with identical stacks, busy groups first
+func javaStackGroups(threads []*JavaThread, options threadDumpOptions) []ThreadStackGroup {
+	index := make(map[string]int)
+	var groups []ThreadStackGroup
+	for _, thread := range threads {
+		if len(thread.Frames) == 0 {
+			continue
+		}
+		key := javaThreadStackKey(thread)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ThreadStackGroup{State: thread.State, Idle: isIdleJavaThread(thread), Frames: thread.Frames[:min(options.depth, len(thread.Frames))]})
+		}
+		groups[i].Threads++
+		if len(groups[i].Names) < 10 {
+			groups[i].Names = append(groups[i].Names, thread.Name)
+		}
+	}
+
+	result := []ThreadStackGroup{}
+	for _, group := range groups {
+		if group.Threads > 1 {
+			result = append(result, group)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Idle != result[j].Idle {
+			return !result[i].Idle
+		}
+		return result[i].Threads > result[j].Threads
+	})
+	if len(result) > options.top {
+		result = result[:options.top]
+	}
+	return result
+}
+
+// javaStuckThreads returns the busy threads of the last dump whose stack was the same in the
+// dumps before it
+func javaStuckThreads(dumps []*javaThreadDump, top int) []ThreadStuckStack {
+	if len(dumps) < 2 {
+		return nil
+	}
+	stacks := make([]map[string]string, len(dumps))
+	for i, dump := range dumps {
+		stacks[i] = make(map[string]string)
+		for _, thread := range dump.threads {
+			stacks[i][thread.Name+"#"+thread.ID] = javaThreadStackKey(thread)
+		}
+	}
+
+	var stuck []ThreadStuckStack
+	last := len(dumps) - 1
+	for _, thread := range dumps[last].threads {
+		if isIdleJavaThread(thread) {
+			continue
+		}
+		key := thread.Name + "#" + thread.ID
+		count := 1
+		for i := last - 1; i >= 0 && stacks[i][key] == stacks[last][key]; i-- {
+			count++
+		}
+		if count > 1 {
+			stuck = append(stuck, ThreadStuckStack{Name: thread.Name, State: thread.State, Frame: javaThreadAppFrame(thread), Dumps: count})
+		}
+	}
+	sort.SliceStable(stuck, func(i, j int) bool { return stuck[i].Dumps > stuck[j].Dumps })
+	if len(stuck) > top {
+		stuck = stuck[:top]
+	}
+	return stuck
+}
+
+// threadDumpFindings describes the problems found in a thread dump file, most severe first
+func threadDumpFindings(analysis *ThreadDumpAnalysis) []string {
+	findings := []string{}
+	for _, deadlock := range analysis.Deadlocks {
+		var chain []string
+		for i, thread := range deadlock.Threads {
+			owner := deadlock.Threads[(i+1)%len(deadlock.Threads)]
+			chain = append(chain, fmt.Sprintf("%q waits for %s held by %q", thread, deadlock.Locks[i], owner))
+		}
+		findings = append(findings, fmt.Sprintf("Deadlock in dump %d: %s", deadlock.Dumps[0], strings.Join(chain, "; ")))
+	}
+	if len(analysis.Deadlocks) == 0 {
+		for _, snapshot := range analysis.Dumps {
+			if snapshot.ReportedDeadlocks > 0 {
+				findings = append(findings, fmt.Sprintf("The JVM reported %d deadlock(s) in dump %d", snapshot.ReportedDeadlocks, snapshot.Index))
+			}
+		}
+	}
+
+	for _, contention := range analysis.Contention[:min(3, len(analysis.Contention))] {
+		owner := "an unknown thread"
+		if contention.Owner != "" {
+			owner = fmt.Sprintf("%q (%s at %s)", contention.Owner, contention.OwnerState, contention.OwnerFrame)
+		}
+		findings = append(findings, fmt.Sprintf("%d thread(s) blocked on %s held by %s", contention.Waiters, contention.Lock, owner))
+	}
+
+	for _, pool := range analysis.Pools {
+		if pool.Saturated && pool.Busy > 0 {
+			findings = append(findings, fmt.Sprintf("Thread pool %s is saturated: all %d threads are busy (%d blocked, %d waiting on the database)", pool.Name, pool.Threads, pool.Blocked, pool.DBWaiting))
+		}
+	}
+
+	if db := analysis.DBWaiting; db.InJDBCCall+db.WaitingForConnection > 0 {
+		finding := fmt.Sprintf("%d thread(s) waiting on the database: %d in JDBC calls, %d waiting for a pooled connection", db.InJDBCCall+db.WaitingForConnection, db.InJDBCCall, db.WaitingForConnection)
+		if len(db.Callers) > 0 {
+			finding += ", mostly from " + db.Callers[0].Frame
+		}
+		findings = append(findings, finding)
+	}
+
+	if len(analysis.StuckThreads) > 0 {
+		stuck := analysis.StuckThreads[0]
+		findings = append(findings, fmt.Sprintf("%d busy thread(s) kept the same stack across dumps, e.g. %q for %d dumps at %s", len(analysis.StuckThreads), stuck.Name, stuck.Dumps, stuck.Frame))
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, "No deadlocks, blocked threads, saturated thread pools or database waits found")
+	}
+	return findings
+}
+
+// overview returns the short form of the analysis for support_bundle_analyze
+func (analysis *ThreadDumpAnalysis) overview() ThreadDumpOverview {
+	overview := ThreadDumpOverview{
+		FilePath:  analysis.FilePath,
+		Service:   analysis.Service,
+		Dumps:     len(analysis.Dumps),
+		Deadlocks: len(analysis.Deadlocks),
+		Findings:  analysis.Findings,
+	}
+	if len(analysis.Dumps) > 0 {
+		last := analysis.Dumps[len(analysis.Dumps)-1]
+		overview.Threads, overview.Blocked, overview.DBWaiting = last.Threads, last.Blocked, last.DBWaiting
+	}
+	return overview
+}
+
+// containsAny reports whether value contains any of the substrings
+func containsAny(value string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(value, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyPrefix reports whether value starts with any of the prefixes
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testJFrogThreadDump = `Full Java thread dump with locks info
+Locked Monitors Information: Collected
+Locked Synchronizers Information: Not Collected
+"2025-08-24T07:26:25.280Z|5714fb0404ab2aa5|http-nio-8081-exec-1" Id=40 in RUNNABLE (running in native)
+    at java.base@21.0.7/sun.nio.ch.Net.poll(Native Method)
+    at java.base@21.0.7/sun.nio.ch.NioSocketImpl.timedRead(NioSocketImpl.java:280)
+    at org.postgresql.core.VisibleBufferedInputStream.readMore(VisibleBufferedInputStream.java:161)
+    at org.postgresql.jdbc.PgPreparedStatement.executeQuery(PgPreparedStatement.java:137)
+    at com.zaxxer.hikari.pool.HikariProxyPreparedStatement.executeQuery(HikariProxyPreparedStatement.java)
+    at org.jfrog.storage.JdbcHelper.executeSelect(JdbcHelper.java:120)
+    at java.base@21.0.7/java.util.concurrent.ThreadPoolExecutor.runWorker(ThreadPoolExecutor.java:1144)
+
+"http-nio-8081-exec-2" Id=41 in TIMED_WAITING on lock=java.util.concurrent.SynchronousQueue$Transferer@1b
+    at java.base@21.0.7/jdk.internal.misc.Unsafe.park(Native Method)
+    at java.base@21.0.7/java.util.concurrent.locks.LockSupport.parkNanos(LockSupport.java:269)
+    at com.zaxxer.hikari.util.ConcurrentBag.borrow(ConcurrentBag.java:151)
+    at com.zaxxer.hikari.pool.HikariPool.getConnection(HikariPool.java:180)
+    at org.jfrog.storage.JdbcHelper.getConnection(JdbcHelper.java:90)
+
+"http-nio-8081-exec-3" Id=42 in BLOCKED on lock=java.lang.Object@abc owned by "cache-loader" Id=50
+    at app//org.artifactory.repo.Cache.get(Cache.java:10)
+
+"http-nio-8081-exec-4" Id=43 in BLOCKED on lock=java.lang.Object@abc owned by "cache-loader" Id=50
+    at app//org.artifactory.repo.Cache.get(Cache.java:10)
+
+"cache-loader" Id=50 in RUNNABLE
+    at app//org.artifactory.repo.Cache.load(Cache.java:20)
+      - locked java.lang.Object@abc
+
+"deadlock-b" Id=61 in BLOCKED on lock=java.lang.Object@a1 owned by "deadlock-a" Id=60
+    at org.example.B.run(B.java:1)
+      - locked java.lang.Object@a2
+
+"deadlock-a" Id=60 in BLOCKED on lock=java.lang.Object@a2 owned by "deadlock-b" Id=61
+    at org.example.A.run(A.java:1)
+      - locked java.lang.Object@a1
+
+"Catalina-utility-1" Id=70 in WAITING on lock=java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject@7
+    at java.base@21.0.7/jdk.internal.misc.Unsafe.park(Native Method)
+    at java.base@21.0.7/java.util.concurrent.ScheduledThreadPoolExecutor$DelayedWorkQueue.take(ScheduledThreadPoolExecutor.java:1170)
+    at java.base@21.0.7/java.util.concurrent.ThreadPoolExecutor.getTask(ThreadPoolExecutor.java:1070)
+
+"Catalina-utility-2" Id=71 in WAITING on lock=java.util.concurrent.locks.AbstractQueuedSynchronizer$ConditionObject@7
+    at java.base@21.0.7/jdk.internal.misc.Unsafe.park(Native Method)
+    at java.base@21.0.7/java.util.concurrent.ScheduledThreadPoolExecutor$DelayedWorkQueue.take(ScheduledThreadPoolExecutor.java:1170)
+    at java.base@21.0.7/java.util.concurrent.ThreadPoolExecutor.getTask(ThreadPoolExecutor.java:1070)
+
+"hbScheduler_Worker-1" Id=80 in TIMED_WAITING
+    at java.base@21.0.7/java.lang.Object.wait0(Native Method)
+    at java.base@21.0.7/java.lang.Object.wait(Object.java:366)
+    at org.quartz.simpl.SimpleThreadPool$WorkerThread.run(SimpleThreadPool.java:561)
+
+"Signal Dispatcher" Id=11 in RUNNABLE
+
+`
+
+const testJstackThreadDump = `2024-03-01 10:00:00
+Full thread dump OpenJDK 64-Bit Server VM (17.0.9+9 mixed mode, sharing):
+
+"worker-1" #21 daemon prio=5 os_prio=0 cpu=10.00ms elapsed=100.00s tid=0x00007f0001 nid=0x101 waiting for monitor entry  [0x00007f1001]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Transfer.debit(Transfer.java:10)
+	- waiting to lock <0x00000000c0000002> (a java.lang.Object)
+	- locked <0x00000000c0000001> (a java.lang.Object)
+	at java.lang.Thread.run(java.base@17.0.9/Thread.java:840)
+
+"worker-2" #22 prio=5 os_prio=0 tid=0x00007f0002 nid=0x102 waiting on condition  [0x00007f1002]
+   java.lang.Thread.State: WAITING (parking)
+	at jdk.internal.misc.Unsafe.park(java.base@17.0.9/Native Method)
+	- parking to wait for  <0x00000000c0000003> (a java.util.concurrent.locks.ReentrantLock$NonfairSync)
+	at java.util.concurrent.locks.ReentrantLock.lock(java.base@17.0.9/ReentrantLock.java:322)
+	at com.example.Transfer.credit(Transfer.java:20)
+	- locked <0x00000000c0000002> (a java.lang.Object)
+
+   Locked ownable synchronizers:
+	- None
+
+"worker-3" #23 prio=5 os_prio=0 tid=0x00007f0003 nid=0x103 waiting for monitor entry  [0x00007f1003]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Transfer.debit(Transfer.java:10)
+	- waiting to lock <0x00000000c0000001> (a java.lang.Object)
+
+   Locked ownable synchronizers:
+	- <0x00000000c0000003> (a java.util.concurrent.locks.ReentrantLock$NonfairSync)
+
+"Finalizer" #3 daemon prio=8 os_prio=0 tid=0x00007f0004 nid=0x3 in Object.wait()  [0x00007f1004]
+   java.lang.Thread.State: WAITING (on object monitor)
+	at java.lang.Object.wait(java.base@17.0.9/Native Method)
+	- waiting on <0x00000000c0000009> (a java.lang.ref.ReferenceQueue$Lock)
+	at java.lang.ref.ReferenceQueue.remove(java.base@17.0.9/ReferenceQueue.java:155)
+	- locked <0x00000000c0000009> (a java.lang.ref.ReferenceQueue$Lock)
+
+"GC Thread#0" os_prio=0 cpu=1.00ms elapsed=100.00s tid=0x00007f0009 nid=0x109 runnable
+
+Found one Java-level deadlock:
+=============================
+"worker-1":
+  waiting to lock monitor 0x00007f (object 0x00000000c0000002, a java.lang.Object),
+  which is held by "worker-2"
+
+Java stack information for the threads listed above:
+===================================================
+"worker-1":
+	at com.example.Transfer.debit(Transfer.java:10)
+	- waiting to lock <0x00000000c0000002> (a java.lang.Object)
+
+Found 1 deadlock.
+`
+
+func TestParseJavaThreadDumps_Jstack(t *testing.T) {
+	dumps, err := parseJavaThreadDumps(strings.NewReader(testJstackThreadDump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 1 || len(dumps[0].threads) != 5 || dumps[0].timestamp != "2024-03-01 10:00:00" || dumps[0].reportedDeadlocks != 1 {
+		t.Fatalf("Unexpected dumps %+v", dumps)
+	}
+	worker := dumps[0].threads[0]
+	if worker.Name != "worker-1" || worker.ID != "21" || !worker.Daemon || worker.State != "BLOCKED" || worker.WaitingOn != "java.lang.Object@0x00000000c0000002" {
+		t.Errorf("Unexpected worker %+v", worker)
+	}
+	if worker.Frames[1] != "java.lang.Thread.run(Thread.java:840)" {
+		t.Errorf("Module names must be stripped, got %q", worker.Frames[1])
+	}
+	// A thread in Object.wait() does not hold the monitor it waits on
+	if finalizer := dumps[0].threads[3]; len(finalizer.LocksHeld) != 0 || finalizer.WaitingOn == "" {
+		t.Errorf("Unexpected finalizer %+v", finalizer)
+	}
+	if gc := dumps[0].threads[4]; gc.Name != "GC Thread#0" || gc.State != "RUNNABLE" || gc.ID != "" {
+		t.Errorf("Unexpected VM thread %+v", gc)
+	}
+
+	// The ReentrantLock is owned through the ownable synchronizers of worker-3
+	analysis := analyzeJavaThreadDumps(dumps, threadDumpOptions{top: 10, depth: 10})
+	if len(analysis.Deadlocks) != 1 {
+		t.Fatalf("Expected one deadlock, got %+v", analysis.Deadlocks)
+	}
+	deadlock := analysis.Deadlocks[0]
+	if !reflect.DeepEqual(deadlock.Threads, []string{"worker-1", "worker-2", "worker-3"}) || deadlock.Locks[1] != "java.util.concurrent.locks.ReentrantLock$NonfairSync@0x00000000c0000003" {
+		t.Errorf("Unexpected deadlock %+v", deadlock)
+	}
+	if !strings.HasPrefix(analysis.Findings[0], `Deadlock in dump 1: "worker-1" waits for java.lang.Object@0x00000000c0000002 held by "worker-2"`) {
+		t.Errorf("Unexpected findings %v", analysis.Findings)
+	}
+}
+
+func TestAnalyzeJavaThreadDumps_DuplicateNames(t *testing.T) {
+	// Older JVMs print no thread number, and pools may reuse a name; only the native ids differ
+	dump := `"pool" prio=5 os_prio=0 tid=0x00007f0003 nid=0x3 runnable  [0x00007f1003]
+   java.lang.Thread.State: RUNNABLE
+	at com.example.Index.rebuild(Index.java:30)
+	- locked <0x00000000c000000c> (a java.lang.Object)
+
+"pool" prio=5 os_prio=0 tid=0x00007f0001 nid=0x1 waiting for monitor entry  [0x00007f1001]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Transfer.debit(Transfer.java:10)
+	- waiting to lock <0x00000000c000000b> (a java.lang.Object)
+	- locked <0x00000000c000000a> (a java.lang.Object)
+
+"pool" prio=5 os_prio=0 tid=0x00007f0002 nid=0x2 waiting for monitor entry  [0x00007f1002]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Transfer.credit(Transfer.java:20)
+	- waiting to lock <0x00000000c000000a> (a java.lang.Object)
+	- locked <0x00000000c000000b> (a java.lang.Object)
+
+"reader" prio=5 os_prio=0 tid=0x00007f0004 nid=0x4 waiting for monitor entry  [0x00007f1004]
+   java.lang.Thread.State: BLOCKED (on object monitor)
+	at com.example.Index.read(Index.java:40)
+	- waiting to lock <0x00000000c000000c> (a java.lang.Object)
+`
+	dumps, err := parseJavaThreadDumps(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis := analyzeJavaThreadDumps(append(dumps, dumps...), threadDumpOptions{top: 10, depth: 10})
+	if len(analysis.Deadlocks) != 1 || !reflect.DeepEqual(analysis.Deadlocks[0].Threads, []string{"pool", "pool"}) || !reflect.DeepEqual(analysis.Deadlocks[0].Dumps, []int{1, 2}) {
+		t.Errorf("Expected the deadlock between the two blocked pool threads, got %+v", analysis.Deadlocks)
+	}
+	var contention *ThreadLockContention
+	for i := range analysis.Contention {
+		if analysis.Contention[i].Lock == "java.lang.Object@0x00000000c000000c" {
+			contention = &analysis.Contention[i]
+		}
+	}
+	if contention == nil || contention.OwnerState != "RUNNABLE" || contention.OwnerFrame != "com.example.Index.rebuild(Index.java:30)" {
+		t.Errorf("Expected the runnable pool thread to own the index lock, got %+v", analysis.Contention)
+	}
+}
+
+func TestAnalyzeJavaThreadDumps(t *testing.T) {
+	// Two identical dumps, as taken a few seconds apart from a stuck server
+	dumps, err := parseJavaThreadDumps(strings.NewReader(strings.Repeat(testJFrogThreadDump, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 2 || len(dumps[1].threads) != 11 {
+		t.Fatalf("Expected two dumps of 11 threads, got %d", len(dumps))
+	}
+	analysis := analyzeJavaThreadDumps(dumps, threadDumpOptions{top: 10, depth: 1})
+
+	snapshot := analysis.Dumps[1]
+	if snapshot.States["BLOCKED"] != 4 || snapshot.Idle != 4 || snapshot.Blocked != 4 || snapshot.DBWaiting != 2 {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+	if len(analysis.Deadlocks) != 1 || !reflect.DeepEqual(analysis.Deadlocks[0].Dumps, []int{1, 2}) || analysis.Deadlocks[0].Threads[0] != "deadlock-a" {
+		t.Errorf("The deadlock must be reported once for both dumps, got %+v", analysis.Deadlocks)
+	}
+
+	contention := analysis.Contention[0]
+	if contention.Lock != "java.lang.Object@abc" || contention.Owner != "cache-loader" || contention.Waiters != 2 || contention.OwnerFrame != "org.artifactory.repo.Cache.load(Cache.java:20)" {
+		t.Errorf("Unexpected contention %+v", analysis.Contention)
+	}
+
+	db := analysis.DBWaiting
+	if db.InJDBCCall != 1 || db.WaitingForConnection != 1 || len(db.Callers) != 2 || !strings.HasPrefix(db.Callers[0].Frame, "org.jfrog.storage.JdbcHelper.") {
+		t.Errorf("Unexpected database waits %+v", db)
+	}
+
+	// The request thread name prefix is ignored, and unnamed or idle pools are not saturated
+	pool := analysis.Pools[0]
+	if pool.Name != "http-nio-8081-exec" || pool.Threads != 4 || !pool.Saturated || pool.Blocked != 2 || pool.DBWaiting != 2 {
+		t.Errorf("Unexpected pools %+v", analysis.Pools)
+	}
+	for _, pool := range analysis.Pools[1:] {
+		if pool.Saturated {
+			t.Errorf("Only the http pool is saturated, got %+v", pool)
+		}
+	}
+
+	if group := analysis.StackGroups[0]; group.Threads != 2 || group.Idle || group.State != "BLOCKED" || len(group.Frames) != 1 {
+		t.Errorf("Unexpected first stack group %+v", group)
+	}
+	if hot := analysis.HotFrames[0]; hot.Frame != "org.artifactory.repo.Cache.get(Cache.java:10)" || hot.Threads != 4 || hot.Dumps != 2 {
+		t.Errorf("Unexpected hot frames %+v", analysis.HotFrames)
+	}
+	if len(analysis.StuckThreads) != 7 || analysis.StuckThreads[0].Dumps != 2 {
+		t.Errorf("Expected the seven busy threads to be stuck, got %+v", analysis.StuckThreads)
+	}
+
+	findings := strings.Join(analysis.Findings, "\n")
+	for _, expected := range []string{"Deadlock in dump 1", "2 thread(s) blocked on java.lang.Object@abc held by \"cache-loader\"", "Thread pool http-nio-8081-exec is saturated", "2 thread(s) waiting on the database", "7 busy thread(s) kept the same stack"} {
+		if !strings.Contains(findings, expected) {
+			t.Errorf("Expected finding %q in %s", expected, findings)
+		}
+	}
+}
+
+func TestThreadDumpHelpers(t *testing.T) {
+	if frame := normalizeThreadFrame("app//org.apache.catalina.startup.Bootstrap.main(Bootstrap.java:476)"); frame != "org.apache.catalina.startup.Bootstrap.main(Bootstrap.java:476)" {
+		t.Errorf("Unexpected frame %q", frame)
+	}
+	if frame := normalizeThreadFrame("java.base@21.0.7/java.lang.invoke.LambdaForm$DMH/0x0000003801099000.invokeVirtual(LambdaForm$DMH)"); frame != "java.lang.invoke.LambdaForm$DMH/0x0000003801099000.invokeVirtual(LambdaForm$DMH)" {
+		t.Errorf("Hidden class names must be kept, got %q", frame)
+	}
+	if thread := parseJavaThreadHeader(`"pool-1-thread-1" daemon prio=5 Id=12 BLOCKED on java.lang.Object@1a2b owned by "main" Id=1`); thread == nil || thread.State != "BLOCKED" || thread.LockOwner != "main" || thread.ownerID != "1" || !thread.Daemon {
+		t.Errorf("Unexpected ThreadInfo thread %+v", thread)
+	}
+	for name, pool := range map[string]string{"pool-3-thread-12": "pool-3-thread", "ForkJoinPool.commonPool-worker-1": "ForkJoinPool.commonPool-worker", "main": "main"} {
+		if got := javaThreadPool(name); got != pool {
+			t.Errorf("Expected pool %s for %s, got %s", pool, name, got)
+		}
+	}
+}
+
+func TestSupportBundleThreadDumps(t *testing.T) {
+	bundle := t.TempDir()
+	dir := filepath.Join(bundle, "artifactory", "jfrt", "artifactory-0", "artifactory", "thread_dumps")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "thread_dumps.tdump"), []byte(testJFrogThreadDump), 0644)
+
+	analysis := &SupportBundleAnalysis{BundlePath: bundle}
+	if err := analyzeSupportBundle(context.Background(), bundle, supportBundleOptions{searchPatterns: []string{"ERROR"}, fileTypes: []string{".log"}, includeThreadDumps: true, maxResults: 10}, analysis); err != nil {
+		t.Fatal(err)
+	}
+	if len(analysis.ThreadDumps) != 1 {
+		t.Fatalf("Expected one thread dump, got %+v", analysis.ThreadDumps)
+	}
+	overview := analysis.ThreadDumps[0]
+	if overview.Service != "artifactory" || overview.Threads != 11 || overview.Deadlocks != 1 || overview.Blocked != 4 || len(overview.Findings) == 0 {
+		t.Errorf("Unexpected overview %+v", overview)
+	}
+}